@@ -0,0 +1,228 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Command batchinspector reads the batch files of a Sidetree anchor from CAS, runs the validations which are run
+// when the anchor is processed and prints a JSON report containing the content of every file, the operations
+// grouped by suffix and all of the findings (errors and warnings).
+//
+// Usage:
+//
+//	batchinspector (-anchor <anchor string> | -core-index <URI>) (-cas-dir <dir> | -cas-url <URL>) [flags]
+//
+// The exit code is 0 if the batch is valid (i.e. there are no errors), 1 if errors were found and 2 if the
+// command line is invalid.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/cas/filecas"
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider"
+)
+
+const (
+	exitValid  = 0
+	exitErrors = 1
+	exitUsage  = 2
+
+	sha2_256 = 18
+
+	defaultTimeout = time.Minute
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("batchinspector", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	anchorString := flags.String("anchor", "", "Anchor string (<number of operations>.<core index URI>)")
+	coreIndexURI := flags.String("core-index", "", "Core index file URI (if the anchor string is unknown)")
+	casDir := flags.String("cas-dir", "", "Directory of a file CAS containing the batch files")
+	casURL := flags.String("cas-url", "", "Base URL of a CAS endpoint which returns the content for <URL>/<URI>")
+	protocolFile := flags.String("protocol", "",
+		"JSON file containing the protocol parameters (fields which aren't specified keep the default value)")
+	maxChunkFiles := flags.Int("max-chunk-files", 1, "Maximum number of chunk files per batch")
+	sources := flags.String("alternate-sources", "", "Comma-separated alternate sources (used with -cas-url)")
+	timeout := flags.Duration("timeout", defaultTimeout, "Timeout for the inspection")
+	withContent := flags.Bool("content", true, "Include the content of the batch files in the report")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if (*anchorString == "") == (*coreIndexURI == "") {
+		return usageError(stderr, "exactly one of -anchor and -core-index must be specified")
+	}
+
+	p, err := loadProtocol(*protocolFile)
+	if err != nil {
+		return usageError(stderr, err.Error())
+	}
+
+	cas, err := newCAS(*casDir, *casURL, p.MultihashAlgorithms)
+	if err != nil {
+		return usageError(stderr, err.Error())
+	}
+
+	var alternateSources []string
+	if *sources != "" {
+		alternateSources = strings.Split(*sources, ",")
+	}
+
+	provider := txnprovider.NewOperationProvider(p, operationparser.New(p), cas,
+		compression.New(compression.WithDefaultAlgorithms()),
+		txnprovider.WithMaxChunkFiles(*maxChunkFiles),
+		txnprovider.WithMaxConcurrentFetches(1),
+		txnprovider.WithSourceCASURIFormatter(func(casURI, source string) (string, error) {
+			return strings.TrimSuffix(source, "/") + "/" + url.PathEscape(casURI), nil
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	var report *txnprovider.ValidationReport
+
+	if *anchorString != "" {
+		report = provider.ValidateAnchor(ctx, *anchorString, alternateSources...)
+	} else {
+		report = provider.ValidateCoreIndexFile(ctx, *coreIndexURI, alternateSources...)
+	}
+
+	if !*withContent {
+		for _, file := range report.Files {
+			file.Content = nil
+		}
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(stderr, "Error writing report: %s\n", err)
+
+		return exitErrors
+	}
+
+	if !report.Valid() {
+		fmt.Fprintf(stderr, "%d error(s) found\n", len(report.Errors()))
+
+		return exitErrors
+	}
+
+	return exitValid
+}
+
+func usageError(stderr io.Writer, msg string) int {
+	fmt.Fprintf(stderr, "%s\n", msg)
+
+	return exitUsage
+}
+
+// loadProtocol returns the protocol parameters, overriding the defaults with the parameters in the given file.
+func loadProtocol(file string) (protocol.Protocol, error) {
+	p := defaultProtocol()
+
+	if file == "" {
+		return p, nil
+	}
+
+	content, err := os.ReadFile(file) //nolint:gosec
+	if err != nil {
+		return protocol.Protocol{}, fmt.Errorf("read protocol file: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &p); err != nil {
+		return protocol.Protocol{}, fmt.Errorf("parse protocol file: %w", err)
+	}
+
+	return p, nil
+}
+
+// defaultProtocol returns the default parameters of the Sidetree protocol specification.
+func defaultProtocol() protocol.Protocol {
+	return protocol.Protocol{
+		MultihashAlgorithms:          []uint{sha2_256},
+		MaxOperationCount:            10000,
+		MaxOperationSize:             2500,
+		MaxOperationHashLength:       100,
+		MaxDeltaSize:                 1000,
+		MaxCasURILength:              100,
+		CompressionAlgorithm:         "GZIP",
+		MaxCoreIndexFileSize:         1000000,
+		MaxProofFileSize:             2500000,
+		MaxProvisionalIndexFileSize:  1000000,
+		MaxChunkFileSize:             10000000,
+		Patches:                      []string{"add-public-keys", "remove-public-keys", "add-services", "remove-services", "ietf-json-patch"},
+		SignatureAlgorithms:          []string{"EdDSA", "ES256", "ES256K"},
+		KeyAlgorithms:                []string{"Ed25519", "P-256", "secp256k1"},
+		MaxMemoryDecompressionFactor: 3,
+	}
+}
+
+type dcas interface {
+	Read(address string) ([]byte, error)
+}
+
+func newCAS(dir, baseURL string, algorithms []uint) (dcas, error) {
+	switch {
+	case dir != "" && baseURL != "":
+		return nil, errors.New("only one of -cas-dir and -cas-url may be specified")
+	case dir != "":
+		return filecas.New(dir, filecas.WithReadOnly(), filecas.WithMultihashAlgorithms(algorithms...))
+	case baseURL != "":
+		return &httpCAS{baseURL: strings.TrimSuffix(baseURL, "/"), client: &http.Client{Timeout: defaultTimeout}}, nil
+	default:
+		return nil, errors.New("one of -cas-dir and -cas-url must be specified")
+	}
+}
+
+// httpCAS reads content from a CAS endpoint which returns the content for <base URL>/<URI>. Alternate sources
+// are read from <source>/<URI>.
+type httpCAS struct {
+	baseURL string
+	client  *http.Client
+}
+
+func (c *httpCAS) Read(address string) ([]byte, error) {
+	u := address
+
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		u = c.baseURL + "/" + url.PathEscape(address)
+	}
+
+	resp, err := c.client.Get(u) //nolint:noctx
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", u, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: status %d", u, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
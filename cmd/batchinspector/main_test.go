@@ -0,0 +1,186 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-go/pkg/jws"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/client"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-svc-go/pkg/cas/filecas"
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+
+	anchorString := prepareBatch(t, dir)
+
+	t.Run("success - CAS directory", func(t *testing.T) {
+		stdout, stderr, code := runInspector(t, "-anchor", anchorString, "-cas-dir", dir)
+		require.Equal(t, exitValid, code, stderr)
+
+		report := &txnprovider.ValidationReport{}
+		require.NoError(t, json.Unmarshal([]byte(stdout), report))
+		require.True(t, report.Valid())
+		require.Len(t, report.Operations, 2)
+		require.Len(t, report.Files, 3)
+		require.NotNil(t, report.Files[0].Content)
+	})
+
+	t.Run("success - CAS endpoint", func(t *testing.T) {
+		store, err := filecas.New(dir, filecas.WithReadOnly())
+		require.NoError(t, err)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			content, err := store.Read(strings.TrimPrefix(r.URL.Path, "/cas/"))
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			_, _ = w.Write(content) //nolint:errcheck
+		}))
+		defer server.Close()
+
+		ad, err := txnprovider.ParseAnchorData(anchorString)
+		require.NoError(t, err)
+
+		stdout, stderr, code := runInspector(t, "-core-index", ad.CoreIndexFileURI, "-cas-url", server.URL+"/cas",
+			"-content=false")
+		require.Equal(t, exitValid, code, stderr)
+
+		report := &txnprovider.ValidationReport{}
+		require.NoError(t, json.Unmarshal([]byte(stdout), report))
+		require.Len(t, report.Operations, 2)
+		require.Nil(t, report.Files[0].Content)
+
+		// The content is read from an alternate source if the CAS endpoint doesn't have it.
+		_, stderr, code = runInspector(t, "-anchor", anchorString, "-cas-url", server.URL+"/unknown",
+			"-alternate-sources", server.URL+"/cas")
+		require.Equal(t, exitValid, code, stderr)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		protocolFile := filepath.Join(t.TempDir(), "protocol.json")
+		require.NoError(t, os.WriteFile(protocolFile, []byte(`{"maxChunkFileSize":10}`), 0o600))
+
+		stdout, stderr, code := runInspector(t, "-anchor", anchorString, "-cas-dir", dir, "-protocol", protocolFile)
+		require.Equal(t, exitErrors, code)
+		require.Contains(t, stderr, "1 error(s) found")
+
+		report := &txnprovider.ValidationReport{}
+		require.NoError(t, json.Unmarshal([]byte(stdout), report))
+		require.Len(t, report.Errors(), 1)
+		require.Equal(t, txnprovider.RuleSizeLimit, report.Errors()[0].Rule)
+		require.Contains(t, report.Errors()[0].Message, "exceeded maximum size 10")
+
+		_, _, code = runInspector(t, "-anchor", "invalid", "-cas-dir", dir)
+		require.Equal(t, exitErrors, code)
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		_, stderr, code := runInspector(t, "-cas-dir", dir)
+		require.Equal(t, exitUsage, code)
+		require.Contains(t, stderr, "exactly one of -anchor and -core-index must be specified")
+
+		_, stderr, code = runInspector(t, "-anchor", anchorString)
+		require.Equal(t, exitUsage, code)
+		require.Contains(t, stderr, "one of -cas-dir and -cas-url must be specified")
+
+		_, stderr, code = runInspector(t, "-anchor", anchorString, "-cas-dir", dir, "-cas-url", "http://localhost")
+		require.Equal(t, exitUsage, code)
+		require.Contains(t, stderr, "only one of -cas-dir and -cas-url may be specified")
+
+		_, stderr, code = runInspector(t, "-anchor", anchorString, "-cas-dir", filepath.Join(dir, "unknown"))
+		require.Equal(t, exitUsage, code)
+		require.Contains(t, stderr, "open store directory")
+
+		_, stderr, code = runInspector(t, "-anchor", anchorString, "-cas-dir", dir, "-protocol", "unknown.json")
+		require.Equal(t, exitUsage, code)
+		require.Contains(t, stderr, "read protocol file")
+
+		_, _, code = runInspector(t, "-unknown")
+		require.Equal(t, exitUsage, code)
+	})
+}
+
+func runInspector(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	code := run(context.Background(), args, stdout, stderr)
+
+	return stdout.String(), stderr.String(), code
+}
+
+// prepareBatch writes the batch files of two create operations to the file CAS in the given directory and
+// returns the anchor string.
+func prepareBatch(t *testing.T, dir string) string {
+	t.Helper()
+
+	store, err := filecas.New(dir)
+	require.NoError(t, err)
+
+	p := defaultProtocol()
+
+	var ops []*operation.QueuedOperation
+
+	for i := 1; i <= 2; i++ {
+		ops = append(ops, newCreateOperation(t, i))
+	}
+
+	anchoringInfo, err := txnprovider.NewOperationHandler(p, store, compression.New(compression.WithDefaultAlgorithms()),
+		operationparser.New(p), &mocks.MetricsProvider{}).PrepareTxnFiles(ops)
+	require.NoError(t, err)
+
+	return anchoringInfo.AnchorString
+}
+
+func newCreateOperation(t *testing.T, num int) *operation.QueuedOperation {
+	t.Helper()
+
+	updateCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x"}, sha2_256)
+	require.NoError(t, err)
+
+	recoveryCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x", Y: "y"}, sha2_256)
+	require.NoError(t, err)
+
+	request, err := client.NewCreateRequest(&client.CreateRequestInfo{
+		OpaqueDocument:     fmt.Sprintf(`{"test":%d}`, num),
+		RecoveryCommitment: recoveryCommitment,
+		UpdateCommitment:   updateCommitment,
+		MultihashCode:      sha2_256,
+	})
+	require.NoError(t, err)
+
+	return &operation.QueuedOperation{
+		Namespace:        "did:sidetree",
+		UniqueSuffix:     fmt.Sprint(num),
+		OperationRequest: request,
+	}
+}
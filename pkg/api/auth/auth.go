@@ -0,0 +1,52 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"errors"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+)
+
+// ErrUnauthenticated is returned when the caller's credentials are missing or invalid.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ErrForbidden is returned when the caller is not allowed to submit the operation.
+var ErrForbidden = errors.New("forbidden")
+
+// Method is the method that was used to authenticate the caller.
+type Method string
+
+const (
+	// MethodBearer indicates that the caller was authenticated using a bearer token.
+	MethodBearer Method = "bearer"
+
+	// MethodAPIKey indicates that the caller was authenticated using an API key.
+	MethodAPIKey Method = "api-key"
+
+	// MethodMTLS indicates that the caller was authenticated using a TLS client certificate.
+	MethodMTLS Method = "mtls"
+)
+
+// Identity holds the identity of the caller that submitted an operation.
+type Identity struct {
+	// Subject identifies the caller (e.g. the 'sub' claim of a bearer token or the subject of a client certificate).
+	Subject string
+
+	// Method is the method that was used to authenticate the caller.
+	Method Method
+
+	// Claims contains additional (method-specific) claims about the caller.
+	Claims map[string]interface{}
+}
+
+// Authorizer decides whether or not the caller may submit the given operation. A nil identity
+// indicates an anonymous caller. If the operation is denied then an error that wraps ErrForbidden
+// is returned.
+type Authorizer interface {
+	Authorize(identity *Identity, op *operation.Operation) error
+}
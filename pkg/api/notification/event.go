@@ -0,0 +1,78 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package notification
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+)
+
+// Status is the status of the operation which caused a change event.
+type Status string
+
+const (
+	// StatusUnpublished indicates that the operation was accepted by this node but has not yet been anchored.
+	StatusUnpublished Status = "unpublished"
+
+	// StatusPublished indicates that the operation was anchored and processed.
+	StatusPublished Status = "published"
+)
+
+// ChangeEvent is emitted when an operation changes the state of a document.
+type ChangeEvent struct {
+	ID                 string         `json:"id"`
+	Namespace          string         `json:"namespace,omitempty"`
+	UniqueSuffix       string         `json:"uniqueSuffix"`
+	OperationType      operation.Type `json:"operationType"`
+	Status             Status         `json:"status"`
+	AnchorOrigin       interface{}    `json:"anchorOrigin,omitempty"`
+	TransactionTime    uint64         `json:"transactionTime,omitempty"`
+	TransactionNumber  uint64         `json:"transactionNumber,omitempty"`
+	CanonicalReference string         `json:"canonicalReference,omitempty"`
+	Timestamp          time.Time      `json:"timestamp"`
+}
+
+// Publisher publishes document change events.
+type Publisher interface {
+	Publish(events ...*ChangeEvent)
+}
+
+// NewUnpublishedEvent returns a change event for an operation that was accepted but not yet anchored.
+// The event ID is derived from the operation request so that a resubmitted operation results in the same ID.
+func NewUnpublishedEvent(namespace string, op *operation.Operation) *ChangeEvent {
+	digest := sha256.Sum256(op.OperationRequest)
+
+	return &ChangeEvent{
+		ID:            fmt.Sprintf("%s:%s:%s", StatusUnpublished, op.UniqueSuffix, hex.EncodeToString(digest[:])),
+		Namespace:     namespace,
+		UniqueSuffix:  op.UniqueSuffix,
+		OperationType: op.Type,
+		Status:        StatusUnpublished,
+		AnchorOrigin:  op.AnchorOrigin,
+		Timestamp:     time.Now(),
+	}
+}
+
+// NewPublishedEvent returns a change event for an operation that was anchored and processed.
+func NewPublishedEvent(namespace string, op *operation.AnchoredOperation) *ChangeEvent {
+	return &ChangeEvent{
+		ID:                 fmt.Sprintf("%s:%s:%d", StatusPublished, op.UniqueSuffix, op.TransactionNumber),
+		Namespace:          namespace,
+		UniqueSuffix:       op.UniqueSuffix,
+		OperationType:      op.Type,
+		Status:             StatusPublished,
+		AnchorOrigin:       op.AnchorOrigin,
+		TransactionTime:    op.TransactionTime,
+		TransactionNumber:  op.TransactionNumber,
+		CanonicalReference: op.CanonicalReference,
+		Timestamp:          time.Now(),
+	}
+}
@@ -6,7 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 
 package operation
 
-import "github.com/trustbloc/sidetree-go/pkg/api/operation"
+import (
+	"context"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
 
 // Reference holds minimum information about did operation (suffix and type).
 type Reference struct {
@@ -28,6 +34,11 @@ type QueuedOperation struct {
 	Namespace        string
 	AnchorOrigin     interface{}
 	Properties       []operation.Property
+	ClientID         string
+
+	// TraceContext contains the (W3C) trace context of the request that submitted the operation so that
+	// the span of the batch which contains the operation may be linked to the originating request.
+	TraceContext map[string]string
 }
 
 // QueuedOperationAtTime contains queued operation info with protocol genesis time.
@@ -49,3 +60,47 @@ func (o QueuedOperationsAtTime) QueuedOperations() []*QueuedOperation {
 
 	return ops
 }
+
+// ProcessOptions contains options for processing an operation.
+type ProcessOptions struct {
+	// Identity is the (optional) identity of the caller that submitted the operation.
+	Identity *auth.Identity
+
+	// Context is the context of the request that submitted the operation. It carries the (optional) trace
+	// span of the request. Defaults to context.Background().
+	Context context.Context
+}
+
+// ProcessOption is an option for processing an operation.
+type ProcessOption func(opts *ProcessOptions)
+
+// WithIdentity sets the identity of the caller that submitted the operation.
+func WithIdentity(identity *auth.Identity) ProcessOption {
+	return func(opts *ProcessOptions) {
+		opts.Identity = identity
+	}
+}
+
+// WithContext sets the context of the request that submitted the operation.
+func WithContext(ctx context.Context) ProcessOption {
+	return func(opts *ProcessOptions) {
+		opts.Context = ctx
+	}
+}
+
+// GetProcessOptions returns the process options.
+func GetProcessOptions(opts ...ProcessOption) ProcessOptions {
+	options := ProcessOptions{}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+
+	if options.Context == nil {
+		options.Context = context.Background()
+	}
+
+	return options
+}
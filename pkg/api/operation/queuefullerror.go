@@ -0,0 +1,41 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueueFull indicates that an operation was not admitted since the operation queue is full.
+var ErrQueueFull = errors.New("operation queue is full")
+
+// QueueFullError is returned when an operation is not admitted to the operation queue since a queue limit
+// (depth, byte budget or client quota) has been reached. The client should retry after RetryAfter.
+type QueueFullError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+// NewQueueFullError returns a new queue full error.
+func NewQueueFullError(retryAfter time.Duration, reason string, args ...interface{}) *QueueFullError {
+	return &QueueFullError{
+		Reason:     fmt.Sprintf(reason, args...),
+		RetryAfter: retryAfter,
+	}
+}
+
+// Error returns the error message.
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrQueueFull, e.Reason)
+}
+
+// Is returns true if the target is ErrQueueFull.
+func (e *QueueFullError) Is(target error) bool {
+	return target == ErrQueueFull
+}
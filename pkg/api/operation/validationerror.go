@@ -0,0 +1,69 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"fmt"
+
+	coreoperation "github.com/trustbloc/sidetree-go/pkg/api/operation"
+)
+
+// Stage identifies the processing stage at which an operation was rejected.
+type Stage string
+
+const (
+	// StageParse indicates that the operation request could not be parsed.
+	StageParse Stage = "parse"
+
+	// StageValidate indicates that the operation failed document validation.
+	StageValidate Stage = "validate"
+
+	// StageDecorate indicates that the operation was rejected by the operation decorator.
+	StageDecorate Stage = "decorate"
+
+	// StageApply indicates that the operation could not be applied to the current state of the document.
+	StageApply Stage = "apply"
+)
+
+// ValidationError is returned when an operation is rejected during parsing, validation, decoration or (for
+// dry runs) while being applied to the current state of the document. The error message is prefixed with
+// "bad request" so that it is handled as a client error by the REST handlers.
+type ValidationError struct {
+	Stage        Stage              `json:"stage"`
+	Type         coreoperation.Type `json:"type,omitempty"`
+	UniqueSuffix string             `json:"uniqueSuffix,omitempty"`
+	Message      string             `json:"message"`
+
+	err error
+}
+
+// NewValidationError returns a new validation error for the given stage. The operation is optional since
+// it is not available if the operation request failed to parse.
+func NewValidationError(stage Stage, op *coreoperation.Operation, err error) *ValidationError {
+	e := &ValidationError{
+		Stage:   stage,
+		Message: err.Error(),
+		err:     err,
+	}
+
+	if op != nil {
+		e.Type = op.Type
+		e.UniqueSuffix = op.UniqueSuffix
+	}
+
+	return e
+}
+
+// Error returns the error message.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("bad request: %s", e.Message)
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package protocol
 
 import (
+	"context"
+
 	"github.com/trustbloc/sidetree-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-go/pkg/api/protocol"
 
@@ -18,12 +20,21 @@ import (
 //go:generate counterfeiter -o ../../mocks/protocolversion.gen.go --fake-name ProtocolVersion . Version
 //go:generate counterfeiter -o ../../mocks/operationhandler.gen.go --fake-name OperationHandler . OperationHandler
 //go:generate counterfeiter -o ../../mocks/operationprovider.gen.go --fake-name OperationProvider . OperationProvider
+//go:generate counterfeiter -o ../../mocks/txnprocessorwithcontext.gen.go --fake-name TxnProcessorWithContext . TxnProcessorWithContext
+//go:generate counterfeiter -o ../../mocks/operationhandlerwithcontext.gen.go --fake-name OperationHandlerWithContext . OperationHandlerWithContext
+//go:generate counterfeiter -o ../../mocks/operationproviderwithcontext.gen.go --fake-name OperationProviderWithContext . OperationProviderWithContext
 
 // TxnProcessor defines the functions for processing a Sidetree transaction.
 type TxnProcessor interface {
 	Process(sidetreeTxn txn.SidetreeTxn, suffixes ...string) (numProcessed int, err error)
 }
 
+// TxnProcessorWithContext may be implemented by a TxnProcessor which accepts a context. The context carries
+// the (optional) trace span of the caller.
+type TxnProcessorWithContext interface {
+	ProcessWithContext(ctx context.Context, sidetreeTxn txn.SidetreeTxn, suffixes ...string) (numProcessed int, err error)
+}
+
 // AnchorDocumentType defines valid values for anchor document type.
 type AnchorDocumentType string
 
@@ -43,26 +54,63 @@ type AnchorDocument struct {
 	Type AnchorDocumentType
 }
 
-// AnchoringInfo contains anchoring info plus additional batch information.
+// AnchoringInfo contains anchoring info plus additional batch information. The anchor string is empty if no
+// operations remain in the batch (e.g. all of them were rejected), in which case no batch files were written
+// and nothing is anchored.
 type AnchoringInfo struct {
 	AnchorString         string
 	Artifacts            []*AnchorDocument
 	OperationReferences  []*coreoperation.Reference
 	AdditionalOperations []*coreoperation.QueuedOperation
 	ExpiredOperations    []*coreoperation.QueuedOperation
+
+	// RejectedOperations contains the operations which were removed from the batch because they can never
+	// be anchored (e.g. the delta exceeds the maximum chunk file size).
+	RejectedOperations []*coreoperation.QueuedOperation
+}
+
+// TxnFilesError is returned by the operation handler if the batch files couldn't be prepared after some of
+// them were already written to CAS. Artifacts contains the files which were written.
+type TxnFilesError struct {
+	Artifacts []*AnchorDocument
+	Err       error
+}
+
+// Error returns the error message.
+func (e *TxnFilesError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *TxnFilesError) Unwrap() error {
+	return e.Err
 }
 
 // OperationHandler defines an interface for creating batch files.
 type OperationHandler interface {
 	// PrepareTxnFiles operations will create relevant batch files, store them in CAS and return anchor string.
+	// If the batch files couldn't be prepared after some of them were written then a *TxnFilesError
+	// containing the written files is returned.
 	PrepareTxnFiles(ops []*coreoperation.QueuedOperation) (*AnchoringInfo, error)
 }
 
+// OperationHandlerWithContext may be implemented by an OperationHandler which accepts a context. The context
+// carries the (optional) trace span of the batch.
+type OperationHandlerWithContext interface {
+	PrepareTxnFilesWithContext(ctx context.Context, ops []*coreoperation.QueuedOperation) (*AnchoringInfo, error)
+}
+
 // OperationProvider retrieves the anchored operations for the given Sidetree transaction.
 type OperationProvider interface {
 	GetTxnOperations(sidetreeTxn *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error)
 }
 
+// OperationProviderWithContext may be implemented by an OperationProvider which accepts a context. The context
+// carries the (optional) trace span of the caller.
+type OperationProviderWithContext interface {
+	GetTxnOperationsWithContext(ctx context.Context, sidetreeTxn *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error)
+}
+
 // Version contains the protocol and corresponding implementations that are compatible with the protocol version.
 type Version interface {
 	protocol.Version
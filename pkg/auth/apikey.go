@@ -0,0 +1,57 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+// DefaultAPIKeyHeader is the default HTTP header which contains the API key.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator authenticates callers using a static set of API keys.
+type APIKeyAuthenticator struct {
+	header string
+	keys   map[string]string
+}
+
+// NewAPIKeyAuthenticator returns a new API key authenticator. The given map contains the subject
+// for each API key. If header is empty then DefaultAPIKeyHeader is used.
+func NewAPIKeyAuthenticator(header string, keys map[string]string) *APIKeyAuthenticator {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	return &APIKeyAuthenticator{
+		header: header,
+		keys:   keys,
+	}
+}
+
+// Authenticate returns the identity for the API key in the request. A nil identity is returned
+// if the request doesn't contain an API key.
+func (a *APIKeyAuthenticator) Authenticate(req *http.Request) (*auth.Identity, error) {
+	key := req.Header.Get(a.header)
+	if key == "" {
+		return nil, nil
+	}
+
+	for k, subject := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return &auth.Identity{
+				Subject: subject,
+				Method:  auth.MethodAPIKey,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: invalid API key", auth.ErrUnauthenticated)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	a := NewAPIKeyAuthenticator("", map[string]string{"key1": "alice"})
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(DefaultAPIKeyHeader, "key1")
+
+		identity, err := a.Authenticate(req)
+		require.NoError(t, err)
+		require.Equal(t, "alice", identity.Subject)
+		require.Equal(t, auth.MethodAPIKey, identity.Method)
+	})
+
+	t.Run("no API key", func(t *testing.T) {
+		identity, err := a.Authenticate(httptest.NewRequest(http.MethodPost, "/", nil))
+		require.NoError(t, err)
+		require.Nil(t, identity)
+	})
+
+	t.Run("invalid API key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(DefaultAPIKeyHeader, "key2")
+
+		identity, err := a.Authenticate(req)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, auth.ErrUnauthenticated))
+		require.Nil(t, identity)
+	})
+}
+
+func TestMTLSAuthenticator(t *testing.T) {
+	a := NewMTLSAuthenticator()
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{
+				Subject:      pkix.Name{CommonName: "client1"},
+				SerialNumber: big.NewInt(1),
+			}}},
+		}
+
+		identity, err := a.Authenticate(req)
+		require.NoError(t, err)
+		require.Equal(t, "client1", identity.Subject)
+		require.Equal(t, auth.MethodMTLS, identity.Method)
+		require.Equal(t, "1", identity.Claims["serialNumber"])
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.TLS = &tls.ConnectionState{}
+
+		identity, err := a.Authenticate(req)
+		require.NoError(t, err)
+		require.Nil(t, identity)
+	})
+}
+
+func TestChain(t *testing.T) {
+	c := NewChain(
+		NewAPIKeyAuthenticator("", map[string]string{"key1": "alice"}),
+		NewAPIKeyAuthenticator("X-Other-Key", map[string]string{"key2": "bob"}),
+	)
+
+	t.Run("first", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(DefaultAPIKeyHeader, "key1")
+
+		identity, err := c.Authenticate(req)
+		require.NoError(t, err)
+		require.Equal(t, "alice", identity.Subject)
+	})
+
+	t.Run("second", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-Other-Key", "key2")
+
+		identity, err := c.Authenticate(req)
+		require.NoError(t, err)
+		require.Equal(t, "bob", identity.Subject)
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		identity, err := c.Authenticate(httptest.NewRequest(http.MethodPost, "/", nil))
+		require.NoError(t, err)
+		require.Nil(t, identity)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(DefaultAPIKeyHeader, "key2")
+		req.Header.Set("X-Other-Key", "key2")
+
+		identity, err := c.Authenticate(req)
+		require.True(t, errors.Is(err, auth.ErrUnauthenticated))
+		require.Nil(t, identity)
+	})
+}
@@ -0,0 +1,45 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"net/http"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+type authenticator interface {
+	Authenticate(req *http.Request) (*auth.Identity, error)
+}
+
+// Chain invokes a list of authenticators in order and returns the first identity found.
+type Chain struct {
+	authenticators []authenticator
+}
+
+// NewChain returns a new authenticator chain.
+func NewChain(authenticators ...authenticator) *Chain {
+	return &Chain{authenticators: authenticators}
+}
+
+// Authenticate returns the identity of the first authenticator that found credentials in the request.
+// If an authenticator found credentials which are not valid then the error is returned immediately.
+// A nil identity is returned if none of the authenticators found credentials.
+func (c *Chain) Authenticate(req *http.Request) (*auth.Identity, error) {
+	for _, a := range c.authenticators {
+		identity, err := a.Authenticate(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if identity != nil {
+			return identity, nil
+		}
+	}
+
+	return nil, nil
+}
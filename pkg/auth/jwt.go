@@ -0,0 +1,293 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+const (
+	bearerPrefix = "Bearer "
+
+	algES256 = "ES256"
+	algEdDSA = "EdDSA"
+	algRS256 = "RS256"
+	algHS256 = "HS256"
+
+	jwtParts           = 3
+	es256SignatureSize = 64
+
+	defaultLeeway = time.Minute
+)
+
+// JWTAuthenticator authenticates callers using a bearer JWT. The signature of the token is verified using
+// locally configured keys (no key discovery is performed). Supported algorithms are ES256, EdDSA, RS256
+// and HS256.
+type JWTAuthenticator struct {
+	keys     map[string]interface{}
+	issuer   string
+	audience string
+	leeway   time.Duration
+	timeNow  func() time.Time
+
+	allowNoExpiry bool
+}
+
+// JWTOption is an option for the JWT authenticator.
+type JWTOption func(a *JWTAuthenticator)
+
+// WithJWTKey adds a verification key with the given key ID. The key must be an *ecdsa.PublicKey (P-256),
+// ed25519.PublicKey, *rsa.PublicKey or, for HS256, a []byte secret. If the token header doesn't
+// contain a key ID then the key with an empty key ID is used.
+func WithJWTKey(kid string, key interface{}) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.keys[kid] = key
+	}
+}
+
+// WithIssuer sets the expected issuer ("iss" claim) of the token.
+func WithIssuer(issuer string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudience sets the expected audience ("aud" claim) of the token.
+func WithAudience(audience string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.audience = audience
+	}
+}
+
+// WithLeeway sets the allowed clock skew when validating the "exp" and "nbf" claims. Defaults to one minute.
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.leeway = leeway
+	}
+}
+
+// WithAllowNoExpiry allows tokens which don't contain an "exp" claim (i.e. tokens which never expire). By
+// default, such tokens are rejected.
+func WithAllowNoExpiry() JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.allowNoExpiry = true
+	}
+}
+
+// NewJWTAuthenticator returns a new JWT bearer token authenticator.
+func NewJWTAuthenticator(opts ...JWTOption) *JWTAuthenticator {
+	a := &JWTAuthenticator{
+		keys:    make(map[string]interface{}),
+		leeway:  defaultLeeway,
+		timeNow: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Authenticate verifies the bearer token in the Authorization header and returns an identity whose subject
+// is the "sub" claim of the token. A nil identity is returned if the request doesn't contain a bearer token.
+func (a *JWTAuthenticator) Authenticate(req *http.Request) (*auth.Identity, error) {
+	header := req.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return nil, nil
+	}
+
+	claims, err := a.verify(strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid bearer token: %s", auth.ErrUnauthenticated, err)
+	}
+
+	sub, ok := claims["sub"].(string)
+	if !ok || sub == "" {
+		return nil, fmt.Errorf("%w: bearer token is missing the 'sub' claim", auth.ErrUnauthenticated)
+	}
+
+	return &auth.Identity{
+		Subject: sub,
+		Method:  auth.MethodBearer,
+		Claims:  claims,
+	}, nil
+}
+
+func (a *JWTAuthenticator) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != jwtParts {
+		return nil, errors.New("token must have three parts")
+	}
+
+	header := &jwtHeader{}
+
+	if err := decodeSegment(parts[0], header); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+
+	key, ok := a.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID [%s]", header.Kid)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]interface{})
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := a.timeNow()
+
+	exp, ok := claims["exp"].(float64)
+
+	switch {
+	case ok:
+		if now.After(time.Unix(int64(exp), 0).Add(a.leeway)) {
+			return errors.New("token has expired")
+		}
+	case claims["exp"] != nil:
+		return errors.New("invalid 'exp' claim")
+	case !a.allowNoExpiry:
+		return errors.New("token is missing the 'exp' claim")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if now.Add(a.leeway).Before(time.Unix(int64(nbf), 0)) {
+			return errors.New("token is not valid yet")
+		}
+	}
+
+	if a.issuer != "" && claims["iss"] != a.issuer {
+		return fmt.Errorf("unexpected issuer [%v]", claims["iss"])
+	}
+
+	if a.audience != "" && !containsAudience(claims["aud"], a.audience) {
+		return fmt.Errorf("token is not intended for audience [%s]", a.audience)
+	}
+
+	return nil
+}
+
+func containsAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, a := range v {
+			if a == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func verifySignature(alg string, key interface{}, signingInput, sig []byte) error {
+	digest := sha256.Sum256(signingInput)
+
+	switch alg {
+	case algES256:
+		pubKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || pubKey.Curve != elliptic.P256() {
+			return fmt.Errorf("key is not a P-256 public key as required by %s", alg)
+		}
+
+		if len(sig) != es256SignatureSize {
+			return errors.New("invalid signature size")
+		}
+
+		r := new(big.Int).SetBytes(sig[:es256SignatureSize/2])
+		s := new(big.Int).SetBytes(sig[es256SignatureSize/2:])
+
+		if !ecdsa.Verify(pubKey, digest[:], r, s) {
+			return errors.New("invalid signature")
+		}
+	case algEdDSA:
+		pubKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is not an Ed25519 public key as required by %s", alg)
+		}
+
+		if !ed25519.Verify(pubKey, signingInput, sig) {
+			return errors.New("invalid signature")
+		}
+	case algRS256:
+		pubKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is not an RSA public key as required by %s", alg)
+		}
+
+		if err := rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("invalid signature")
+		}
+	case algHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("key is not a secret as required by %s", alg)
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signingInput)
+
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm [%s]", alg)
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
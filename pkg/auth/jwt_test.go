@@ -0,0 +1,204 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+func TestJWTAuthenticator(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	edPubKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	secret := []byte("secret")
+
+	a := NewJWTAuthenticator(
+		WithJWTKey("ec", &ecKey.PublicKey),
+		WithJWTKey("ed", edPubKey),
+		WithJWTKey("rsa", &rsaKey.PublicKey),
+		WithJWTKey("", secret),
+		WithIssuer("https://issuer.example.com"),
+		WithAudience("sidetree"),
+		WithLeeway(time.Second),
+	)
+
+	claims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub": "alice",
+			"iss": "https://issuer.example.com",
+			"aud": []interface{}{"other", "sidetree"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"nbf": time.Now().Add(-time.Hour).Unix(),
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		tokens := map[string]string{
+			"ES256": newJWT(t, "ES256", "ec", claims(), func(input []byte) []byte {
+				digest := sha256.Sum256(input)
+
+				r, s, e := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+				require.NoError(t, e)
+
+				sig := make([]byte, 64)
+				r.FillBytes(sig[:32])
+				s.FillBytes(sig[32:])
+
+				return sig
+			}),
+			"EdDSA": newJWT(t, "EdDSA", "ed", claims(), func(input []byte) []byte {
+				return ed25519.Sign(edKey, input)
+			}),
+			"RS256": newJWT(t, "RS256", "rsa", claims(), func(input []byte) []byte {
+				digest := sha256.Sum256(input)
+
+				sig, e := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+				require.NoError(t, e)
+
+				return sig
+			}),
+			"HS256": newJWT(t, "HS256", "", claims(), hs256(secret)),
+		}
+
+		for alg, token := range tokens {
+			identity, err := a.Authenticate(newBearerRequest(token))
+			require.NoError(t, err, alg)
+			require.Equal(t, "alice", identity.Subject)
+			require.Equal(t, auth.MethodBearer, identity.Method)
+			require.Equal(t, "https://issuer.example.com", identity.Claims["iss"])
+		}
+	})
+
+	t.Run("no bearer token", func(t *testing.T) {
+		identity, err := a.Authenticate(httptest.NewRequest(http.MethodPost, "/", nil))
+		require.NoError(t, err)
+		require.Nil(t, identity)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		expired := claims()
+		expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+		notYetValid := claims()
+		notYetValid["nbf"] = time.Now().Add(time.Minute).Unix()
+
+		wrongIssuer := claims()
+		wrongIssuer["iss"] = "https://other.example.com"
+
+		wrongAudience := claims()
+		wrongAudience["aud"] = "other"
+
+		noSubject := claims()
+		delete(noSubject, "sub")
+
+		noExpiry := claims()
+		delete(noExpiry, "exp")
+
+		invalidExpiry := claims()
+		invalidExpiry["exp"] = "tomorrow"
+
+		tests := map[string]struct {
+			token string
+			err   string
+		}{
+			"malformed":         {token: "abc", err: "token must have three parts"},
+			"invalid header":    {token: "!!.abc.abc", err: "decode header"},
+			"unknown key":       {token: newJWT(t, "HS256", "xyz", claims(), hs256(secret)), err: "unknown key ID [xyz]"},
+			"invalid signature": {token: newJWT(t, "HS256", "", claims(), hs256([]byte("other"))), err: "invalid signature"},
+			"unsupported alg":   {token: newJWT(t, "none", "", claims(), hs256(secret)), err: "unsupported algorithm [none]"},
+			"alg/key mismatch":  {token: newJWT(t, "ES256", "", claims(), hs256(secret)), err: "key is not a P-256 public key"},
+			"expired":           {token: newJWT(t, "HS256", "", expired, hs256(secret)), err: "token has expired"},
+			"not yet valid":     {token: newJWT(t, "HS256", "", notYetValid, hs256(secret)), err: "token is not valid yet"},
+			"wrong issuer":      {token: newJWT(t, "HS256", "", wrongIssuer, hs256(secret)), err: "unexpected issuer"},
+			"wrong audience":    {token: newJWT(t, "HS256", "", wrongAudience, hs256(secret)), err: "not intended for audience"},
+			"no subject":        {token: newJWT(t, "HS256", "", noSubject, hs256(secret)), err: "missing the 'sub' claim"},
+			"no expiry":         {token: newJWT(t, "HS256", "", noExpiry, hs256(secret)), err: "missing the 'exp' claim"},
+			"invalid expiry":    {token: newJWT(t, "HS256", "", invalidExpiry, hs256(secret)), err: "invalid 'exp' claim"},
+		}
+
+		for name, tc := range tests {
+			identity, err := a.Authenticate(newBearerRequest(tc.token))
+			require.Error(t, err, name)
+			require.True(t, errors.Is(err, auth.ErrUnauthenticated), name)
+			require.Contains(t, err.Error(), tc.err, name)
+			require.Nil(t, identity)
+		}
+	})
+
+	t.Run("allow no expiry", func(t *testing.T) {
+		noExpiry := claims()
+		delete(noExpiry, "exp")
+
+		a := NewJWTAuthenticator(WithJWTKey("", secret), WithAllowNoExpiry())
+
+		identity, err := a.Authenticate(newBearerRequest(newJWT(t, "HS256", "", noExpiry, hs256(secret))))
+		require.NoError(t, err)
+		require.Equal(t, "alice", identity.Subject)
+
+		// An expired token is still rejected.
+		expired := claims()
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+		_, err = a.Authenticate(newBearerRequest(newJWT(t, "HS256", "", expired, hs256(secret))))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "token has expired")
+	})
+}
+
+func newBearerRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return req
+}
+
+func hs256(secret []byte) func(input []byte) []byte {
+	return func(input []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+
+		return mac.Sum(nil)
+	}
+}
+
+func newJWT(t *testing.T, alg, kid string, claims map[string]interface{}, sign func(input []byte) []byte) string {
+	t.Helper()
+
+	headerBytes, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+
+	claimsBytes, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	input := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(claimsBytes)
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(input)))
+}
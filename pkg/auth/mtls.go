@@ -0,0 +1,43 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"net/http"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+// MTLSAuthenticator authenticates callers using the TLS client certificate. The certificate must have
+// been verified by the TLS server (i.e. the server must be configured to require and verify client
+// certificates).
+type MTLSAuthenticator struct{}
+
+// NewMTLSAuthenticator returns a new mutual TLS authenticator.
+func NewMTLSAuthenticator() *MTLSAuthenticator {
+	return &MTLSAuthenticator{}
+}
+
+// Authenticate returns an identity whose subject is the common name of the client certificate.
+// A nil identity is returned if the request doesn't contain a verified client certificate.
+func (a *MTLSAuthenticator) Authenticate(req *http.Request) (*auth.Identity, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+
+	cert := req.TLS.VerifiedChains[0][0]
+
+	return &auth.Identity{
+		Subject: cert.Subject.CommonName,
+		Method:  auth.MethodMTLS,
+		Claims: map[string]interface{}{
+			"subject":      cert.Subject.String(),
+			"issuer":       cert.Issuer.String(),
+			"serialNumber": cert.SerialNumber.String(),
+		},
+	}, nil
+}
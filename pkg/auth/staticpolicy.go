@@ -0,0 +1,122 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"fmt"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+const (
+	// AnySubject matches any authenticated caller.
+	AnySubject = "*"
+
+	// Anonymous matches a caller that did not provide any credentials.
+	Anonymous = "anonymous"
+
+	// AnyAnchorOrigin matches any anchor origin.
+	AnyAnchorOrigin = "*"
+)
+
+// Rule allows the given subjects to submit the given operation types for the given anchor origins.
+type Rule struct {
+	// Subjects contains the subjects to which the rule applies. AnySubject matches any authenticated
+	// caller and Anonymous matches a caller that did not provide any credentials.
+	Subjects []string `json:"subjects"`
+
+	// OperationTypes contains the allowed operation types. If empty then all operation types are allowed.
+	OperationTypes []operation.Type `json:"operationTypes,omitempty"`
+
+	// AnchorOrigins contains the allowed anchor origins. If empty then all anchor origins are allowed.
+	AnchorOrigins []string `json:"anchorOrigins,omitempty"`
+}
+
+// StaticPolicy is an authorizer that allows an operation if at least one of the configured rules matches
+// the caller, the operation type and the anchor origin of the operation. All other operations are denied.
+type StaticPolicy struct {
+	rules []Rule
+}
+
+// NewStaticPolicy returns a new static policy with the given rules.
+func NewStaticPolicy(rules ...Rule) *StaticPolicy {
+	return &StaticPolicy{rules: rules}
+}
+
+// Authorize returns an error that wraps auth.ErrForbidden if none of the rules allow the operation.
+func (p *StaticPolicy) Authorize(identity *auth.Identity, op *operation.Operation) error {
+	subject := Anonymous
+	if identity != nil {
+		subject = identity.Subject
+	}
+
+	for i := range p.rules {
+		if p.rules[i].matches(identity, op) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: subject [%s] is not allowed to submit %s operation for anchor origin [%v]",
+		auth.ErrForbidden, subject, op.Type, op.AnchorOrigin)
+}
+
+func (r *Rule) matches(identity *auth.Identity, op *operation.Operation) bool {
+	return r.matchesSubject(identity) && r.matchesOperationType(op.Type) && r.matchesAnchorOrigin(op.AnchorOrigin)
+}
+
+func (r *Rule) matchesSubject(identity *auth.Identity) bool {
+	for _, s := range r.Subjects {
+		if identity == nil {
+			if s == Anonymous {
+				return true
+			}
+
+			continue
+		}
+
+		if s == AnySubject || s == identity.Subject {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchesOperationType(opType operation.Type) bool {
+	if len(r.OperationTypes) == 0 {
+		return true
+	}
+
+	for _, t := range r.OperationTypes {
+		if t == opType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchesAnchorOrigin(anchorOrigin interface{}) bool {
+	if len(r.AnchorOrigins) == 0 {
+		return true
+	}
+
+	origin, ok := anchorOrigin.(string)
+	if !ok && anchorOrigin != nil {
+		origin = fmt.Sprintf("%v", anchorOrigin)
+	}
+
+	for _, o := range r.AnchorOrigins {
+		if o == AnyAnchorOrigin || o == origin {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+)
+
+func TestStaticPolicy_Authorize(t *testing.T) {
+	alice := &auth.Identity{Subject: "alice", Method: auth.MethodBearer}
+	bob := &auth.Identity{Subject: "bob", Method: auth.MethodAPIKey}
+
+	createOp := &operation.Operation{Type: operation.TypeCreate, AnchorOrigin: "https://orb.domain1.com"}
+	updateOp := &operation.Operation{Type: operation.TypeUpdate, AnchorOrigin: "https://orb.domain2.com"}
+
+	p := NewStaticPolicy(
+		Rule{Subjects: []string{Anonymous}, OperationTypes: []operation.Type{operation.TypeCreate}},
+		Rule{Subjects: []string{"alice"}},
+		Rule{
+			Subjects:       []string{AnySubject},
+			OperationTypes: []operation.Type{operation.TypeUpdate},
+			AnchorOrigins:  []string{"https://orb.domain1.com"},
+		},
+	)
+
+	t.Run("anonymous", func(t *testing.T) {
+		require.NoError(t, p.Authorize(nil, createOp))
+
+		err := p.Authorize(nil, updateOp)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, auth.ErrForbidden))
+		require.Contains(t, err.Error(), "subject [anonymous] is not allowed to submit update operation")
+	})
+
+	t.Run("subject", func(t *testing.T) {
+		require.NoError(t, p.Authorize(alice, createOp))
+		require.NoError(t, p.Authorize(alice, updateOp))
+	})
+
+	t.Run("any subject", func(t *testing.T) {
+		require.NoError(t, p.Authorize(bob, &operation.Operation{
+			Type:         operation.TypeUpdate,
+			AnchorOrigin: "https://orb.domain1.com",
+		}))
+
+		err := p.Authorize(bob, updateOp)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, auth.ErrForbidden))
+
+		err = p.Authorize(bob, createOp)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, auth.ErrForbidden))
+	})
+
+	t.Run("any anchor origin", func(t *testing.T) {
+		p := NewStaticPolicy(Rule{Subjects: []string{"bob"}, AnchorOrigins: []string{AnyAnchorOrigin}})
+
+		require.NoError(t, p.Authorize(bob, updateOp))
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		err := NewStaticPolicy().Authorize(alice, createOp)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, auth.ErrForbidden))
+	})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package batch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+)
+
+// admission enforces the limits on the operation queue. The queue depth, byte budget and client quotas are
+// tracked (under the mutex, so that concurrent additions can't exceed the limits) for the operations that were
+// added by this writer and not yet committed. The queue depth also includes the operations which were already
+// in the queue when the writer was created (e.g. added to a persistent queue by a previous instance).
+type admission struct {
+	maxDepth     uint
+	maxBytes     int64
+	maxClientOps uint
+	retryAfter   time.Duration
+
+	mutex     sync.Mutex
+	depth     uint
+	bytes     int64
+	clientOps map[string]uint
+}
+
+func newAdmission(opts Options, queueLen uint) *admission {
+	retryAfter := defaultRetryAfter
+	if opts.RetryAfter != 0 {
+		retryAfter = opts.RetryAfter
+	}
+
+	return &admission{
+		maxDepth:     opts.MaxQueueDepth,
+		maxBytes:     opts.MaxQueueBytes,
+		maxClientOps: opts.MaxOperationsPerClient,
+		retryAfter:   retryAfter,
+		depth:        queueLen,
+		clientOps:    make(map[string]uint),
+	}
+}
+
+// admit returns an operation.QueueFullError if adding the given operation would exceed any of the limits.
+// Otherwise, the operation is accounted for and nil is returned.
+func (a *admission) admit(op *operation.QueuedOperation) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.maxDepth > 0 && a.depth >= a.maxDepth {
+		return operation.NewQueueFullError(a.retryAfter, "maximum queue depth %d reached", a.maxDepth)
+	}
+
+	size := int64(len(op.OperationRequest))
+
+	if a.maxBytes > 0 && a.bytes+size > a.maxBytes {
+		return operation.NewQueueFullError(a.retryAfter, "maximum queue size of %d bytes reached", a.maxBytes)
+	}
+
+	if a.maxClientOps > 0 && op.ClientID != "" && a.clientOps[op.ClientID] >= a.maxClientOps {
+		return operation.NewQueueFullError(a.retryAfter, "client [%s] has reached its quota of %d queued operations",
+			op.ClientID, a.maxClientOps)
+	}
+
+	a.add(op)
+
+	return nil
+}
+
+// reserve accounts for the given operation without checking the limits.
+func (a *admission) reserve(op *operation.QueuedOperation) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.add(op)
+}
+
+// add accounts for the given operation without checking the limits.
+func (a *admission) add(op *operation.QueuedOperation) {
+	a.depth++
+	a.bytes += int64(len(op.OperationRequest))
+
+	if op.ClientID != "" {
+		a.clientOps[op.ClientID]++
+	}
+}
+
+// release releases the given operations (which have been removed from the queue).
+func (a *admission) release(ops ...*operation.QueuedOperation) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, op := range ops {
+		if a.depth > 0 {
+			a.depth--
+		}
+
+		a.bytes -= int64(len(op.OperationRequest))
+		if a.bytes < 0 {
+			// The operation may have been added to a persistent queue by a previous instance.
+			a.bytes = 0
+		}
+
+		if n, ok := a.clientOps[op.ClientID]; ok {
+			if n <= 1 {
+				delete(a.clientOps, op.ClientID)
+			} else {
+				a.clientOps[op.ClientID] = n - 1
+			}
+		}
+	}
+}
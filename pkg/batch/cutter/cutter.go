@@ -8,6 +8,7 @@ package cutter
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/trustbloc/logutil-go/pkg/log"
 
@@ -51,23 +52,56 @@ type Result struct {
 	Nack func(error)
 }
 
+// RejectedOperationHandler is invoked for a queued operation which is rejected by the protocol version that
+// became current after it was queued.
+type RejectedOperationHandler = func(op *operation.QueuedOperation, err error)
+
 // BatchCutter implements batch cutting.
 type BatchCutter struct {
 	pendingBatch OperationQueue
 	client       protocol.Client
+	rejected     RejectedOperationHandler
+
+	// The mutex prevents operations from being added while the queue is migrated.
+	mutex           sync.RWMutex
+	currentGenesis  uint64
+	migrationNeeded bool
+}
+
+// Option is a batch cutter option.
+type Option func(c *BatchCutter)
+
+// WithRejectedOperationHandler sets the handler which is invoked for each queued operation that is not valid
+// under the protocol version that became current after the operation was queued. The operation is removed
+// from the queue before the handler is invoked, and the handler may add operations to the cutter.
+func WithRejectedOperationHandler(handler RejectedOperationHandler) Option {
+	return func(c *BatchCutter) {
+		c.rejected = handler
+	}
 }
 
 // New creates a Cutter implementation.
-func New(client protocol.Client, queue OperationQueue) *BatchCutter {
-	return &BatchCutter{
-		client:       client,
-		pendingBatch: queue,
+func New(client protocol.Client, queue OperationQueue, opts ...Option) *BatchCutter {
+	c := &BatchCutter{
+		client:          client,
+		pendingBatch:    queue,
+		rejected:        func(*operation.QueuedOperation, error) {},
+		migrationNeeded: true,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
 // Add adds the given operation to pending batch queue and returns the total
 // number of pending operations.
 func (r *BatchCutter) Add(op *operation.QueuedOperation, protocolVersion uint64) (uint, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
 	// Enqueuing operation into batch
 	return r.pendingBatch.Add(op, protocolVersion)
 }
@@ -85,6 +119,14 @@ func (r *BatchCutter) Cut(force bool) (Result, error) {
 		return Result{}, err
 	}
 
+	if r.migrationNeeded || currentProtocol.Protocol().GenesisTime != r.currentGenesis {
+		if err := r.migrate(currentProtocol); err != nil {
+			return Result{Pending: pending}, fmt.Errorf("migrate queued operations: %w", err)
+		}
+
+		pending = r.pendingBatch.Len()
+	}
+
 	maxOperationsPerBatch := currentProtocol.Protocol().MaxOperationCount
 	if !force && pending < maxOperationsPerBatch {
 		return Result{Pending: pending}, nil
@@ -123,6 +165,108 @@ func (r *BatchCutter) Cut(force bool) (Result, error) {
 	}, nil
 }
 
+// migrate moves the queued operations which were added under a protocol version that is older than the
+// current protocol version forward to the current version, so that they're anchored under the current version.
+// Each of these operations is validated against the current version first and the operations which are no
+// longer valid are removed from the queue and passed to the rejected operation handler. The order of the
+// operations in the queue is preserved.
+func (r *BatchCutter) migrate(current protocol.Version) error {
+	rejected, err := r.migrateQueue(current)
+	if err != nil {
+		return err
+	}
+
+	// The rejected operation handler is invoked without holding the lock since the handler may call back
+	// into the cutter (e.g. to add an operation).
+	for _, op := range rejected {
+		logger.Warn("Rejected queued operation since it's not valid under the current protocol version.",
+			logfields.WithSuffix(op.op.UniqueSuffix), logfields.WithGenesisTime(current.Protocol().GenesisTime),
+			log.WithError(op.err))
+
+		r.rejected(op.op, op.err)
+	}
+
+	return nil
+}
+
+type rejectedOp struct {
+	op  *operation.QueuedOperation
+	err error
+}
+
+// migrateQueue migrates the queued operations to the given protocol version (see migrate) and returns the
+// operations which were removed from the queue since they're not valid under that version.
+func (r *BatchCutter) migrateQueue(current protocol.Version) ([]*rejectedOp, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	genesisTime := current.Protocol().GenesisTime
+
+	ops, err := r.pendingBatch.Peek(r.pendingBatch.Len())
+	if err != nil {
+		return nil, fmt.Errorf("peek queue: %w", err)
+	}
+
+	if !hasOperationsBefore(ops, genesisTime) {
+		r.currentGenesis = genesisTime
+		r.migrationNeeded = false
+
+		return nil, nil
+	}
+
+	ops, ack, nack, err := r.pendingBatch.Remove(uint(len(ops)))
+	if err != nil {
+		return nil, fmt.Errorf("remove from queue: %w", err)
+	}
+
+	var rejected []*rejectedOp
+
+	migrated := 0
+
+	for _, op := range ops {
+		protocolVersion := op.ProtocolVersion
+
+		if protocolVersion < genesisTime {
+			if _, e := current.OperationParser().Parse(op.Namespace, op.OperationRequest); e != nil {
+				rejected = append(rejected, &rejectedOp{op: &op.QueuedOperation, err: e})
+
+				continue
+			}
+
+			protocolVersion = genesisTime
+			migrated++
+		}
+
+		if _, e := r.pendingBatch.Add(&op.QueuedOperation, protocolVersion); e != nil {
+			// The operations which were already added are duplicated in the queue. This can't happen with the
+			// in-memory queue.
+			nack(e)
+
+			return nil, fmt.Errorf("add to queue: %w", e)
+		}
+	}
+
+	ack()
+
+	r.currentGenesis = genesisTime
+	r.migrationNeeded = false
+
+	logger.Info("Migrated queued operations to the current protocol version.", logfields.WithGenesisTime(genesisTime),
+		logfields.WithTotal(migrated), logfields.WithTotalPending(r.pendingBatch.Len()))
+
+	return rejected, nil
+}
+
+func hasOperationsBefore(ops []*operation.QueuedOperationAtTime, genesisTime uint64) bool {
+	for _, op := range ops {
+		if op.ProtocolVersion < genesisTime {
+			return true
+		}
+	}
+
+	return false
+}
+
 // getOperationsAtProtocolVersion iterates through the operations and returns the operations which are at the same protocol genesis time.
 func getOperationsAtProtocolVersion(opsAtTime []*operation.QueuedOperationAtTime) ([]*operation.QueuedOperation, uint64) {
 	var ops []*operation.QueuedOperation
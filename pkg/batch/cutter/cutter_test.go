@@ -12,6 +12,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	coreoperation "github.com/trustbloc/sidetree-go/pkg/api/operation"
+	stmocks "github.com/trustbloc/sidetree-go/pkg/mocks"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/batch/opqueue"
@@ -124,3 +126,174 @@ func TestBatchCutter(t *testing.T) {
 
 	require.Zero(t, result.Ack())
 }
+
+func TestBatchCutter_Migrate(t *testing.T) {
+	t.Run("compatible and incompatible operations", func(t *testing.T) {
+		c := mocks.NewMockProtocolClient()
+		c.Protocol.MaxOperationCount = 10
+		c.CurrentVersion.ProtocolReturns(c.Protocol)
+
+		type rejectedOp struct {
+			op  *operation.QueuedOperation
+			err error
+		}
+
+		var rejected []rejectedOp
+
+		r := New(c, &opqueue.MemQueue{}, WithRejectedOperationHandler(func(op *operation.QueuedOperation, err error) {
+			rejected = append(rejected, rejectedOp{op: op, err: err})
+		}))
+
+		_, err := r.Add(operation1, 0)
+		require.NoError(t, err)
+		_, err = r.Add(operation2, 0)
+		require.NoError(t, err)
+		_, err = r.Add(operation3, 0)
+		require.NoError(t, err)
+
+		// Upgrade the protocol. The new version rejects operation2.
+		p := c.Protocol
+		p.GenesisTime = 100
+
+		parser := &stmocks.OperationParser{}
+		parser.ParseCalls(func(_ string, request []byte) (*coreoperation.Operation, error) {
+			if string(request) == string(operation2.OperationRequest) {
+				return nil, errors.New("invalid operation")
+			}
+
+			return &coreoperation.Operation{}, nil
+		})
+
+		upgraded := mocks.GetProtocolVersion(p)
+		upgraded.OperationParserReturns(parser)
+
+		c.CurrentVersion = upgraded
+
+		_, err = r.Add(operation4, 100)
+		require.NoError(t, err)
+
+		result, err := r.Cut(true)
+		require.NoError(t, err)
+		require.Len(t, result.Operations, 3)
+		require.Equal(t, operation1, result.Operations[0])
+		require.Equal(t, operation3, result.Operations[1])
+		require.Equal(t, operation4, result.Operations[2])
+		require.Equal(t, uint64(100), result.ProtocolVersion)
+		require.Zero(t, result.Pending)
+		require.Zero(t, result.Ack())
+
+		require.Len(t, rejected, 1)
+		require.Equal(t, operation2, rejected[0].op)
+		require.EqualError(t, rejected[0].err, "invalid operation")
+
+		// Only the operations which were queued under the previous version are validated.
+		require.Equal(t, 3, parser.ParseCallCount())
+
+		// The queue is only migrated when the current version changes.
+		_, err = r.Add(operation5, 0)
+		require.NoError(t, err)
+
+		result, err = r.Cut(true)
+		require.NoError(t, err)
+		require.Len(t, result.Operations, 1)
+		require.Equal(t, uint64(0), result.ProtocolVersion)
+		require.Equal(t, 3, parser.ParseCallCount())
+	})
+
+	t.Run("handler adds operation", func(t *testing.T) {
+		c := mocks.NewMockProtocolClient()
+		c.CurrentVersion.ProtocolReturns(c.Protocol)
+
+		var r *BatchCutter
+
+		// The handler resubmits the rejected operation under the current version, which must not deadlock.
+		r = New(c, &opqueue.MemQueue{}, WithRejectedOperationHandler(func(op *operation.QueuedOperation, _ error) {
+			_, err := r.Add(op, 100)
+			require.NoError(t, err)
+		}))
+
+		_, err := r.Add(operation1, 0)
+		require.NoError(t, err)
+
+		p := c.Protocol
+		p.GenesisTime = 100
+
+		parser := &stmocks.OperationParser{}
+		parser.ParseReturns(nil, errors.New("invalid operation"))
+
+		upgraded := mocks.GetProtocolVersion(p)
+		upgraded.OperationParserReturns(parser)
+
+		c.CurrentVersion = upgraded
+
+		result, err := r.Cut(true)
+		require.NoError(t, err)
+		require.Len(t, result.Operations, 1)
+		require.Equal(t, operation1, result.Operations[0])
+		require.Equal(t, uint64(100), result.ProtocolVersion)
+	})
+
+	t.Run("queue error", func(t *testing.T) {
+		c := mocks.NewMockProtocolClient()
+		c.CurrentVersion.ProtocolReturns(c.Protocol)
+
+		q := &mockQueue{MemQueue: &opqueue.MemQueue{}}
+
+		r := New(c, q)
+
+		_, err := r.Add(operation1, 0)
+		require.NoError(t, err)
+
+		p := c.Protocol
+		p.GenesisTime = 100
+
+		c.CurrentVersion = mocks.GetProtocolVersion(p)
+
+		q.addErr = errors.New("injected add error")
+
+		result, err := r.Cut(true)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "migrate queued operations: add to queue: injected add error")
+		require.Empty(t, result.Operations)
+
+		// The operation is still in the queue since the removal was rolled back.
+		q.addErr = nil
+
+		result, err = r.Cut(true)
+		require.NoError(t, err)
+		require.Len(t, result.Operations, 1)
+		require.Equal(t, uint64(100), result.ProtocolVersion)
+
+		q.peekErr = errors.New("injected peek error")
+
+		p.GenesisTime = 200
+		c.CurrentVersion = mocks.GetProtocolVersion(p)
+
+		_, err = r.Cut(true)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "migrate queued operations: peek queue: injected peek error")
+	})
+}
+
+type mockQueue struct {
+	*opqueue.MemQueue
+
+	addErr  error
+	peekErr error
+}
+
+func (q *mockQueue) Add(op *operation.QueuedOperation, protocolVersion uint64) (uint, error) {
+	if q.addErr != nil {
+		return 0, q.addErr
+	}
+
+	return q.MemQueue.Add(op, protocolVersion)
+}
+
+func (q *mockQueue) Peek(num uint) (operation.QueuedOperationsAtTime, error) {
+	if q.peekErr != nil {
+		return nil, q.peekErr
+	}
+
+	return q.MemQueue.Peek(num)
+}
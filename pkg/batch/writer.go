@@ -17,18 +17,22 @@ SPDX-License-Identifier: Apache-2.0
 package batch
 
 import (
+	"context"
 	"fmt"
 	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/trustbloc/logutil-go/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
 	"github.com/trustbloc/sidetree-svc-go/pkg/batch/cutter"
 	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
 )
 
 const (
@@ -36,11 +40,29 @@ const (
 
 	defaultBatchTimeout    = 2 * time.Second
 	defaultMonitorInterval = time.Second
+	defaultRetryAfter      = 5 * time.Second
 )
 
+var tracer = otel.Tracer(loggerModule)
+
+var errRejected = errors.New("operation can't be included in a batch")
+
 // Option defines Writer options such as batch timeout.
 type Option func(opts *Options) error
 
+type metricsProvider interface {
+	BatchQueueDepth(value uint)
+	BatchPendingOperations(value uint)
+}
+
+// artifactRecorder records the batch files (artifacts) which were written to CAS along with the
+// anchor status of the batch (see the retention package).
+type artifactRecorder interface {
+	RecordPending(anchorString string, artifacts []*protocol.AnchorDocument) error
+	RecordAnchored(anchorString string, artifacts []*protocol.AnchorDocument) error
+	RecordFailed(anchorString string, artifacts []*protocol.AnchorDocument) error
+}
+
 type batchCutter interface {
 	Add(operation *operation.QueuedOperation, protocolVersion uint64) (uint, error)
 	Cut(force bool) (cutter.Result, error)
@@ -56,7 +78,12 @@ type Writer struct {
 	protocol           protocol.Client
 	monitorTicker      *time.Ticker
 	batchTimeoutTicker *time.Ticker
+	admission          *admission
+	metrics            metricsProvider
+	artifacts          artifactRecorder
 	logger             *log.Log
+
+	rejectedOperationHandler cutter.RejectedOperationHandler
 }
 
 // Context contains batch writer context.
@@ -104,15 +131,48 @@ func New(namespace string, context Context, options ...Option) (*Writer, error)
 		monitorInterval = rOpts.MonitorInterval
 	}
 
+	var metrics metricsProvider = &noopMetricsProvider{}
+	if rOpts.metrics != nil {
+		metrics = rOpts.metrics
+	}
+
+	var artifacts artifactRecorder = &noopArtifactRecorder{}
+	if rOpts.artifactRecorder != nil {
+		artifacts = rOpts.artifactRecorder
+	}
+
+	admission := newAdmission(rOpts, context.OperationQueue().Len())
+
+	logger := log.New(loggerModule, log.WithFields(logfields.WithNamespace(namespace)))
+
+	// Operations which were queued under a previous protocol version and which are no longer valid under the
+	// current version are removed from the queue by the batch cutter.
+	rejected := func(op *operation.QueuedOperation, err error) {
+		admission.release(op)
+
+		logger.Warn("Queued operation was rejected after a protocol upgrade", logfields.WithSuffix(op.UniqueSuffix),
+			log.WithError(err))
+
+		if rOpts.rejectedOperationHandler != nil {
+			rOpts.rejectedOperationHandler(op, err)
+		}
+	}
+
 	return &Writer{
-		namespace:          namespace,
-		batchCutter:        cutter.New(context.Protocol(), context.OperationQueue()),
+		namespace: namespace,
+		batchCutter: cutter.New(context.Protocol(), context.OperationQueue(),
+			cutter.WithRejectedOperationHandler(rejected)),
 		exitChan:           make(chan struct{}),
 		context:            context,
 		protocol:           context.Protocol(),
 		batchTimeoutTicker: time.NewTicker(batchTimeout),
 		monitorTicker:      time.NewTicker(monitorInterval),
-		logger:             log.New(loggerModule, log.WithFields(logfields.WithNamespace(namespace))),
+		admission:          admission,
+		metrics:            metrics,
+		artifacts:          artifacts,
+		logger:             logger,
+
+		rejectedOperationHandler: rOpts.rejectedOperationHandler,
 	}, nil
 }
 
@@ -142,16 +202,28 @@ func (r *Writer) Stopped() bool {
 }
 
 // Add the given operation to a queue of operations to be batched and anchored on anchoring system.
+// An operation.QueueFullError is returned if the operation would exceed the maximum queue depth,
+// the maximum queue size or the quota of the client that submitted the operation.
 func (r *Writer) Add(op *operation.QueuedOperation, protocolVersion uint64) error {
 	if r.Stopped() {
 		return errors.New("writer is stopped")
 	}
 
-	_, err := r.batchCutter.Add(op, protocolVersion)
+	if err := r.admission.admit(op); err != nil {
+		r.logger.Warn("Operation not admitted to the queue", logfields.WithSuffix(op.UniqueSuffix), log.WithError(err))
+
+		return err
+	}
+
+	depth, err := r.batchCutter.Add(op, protocolVersion)
 	if err != nil {
+		r.admission.release(op)
+
 		return err
 	}
 
+	r.metrics.BatchQueueDepth(depth)
+
 	return nil
 }
 
@@ -221,7 +293,12 @@ func (r *Writer) drain() (pending uint, err error) {
 }
 
 func (r *Writer) cutAndProcess(forceCut bool) (numProcessed int, pending uint, err error) {
+	cutStartTime := time.Now()
+
 	result, err := r.batchCutter.Cut(forceCut)
+
+	cutEndTime := time.Now()
+
 	if err != nil {
 		r.logger.Error("Error cutting batch", log.WithError(err))
 
@@ -229,14 +306,21 @@ func (r *Writer) cutAndProcess(forceCut bool) (numProcessed int, pending uint, e
 	}
 
 	if len(result.Operations) == 0 {
+		r.metrics.BatchQueueDepth(result.Pending)
+
 		return 0, result.Pending, nil
 	}
 
+	ctx, span := r.startBatchSpan(result.Operations, cutStartTime, cutEndTime)
+	defer span.End()
+
 	r.logger.Info("Processing batch operations for protocol genesis time...",
 		logfields.WithTotal(len(result.Operations)), logfields.WithGenesisTime(result.ProtocolVersion))
 
-	err = r.process(result.Operations, result.ProtocolVersion)
+	err = r.process(ctx, result.Operations, result.ProtocolVersion)
 	if err != nil {
+		tracing.RecordError(span, err)
+
 		r.logger.Error("Error processing batch operations", logfields.WithTotal(len(result.Operations)), log.WithError(err))
 
 		result.Nack(err)
@@ -249,12 +333,47 @@ func (r *Writer) cutAndProcess(forceCut bool) (numProcessed int, pending uint, e
 
 	pending = result.Ack()
 
+	r.admission.release(result.Operations...)
+
+	r.metrics.BatchPendingOperations(pending)
+	r.metrics.BatchQueueDepth(pending)
+
 	r.logger.Info("Successfully committed to batch cutter.", logfields.WithTotalPending(pending))
 
 	return len(result.Operations), pending, nil
 }
 
-func (r *Writer) process(ops []*operation.QueuedOperation, protocolVersion uint64) error {
+// startBatchSpan starts the span of a batch that was cut in the given time interval. Since the operations in the
+// batch were submitted by other requests (i.e. other traces), the span is the root of a new trace which
+// is linked to the spans of the originating requests.
+func (r *Writer) startBatchSpan(ops []*operation.QueuedOperation, cutStartTime, cutEndTime time.Time) (context.Context,
+	trace.Span) {
+	traceContexts := make([]map[string]string, len(ops))
+
+	for i, op := range ops {
+		traceContexts[i] = op.TraceContext
+	}
+
+	ctx, span := tracer.Start(context.Background(), "Writer.processBatch",
+		trace.WithTimestamp(cutStartTime),
+		trace.WithLinks(tracing.Links(traceContexts...)...),
+		trace.WithAttributes(tracing.WithNamespace(r.namespace), tracing.WithTotal(len(ops))),
+	)
+
+	for _, op := range ops {
+		span.AddEvent("operation", trace.WithAttributes(tracing.WithSuffix(op.UniqueSuffix),
+			tracing.WithOperationType(string(op.Type))))
+	}
+
+	// The span for cutting the batch is recorded after the fact since a cut usually doesn't result
+	// in a batch, in which case no span is recorded.
+	_, cutSpan := tracer.Start(ctx, "BatchCutter.Cut", trace.WithTimestamp(cutStartTime))
+	cutSpan.End(trace.WithTimestamp(cutEndTime))
+
+	return ctx, span
+}
+
+func (r *Writer) process(ctx context.Context, ops []*operation.QueuedOperation, protocolVersion uint64) error {
 	if len(ops) == 0 {
 		return errors.New("create batch called with no pending operations, should not happen")
 	}
@@ -264,30 +383,113 @@ func (r *Writer) process(ops []*operation.QueuedOperation, protocolVersion uint6
 		return err
 	}
 
-	anchoringInfo, err := p.OperationHandler().PrepareTxnFiles(ops)
+	anchoringInfo, err := prepareTxnFiles(ctx, p.OperationHandler(), ops)
 	if err != nil {
+		// The files which were written before the error will never be anchored.
+		var filesErr *protocol.TxnFilesError
+		if errors.As(err, &filesErr) {
+			r.recordArtifacts(&protocol.AnchoringInfo{Artifacts: filesErr.Artifacts}, r.artifacts.RecordFailed)
+		}
+
 		return err
 	}
 
-	r.logger.Info("Writing anchor string", logfields.WithAnchorString(anchoringInfo.AnchorString))
-
-	// Create Sidetree transaction in anchoring system (write anchor string)
-	err = r.context.Anchor().WriteAnchor(anchoringInfo.AnchorString, anchoringInfo.Artifacts,
-		anchoringInfo.OperationReferences, protocolVersion)
-	if err != nil {
-		return fmt.Errorf("write anchor [%s]: %w", anchoringInfo.AnchorString, err)
+	if anchoringInfo.AnchorString != "" {
+		if err := r.anchor(ctx, anchoringInfo, protocolVersion); err != nil {
+			return err
+		}
+	} else {
+		r.logger.Info("No operations remain in the batch - skipping anchor")
 	}
 
 	// Sidetree spec allows for one operation per suffix in the batch
 	// Process additional operations for suffix in the next batch
+	// (the additional operations were already admitted so the queue limits are not enforced)
 	for _, op := range anchoringInfo.AdditionalOperations {
-		if e := r.Add(op, protocolVersion); e != nil {
+		if e := r.readd(op, protocolVersion); e != nil {
 			// this error should never happen since parsing of this operation has already been done for the previous batch
 			r.logger.Warn("Unable to add additional operation to the next batch",
 				logfields.WithSuffix(op.UniqueSuffix), log.WithError(e))
 		}
 	}
 
+	// Rejected operations were removed from the batch by the operation handler since they can never be anchored.
+	for _, op := range anchoringInfo.RejectedOperations {
+		r.logger.Warn("Operation was rejected by the operation handler", logfields.WithSuffix(op.UniqueSuffix))
+
+		if r.rejectedOperationHandler != nil {
+			r.rejectedOperationHandler(op, errRejected)
+		}
+	}
+
+	return nil
+}
+
+// anchor writes the anchor string of the batch to the anchoring system and records the status of the artifacts.
+func (r *Writer) anchor(ctx context.Context, anchoringInfo *protocol.AnchoringInfo, protocolVersion uint64) error {
+	trace.SpanFromContext(ctx).SetAttributes(tracing.WithAnchorString(anchoringInfo.AnchorString))
+
+	r.logger.Info("Writing anchor string", logfields.WithAnchorString(anchoringInfo.AnchorString))
+
+	r.recordArtifacts(anchoringInfo, r.artifacts.RecordPending)
+
+	// Create Sidetree transaction in anchoring system (write anchor string)
+	err := r.writeAnchor(ctx, anchoringInfo, protocolVersion)
+	if err != nil {
+		r.recordArtifacts(anchoringInfo, r.artifacts.RecordFailed)
+
+		return fmt.Errorf("write anchor [%s]: %w", anchoringInfo.AnchorString, err)
+	}
+
+	r.recordArtifacts(anchoringInfo, r.artifacts.RecordAnchored)
+
+	return nil
+}
+
+// prepareTxnFiles prepares the batch files, passing the context to the operation handler if it accepts one.
+func prepareTxnFiles(ctx context.Context, handler protocol.OperationHandler,
+	ops []*operation.QueuedOperation) (*protocol.AnchoringInfo, error) {
+	if h, ok := handler.(protocol.OperationHandlerWithContext); ok {
+		return h.PrepareTxnFilesWithContext(ctx, ops)
+	}
+
+	return handler.PrepareTxnFiles(ops)
+}
+
+func (r *Writer) writeAnchor(ctx context.Context, anchoringInfo *protocol.AnchoringInfo, protocolVersion uint64) error {
+	_, span := tracer.Start(ctx, "AnchorWriter.WriteAnchor",
+		trace.WithAttributes(tracing.WithAnchorString(anchoringInfo.AnchorString)))
+	defer span.End()
+
+	return tracing.RecordError(span, r.context.Anchor().WriteAnchor(anchoringInfo.AnchorString, anchoringInfo.Artifacts,
+		anchoringInfo.OperationReferences, protocolVersion))
+}
+
+// recordArtifacts records the artifacts of the batch. An error is only logged since the artifact records
+// are used for garbage collection and must not prevent the batch from being anchored.
+func (r *Writer) recordArtifacts(anchoringInfo *protocol.AnchoringInfo,
+	record func(anchorString string, artifacts []*protocol.AnchorDocument) error) {
+	if err := record(anchoringInfo.AnchorString, anchoringInfo.Artifacts); err != nil {
+		r.logger.Warn("Error recording batch artifacts", logfields.WithAnchorString(anchoringInfo.AnchorString),
+			log.WithError(err))
+	}
+}
+
+// readd adds an operation (which was previously admitted) back to the queue without enforcing the queue limits.
+func (r *Writer) readd(op *operation.QueuedOperation, protocolVersion uint64) error {
+	if r.Stopped() {
+		return errors.New("writer is stopped")
+	}
+
+	r.admission.reserve(op)
+
+	_, err := r.batchCutter.Add(op, protocolVersion)
+	if err != nil {
+		r.admission.release(op)
+
+		return err
+	}
+
 	return nil
 }
 
@@ -310,10 +512,92 @@ func WithMonitorInterval(interval time.Duration) Option {
 	}
 }
 
+// WithMaxQueueDepth sets the maximum number of operations in the operation queue. If the limit is reached then
+// Add returns an operation.QueueFullError. Zero (default) means no limit.
+func WithMaxQueueDepth(value uint) Option {
+	return func(o *Options) error {
+		o.MaxQueueDepth = value
+
+		return nil
+	}
+}
+
+// WithMaxQueueBytes sets the maximum total size (in bytes) of the operation requests that were added by
+// this writer and not yet anchored. If the limit is reached then Add returns an operation.QueueFullError.
+// Zero (default) means no limit.
+func WithMaxQueueBytes(value int64) Option {
+	return func(o *Options) error {
+		o.MaxQueueBytes = value
+
+		return nil
+	}
+}
+
+// WithMaxOperationsPerClient sets the maximum number of queued operations for a single client (identified
+// by operation.QueuedOperation.ClientID) so that one client can't take all of the queue capacity.
+// Zero (default) means no limit.
+func WithMaxOperationsPerClient(value uint) Option {
+	return func(o *Options) error {
+		o.MaxOperationsPerClient = value
+
+		return nil
+	}
+}
+
+// WithRetryAfter sets the duration after which a client should retry when an operation is not admitted
+// since the queue is full. Defaults to five seconds.
+func WithRetryAfter(value time.Duration) Option {
+	return func(o *Options) error {
+		o.RetryAfter = value
+
+		return nil
+	}
+}
+
+// WithMetricsProvider sets the provider which records the depth of the operation queue and
+// the number of pending operations after each cut.
+func WithMetricsProvider(metrics metricsProvider) Option {
+	return func(o *Options) error {
+		o.metrics = metrics
+
+		return nil
+	}
+}
+
+// WithArtifactRecorder sets the recorder of the batch files (artifacts) which are written to CAS, along with
+// the anchor status of each batch, so that orphaned and expired files may be garbage collected
+// (see retention.Manager).
+func WithArtifactRecorder(recorder artifactRecorder) Option {
+	return func(o *Options) error {
+		o.artifactRecorder = recorder
+
+		return nil
+	}
+}
+
+// WithRejectedOperationHandler sets the handler which is notified of queued operations that were dropped from
+// the queue because they're not valid under the protocol version that became current after they were queued,
+// or because the operation handler rejected them (e.g. the delta exceeds the maximum chunk file size).
+func WithRejectedOperationHandler(handler cutter.RejectedOperationHandler) Option {
+	return func(o *Options) error {
+		o.rejectedOperationHandler = handler
+
+		return nil
+	}
+}
+
 // Options allows the user to specify more advanced options.
 type Options struct {
-	BatchTimeout    time.Duration
-	MonitorInterval time.Duration
+	BatchTimeout           time.Duration
+	MonitorInterval        time.Duration
+	MaxQueueDepth          uint
+	MaxQueueBytes          int64
+	MaxOperationsPerClient uint
+	RetryAfter             time.Duration
+
+	metrics                  metricsProvider
+	artifactRecorder         artifactRecorder
+	rejectedOperationHandler cutter.RejectedOperationHandler
 }
 
 // prepareOptsFromOptions reads options.
@@ -328,3 +612,15 @@ func prepareOptsFromOptions(options ...Option) (Options, error) {
 
 	return rOpts, nil
 }
+
+type noopMetricsProvider struct{}
+
+func (m *noopMetricsProvider) BatchQueueDepth(uint) {}
+
+func (m *noopMetricsProvider) BatchPendingOperations(uint) {}
+
+type noopArtifactRecorder struct{}
+
+func (r *noopArtifactRecorder) RecordPending(string, []*protocol.AnchorDocument) error  { return nil }
+func (r *noopArtifactRecorder) RecordAnchored(string, []*protocol.AnchorDocument) error { return nil }
+func (r *noopArtifactRecorder) RecordFailed(string, []*protocol.AnchorDocument) error   { return nil }
@@ -7,13 +7,16 @@ SPDX-License-Identifier: Apache-2.0
 package batch
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
 
 	"github.com/trustbloc/sidetree-go/pkg/commitment"
 	"github.com/trustbloc/sidetree-go/pkg/jws"
@@ -27,7 +30,10 @@ import (
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-svc-go/pkg/batch/cutter"
 	"github.com/trustbloc/sidetree-svc-go/pkg/batch/opqueue"
+	"github.com/trustbloc/sidetree-svc-go/pkg/cas/retention"
 	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing/tracingtest"
 	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider/models"
@@ -441,6 +447,255 @@ func TestProcessError(t *testing.T) {
 	})
 }
 
+func TestAdmissionControl(t *testing.T) {
+	t.Run("max queue depth", func(t *testing.T) {
+		ctx := newMockContext()
+		writer, err := New(namespace, ctx, WithMaxQueueDepth(2), WithRetryAfter(3*time.Second))
+		require.NoError(t, err)
+
+		operations := generateOperations(3)
+
+		require.NoError(t, writer.Add(operations[0], 0))
+		require.NoError(t, writer.Add(operations[1], 0))
+
+		err = writer.Add(operations[2], 0)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, operation.ErrQueueFull))
+		require.Contains(t, err.Error(), "maximum queue depth 2 reached")
+
+		var queueFullErr *operation.QueueFullError
+		require.True(t, errors.As(err, &queueFullErr))
+		require.Equal(t, 3*time.Second, queueFullErr.RetryAfter)
+
+		require.Equal(t, uint(2), ctx.OpQueue.Len())
+
+		// Anchor the pending operations to free up capacity.
+		writer.Start()
+		defer writer.Stop()
+
+		require.Eventually(t, func() bool { return ctx.OpQueue.Len() == 0 }, 5*time.Second, 50*time.Millisecond)
+
+		require.Eventually(t, func() bool { return writer.Add(operations[2], 0) == nil }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("max queue depth - concurrent adds", func(t *testing.T) {
+		ctx := newMockContext()
+		writer, err := New(namespace, ctx, WithMaxQueueDepth(5))
+		require.NoError(t, err)
+
+		operations := generateOperations(20)
+
+		var wg sync.WaitGroup
+
+		for _, op := range operations {
+			wg.Add(1)
+
+			go func(op *operation.QueuedOperation) {
+				defer wg.Done()
+
+				_ = writer.Add(op, 0)
+			}(op)
+		}
+
+		wg.Wait()
+
+		require.Equal(t, uint(5), ctx.OpQueue.Len())
+	})
+
+	t.Run("max queue depth - existing operations", func(t *testing.T) {
+		operations := generateOperations(2)
+
+		ctx := newMockContext()
+
+		// The queue contains an operation which was added before the writer was created.
+		_, err := ctx.OpQueue.Add(operations[0], 0)
+		require.NoError(t, err)
+
+		writer, err := New(namespace, ctx, WithMaxQueueDepth(1))
+		require.NoError(t, err)
+
+		err = writer.Add(operations[1], 0)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "maximum queue depth 1 reached")
+	})
+
+	t.Run("max queue bytes", func(t *testing.T) {
+		operations := generateOperations(3)
+
+		ctx := newMockContext()
+		writer, err := New(namespace, ctx,
+			WithMaxQueueBytes(int64(len(operations[0].OperationRequest)+len(operations[1].OperationRequest))))
+		require.NoError(t, err)
+
+		require.NoError(t, writer.Add(operations[0], 0))
+		require.NoError(t, writer.Add(operations[1], 0))
+
+		err = writer.Add(operations[2], 0)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, operation.ErrQueueFull))
+		require.Contains(t, err.Error(), "maximum queue size")
+
+		writer.Start()
+		defer writer.Stop()
+
+		require.Eventually(t, func() bool { return ctx.OpQueue.Len() == 0 }, 5*time.Second, 50*time.Millisecond)
+
+		require.Eventually(t, func() bool { return writer.Add(operations[2], 0) == nil }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("max operations per client", func(t *testing.T) {
+		ctx := newMockContext()
+		writer, err := New(namespace, ctx, WithMaxOperationsPerClient(1))
+		require.NoError(t, err)
+
+		operations := generateOperations(4)
+		operations[0].ClientID = "client1"
+		operations[1].ClientID = "client1"
+		operations[2].ClientID = "client2"
+
+		require.NoError(t, writer.Add(operations[0], 0))
+
+		err = writer.Add(operations[1], 0)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, operation.ErrQueueFull))
+		require.Contains(t, err.Error(), "client [client1] has reached its quota of 1 queued operations")
+
+		require.NoError(t, writer.Add(operations[2], 0))
+
+		// Anonymous operations are not subject to client quotas.
+		require.NoError(t, writer.Add(operations[3], 0))
+	})
+
+	t.Run("queue add error", func(t *testing.T) {
+		q := &mocks.OperationQueue{}
+		q.AddReturns(0, errors.New("add error"))
+
+		ctx := newMockContext()
+		ctx.OpQueue = q
+
+		writer, err := New(namespace, ctx, WithMaxOperationsPerClient(1))
+		require.NoError(t, err)
+
+		op, err := generateOperation(1)
+		require.NoError(t, err)
+
+		op.ClientID = "client1"
+
+		require.EqualError(t, writer.Add(op, 0), "add error")
+
+		// The failed operation shouldn't count against the quota.
+		q.AddReturns(1, nil)
+
+		require.NoError(t, writer.Add(op, 0))
+	})
+}
+
+func TestRejectedOperations(t *testing.T) {
+	ctx := newMockContext()
+
+	var rejected []*operation.QueuedOperation
+
+	writer, err := New(namespace, ctx, WithMaxOperationsPerClient(1),
+		WithRejectedOperationHandler(func(op *operation.QueuedOperation, err error) {
+			require.Error(t, err)
+
+			rejected = append(rejected, op)
+		}))
+	require.NoError(t, err)
+
+	op, err := generateOperation(1)
+	require.NoError(t, err)
+
+	op.ClientID = "client1"
+
+	require.NoError(t, writer.Add(op, 0))
+
+	// Upgrade to a protocol version which doesn't accept the queued operation.
+	p := ctx.ProtocolClient.Protocol
+	p.GenesisTime = 100
+	p.MaxOperationSize = 10
+
+	pv := mocks.GetProtocolVersion(p)
+	pv.OperationParserReturns(operationparser.New(p))
+
+	ctx.ProtocolClient.CurrentVersion = pv
+
+	require.Zero(t, writer.processAvailable(true))
+	require.Len(t, rejected, 1)
+	require.Equal(t, op.UniqueSuffix, rejected[0].UniqueSuffix)
+	require.Zero(t, ctx.OpQueue.Len())
+
+	// The rejected operation no longer counts against the quota of the client.
+	require.NoError(t, writer.Add(op, 100))
+}
+
+func TestRejectedOperations_OperationHandler(t *testing.T) {
+	ctx := newMockContext()
+
+	var rejected []*operation.QueuedOperation
+
+	writer, err := New(namespace, ctx,
+		WithRejectedOperationHandler(func(op *operation.QueuedOperation, err error) {
+			require.Error(t, err)
+
+			rejected = append(rejected, op)
+		}))
+	require.NoError(t, err)
+
+	operations := generateOperations(2)
+
+	for _, op := range operations {
+		require.NoError(t, writer.Add(op, 0))
+	}
+
+	// The operation handler rejects the second operation.
+	oh := &mocks.OperationHandler{}
+	oh.PrepareTxnFilesReturns(&protocol.AnchoringInfo{
+		AnchorString:       "anchor",
+		RejectedOperations: operations[1:],
+	}, nil)
+
+	ctx.ProtocolClient.CurrentVersion.OperationHandlerReturns(oh)
+
+	require.Zero(t, writer.processAvailable(true))
+	require.Len(t, rejected, 1)
+	require.Equal(t, operations[1].UniqueSuffix, rejected[0].UniqueSuffix)
+	require.Zero(t, ctx.OpQueue.Len())
+}
+
+func TestRejectedOperations_NothingToAnchor(t *testing.T) {
+	ctx := newMockContext()
+
+	var rejected []*operation.QueuedOperation
+
+	writer, err := New(namespace, ctx,
+		WithRejectedOperationHandler(func(op *operation.QueuedOperation, err error) {
+			require.Error(t, err)
+
+			rejected = append(rejected, op)
+		}))
+	require.NoError(t, err)
+
+	operations := generateOperations(2)
+
+	for _, op := range operations {
+		require.NoError(t, writer.Add(op, 0))
+	}
+
+	// The operation handler rejects all operations so there's nothing to anchor.
+	oh := &mocks.OperationHandler{}
+	oh.PrepareTxnFilesReturns(&protocol.AnchoringInfo{
+		RejectedOperations: operations,
+	}, nil)
+
+	ctx.ProtocolClient.CurrentVersion.OperationHandlerReturns(oh)
+
+	require.Zero(t, writer.processAvailable(true))
+	require.Len(t, rejected, 2)
+	require.Empty(t, ctx.AnchorWriter.GetAnchors())
+	require.Zero(t, ctx.OpQueue.Len())
+}
+
 // withError allows for testing an error in options.
 func withError() Option {
 	return func(o *Options) error {
@@ -577,3 +832,230 @@ func newMockProtocolClient() *mocks.MockProtocolClient {
 
 	return pc
 }
+
+func TestWriterMetrics(t *testing.T) {
+	ctx := newMockContext()
+
+	metrics := &mockMetrics{}
+
+	writer, err := New(namespace, ctx, WithMetricsProvider(metrics))
+	require.NoError(t, err)
+
+	operations := generateOperations(3)
+
+	for _, op := range operations {
+		require.NoError(t, writer.Add(op, 0))
+	}
+
+	require.Equal(t, uint(3), metrics.getQueueDepth())
+
+	writer.Start()
+	defer writer.Stop()
+
+	// Max two operations per batch: the first cut leaves one pending operation and the second leaves none.
+	require.Eventually(t, func() bool { return metrics.getQueueDepth() == 0 }, 5*time.Second, 50*time.Millisecond)
+	require.Equal(t, []uint{1, 0}, metrics.getPending())
+}
+
+func TestWriterArtifactRecorder(t *testing.T) {
+	t.Run("anchored", func(t *testing.T) {
+		ctx := newMockContext()
+
+		manager := retention.New(retention.NewMemStore())
+
+		writer, err := New(namespace, ctx, WithArtifactRecorder(manager))
+		require.NoError(t, err)
+
+		for _, op := range generateOperations(2) {
+			require.NoError(t, writer.Add(op, 0))
+		}
+
+		writer.Start()
+		defer writer.Stop()
+
+		require.Eventually(t, func() bool { return len(ctx.AnchorWriter.GetAnchors()) == 1 },
+			5*time.Second, 50*time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			plan, e := manager.Plan()
+			require.NoError(t, e)
+
+			return len(plan.Pin) > 0 && len(plan.Delete) == 0
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("anchor error", func(t *testing.T) {
+		ctx := newMockContext()
+		ctx.AnchorWriter = mocks.NewMockAnchorWriter(fmt.Errorf("anchor writer error"))
+
+		manager := retention.New(retention.NewMemStore(), retention.WithOrphanGracePeriod(time.Millisecond))
+
+		writer, err := New(namespace, ctx, WithArtifactRecorder(manager))
+		require.NoError(t, err)
+
+		for _, op := range generateOperations(2) {
+			require.NoError(t, writer.Add(op, 0))
+		}
+
+		writer.Start()
+		defer writer.Stop()
+
+		require.Eventually(t, func() bool {
+			plan, e := manager.Plan()
+			require.NoError(t, e)
+
+			return len(plan.Delete) > 0 && len(plan.Pin) == 0
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("prepare txn files error", func(t *testing.T) {
+		ctx := newMockContext()
+
+		manager := retention.New(retention.NewMemStore(), retention.WithOrphanGracePeriod(time.Millisecond))
+
+		writer, err := New(namespace, ctx, WithArtifactRecorder(manager))
+		require.NoError(t, err)
+
+		for _, op := range generateOperations(2) {
+			require.NoError(t, writer.Add(op, 0))
+		}
+
+		// The chunk file was written before the operation handler failed.
+		oh := &mocks.OperationHandler{}
+		oh.PrepareTxnFilesReturns(nil, &protocol.TxnFilesError{
+			Artifacts: []*protocol.AnchorDocument{{ID: "chunk", Type: protocol.TypeProvisional}},
+			Err:       errors.New("injected CAS error"),
+		})
+
+		ctx.ProtocolClient.CurrentVersion.OperationHandlerReturns(oh)
+
+		// The operations remain in the queue.
+		require.Equal(t, uint(2), writer.processAvailable(true))
+		require.Empty(t, ctx.AnchorWriter.GetAnchors())
+
+		require.Eventually(t, func() bool {
+			plan, e := manager.Plan()
+			require.NoError(t, e)
+
+			return len(plan.Delete) == 1 && plan.Delete[0] == "chunk"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("recorder error", func(t *testing.T) {
+		ctx := newMockContext()
+
+		writer, err := New(namespace, ctx, WithArtifactRecorder(&mockArtifactRecorder{err: errors.New("injected error")}))
+		require.NoError(t, err)
+
+		for _, op := range generateOperations(2) {
+			require.NoError(t, writer.Add(op, 0))
+		}
+
+		writer.Start()
+		defer writer.Stop()
+
+		// The batch is anchored anyway.
+		require.Eventually(t, func() bool { return len(ctx.AnchorWriter.GetAnchors()) == 1 },
+			5*time.Second, 50*time.Millisecond)
+	})
+}
+
+type mockArtifactRecorder struct {
+	err error
+}
+
+func (m *mockArtifactRecorder) RecordPending(string, []*protocol.AnchorDocument) error {
+	return m.err
+}
+
+func (m *mockArtifactRecorder) RecordAnchored(string, []*protocol.AnchorDocument) error {
+	return m.err
+}
+
+func (m *mockArtifactRecorder) RecordFailed(string, []*protocol.AnchorDocument) error {
+	return m.err
+}
+
+type mockMetrics struct {
+	mutex      sync.Mutex
+	queueDepth uint
+	pending    []uint
+}
+
+func (m *mockMetrics) BatchQueueDepth(value uint) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.queueDepth = value
+}
+
+func (m *mockMetrics) BatchPendingOperations(value uint) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pending = append(m.pending, value)
+}
+
+func (m *mockMetrics) getQueueDepth() uint {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.queueDepth
+}
+
+func (m *mockMetrics) getPending() []uint {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]uint(nil), m.pending...)
+}
+
+func TestWriterTracing(t *testing.T) {
+	exporter := tracingtest.Exporter()
+
+	ctx := newMockContext()
+
+	writer, err := New(namespace, ctx)
+	require.NoError(t, err)
+
+	reqCtx, reqSpan := otel.Tracer("test").Start(context.Background(), "request")
+
+	operations := generateOperations(2)
+	operations[0].TraceContext = tracing.Inject(reqCtx)
+
+	for _, op := range operations {
+		require.NoError(t, writer.Add(op, 0))
+	}
+
+	reqSpan.End()
+
+	writer.Start()
+	defer writer.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(tracingtest.Spans(exporter, "Writer.processBatch")) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	batchSpan := tracingtest.Spans(exporter, "Writer.processBatch")[0]
+
+	require.Len(t, batchSpan.Links, 1)
+	require.Equal(t, reqSpan.SpanContext().SpanID(), batchSpan.Links[0].SpanContext.SpanID())
+	require.NotEqual(t, reqSpan.SpanContext().TraceID(), batchSpan.SpanContext.TraceID())
+	require.Len(t, batchSpan.Events, 2)
+	require.Contains(t, batchSpan.Attributes, tracing.WithAnchorString(ctx.AnchorWriter.GetAnchors()[0]))
+
+	for _, name := range []string{"BatchCutter.Cut", "OperationHandler.PrepareTxnFiles", "AnchorWriter.WriteAnchor"} {
+		spans := tracingtest.Spans(exporter, name)
+		require.Len(t, spans, 1, name)
+		require.Equal(t, batchSpan.SpanContext.SpanID(), spans[0].Parent.SpanID(), name)
+	}
+
+	prepareSpan := tracingtest.Spans(exporter, "OperationHandler.PrepareTxnFiles")[0]
+
+	casWriteSpans := tracingtest.Spans(exporter, "CAS.Write")
+	require.NotEmpty(t, casWriteSpans)
+
+	for _, span := range casWriteSpans {
+		require.Equal(t, prepareSpan.SpanContext.SpanID(), span.Parent.SpanID())
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package cachingcas implements a read-through cache in front of a content addressable store. Since the
+// content at a content address never changes, cached content never has to be invalidated. Content is
+// cached in a memory tier (an LRU cache which is bounded by the total size of the cached content) and,
+// optionally, in a disk tier (e.g. a filecas.Store) which survives restarts.
+//
+// The cache policy may be configured per file type using the aliases which are passed to ReadWithAlias
+// and WriteWithAlias ("core index", "core proof", "provisional index", "provisional proof" and "chunk").
+package cachingcas
+
+import (
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/contenthash"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+)
+
+var logger = log.New("sidetree-svc-cachingcas")
+
+const (
+	defaultMaxMemorySize = 64 * 1024 * 1024
+
+	// TierMemory is the name of the memory cache tier (used in metrics).
+	TierMemory = "memory"
+	// TierDisk is the name of the disk cache tier (used in metrics).
+	TierDisk = "disk"
+
+	unknownAlias = "unknown"
+)
+
+// Policy specifies the cache tiers in which the content of a file type is cached.
+type Policy struct {
+	Memory bool
+	Disk   bool
+}
+
+type metricsProvider interface {
+	CASCacheHit(dataType, tier string)
+	CASCacheMiss(dataType string)
+}
+
+// Client is a cas.Client which caches the content that is read from (or written to) the target client.
+type Client struct {
+	target        cas.Client
+	memory        *lru
+	maxMemorySize int64
+	disk          cas.Client
+	policies      map[string]Policy
+	defaultPolicy Policy
+	metrics       metricsProvider
+}
+
+// Option is a caching client option.
+type Option func(c *Client)
+
+// WithMaxMemorySize sets the maximum total size (in bytes) of the content in the memory cache (default 64MiB).
+func WithMaxMemorySize(value int64) Option {
+	return func(c *Client) {
+		c.maxMemorySize = value
+	}
+}
+
+// WithDiskStore sets the store which is used as the disk cache tier. The store must compute the same
+// addresses as the target client (e.g. a filecas.Store which uses the protocol's multihash algorithms).
+func WithDiskStore(store cas.Client) Option {
+	return func(c *Client) {
+		c.disk = store
+	}
+}
+
+// WithPolicy sets the cache policy for the given file type (alias).
+func WithPolicy(alias string, policy Policy) Option {
+	return func(c *Client) {
+		c.policies[alias] = policy
+	}
+}
+
+// WithDefaultPolicy sets the cache policy for content without a file type and for file types which don't
+// have a policy (default is to cache in all tiers).
+func WithDefaultPolicy(policy Policy) Option {
+	return func(c *Client) {
+		c.defaultPolicy = policy
+	}
+}
+
+// WithMetricsProvider sets the provider which records cache hits and misses.
+func WithMetricsProvider(metrics metricsProvider) Option {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+// New returns a new caching client for the given target client.
+func New(target cas.Client, opts ...Option) *Client {
+	c := &Client{
+		target:        target,
+		maxMemorySize: defaultMaxMemorySize,
+		policies:      make(map[string]Policy),
+		defaultPolicy: Policy{Memory: true, Disk: true},
+		metrics:       &noopMetricsProvider{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.memory = newLRU(c.maxMemorySize)
+
+	return c
+}
+
+// Write writes the content to the target client and caches it using the default policy.
+func (c *Client) Write(content []byte) (string, error) {
+	return c.WriteWithAlias("", content)
+}
+
+// WriteWithAlias writes the content to the target client and caches it using the policy of the given
+// file type. The content is cached since it's likely to be read back (e.g. by the observer).
+func (c *Client) WriteWithAlias(alias string, content []byte) (string, error) {
+	address, err := c.target.Write(content)
+	if err != nil {
+		return "", err
+	}
+
+	c.put(alias, c.policy(alias), address, content)
+
+	return address, nil
+}
+
+// Read reads the content at the given address using the default policy.
+func (c *Client) Read(address string) ([]byte, error) {
+	return c.ReadWithAlias("", address)
+}
+
+// ReadWithAlias reads the content at the given address using the policy of the given file type. The
+// content is returned from the cache if it exists, otherwise it is read from the target client and cached.
+// The returned content is shared with the cache and must not be modified.
+func (c *Client) ReadWithAlias(alias, address string) ([]byte, error) {
+	policy := c.policy(alias)
+
+	if !contenthash.IsMultihash(address) || (!policy.Memory && !policy.Disk) {
+		return c.target.Read(address)
+	}
+
+	if content, ok := c.get(alias, policy, address); ok {
+		return content, nil
+	}
+
+	c.metrics.CASCacheMiss(metricsAlias(alias))
+
+	content, err := c.target.Read(address)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(alias, policy, address, content)
+
+	return content, nil
+}
+
+func (c *Client) get(alias string, policy Policy, address string) ([]byte, bool) {
+	if policy.Memory {
+		if content, ok := c.memory.get(address); ok {
+			c.metrics.CASCacheHit(metricsAlias(alias), TierMemory)
+
+			return content, true
+		}
+	}
+
+	if policy.Disk && c.disk != nil {
+		content, err := c.disk.Read(address)
+		if err == nil {
+			c.metrics.CASCacheHit(metricsAlias(alias), TierDisk)
+
+			if policy.Memory {
+				c.memory.add(address, content)
+			}
+
+			return content, true
+		}
+
+		logger.Debug("Content not found in disk cache", logfields.WithURIString(address), log.WithError(err))
+	}
+
+	return nil, false
+}
+
+// put caches the content if it matches its content address. Content which doesn't match its address
+// (or whose address is not a content address) may change and is therefore never cached.
+func (c *Client) put(alias string, policy Policy, address string, content []byte) {
+	if !policy.Memory && !policy.Disk {
+		return
+	}
+
+	if err := contenthash.Verify(address, content); err != nil {
+		logger.Debug("Not caching content", logfields.WithURIString(address), logfields.WithAlias(alias),
+			log.WithError(err))
+
+		return
+	}
+
+	if policy.Memory {
+		c.memory.add(address, content)
+	}
+
+	if policy.Disk && c.disk != nil {
+		diskAddress, err := c.disk.Write(content)
+		if err != nil {
+			logger.Warn("Error writing content to disk cache", logfields.WithURIString(address), log.WithError(err))
+
+			return
+		}
+
+		if diskAddress != address {
+			logger.Warn("Disk cache uses a different address for the content",
+				logfields.WithURIString(address), logfields.WithAlias(alias))
+		}
+	}
+}
+
+func (c *Client) policy(alias string) Policy {
+	if policy, ok := c.policies[alias]; ok {
+		return policy
+	}
+
+	return c.defaultPolicy
+}
+
+func metricsAlias(alias string) string {
+	if alias == "" {
+		return unknownAlias
+	}
+
+	return alias
+}
+
+type noopMetricsProvider struct{}
+
+func (m *noopMetricsProvider) CASCacheHit(string, string) {}
+func (m *noopMetricsProvider) CASCacheMiss(string)        {}
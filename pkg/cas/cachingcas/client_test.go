@@ -0,0 +1,302 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cachingcas
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/cas/filecas"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+)
+
+const chunkAlias = "chunk"
+
+func TestClient_Read(t *testing.T) {
+	content := []byte("test content")
+
+	t.Run("memory hit", func(t *testing.T) {
+		target := newCountingCAS()
+		metrics := newMockMetrics()
+
+		c := New(target, WithMetricsProvider(metrics))
+
+		address, err := target.Write(content)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			read, e := c.ReadWithAlias(chunkAlias, address)
+			require.NoError(t, e)
+			require.Equal(t, content, read)
+		}
+
+		require.Equal(t, 1, target.reads)
+		require.Equal(t, 1, metrics.misses[chunkAlias])
+		require.Equal(t, 2, metrics.hits[chunkAlias+":"+TierMemory])
+	})
+
+	t.Run("disk hit", func(t *testing.T) {
+		disk, err := filecas.New(t.TempDir())
+		require.NoError(t, err)
+
+		target := newCountingCAS()
+		address, err := target.Write(content)
+		require.NoError(t, err)
+
+		c := New(target, WithDiskStore(disk))
+
+		read, err := c.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+		require.Equal(t, 1, target.reads)
+
+		// A new client (e.g. after a restart) reads the content from disk.
+		metrics := newMockMetrics()
+
+		c = New(target, WithDiskStore(disk), WithMetricsProvider(metrics))
+
+		read, err = c.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+		require.Equal(t, 1, target.reads)
+		require.Equal(t, 1, metrics.hits[unknownAlias+":"+TierDisk])
+
+		// The content was promoted to memory.
+		read, err = c.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+		require.Equal(t, 1, metrics.hits[unknownAlias+":"+TierMemory])
+	})
+
+	t.Run("policy per file type", func(t *testing.T) {
+		disk, err := filecas.New(t.TempDir())
+		require.NoError(t, err)
+
+		target := newCountingCAS()
+		address, err := target.Write(content)
+		require.NoError(t, err)
+
+		c := New(target, WithDiskStore(disk),
+			WithDefaultPolicy(Policy{}),
+			WithPolicy(chunkAlias, Policy{Disk: true}),
+		)
+
+		// Not cached using the default policy.
+		for i := 0; i < 2; i++ {
+			_, err = c.ReadWithAlias("core index", address)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, target.reads)
+		require.Equal(t, 0, c.memory.len())
+
+		// Cached on disk only using the chunk policy.
+		for i := 0; i < 2; i++ {
+			_, err = c.ReadWithAlias(chunkAlias, address)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 3, target.reads)
+		require.Equal(t, 0, c.memory.len())
+
+		_, err = disk.Read(address)
+		require.NoError(t, err)
+	})
+
+	t.Run("not a content address", func(t *testing.T) {
+		target := newCountingCAS()
+		target.content["https:orb.domain.com:address"] = content
+
+		c := New(target)
+
+		for i := 0; i < 2; i++ {
+			read, err := c.Read("https:orb.domain.com:address")
+			require.NoError(t, err)
+			require.Equal(t, content, read)
+		}
+
+		require.Equal(t, 2, target.reads)
+	})
+
+	t.Run("content doesn't match address", func(t *testing.T) {
+		target := newCountingCAS()
+
+		address, err := target.Write(content)
+		require.NoError(t, err)
+
+		target.content[address] = []byte("other content")
+
+		c := New(target)
+
+		for i := 0; i < 2; i++ {
+			read, e := c.Read(address)
+			require.NoError(t, e)
+			require.Equal(t, []byte("other content"), read)
+		}
+
+		require.Equal(t, 2, target.reads)
+		require.Equal(t, 0, c.memory.len())
+	})
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		target := newCountingCAS()
+
+		address, err := target.Write(content)
+		require.NoError(t, err)
+
+		c := New(target)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				read, e := c.ReadWithAlias(chunkAlias, address)
+				require.NoError(t, e)
+				require.Equal(t, content, read)
+			}()
+		}
+
+		wg.Wait()
+	})
+
+	t.Run("target error", func(t *testing.T) {
+		errExpected := errors.New("injected read error")
+
+		c := New(mocks.NewMockCasClient(errExpected))
+
+		address, err := mocks.NewMockCasClient(nil).Write(content)
+		require.NoError(t, err)
+
+		_, err = c.Read(address)
+		require.ErrorIs(t, err, errExpected)
+	})
+}
+
+func TestClient_Write(t *testing.T) {
+	content := []byte("test content")
+
+	t.Run("success", func(t *testing.T) {
+		disk, err := filecas.New(t.TempDir())
+		require.NoError(t, err)
+
+		target := newCountingCAS()
+
+		c := New(target, WithDiskStore(disk), WithMaxMemorySize(1024))
+
+		address, err := c.WriteWithAlias(chunkAlias, content)
+		require.NoError(t, err)
+
+		read, err := c.ReadWithAlias(chunkAlias, address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+		require.Equal(t, 0, target.reads)
+
+		read, err = disk.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+	})
+
+	t.Run("success - disk store error", func(t *testing.T) {
+		disk, err := filecas.New(t.TempDir(), filecas.WithReadOnly())
+		require.NoError(t, err)
+
+		c := New(newCountingCAS(), WithDiskStore(disk))
+
+		address, err := c.Write(content)
+		require.NoError(t, err)
+
+		read, err := c.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+	})
+
+	t.Run("target error", func(t *testing.T) {
+		errExpected := errors.New("injected write error")
+
+		c := New(mocks.NewMockCasClient(errExpected))
+
+		_, err := c.Write(content)
+		require.ErrorIs(t, err, errExpected)
+	})
+}
+
+type countingCAS struct {
+	*mocks.MockCasClient
+
+	mutex   sync.Mutex
+	reads   int
+	content map[string][]byte
+}
+
+func newCountingCAS() *countingCAS {
+	return &countingCAS{
+		MockCasClient: mocks.NewMockCasClient(nil),
+		content:       make(map[string][]byte),
+	}
+}
+
+func (m *countingCAS) Write(content []byte) (string, error) {
+	address, err := m.MockCasClient.Write(content)
+	if err != nil {
+		return "", err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.content[address] = content
+
+	return address, nil
+}
+
+func (m *countingCAS) Read(address string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.reads++
+
+	content, ok := m.content[address]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	return content, nil
+}
+
+type mockMetrics struct {
+	mutex  sync.Mutex
+	hits   map[string]int
+	misses map[string]int
+}
+
+func newMockMetrics() *mockMetrics {
+	return &mockMetrics{
+		hits:   make(map[string]int),
+		misses: make(map[string]int),
+	}
+}
+
+func (m *mockMetrics) CASCacheHit(dataType, tier string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.hits[dataType+":"+tier]++
+}
+
+func (m *mockMetrics) CASCacheMiss(dataType string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.misses[dataType]++
+}
@@ -0,0 +1,93 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cachingcas
+
+import (
+	"container/list"
+	"sync"
+)
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+// lru is a least-recently-used cache which is bounded by the total size of its values.
+type lru struct {
+	maxSize int64
+
+	mutex   sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func newLRU(maxSize int64) *lru {
+	return &lru{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *lru) get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+
+	return e.Value.(*lruEntry).value, true //nolint:forcetypeassert
+}
+
+// add adds the value to the cache, evicting the least recently used entries if necessary. A value which
+// is larger than the cache itself is not added.
+func (c *lru) add(key string, value []byte) {
+	size := int64(len(value))
+	if size > c.maxSize {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		// The content is immutable so the existing value is the same.
+		c.order.MoveToFront(e)
+
+		return
+	}
+
+	for c.size+size > c.maxSize {
+		c.removeOldest()
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	c.size += size
+}
+
+func (c *lru) removeOldest() {
+	e := c.order.Back()
+
+	entry := e.Value.(*lruEntry) //nolint:forcetypeassert
+
+	c.order.Remove(e)
+	delete(c.entries, entry.key)
+
+	c.size -= int64(len(entry.value))
+}
+
+func (c *lru) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
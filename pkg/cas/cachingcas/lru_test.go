@@ -0,0 +1,73 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cachingcas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	t.Run("evicts least recently used", func(t *testing.T) {
+		c := newLRU(10)
+
+		c.add("k1", []byte("1234"))
+		c.add("k2", []byte("1234"))
+
+		// Access k1 so that k2 is the least recently used.
+		_, ok := c.get("k1")
+		require.True(t, ok)
+
+		c.add("k3", []byte("1234"))
+		require.Equal(t, 2, c.len())
+
+		_, ok = c.get("k2")
+		require.False(t, ok)
+
+		v, ok := c.get("k1")
+		require.True(t, ok)
+		require.Equal(t, []byte("1234"), v)
+
+		_, ok = c.get("k3")
+		require.True(t, ok)
+	})
+
+	t.Run("evicts multiple entries", func(t *testing.T) {
+		c := newLRU(10)
+
+		c.add("k1", []byte("123"))
+		c.add("k2", []byte("123"))
+		c.add("k3", []byte("123"))
+		c.add("k4", []byte("123456789"))
+
+		require.Equal(t, 1, c.len())
+		require.Equal(t, int64(9), c.size)
+	})
+
+	t.Run("value larger than cache", func(t *testing.T) {
+		c := newLRU(10)
+
+		c.add("k1", []byte("123"))
+		c.add("k2", []byte("12345678901"))
+
+		require.Equal(t, 1, c.len())
+
+		_, ok := c.get("k2")
+		require.False(t, ok)
+	})
+
+	t.Run("existing entry", func(t *testing.T) {
+		c := newLRU(10)
+
+		c.add("k1", []byte("123"))
+		c.add("k1", []byte("123"))
+
+		require.Equal(t, 1, c.len())
+		require.Equal(t, int64(3), c.size)
+	})
+}
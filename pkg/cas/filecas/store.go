@@ -0,0 +1,304 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package filecas implements a content addressable store which keeps its objects on the local file system.
+// The address of an object is the base64url-encoded multihash of its content (i.e. the same format as
+// the CAS URIs in Sidetree anchor strings and index files). Objects are kept in sharded directories
+// (<dir>/<xx>/<yy>/<address>, where xx and yy are the first two bytes of the hash digest in hex) so that
+// no single directory grows too large.
+package filecas
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/contenthash"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+)
+
+var logger = log.New("sidetree-svc-filecas")
+
+const (
+	sha2_256 = 18
+
+	defaultMaxObjectSize = 10 * 1024 * 1024
+
+	dirPermissions = 0o750
+)
+
+var (
+	// ErrNotFound is returned if the content for the given address doesn't exist in the store.
+	ErrNotFound = errors.New("content not found")
+
+	// ErrReadOnly is returned by Write if the store was opened in read-only mode.
+	ErrReadOnly = errors.New("store is read-only")
+
+	// ErrContentTooLarge is returned if the content exceeds the maximum object size.
+	ErrContentTooLarge = errors.New("content exceeds maximum object size")
+
+	// ErrHashMismatch is returned by Read if the stored content doesn't match its address.
+	ErrHashMismatch = contenthash.ErrMismatch
+)
+
+// Store is a content addressable store which keeps its objects on the local file system. It is safe
+// for concurrent use, including by multiple processes sharing the same directory, since objects are
+// written to a temporary file which is atomically renamed into place.
+type Store struct {
+	dir           string
+	algorithms    []uint
+	maxObjectSize int64
+	readOnly      bool
+}
+
+// Option is a file CAS option.
+type Option func(s *Store)
+
+// WithMultihashAlgorithms sets the multihash algorithms which are accepted in addresses (default SHA2-256).
+// The first algorithm is used to compute the address of new content, so the protocol's MultihashAlgorithms
+// may be passed directly.
+func WithMultihashAlgorithms(algorithms ...uint) Option {
+	return func(s *Store) {
+		s.algorithms = algorithms
+	}
+}
+
+// WithMaxObjectSize sets the maximum size (in bytes) of an object (default 10MiB). Larger content is
+// rejected by Write and stored objects which are larger are not read.
+func WithMaxObjectSize(value int64) Option {
+	return func(s *Store) {
+		s.maxObjectSize = value
+	}
+}
+
+// WithReadOnly opens the store in read-only mode, for example to serve archived batch files. The
+// directory must exist and Write returns ErrReadOnly.
+func WithReadOnly() Option {
+	return func(s *Store) {
+		s.readOnly = true
+	}
+}
+
+// New returns a new file CAS which stores its objects in the given directory. The directory is
+// created if it doesn't exist (unless the store is read-only).
+func New(dir string, opts ...Option) (*Store, error) {
+	s := &Store{
+		dir:           dir,
+		algorithms:    []uint{sha2_256},
+		maxObjectSize: defaultMaxObjectSize,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if len(s.algorithms) == 0 {
+		return nil, errors.New("at least one multihash algorithm must be specified")
+	}
+
+	for _, alg := range s.algorithms {
+		if _, err := hashing.GetHashFromMultihash(alg); err != nil {
+			return nil, fmt.Errorf("multihash algorithm %d: %w", alg, err)
+		}
+	}
+
+	if s.maxObjectSize <= 0 {
+		return nil, errors.New("maximum object size must be greater than 0")
+	}
+
+	if s.readOnly {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("open store directory [%s]: %w", dir, err)
+		}
+
+		if !info.IsDir() {
+			return nil, fmt.Errorf("store path [%s] is not a directory", dir)
+		}
+
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return nil, fmt.Errorf("create store directory [%s]: %w", dir, err)
+	}
+
+	return s, nil
+}
+
+// Write writes the given content to the store and returns its address. Writing content which already
+// exists in the store is a no-op.
+func (s *Store) Write(content []byte) (string, error) {
+	if s.readOnly {
+		return "", ErrReadOnly
+	}
+
+	if int64(len(content)) > s.maxObjectSize {
+		return "", fmt.Errorf("%w: content size %d exceeds %d", ErrContentTooLarge, len(content), s.maxObjectSize)
+	}
+
+	mh, err := hashing.ComputeMultihash(s.algorithms[0], content)
+	if err != nil {
+		return "", fmt.Errorf("compute multihash: %w", err)
+	}
+
+	address := encoder.EncodeToString(mh)
+
+	path, err := s.path(address)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		logger.Debug("Content already exists in store", logfields.WithURIString(address))
+
+		return address, nil
+	}
+
+	if err := writeFile(path, content); err != nil {
+		return "", fmt.Errorf("write content for address [%s]: %w", address, err)
+	}
+
+	logger.Debug("Wrote content to store", logfields.WithURIString(address))
+
+	return address, nil
+}
+
+// Read returns the content at the given address. The hash of the content is verified against the
+// address, so corrupted content is never returned.
+func (s *Store) Read(address string) ([]byte, error) {
+	path, err := s.path(address)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.readFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, address)
+		}
+
+		return nil, fmt.Errorf("read content for address [%s]: %w", address, err)
+	}
+
+	if err := contenthash.Verify(address, content); err != nil {
+		logger.Warn("Stored content doesn't match its address", logfields.WithURIString(address),
+			log.WithError(err))
+
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// path validates the given address and returns the path of its file.
+func (s *Store) path(address string) (string, error) {
+	if !hashing.IsComputedUsingMultihashAlgorithms(address, s.algorithms) {
+		return "", fmt.Errorf("invalid address [%s]: not a multihash using one of the supported algorithms %v",
+			address, s.algorithms)
+	}
+
+	mh, err := hashing.GetMultihash(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid address [%s]: %w", address, err)
+	}
+
+	hash, err := hashing.GetHashFromMultihash(uint(mh.Code))
+	if err != nil {
+		return "", fmt.Errorf("invalid address [%s]: %w", address, err)
+	}
+
+	if len(mh.Digest) != hash.Size() {
+		return "", fmt.Errorf("invalid address [%s]: invalid digest length %d", address, len(mh.Digest))
+	}
+
+	// The address is valid base64url (which doesn't contain path separators) so it's safe to use as a file name.
+	return filepath.Join(s.dir, hex.EncodeToString(mh.Digest[:1]), hex.EncodeToString(mh.Digest[1:2]), address), nil
+}
+
+func (s *Store) readFile(path string) ([]byte, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close() //nolint:errcheck
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() > s.maxObjectSize {
+		return nil, fmt.Errorf("%w: content size %d exceeds %d", ErrContentTooLarge, info.Size(), s.maxObjectSize)
+	}
+
+	// Guard against the file growing after it was opened.
+	content, err := io.ReadAll(io.LimitReader(f, s.maxObjectSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(content)) > s.maxObjectSize {
+		return nil, fmt.Errorf("%w: content size exceeds %d", ErrContentTooLarge, s.maxObjectSize)
+	}
+
+	return content, nil
+}
+
+// writeFile writes the content to a temporary file in the target directory and then renames it to the
+// given path, so readers never see a partially written file.
+func writeFile(path string, content []byte) error {
+	dir := filepath.Dir(path)
+
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+
+	tmpPath := f.Name()
+
+	// Remove the temporary file if it wasn't renamed.
+	defer func() {
+		_ = os.Remove(tmpPath) //nolint:errcheck
+	}()
+
+	if _, err := f.Write(content); err != nil {
+		_ = f.Close() //nolint:errcheck
+
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close() //nolint:errcheck
+
+		return fmt.Errorf("sync temporary file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename temporary file: %w", err)
+	}
+
+	return nil
+}
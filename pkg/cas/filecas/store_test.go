@@ -0,0 +1,318 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filecas
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+)
+
+const sha2_512 = 19
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "cas")
+
+		s, err := New(dir)
+		require.NoError(t, err)
+		require.NotNil(t, s)
+
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+	})
+
+	t.Run("success - read-only", func(t *testing.T) {
+		s, err := New(t.TempDir(), WithReadOnly())
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+
+	t.Run("error - read-only directory doesn't exist", func(t *testing.T) {
+		s, err := New(filepath.Join(t.TempDir(), "cas"), WithReadOnly())
+		require.Error(t, err)
+		require.Nil(t, s)
+		require.True(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("error - read-only path is not a directory", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(path, []byte("test"), 0o600))
+
+		s, err := New(path, WithReadOnly())
+		require.Error(t, err)
+		require.Nil(t, s)
+		require.Contains(t, err.Error(), "is not a directory")
+	})
+
+	t.Run("error - create directory", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(path, []byte("test"), 0o600))
+
+		s, err := New(filepath.Join(path, "cas"))
+		require.Error(t, err)
+		require.Nil(t, s)
+		require.Contains(t, err.Error(), "create store directory")
+	})
+
+	t.Run("error - no multihash algorithms", func(t *testing.T) {
+		s, err := New(t.TempDir(), WithMultihashAlgorithms())
+		require.EqualError(t, err, "at least one multihash algorithm must be specified")
+		require.Nil(t, s)
+	})
+
+	t.Run("error - unsupported multihash algorithm", func(t *testing.T) {
+		s, err := New(t.TempDir(), WithMultihashAlgorithms(sha2_256, 55))
+		require.Error(t, err)
+		require.Nil(t, s)
+		require.Contains(t, err.Error(), "multihash algorithm 55")
+	})
+
+	t.Run("error - invalid max object size", func(t *testing.T) {
+		s, err := New(t.TempDir(), WithMaxObjectSize(0))
+		require.EqualError(t, err, "maximum object size must be greater than 0")
+		require.Nil(t, s)
+	})
+}
+
+func TestStore_WriteRead(t *testing.T) {
+	content := []byte("test content")
+
+	t.Run("success", func(t *testing.T) {
+		s, err := New(t.TempDir())
+		require.NoError(t, err)
+
+		var client cas.Client = s
+
+		address, err := client.Write(content)
+		require.NoError(t, err)
+
+		read, err := client.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+	})
+
+	t.Run("success - address compatible with mock CAS", func(t *testing.T) {
+		s, err := New(t.TempDir())
+		require.NoError(t, err)
+
+		address, err := s.Write(content)
+		require.NoError(t, err)
+
+		mockAddress, err := mocks.NewMockCasClient(nil).Write(content)
+		require.NoError(t, err)
+		require.Equal(t, mockAddress, address)
+	})
+
+	t.Run("success - sharded directories", func(t *testing.T) {
+		dir := t.TempDir()
+
+		s, err := New(dir)
+		require.NoError(t, err)
+
+		address, err := s.Write(content)
+		require.NoError(t, err)
+
+		mh, err := hashing.GetMultihash(address)
+		require.NoError(t, err)
+
+		info, err := os.Stat(filepath.Join(dir, hex.EncodeToString(mh.Digest[:1]), hex.EncodeToString(mh.Digest[1:2]), address))
+		require.NoError(t, err)
+		require.Equal(t, int64(len(content)), info.Size())
+
+		// No temporary files are left behind.
+		entries, err := os.ReadDir(filepath.Join(dir, hex.EncodeToString(mh.Digest[:1]), hex.EncodeToString(mh.Digest[1:2])))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("success - write existing content", func(t *testing.T) {
+		s, err := New(t.TempDir())
+		require.NoError(t, err)
+
+		address1, err := s.Write(content)
+		require.NoError(t, err)
+
+		address2, err := s.Write(content)
+		require.NoError(t, err)
+		require.Equal(t, address1, address2)
+	})
+
+	t.Run("success - multiple multihash algorithms", func(t *testing.T) {
+		dir := t.TempDir()
+
+		s512, err := New(dir, WithMultihashAlgorithms(sha2_512))
+		require.NoError(t, err)
+
+		address512, err := s512.Write(content)
+		require.NoError(t, err)
+
+		s, err := New(dir, WithMultihashAlgorithms(sha2_256, sha2_512))
+		require.NoError(t, err)
+
+		address256, err := s.Write(content)
+		require.NoError(t, err)
+		require.NotEqual(t, address256, address512)
+
+		read, err := s.Read(address512)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+
+		// The SHA2-256 store doesn't accept SHA2-512 addresses.
+		s256, err := New(dir)
+		require.NoError(t, err)
+
+		_, err = s256.Read(address512)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid address")
+	})
+
+	t.Run("success - concurrent writes", func(t *testing.T) {
+		s, err := New(t.TempDir())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, e := s.Write(content)
+				require.NoError(t, e)
+			}()
+		}
+
+		wg.Wait()
+
+		address, err := s.Write(content)
+		require.NoError(t, err)
+
+		read, err := s.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+	})
+
+	t.Run("success - read-only", func(t *testing.T) {
+		dir := t.TempDir()
+
+		s, err := New(dir)
+		require.NoError(t, err)
+
+		address, err := s.Write(content)
+		require.NoError(t, err)
+
+		ro, err := New(dir, WithReadOnly())
+		require.NoError(t, err)
+
+		read, err := ro.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+
+		_, err = ro.Write([]byte("other content"))
+		require.True(t, errors.Is(err, ErrReadOnly))
+	})
+
+	t.Run("error - not found", func(t *testing.T) {
+		s, err := New(t.TempDir())
+		require.NoError(t, err)
+
+		address, err := mocks.NewMockCasClient(nil).Write(content)
+		require.NoError(t, err)
+
+		_, err = s.Read(address)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("error - invalid address", func(t *testing.T) {
+		s, err := New(t.TempDir())
+		require.NoError(t, err)
+
+		for _, address := range []string{"", "address", "../../etc/passwd", sha256AddressWithDigest([]byte{1})} {
+			_, err = s.Read(address)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid address")
+		}
+	})
+
+	t.Run("error - hash mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+
+		s, err := New(dir)
+		require.NoError(t, err)
+
+		address, err := s.Write(content)
+		require.NoError(t, err)
+
+		path, err := s.path(address)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, []byte("corrupted content"), 0o600))
+
+		_, err = s.Read(address)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrHashMismatch))
+	})
+
+	t.Run("error - content too large", func(t *testing.T) {
+		dir := t.TempDir()
+
+		s, err := New(dir, WithMaxObjectSize(int64(len(content))))
+		require.NoError(t, err)
+
+		address, err := s.Write(content)
+		require.NoError(t, err)
+
+		_, err = s.Write(append(content, '!'))
+		require.True(t, errors.Is(err, ErrContentTooLarge))
+
+		small, err := New(dir, WithMaxObjectSize(int64(len(content)-1)), WithReadOnly())
+		require.NoError(t, err)
+
+		_, err = small.Read(address)
+		require.True(t, errors.Is(err, ErrContentTooLarge))
+	})
+
+	t.Run("error - write to file", func(t *testing.T) {
+		dir := t.TempDir()
+
+		s, err := New(dir)
+		require.NoError(t, err)
+
+		address, err := mocks.NewMockCasClient(nil).Write(content)
+		require.NoError(t, err)
+
+		path, err := s.path(address)
+		require.NoError(t, err)
+
+		// Create a file where the shard directory should be.
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Dir(path)), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Dir(path), []byte("test"), 0o600))
+
+		_, err = s.Write(content)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create directory")
+	})
+}
+
+func sha256AddressWithDigest(digest []byte) string {
+	return encoder.EncodeToString(append([]byte{sha2_256, byte(len(digest))}, digest...))
+}
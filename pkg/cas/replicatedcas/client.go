@@ -0,0 +1,334 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package replicatedcas implements a content addressable store client which replicates content to
+// multiple CAS backends, so that an anchored batch may still be resolved if one of the backends loses
+// its data. A write succeeds once a quorum of the replicas return the same address. The replicas which
+// failed are recorded in a repair queue so that the content may be copied to them later (see RunRepairs).
+package replicatedcas
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/cas"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+)
+
+var logger = log.New("sidetree-svc-replicatedcas")
+
+const defaultMaxRepairAttempts = 10
+
+// Replica is a named CAS backend.
+type Replica struct {
+	Name   string
+	Client cas.Client
+}
+
+// Client is a cas.Client which replicates content to multiple CAS backends.
+type Client struct {
+	replicas          []*Replica
+	quorum            int
+	queue             RepairQueue
+	maxRepairAttempts int
+}
+
+// Option is a replicated client option.
+type Option func(c *Client)
+
+// WithQuorum sets the number of replicas which must confirm a write (default is a majority of the replicas).
+func WithQuorum(value int) Option {
+	return func(c *Client) {
+		c.quorum = value
+	}
+}
+
+// WithRepairQueue sets the queue into which failed replica writes are recorded (default is an in-memory queue).
+func WithRepairQueue(queue RepairQueue) Option {
+	return func(c *Client) {
+		c.queue = queue
+	}
+}
+
+// WithMaxRepairAttempts sets the number of times that a repair is attempted before it's abandoned (default 10).
+func WithMaxRepairAttempts(value int) Option {
+	return func(c *Client) {
+		c.maxRepairAttempts = value
+	}
+}
+
+// New returns a new replicated client for the given replicas.
+func New(replicas []*Replica, opts ...Option) (*Client, error) {
+	if len(replicas) == 0 {
+		return nil, errors.New("at least one replica must be specified")
+	}
+
+	names := make(map[string]struct{})
+
+	for _, r := range replicas {
+		if r.Name == "" {
+			return nil, errors.New("replica name must not be empty")
+		}
+
+		if _, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("duplicate replica name [%s]", r.Name)
+		}
+
+		names[r.Name] = struct{}{}
+	}
+
+	c := &Client{
+		replicas:          replicas,
+		quorum:            len(replicas)/2 + 1,
+		queue:             NewMemRepairQueue(),
+		maxRepairAttempts: defaultMaxRepairAttempts,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.quorum < 1 || c.quorum > len(replicas) {
+		return nil, fmt.Errorf("quorum %d must be between 1 and the number of replicas (%d)", c.quorum, len(replicas))
+	}
+
+	return c, nil
+}
+
+type writeResult struct {
+	replica *Replica
+	address string
+	err     error
+}
+
+// Write writes the content to all replicas in parallel. The address is returned as soon as a quorum of
+// replicas returned the same address. The replicas which didn't confirm the address (including the
+// replicas which complete after the quorum was reached) are added to the repair queue.
+func (c *Client) Write(content []byte) (string, error) {
+	results := make(chan *writeResult, len(c.replicas))
+
+	for _, r := range c.replicas {
+		go func(r *Replica) {
+			address, err := r.Client.Write(content)
+
+			results <- &writeResult{replica: r, address: address, err: err}
+		}(r)
+	}
+
+	votes := make(map[string]int)
+
+	var received []*writeResult
+
+	for i := range c.replicas {
+		result := <-results
+		received = append(received, result)
+
+		if result.err == nil {
+			votes[result.address]++
+
+			if votes[result.address] >= c.quorum {
+				pending := len(c.replicas) - i - 1
+
+				go c.recordFailures(result.address, received, results, pending)
+
+				return result.address, nil
+			}
+		}
+
+		if maxVotes(votes)+len(c.replicas)-i-1 < c.quorum {
+			// The quorum can no longer be reached.
+			break
+		}
+	}
+
+	return "", fmt.Errorf("quorum of %d replicas not reached for CAS write: %s", c.quorum, describe(received))
+}
+
+// Read reads the content from the first replica that returns it.
+func (c *Client) Read(address string) ([]byte, error) {
+	var errs []string
+
+	for _, r := range c.replicas {
+		content, err := r.Client.Read(address)
+		if err == nil {
+			return content, nil
+		}
+
+		logger.Debug("Error reading content from replica", logfields.WithURIString(address),
+			logfields.WithReplica(r.Name), log.WithError(err))
+
+		errs = append(errs, fmt.Sprintf("%s: %s", r.Name, err))
+	}
+
+	return nil, fmt.Errorf("read content at address [%s] from all replicas failed: [%s]",
+		address, strings.Join(errs, "; "))
+}
+
+// Repair copies the content of the task's address from the other replicas to the task's replica.
+func (c *Client) Repair(task *RepairTask) error {
+	target, ok := c.replica(task.Replica)
+	if !ok {
+		return fmt.Errorf("replica [%s] not found", task.Replica)
+	}
+
+	var errs []string
+
+	for _, r := range c.replicas {
+		if r == target {
+			continue
+		}
+
+		content, err := r.Client.Read(task.Address)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", r.Name, err))
+
+			continue
+		}
+
+		address, err := target.Client.Write(content)
+		if err != nil {
+			return fmt.Errorf("write content to replica [%s]: %w", target.Name, err)
+		}
+
+		if address != task.Address {
+			return fmt.Errorf("replica [%s] returned address [%s] instead of [%s]", target.Name, address, task.Address)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("read content at address [%s] from other replicas failed: [%s]",
+		task.Address, strings.Join(errs, "; "))
+}
+
+// RunRepairs takes (at most) the given number of tasks from the repair queue and repairs them. Tasks which
+// fail are added back to the queue unless the maximum number of attempts was reached. The number of
+// repaired tasks is returned. This function is intended to be invoked periodically by a background job.
+func (c *Client) RunRepairs(max int) (int, error) {
+	tasks, err := c.queue.Take(max)
+	if err != nil {
+		return 0, fmt.Errorf("take tasks from repair queue: %w", err)
+	}
+
+	var failed []*RepairTask
+
+	repaired := 0
+
+	for _, task := range tasks {
+		err := c.Repair(task)
+		if err == nil {
+			logger.Info("Repaired replica", logfields.WithURIString(task.Address), logfields.WithReplica(task.Replica))
+
+			repaired++
+
+			continue
+		}
+
+		task.Attempts++
+		task.Error = err.Error()
+
+		if task.Attempts >= c.maxRepairAttempts {
+			logger.Error("Abandoning repair of replica after maximum attempts", logfields.WithURIString(task.Address),
+				logfields.WithReplica(task.Replica), log.WithError(err))
+
+			continue
+		}
+
+		logger.Warn("Error repairing replica", logfields.WithURIString(task.Address),
+			logfields.WithReplica(task.Replica), log.WithError(err))
+
+		failed = append(failed, task)
+	}
+
+	if len(failed) > 0 {
+		if err := c.queue.Add(failed...); err != nil {
+			return repaired, fmt.Errorf("add failed tasks to repair queue: %w", err)
+		}
+	}
+
+	return repaired, nil
+}
+
+// recordFailures adds the replicas which didn't confirm the address to the repair queue. The results of
+// the pending writes are awaited first.
+func (c *Client) recordFailures(address string, received []*writeResult, results <-chan *writeResult, pending int) {
+	for i := 0; i < pending; i++ {
+		received = append(received, <-results)
+	}
+
+	var tasks []*RepairTask
+
+	for _, result := range received {
+		var reason string
+
+		switch {
+		case result.err != nil:
+			reason = result.err.Error()
+		case result.address != address:
+			reason = fmt.Sprintf("replica returned address [%s]", result.address)
+		default:
+			continue
+		}
+
+		logger.Warn("Write to replica failed. Adding replica to repair queue.", logfields.WithURIString(address),
+			logfields.WithReplica(result.replica.Name), logfields.WithReason(reason))
+
+		tasks = append(tasks, &RepairTask{
+			Address: address,
+			Replica: result.replica.Name,
+			Error:   reason,
+			Created: time.Now(),
+		})
+	}
+
+	if len(tasks) == 0 {
+		return
+	}
+
+	if err := c.queue.Add(tasks...); err != nil {
+		logger.Error("Error adding tasks to repair queue", logfields.WithURIString(address), log.WithError(err))
+	}
+}
+
+func (c *Client) replica(name string) (*Replica, bool) {
+	for _, r := range c.replicas {
+		if r.Name == name {
+			return r, true
+		}
+	}
+
+	return nil, false
+}
+
+func maxVotes(votes map[string]int) int {
+	result := 0
+
+	for _, n := range votes {
+		if n > result {
+			result = n
+		}
+	}
+
+	return result
+}
+
+func describe(results []*writeResult) string {
+	var s []string
+
+	for _, result := range results {
+		if result.err != nil {
+			s = append(s, fmt.Sprintf("%s: %s", result.replica.Name, result.err))
+		} else {
+			s = append(s, fmt.Sprintf("%s: %s", result.replica.Name, result.address))
+		}
+	}
+
+	return "[" + strings.Join(s, "; ") + "]"
+}
@@ -0,0 +1,399 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package replicatedcas
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c, err := New(newReplicas(3))
+		require.NoError(t, err)
+		require.Equal(t, 2, c.quorum)
+	})
+
+	t.Run("error - no replicas", func(t *testing.T) {
+		_, err := New(nil)
+		require.EqualError(t, err, "at least one replica must be specified")
+	})
+
+	t.Run("error - empty replica name", func(t *testing.T) {
+		_, err := New([]*Replica{{Client: mocks.NewMockCasClient(nil)}})
+		require.EqualError(t, err, "replica name must not be empty")
+	})
+
+	t.Run("error - duplicate replica name", func(t *testing.T) {
+		_, err := New([]*Replica{
+			{Name: "r1", Client: mocks.NewMockCasClient(nil)},
+			{Name: "r1", Client: mocks.NewMockCasClient(nil)},
+		})
+		require.EqualError(t, err, "duplicate replica name [r1]")
+	})
+
+	t.Run("error - invalid quorum", func(t *testing.T) {
+		_, err := New(newReplicas(3), WithQuorum(4))
+		require.EqualError(t, err, "quorum 4 must be between 1 and the number of replicas (3)")
+
+		_, err = New(newReplicas(3), WithQuorum(0))
+		require.Error(t, err)
+	})
+}
+
+func TestClient_Write(t *testing.T) {
+	content := []byte("test content")
+
+	t.Run("success - all replicas", func(t *testing.T) {
+		replicas := newReplicas(3)
+		queue := NewMemRepairQueue()
+
+		c, err := New(replicas, WithRepairQueue(queue))
+		require.NoError(t, err)
+
+		address, err := c.Write(content)
+		require.NoError(t, err)
+
+		// The write may return before the last replica completes.
+		for _, r := range replicas {
+			require.Eventually(t, func() bool {
+				read, e := r.Client.Read(address)
+
+				return e == nil && string(read) == string(content)
+			}, time.Second, 5*time.Millisecond)
+		}
+
+		require.Zero(t, queue.Len())
+	})
+
+	t.Run("success - quorum reached with failed replica", func(t *testing.T) {
+		replicas := newReplicas(3)
+		replicas[1].Client.(*mockReplica).writeErr = errors.New("injected write error")
+
+		queue := NewMemRepairQueue()
+
+		c, err := New(replicas, WithRepairQueue(queue))
+		require.NoError(t, err)
+
+		address, err := c.Write(content)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return queue.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+		tasks, err := queue.Take(10)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		require.Equal(t, address, tasks[0].Address)
+		require.Equal(t, "r1", tasks[0].Replica)
+		require.Equal(t, "injected write error", tasks[0].Error)
+	})
+
+	t.Run("success - quorum reached before slow replica completes", func(t *testing.T) {
+		replicas := newReplicas(3)
+
+		slow := replicas[2].Client.(*mockReplica)
+		slow.writeErr = errors.New("injected write error")
+		slow.release = make(chan struct{})
+
+		queue := NewMemRepairQueue()
+
+		c, err := New(replicas, WithRepairQueue(queue))
+		require.NoError(t, err)
+
+		address, err := c.Write(content)
+		require.NoError(t, err)
+		require.NotEmpty(t, address)
+
+		// The failure of the slow replica is recorded once its write completes.
+		require.Zero(t, queue.Len())
+
+		close(slow.release)
+
+		require.Eventually(t, func() bool { return queue.Len() == 1 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("success - replica returns different address", func(t *testing.T) {
+		replicas := newReplicas(3)
+		replicas[0].Client.(*mockReplica).address = "other-address"
+
+		queue := NewMemRepairQueue()
+
+		c, err := New(replicas, WithRepairQueue(queue))
+		require.NoError(t, err)
+
+		address, err := c.Write(content)
+		require.NoError(t, err)
+		require.NotEqual(t, "other-address", address)
+
+		require.Eventually(t, func() bool { return queue.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+		tasks, err := queue.Take(1)
+		require.NoError(t, err)
+		require.Equal(t, "r0", tasks[0].Replica)
+		require.Contains(t, tasks[0].Error, "other-address")
+	})
+
+	t.Run("error - quorum not reached", func(t *testing.T) {
+		replicas := newReplicas(3)
+		replicas[0].Client.(*mockReplica).writeErr = errors.New("injected write error")
+		replicas[1].Client.(*mockReplica).writeErr = errors.New("injected write error")
+
+		queue := NewMemRepairQueue()
+
+		c, err := New(replicas, WithRepairQueue(queue))
+		require.NoError(t, err)
+
+		_, err = c.Write(content)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "quorum of 2 replicas not reached for CAS write")
+		require.Contains(t, err.Error(), "r0: injected write error")
+		require.Zero(t, queue.Len())
+	})
+
+	t.Run("error - quorum not reached due to different addresses", func(t *testing.T) {
+		replicas := newReplicas(2)
+		replicas[0].Client.(*mockReplica).address = "other-address"
+
+		c, err := New(replicas)
+		require.NoError(t, err)
+
+		_, err = c.Write(content)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "r0: other-address")
+	})
+}
+
+func TestClient_Read(t *testing.T) {
+	content := []byte("test content")
+
+	t.Run("success", func(t *testing.T) {
+		replicas := newReplicas(2)
+
+		address, err := replicas[1].Client.Write(content)
+		require.NoError(t, err)
+
+		c, err := New(replicas)
+		require.NoError(t, err)
+
+		read, err := c.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+	})
+
+	t.Run("error - not found in any replica", func(t *testing.T) {
+		c, err := New(newReplicas(2))
+		require.NoError(t, err)
+
+		_, err = c.Read("address")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "read content at address [address] from all replicas failed")
+		require.Contains(t, err.Error(), "r0: not found")
+		require.Contains(t, err.Error(), "r1: not found")
+	})
+}
+
+func TestClient_RunRepairs(t *testing.T) {
+	content := []byte("test content")
+
+	t.Run("success", func(t *testing.T) {
+		replicas := newReplicas(3)
+		failed := replicas[1].Client.(*mockReplica)
+		failed.writeErr = errors.New("injected write error")
+
+		queue := NewMemRepairQueue()
+
+		c, err := New(replicas, WithRepairQueue(queue))
+		require.NoError(t, err)
+
+		address, err := c.Write(content)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return queue.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+		// The replica is still failing.
+		n, err := c.RunRepairs(10)
+		require.NoError(t, err)
+		require.Zero(t, n)
+		require.Equal(t, 1, queue.Len())
+
+		failed.writeErr = nil
+
+		n, err = c.RunRepairs(10)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Zero(t, queue.Len())
+
+		read, err := failed.Read(address)
+		require.NoError(t, err)
+		require.Equal(t, content, read)
+	})
+
+	t.Run("abandoned after maximum attempts", func(t *testing.T) {
+		queue := NewMemRepairQueue()
+
+		c, err := New(newReplicas(2), WithRepairQueue(queue), WithMaxRepairAttempts(2))
+		require.NoError(t, err)
+
+		require.NoError(t, queue.Add(&RepairTask{Address: "address", Replica: "r0"}))
+
+		n, err := c.RunRepairs(10)
+		require.NoError(t, err)
+		require.Zero(t, n)
+		require.Equal(t, 1, queue.Len())
+
+		n, err = c.RunRepairs(10)
+		require.NoError(t, err)
+		require.Zero(t, n)
+		require.Zero(t, queue.Len())
+	})
+
+	t.Run("error - take from queue", func(t *testing.T) {
+		c, err := New(newReplicas(2), WithRepairQueue(&mockRepairQueue{takeErr: errors.New("injected take error")}))
+		require.NoError(t, err)
+
+		_, err = c.RunRepairs(10)
+		require.EqualError(t, err, "take tasks from repair queue: injected take error")
+	})
+
+	t.Run("error - add to queue", func(t *testing.T) {
+		queue := &mockRepairQueue{
+			tasks:  []*RepairTask{{Address: "address", Replica: "r0"}},
+			addErr: errors.New("injected add error"),
+		}
+
+		c, err := New(newReplicas(2), WithRepairQueue(queue))
+		require.NoError(t, err)
+
+		_, err = c.RunRepairs(10)
+		require.EqualError(t, err, "add failed tasks to repair queue: injected add error")
+	})
+}
+
+func TestClient_Repair(t *testing.T) {
+	content := []byte("test content")
+
+	t.Run("error - replica not found", func(t *testing.T) {
+		c, err := New(newReplicas(2))
+		require.NoError(t, err)
+
+		err = c.Repair(&RepairTask{Address: "address", Replica: "r5"})
+		require.EqualError(t, err, "replica [r5] not found")
+	})
+
+	t.Run("error - different address", func(t *testing.T) {
+		replicas := newReplicas(2)
+
+		address, err := replicas[1].Client.Write(content)
+		require.NoError(t, err)
+
+		replicas[0].Client.(*mockReplica).address = "other-address"
+
+		c, err := New(replicas)
+		require.NoError(t, err)
+
+		err = c.Repair(&RepairTask{Address: address, Replica: "r0"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "replica [r0] returned address [other-address]")
+	})
+}
+
+func TestMemRepairQueue(t *testing.T) {
+	q := NewMemRepairQueue()
+
+	require.NoError(t, q.Add(&RepairTask{Address: "a1"}, &RepairTask{Address: "a2"}, &RepairTask{Address: "a3"}))
+	require.Equal(t, 3, q.Len())
+
+	tasks, err := q.Take(2)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Equal(t, "a1", tasks[0].Address)
+	require.Equal(t, "a2", tasks[1].Address)
+
+	tasks, err = q.Take(2)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "a3", tasks[0].Address)
+
+	tasks, err = q.Take(2)
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+}
+
+func newReplicas(n int) []*Replica {
+	replicas := make([]*Replica, n)
+
+	for i := range replicas {
+		replicas[i] = &Replica{
+			Name:   fmt.Sprintf("r%d", i),
+			Client: &mockReplica{MockCasClient: mocks.NewMockCasClient(nil)},
+		}
+	}
+
+	return replicas
+}
+
+type mockReplica struct {
+	*mocks.MockCasClient
+
+	writeErr error
+	address  string
+	release  chan struct{}
+}
+
+func (m *mockReplica) Write(content []byte) (string, error) {
+	if m.release != nil {
+		<-m.release
+	}
+
+	if m.writeErr != nil {
+		return "", m.writeErr
+	}
+
+	address, err := m.MockCasClient.Write(content)
+	if err != nil {
+		return "", err
+	}
+
+	if m.address != "" {
+		return m.address, nil
+	}
+
+	return address, nil
+}
+
+type mockRepairQueue struct {
+	tasks   []*RepairTask
+	addErr  error
+	takeErr error
+}
+
+func (m *mockRepairQueue) Add(tasks ...*RepairTask) error {
+	if m.addErr != nil {
+		return m.addErr
+	}
+
+	m.tasks = append(m.tasks, tasks...)
+
+	return nil
+}
+
+func (m *mockRepairQueue) Take(int) ([]*RepairTask, error) {
+	if m.takeErr != nil {
+		return nil, m.takeErr
+	}
+
+	tasks := m.tasks
+	m.tasks = nil
+
+	return tasks, nil
+}
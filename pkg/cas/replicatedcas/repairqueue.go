@@ -0,0 +1,77 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package replicatedcas
+
+import (
+	"sync"
+	"time"
+)
+
+// RepairTask records a replica which doesn't hold the content at the given address (because the write
+// to the replica failed) so that the content may be copied to the replica later.
+type RepairTask struct {
+	Address  string    `json:"address"`
+	Replica  string    `json:"replica"`
+	Error    string    `json:"error,omitempty"`
+	Attempts int       `json:"attempts,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// RepairQueue stores the pending repair tasks.
+type RepairQueue interface {
+	// Add adds the given tasks to the queue.
+	Add(tasks ...*RepairTask) error
+	// Take removes (at most) the given number of tasks from the queue and returns them.
+	Take(max int) ([]*RepairTask, error)
+}
+
+// MemRepairQueue is an in-memory repair queue. The tasks are lost when the process exits, so a
+// persistent queue should be used if the replicas must be repaired across restarts.
+type MemRepairQueue struct {
+	mutex sync.Mutex
+	tasks []*RepairTask
+}
+
+// NewMemRepairQueue returns a new in-memory repair queue.
+func NewMemRepairQueue() *MemRepairQueue {
+	return &MemRepairQueue{}
+}
+
+// Add adds the given tasks to the queue.
+func (q *MemRepairQueue) Add(tasks ...*RepairTask) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.tasks = append(q.tasks, tasks...)
+
+	return nil
+}
+
+// Take removes (at most) the given number of tasks from the front of the queue and returns them.
+func (q *MemRepairQueue) Take(max int) ([]*RepairTask, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if max > len(q.tasks) {
+		max = len(q.tasks)
+	}
+
+	tasks := make([]*RepairTask, max)
+	copy(tasks, q.tasks)
+
+	q.tasks = q.tasks[max:]
+
+	return tasks, nil
+}
+
+// Len returns the number of tasks in the queue.
+func (q *MemRepairQueue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return len(q.tasks)
+}
@@ -0,0 +1,315 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package retention keeps track of the batch files (artifacts) which are written to CAS by the batch writer
+// along with the anchor status of their batches, and plans which files a CAS backend should retain.
+//
+// The files of anchored batches are pinned. Permanent files (core index and core proof) are retained
+// indefinitely whereas provisional files (provisional index, provisional proof and chunks) are unpinned
+// once the provisional retention period has expired. The files of batches which were never anchored
+// (because writing the anchor failed or the process exited before the anchor was written) are orphans
+// which may be deleted.
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+)
+
+var logger = log.New("sidetree-svc-retention")
+
+const defaultOrphanGracePeriod = time.Hour
+
+// Backend is implemented by a CAS backend which can pin, unpin and delete content.
+type Backend interface {
+	// Pin ensures that the content at the given address is retained.
+	Pin(address string) error
+	// Unpin allows the content at the given address to be garbage collected by the backend.
+	Unpin(address string) error
+	// Delete deletes the content at the given address.
+	Delete(address string) error
+}
+
+// Plan contains the addresses of the artifacts which should be pinned, unpinned and deleted.
+type Plan struct {
+	Pin    []string `json:"pin,omitempty"`
+	Unpin  []string `json:"unpin,omitempty"`
+	Delete []string `json:"delete,omitempty"`
+}
+
+// IsEmpty returns true if the plan doesn't contain any actions.
+func (p *Plan) IsEmpty() bool {
+	return len(p.Pin) == 0 && len(p.Unpin) == 0 && len(p.Delete) == 0
+}
+
+// Manager records the artifacts written by the batch writer and creates retention plans.
+type Manager struct {
+	store                Store
+	provisionalRetention time.Duration
+	orphanGracePeriod    time.Duration
+	now                  func() time.Time
+
+	mutex sync.Mutex
+}
+
+// Option is a retention manager option.
+type Option func(m *Manager)
+
+// WithProvisionalRetention sets the period (after the batch was anchored) for which provisional files are
+// retained. Zero (default) retains provisional files indefinitely.
+func WithProvisionalRetention(value time.Duration) Option {
+	return func(m *Manager) {
+		m.provisionalRetention = value
+	}
+}
+
+// WithOrphanGracePeriod sets the period after which the files of a batch whose anchor hasn't been written
+// (or failed to be written) are considered to be orphans (default one hour). The period should be longer
+// than the time it takes to write an anchor and to retry the operations of a failed batch.
+func WithOrphanGracePeriod(value time.Duration) Option {
+	return func(m *Manager) {
+		m.orphanGracePeriod = value
+	}
+}
+
+// New returns a new retention manager which stores the artifact records in the given store.
+func New(store Store, opts ...Option) *Manager {
+	m := &Manager{
+		store:             store,
+		orphanGracePeriod: defaultOrphanGracePeriod,
+		now:               time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// RecordPending records the artifacts of a batch whose anchor is about to be written.
+func (m *Manager) RecordPending(anchorString string, artifacts []*protocol.AnchorDocument) error {
+	return m.record(anchorString, artifacts, StatusPending)
+}
+
+// RecordAnchored records that the anchor of the batch containing the given artifacts was written.
+func (m *Manager) RecordAnchored(anchorString string, artifacts []*protocol.AnchorDocument) error {
+	return m.record(anchorString, artifacts, StatusAnchored)
+}
+
+// RecordFailed records that the anchor of the batch containing the given artifacts could not be written.
+func (m *Manager) RecordFailed(anchorString string, artifacts []*protocol.AnchorDocument) error {
+	return m.record(anchorString, artifacts, StatusFailed)
+}
+
+func (m *Manager) record(anchorString string, docs []*protocol.AnchorDocument, status BatchStatus) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+
+	artifacts := make([]*Artifact, 0, len(docs))
+
+	for _, doc := range docs {
+		a, err := m.store.Get(doc.ID)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("get artifact [%s]: %w", doc.ID, err)
+			}
+
+			a = &Artifact{
+				Address: doc.ID,
+				Desc:    doc.Desc,
+				Type:    doc.Type,
+				Created: now,
+			}
+		}
+
+		a.setStatus(anchorString, status, now)
+
+		artifacts = append(artifacts, a)
+	}
+
+	if err := m.store.Put(artifacts...); err != nil {
+		return fmt.Errorf("store artifacts: %w", err)
+	}
+
+	logger.Debug("Recorded batch artifacts", logfields.WithAnchorString(anchorString),
+		logfields.WithTotal(len(artifacts)), log.WithState(string(status)))
+
+	return nil
+}
+
+// Plan returns the actions which should be applied to the CAS backend:
+//   - artifacts of anchored batches which aren't pinned yet are pinned;
+//   - provisional artifacts whose retention period has expired are unpinned;
+//   - artifacts of batches which were never anchored (orphans) are deleted.
+func (m *Manager) Plan() (*Plan, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	artifacts, err := m.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("list artifacts: %w", err)
+	}
+
+	now := m.now()
+
+	plan := &Plan{}
+
+	for _, a := range artifacts {
+		switch m.action(a, now) {
+		case actionPin:
+			plan.Pin = append(plan.Pin, a.Address)
+		case actionUnpin:
+			plan.Unpin = append(plan.Unpin, a.Address)
+		case actionDelete:
+			plan.Delete = append(plan.Delete, a.Address)
+		case actionNone:
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply applies the plan to the given backend and updates the artifact records accordingly. The records
+// of unpinned and deleted artifacts are removed. An action is skipped if the artifact changed after the plan
+// was created (e.g. an orphan was included in a batch which was anchored). All actions are attempted, even
+// if some of them fail.
+func (m *Manager) Apply(plan *Plan, backend Backend) error {
+	var errs []string
+
+	for _, address := range plan.Pin {
+		if err := m.apply(address, actionPin, backend.Pin); err != nil {
+			errs = append(errs, fmt.Sprintf("pin %s: %s", address, err))
+		}
+	}
+
+	for _, address := range plan.Unpin {
+		if err := m.apply(address, actionUnpin, backend.Unpin); err != nil {
+			errs = append(errs, fmt.Sprintf("unpin %s: %s", address, err))
+		}
+	}
+
+	for _, address := range plan.Delete {
+		if err := m.apply(address, actionDelete, backend.Delete); err != nil {
+			errs = append(errs, fmt.Sprintf("delete %s: %s", address, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d retention actions failed: [%s]", len(errs), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (m *Manager) apply(address string, expected action, backendAction func(address string) error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, err := m.store.Get(address)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	if m.action(a, m.now()) != expected {
+		logger.Debug("Skipping retention action since the artifact changed", logfields.WithURIString(address))
+
+		return nil
+	}
+
+	if err := backendAction(address); err != nil {
+		logger.Warn("Error applying retention action", logfields.WithURIString(address), log.WithError(err))
+
+		return err
+	}
+
+	if expected == actionPin {
+		a.Pinned = true
+
+		return m.store.Put(a)
+	}
+
+	return m.store.Delete(address)
+}
+
+type action int
+
+const (
+	actionNone action = iota
+	actionPin
+	actionUnpin
+	actionDelete
+)
+
+func (m *Manager) action(a *Artifact, now time.Time) action {
+	anchored, ok := a.lastAnchored()
+	if ok {
+		if a.Type == protocol.TypeProvisional && m.provisionalRetention > 0 &&
+			now.Sub(anchored) > m.provisionalRetention {
+			return actionUnpin
+		}
+
+		if !a.Pinned {
+			return actionPin
+		}
+
+		return actionNone
+	}
+
+	for _, b := range a.Batches {
+		if now.Sub(b.Updated) <= m.orphanGracePeriod {
+			// The anchor of a pending batch may still be written. The operations of a failed batch are
+			// added back to the queue and the next batch may write the same (content-addressed) files, so
+			// they're not deleted before the grace period has expired either.
+			return actionNone
+		}
+	}
+
+	return actionDelete
+}
+
+func (a *Artifact) setStatus(anchorString string, status BatchStatus, now time.Time) {
+	for _, b := range a.Batches {
+		if b.AnchorString == anchorString {
+			b.Status = status
+			b.Updated = now
+
+			return
+		}
+	}
+
+	a.Batches = append(a.Batches, &BatchRef{AnchorString: anchorString, Status: status, Updated: now})
+}
+
+// lastAnchored returns the time at which the last batch containing the artifact was anchored.
+func (a *Artifact) lastAnchored() (time.Time, bool) {
+	var last time.Time
+
+	anchored := false
+
+	for _, b := range a.Batches {
+		if b.Status == StatusAnchored && (!anchored || b.Updated.After(last)) {
+			last = b.Updated
+			anchored = true
+		}
+	}
+
+	return last, anchored
+}
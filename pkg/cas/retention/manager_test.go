@@ -0,0 +1,311 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package retention
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+)
+
+const (
+	anchor1 = "1.coreIndexURI1"
+	anchor2 = "1.coreIndexURI2"
+
+	coreIndexURI        = "coreIndexURI"
+	provisionalIndexURI = "provisionalIndexURI"
+	chunkURI            = "chunkURI"
+)
+
+func TestManager_Plan(t *testing.T) {
+	t.Run("anchored - pin", func(t *testing.T) {
+		m, _ := newTestManager()
+
+		require.NoError(t, m.RecordPending(anchor1, newDocs()))
+
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+
+		require.NoError(t, m.RecordAnchored(anchor1, newDocs()))
+
+		plan, err = m.Plan()
+		require.NoError(t, err)
+		require.Equal(t, []string{chunkURI, coreIndexURI, provisionalIndexURI}, plan.Pin)
+		require.Empty(t, plan.Unpin)
+		require.Empty(t, plan.Delete)
+	})
+
+	t.Run("provisional retention expired - unpin", func(t *testing.T) {
+		m, now := newTestManager(WithProvisionalRetention(time.Hour))
+
+		require.NoError(t, m.RecordAnchored(anchor1, newDocs()))
+		require.NoError(t, m.Apply(mustPlan(t, m), &mockBackend{}))
+
+		*now = now.Add(30 * time.Minute)
+
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+
+		*now = now.Add(time.Hour)
+
+		plan, err = m.Plan()
+		require.NoError(t, err)
+		require.Empty(t, plan.Pin)
+		require.Equal(t, []string{chunkURI, provisionalIndexURI}, plan.Unpin)
+		require.Empty(t, plan.Delete)
+	})
+
+	t.Run("no provisional retention - retained indefinitely", func(t *testing.T) {
+		m, now := newTestManager()
+
+		require.NoError(t, m.RecordAnchored(anchor1, newDocs()))
+		require.NoError(t, m.Apply(mustPlan(t, m), &mockBackend{}))
+
+		*now = now.Add(24 * 365 * time.Hour)
+
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+	})
+
+	t.Run("failed after grace period - delete", func(t *testing.T) {
+		m, now := newTestManager(WithOrphanGracePeriod(time.Minute))
+
+		require.NoError(t, m.RecordPending(anchor1, newDocs()))
+		require.NoError(t, m.RecordFailed(anchor1, newDocs()))
+
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+
+		*now = now.Add(time.Minute)
+
+		plan, err = m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+
+		*now = now.Add(time.Second)
+
+		plan, err = m.Plan()
+		require.NoError(t, err)
+		require.Empty(t, plan.Pin)
+		require.Empty(t, plan.Unpin)
+		require.Equal(t, []string{chunkURI, coreIndexURI, provisionalIndexURI}, plan.Delete)
+	})
+
+	t.Run("pending after grace period - delete", func(t *testing.T) {
+		m, now := newTestManager(WithOrphanGracePeriod(time.Minute))
+
+		require.NoError(t, m.RecordPending(anchor1, newDocs()))
+
+		*now = now.Add(time.Minute)
+
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+
+		*now = now.Add(time.Second)
+
+		plan, err = m.Plan()
+		require.NoError(t, err)
+		require.Len(t, plan.Delete, 3)
+	})
+
+	t.Run("failed batch anchored in another batch - pin", func(t *testing.T) {
+		m, now := newTestManager()
+
+		require.NoError(t, m.RecordFailed(anchor1, newDocs()))
+
+		// The files of the failed batch are retained for the grace period since the next batch may contain
+		// the same files.
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+
+		*now = now.Add(defaultOrphanGracePeriod + time.Second)
+
+		require.NoError(t, m.RecordPending(anchor2, newDocs()[1:]))
+
+		plan, err = m.Plan()
+		require.NoError(t, err)
+		require.Equal(t, []string{coreIndexURI}, plan.Delete)
+
+		require.NoError(t, m.RecordAnchored(anchor2, newDocs()[1:]))
+
+		plan, err = m.Plan()
+		require.NoError(t, err)
+		require.Equal(t, []string{chunkURI, provisionalIndexURI}, plan.Pin)
+		require.Equal(t, []string{coreIndexURI}, plan.Delete)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		errExpected := errors.New("injected store error")
+
+		m := New(&mockStore{Store: NewMemStore(), err: errExpected})
+
+		_, err := m.Plan()
+		require.ErrorIs(t, err, errExpected)
+
+		err = m.RecordPending(anchor1, newDocs())
+		require.ErrorIs(t, err, errExpected)
+	})
+}
+
+func TestManager_Apply(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		m, now := newTestManager()
+
+		require.NoError(t, m.RecordAnchored(anchor1, newDocs()[:1]))
+		require.NoError(t, m.RecordFailed(anchor2, newDocs()[1:]))
+
+		*now = now.Add(defaultOrphanGracePeriod + time.Second)
+
+		backend := &mockBackend{}
+
+		require.NoError(t, m.Apply(mustPlan(t, m), backend))
+		require.Equal(t, []string{coreIndexURI}, backend.pinned)
+		require.Equal(t, []string{chunkURI, provisionalIndexURI}, backend.deleted)
+
+		a, err := m.store.Get(coreIndexURI)
+		require.NoError(t, err)
+		require.True(t, a.Pinned)
+
+		_, err = m.store.Get(chunkURI)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.True(t, plan.IsEmpty())
+	})
+
+	t.Run("artifact changed - skipped", func(t *testing.T) {
+		m, now := newTestManager()
+
+		require.NoError(t, m.RecordFailed(anchor1, newDocs()))
+
+		*now = now.Add(defaultOrphanGracePeriod + time.Second)
+
+		plan := mustPlan(t, m)
+		require.Len(t, plan.Delete, 3)
+
+		require.NoError(t, m.RecordAnchored(anchor2, newDocs()))
+
+		backend := &mockBackend{}
+
+		require.NoError(t, m.Apply(plan, backend))
+		require.Empty(t, backend.deleted)
+
+		// Artifacts which no longer exist are also skipped.
+		require.NoError(t, m.Apply(&Plan{Unpin: []string{"unknown"}}, backend))
+		require.Empty(t, backend.unpinned)
+	})
+
+	t.Run("backend error", func(t *testing.T) {
+		m, now := newTestManager()
+
+		require.NoError(t, m.RecordAnchored(anchor1, newDocs()[:1]))
+		require.NoError(t, m.RecordFailed(anchor2, newDocs()[1:]))
+
+		*now = now.Add(defaultOrphanGracePeriod + time.Second)
+
+		backend := &mockBackend{err: errors.New("injected backend error")}
+
+		err := m.Apply(mustPlan(t, m), backend)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "3 retention actions failed")
+		require.Contains(t, err.Error(), "pin coreIndexURI: injected backend error")
+		require.Contains(t, err.Error(), "delete chunkURI: injected backend error")
+
+		// The failed actions are planned again.
+		plan, err := m.Plan()
+		require.NoError(t, err)
+		require.Equal(t, []string{coreIndexURI}, plan.Pin)
+		require.Len(t, plan.Delete, 2)
+	})
+}
+
+func newTestManager(opts ...Option) (*Manager, *time.Time) {
+	now := time.Now()
+
+	m := New(NewMemStore(), opts...)
+	m.now = func() time.Time { return now }
+
+	return m, &now
+}
+
+func mustPlan(t *testing.T, m *Manager) *Plan {
+	t.Helper()
+
+	plan, err := m.Plan()
+	require.NoError(t, err)
+
+	return plan
+}
+
+func newDocs() []*protocol.AnchorDocument {
+	return []*protocol.AnchorDocument{
+		{ID: coreIndexURI, Desc: "core index", Type: protocol.TypePermanent},
+		{ID: provisionalIndexURI, Desc: "provisional index", Type: protocol.TypeProvisional},
+		{ID: chunkURI, Desc: "chunk", Type: protocol.TypeProvisional},
+	}
+}
+
+type mockBackend struct {
+	pinned   []string
+	unpinned []string
+	deleted  []string
+	err      error
+}
+
+func (b *mockBackend) Pin(address string) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	b.pinned = append(b.pinned, address)
+
+	return nil
+}
+
+func (b *mockBackend) Unpin(address string) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	b.unpinned = append(b.unpinned, address)
+
+	return nil
+}
+
+func (b *mockBackend) Delete(address string) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	b.deleted = append(b.deleted, address)
+
+	return nil
+}
+
+type mockStore struct {
+	Store
+	err error
+}
+
+func (s *mockStore) Get(string) (*Artifact, error) {
+	return nil, s.err
+}
+
+func (s *mockStore) List() ([]*Artifact, error) {
+	return nil, s.err
+}
@@ -0,0 +1,140 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package retention
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+)
+
+// ErrNotFound is returned by a Store if the artifact doesn't exist.
+var ErrNotFound = errors.New("artifact not found")
+
+// BatchStatus is the anchor status of a batch.
+type BatchStatus string
+
+const (
+	// StatusPending indicates that the batch files were written but the anchor hasn't been written yet.
+	StatusPending BatchStatus = "pending"
+	// StatusAnchored indicates that the anchor of the batch was written.
+	StatusAnchored BatchStatus = "anchored"
+	// StatusFailed indicates that the anchor of the batch could not be written.
+	StatusFailed BatchStatus = "failed"
+)
+
+// BatchRef references a batch (identified by its anchor string) which includes an artifact. An artifact
+// may be included in more than one batch since identical files have the same content address (e.g. when
+// the operations of a failed batch are cut into a new batch).
+type BatchRef struct {
+	AnchorString string      `json:"anchorString"`
+	Status       BatchStatus `json:"status"`
+	Updated      time.Time   `json:"updated"`
+}
+
+// Artifact records a file which was written to CAS by the batch writer.
+type Artifact struct {
+	Address string                      `json:"address"`
+	Desc    string                      `json:"desc,omitempty"`
+	Type    protocol.AnchorDocumentType `json:"type"`
+	Batches []*BatchRef                 `json:"batches"`
+	Pinned  bool                        `json:"pinned,omitempty"`
+	Created time.Time                   `json:"created"`
+}
+
+// Store stores the artifact records.
+type Store interface {
+	// Get returns the artifact with the given address or ErrNotFound.
+	Get(address string) (*Artifact, error)
+	// Put adds or replaces the given artifacts.
+	Put(artifacts ...*Artifact) error
+	// Delete deletes the artifact with the given address.
+	Delete(address string) error
+	// List returns all artifacts.
+	List() ([]*Artifact, error)
+}
+
+// MemStore is an in-memory artifact store. The records are lost when the process exits, so a
+// persistent store should be used in production.
+type MemStore struct {
+	mutex     sync.RWMutex
+	artifacts map[string]*Artifact
+}
+
+// NewMemStore returns a new in-memory artifact store.
+func NewMemStore() *MemStore {
+	return &MemStore{artifacts: make(map[string]*Artifact)}
+}
+
+// Get returns a copy of the artifact with the given address or ErrNotFound.
+func (s *MemStore) Get(address string) (*Artifact, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	a, ok := s.artifacts[address]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return a.copy(), nil
+}
+
+// Put adds or replaces the given artifacts.
+func (s *MemStore) Put(artifacts ...*Artifact) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, a := range artifacts {
+		s.artifacts[a.Address] = a.copy()
+	}
+
+	return nil
+}
+
+// Delete deletes the artifact with the given address.
+func (s *MemStore) Delete(address string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.artifacts, address)
+
+	return nil
+}
+
+// List returns copies of all artifacts ordered by address.
+func (s *MemStore) List() ([]*Artifact, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	artifacts := make([]*Artifact, 0, len(s.artifacts))
+
+	for _, a := range s.artifacts {
+		artifacts = append(artifacts, a.copy())
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].Address < artifacts[j].Address
+	})
+
+	return artifacts, nil
+}
+
+func (a *Artifact) copy() *Artifact {
+	c := *a
+
+	c.Batches = make([]*BatchRef, len(a.Batches))
+
+	for i, b := range a.Batches {
+		ref := *b
+		c.Batches[i] = &ref
+	}
+
+	return &c
+}
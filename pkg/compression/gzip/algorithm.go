@@ -62,6 +62,16 @@ func (a *Algorithm) Decompress(data []byte) ([]byte, error) {
 	return zrBytes, nil
 }
 
+// NewReader returns a reader which decompresses the gzip data read from the given reader.
+func (a *Algorithm) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new reader: %s", err.Error())
+	}
+
+	return zr, nil
+}
+
 // Accept algorithm.
 func (a *Algorithm) Accept(alg string) bool {
 	return alg == algName
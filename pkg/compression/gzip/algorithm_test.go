@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package gzip
 
 import (
+	"bytes"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -69,6 +71,33 @@ func TestAlgorithm_Decompress(t *testing.T) {
 	})
 }
 
+func TestAlgorithm_NewReader(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		alg := New()
+
+		test := []byte("hello world")
+		compressed, err := alg.Compress(test)
+		require.NoError(t, err)
+
+		r, err := alg.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, test, data)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("error - data not compressed", func(t *testing.T) {
+		alg := New()
+
+		r, err := alg.NewReader(bytes.NewReader([]byte("test data")))
+		require.Error(t, err)
+		require.Nil(t, r)
+		require.Contains(t, err.Error(), "failed to create new reader")
+	})
+}
+
 func TestAlgorithm_Close(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		alg := New()
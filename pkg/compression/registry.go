@@ -7,11 +7,20 @@ SPDX-License-Identifier: Apache-2.0
 package compression
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/compression/gzip"
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression/zstd"
 )
 
+// ErrSizeLimitExceeded is returned by DecompressWithLimit if the size of the decompressed content
+// exceeds the given limit.
+var ErrSizeLimitExceeded = errors.New("decompressed content size exceeds limit")
+
 // Option is a registry instance option.
 type Option func(opts *Registry)
 
@@ -24,6 +33,9 @@ type Registry struct {
 type Algorithm interface {
 	Compress(value []byte) ([]byte, error)
 	Decompress(value []byte) ([]byte, error)
+	// NewReader returns a reader which decompresses the compressed data read from the given reader.
+	// The reader must be closed in order to release its resources.
+	NewReader(r io.Reader) (io.ReadCloser, error)
 	Accept(alg string) bool
 	Close() error
 }
@@ -74,6 +86,60 @@ func (r *Registry) Decompress(alg string, data []byte) ([]byte, error) {
 	return result, nil
 }
 
+// DecompressWithLimit decompresses the data using the specified algorithm. The data is decompressed as a
+// stream and decompression stops as soon as the size of the decompressed content exceeds maxSize bytes, in
+// which case an error wrapping ErrSizeLimitExceeded is returned. This function should be used for
+// untrusted content since, unlike Decompress, no more than maxSize bytes are ever allocated for the
+// decompressed content (i.e. it is not susceptible to decompression bombs).
+func (r *Registry) DecompressWithLimit(alg string, data []byte, maxSize uint64) ([]byte, error) {
+	algorithm, err := r.resolveAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := algorithm.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompression failed for alg[%s]: %s", alg, err.Error())
+	}
+
+	defer func() {
+		_ = reader.Close() //nolint:errcheck
+	}()
+
+	result, err := readWithLimit(reader, maxSize)
+	if err != nil {
+		if errors.Is(err, ErrSizeLimitExceeded) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("decompression failed for alg[%s]: %s", alg, err.Error())
+	}
+
+	return result, nil
+}
+
+// readWithLimit reads the content of the given reader. An error is returned as soon as more than
+// maxSize bytes are read.
+func readWithLimit(r io.Reader, maxSize uint64) ([]byte, error) {
+	limit := int64(math.MaxInt64 - 1)
+	if maxSize < uint64(limit) {
+		limit = int64(maxSize)
+	}
+
+	var buf bytes.Buffer
+
+	n, err := io.Copy(&buf, io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if n > limit {
+		return nil, fmt.Errorf("%w: %d bytes", ErrSizeLimitExceeded, maxSize)
+	}
+
+	return buf.Bytes(), nil
+}
+
 // Close frees resources being maintained by compression algorithm.
 func (r *Registry) Close() error {
 	for _, v := range r.algorithms {
@@ -105,6 +171,6 @@ func WithAlgorithm(alg Algorithm) Option {
 // WithDefaultAlgorithms adds default compression algorithms to the list of available algorithms.
 func WithDefaultAlgorithms() Option {
 	return func(opts *Registry) {
-		opts.algorithms = append(opts.algorithms, gzip.New())
+		opts.algorithms = append(opts.algorithms, gzip.New(), zstd.New())
 	}
 }
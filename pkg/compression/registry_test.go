@@ -8,6 +8,7 @@ package compression
 
 import (
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -15,7 +16,10 @@ import (
 	"github.com/trustbloc/sidetree-svc-go/pkg/compression/gzip"
 )
 
-const algGZIP = "GZIP"
+const (
+	algGZIP = "GZIP"
+	algZSTD = "ZSTD"
+)
 
 func TestNew(t *testing.T) {
 	t.Run("test new success", func(t *testing.T) {
@@ -47,6 +51,23 @@ func TestRegistry_Compress(t *testing.T) {
 		require.Equal(t, data, test)
 	})
 
+	t.Run("success - default algorithms", func(t *testing.T) {
+		registry := New(WithDefaultAlgorithms())
+		defer func() { require.NoError(t, registry.Close()) }()
+
+		test := []byte("hello world")
+
+		for _, alg := range []string{algGZIP, algZSTD} {
+			compressed, err := registry.Compress(alg, test)
+			require.NoError(t, err)
+			require.NotEmpty(t, compressed)
+
+			data, err := registry.Decompress(alg, compressed)
+			require.NoError(t, err)
+			require.Equal(t, test, data)
+		}
+	})
+
 	t.Run("error - algorithm not supported", func(t *testing.T) {
 		registry := New()
 
@@ -104,6 +125,84 @@ func TestRegistry_Decompress(t *testing.T) {
 	})
 }
 
+func TestRegistry_DecompressWithLimit(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		registry := New(WithDefaultAlgorithms())
+		defer func() { require.NoError(t, registry.Close()) }()
+
+		test := []byte("hello world")
+
+		for _, alg := range []string{algGZIP, algZSTD} {
+			compressed, err := registry.Compress(alg, test)
+			require.NoError(t, err)
+
+			data, err := registry.DecompressWithLimit(alg, compressed, uint64(len(test)))
+			require.NoError(t, err)
+			require.Equal(t, test, data)
+		}
+	})
+
+	t.Run("error - decompression bomb", func(t *testing.T) {
+		registry := New(WithDefaultAlgorithms())
+		defer func() { require.NoError(t, registry.Close()) }()
+
+		// 64MiB of zeros compresses to less than 256KiB.
+		bomb := make([]byte, 64<<20)
+
+		for _, alg := range []string{algGZIP, algZSTD} {
+			compressed, err := registry.Compress(alg, bomb)
+			require.NoError(t, err)
+			require.Less(t, len(compressed), 256<<10)
+
+			data, err := registry.DecompressWithLimit(alg, compressed, 1<<20)
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrSizeLimitExceeded))
+			require.Empty(t, data)
+		}
+	})
+
+	t.Run("error - decompression stops at limit", func(t *testing.T) {
+		alg := &mockAlgorithm{}
+
+		registry := New(WithAlgorithm(alg))
+
+		data, err := registry.DecompressWithLimit("mock", []byte("test data"), 1000)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrSizeLimitExceeded))
+		require.Empty(t, data)
+		require.Equal(t, int64(1001), alg.reader.n)
+		require.True(t, alg.reader.closed)
+	})
+
+	t.Run("error - algorithm not supported", func(t *testing.T) {
+		registry := New()
+
+		data, err := registry.DecompressWithLimit("alg", []byte("test data"), 1000)
+		require.Error(t, err)
+		require.Empty(t, data)
+		require.Contains(t, err.Error(), "compression algorithm 'alg' not supported")
+	})
+
+	t.Run("error - new reader error", func(t *testing.T) {
+		registry := New(WithAlgorithm(&mockAlgorithm{NewReaderErr: errors.New("test error")}))
+
+		data, err := registry.DecompressWithLimit("mock", []byte("test data"), 1000)
+		require.Error(t, err)
+		require.Empty(t, data)
+		require.Contains(t, err.Error(), "decompression failed for alg[mock]: test error")
+	})
+
+	t.Run("error - read error", func(t *testing.T) {
+		registry := New(WithDefaultAlgorithms())
+		defer func() { require.NoError(t, registry.Close()) }()
+
+		data, err := registry.DecompressWithLimit(algZSTD, []byte("test data"), 1000)
+		require.Error(t, err)
+		require.Empty(t, data)
+		require.Contains(t, err.Error(), "decompression failed for alg[ZSTD]")
+	})
+}
+
 func TestRegistry_Close(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		registry := New(WithAlgorithm(gzip.New()), WithAlgorithm(&mockAlgorithm{}))
@@ -119,7 +218,10 @@ func TestRegistry_Close(t *testing.T) {
 type mockAlgorithm struct {
 	CompressErr   error
 	DecompressErr error
+	NewReaderErr  error
 	CloseErr      error
+
+	reader *mockReader
 }
 
 // Compress will mock compressing data.
@@ -140,6 +242,17 @@ func (m *mockAlgorithm) Decompress(data []byte) ([]byte, error) {
 	return data, nil
 }
 
+// NewReader returns a reader which produces an infinite stream of zeros.
+func (m *mockAlgorithm) NewReader(io.Reader) (io.ReadCloser, error) {
+	if m.NewReaderErr != nil {
+		return nil, m.NewReaderErr
+	}
+
+	m.reader = &mockReader{}
+
+	return m.reader, nil
+}
+
 // Accept algorithm.
 func (m *mockAlgorithm) Accept(alg string) bool {
 	return true
@@ -149,3 +262,24 @@ func (m *mockAlgorithm) Accept(alg string) bool {
 func (m *mockAlgorithm) Close() error {
 	return m.CloseErr
 }
+
+type mockReader struct {
+	n      int64
+	closed bool
+}
+
+func (m *mockReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	m.n += int64(len(p))
+
+	return len(p), nil
+}
+
+func (m *mockReader) Close() error {
+	m.closed = true
+
+	return nil
+}
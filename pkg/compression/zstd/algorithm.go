@@ -0,0 +1,288 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zstd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	algName = "ZSTD"
+
+	// defaultMaxWindowSize is the window size used by the encoder at all compression levels.
+	defaultMaxWindowSize = 8 << 20
+)
+
+// Level is the compression level.
+type Level = zstd.EncoderLevel
+
+// Compression levels.
+const (
+	SpeedFastest           = zstd.SpeedFastest
+	SpeedDefault           = zstd.SpeedDefault
+	SpeedBetterCompression = zstd.SpeedBetterCompression
+	SpeedBestCompression   = zstd.SpeedBestCompression
+)
+
+// Algorithm implements Zstandard compression/decompression. The algorithm is safe for concurrent use;
+// the encoder and decoder each maintain a pool of (at most 'concurrency') internal encoders/decoders
+// which are released when the algorithm is closed.
+type Algorithm struct {
+	name         string
+	level        Level
+	concurrency  int
+	dictionary   []byte
+	dictionaries [][]byte
+	maxWindow    uint64
+
+	mutex   sync.RWMutex
+	closed  bool
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// Option is a zstd algorithm option.
+type Option func(a *Algorithm)
+
+// WithName sets the name under which the algorithm is registered (default "ZSTD"). Since content that
+// was compressed with a dictionary may only be decompressed with the same dictionary, a protocol version
+// which uses a dictionary should use a distinct algorithm name (e.g. "ZSTD-D1").
+func WithName(name string) Option {
+	return func(a *Algorithm) {
+		a.name = name
+	}
+}
+
+// WithLevel sets the compression level (default SpeedDefault).
+func WithLevel(level Level) Option {
+	return func(a *Algorithm) {
+		a.level = level
+	}
+}
+
+// WithConcurrency sets the maximum number of concurrent compress/decompress operations (default is
+// the number of CPUs).
+func WithConcurrency(value int) Option {
+	return func(a *Algorithm) {
+		a.concurrency = value
+	}
+}
+
+// WithDictionary sets the dictionary which is used to compress and decompress content. The dictionary
+// must be in zstd dictionary format (see TrainDictionary).
+func WithDictionary(dictionary []byte) Option {
+	return func(a *Algorithm) {
+		a.dictionary = dictionary
+	}
+}
+
+// WithDecoderDictionaries adds dictionaries which are only used for decompression. The dictionary that
+// was used to compress the content is selected by the dictionary ID in the frame header. This allows
+// content that was compressed with a previous dictionary to be decompressed after the dictionary was replaced.
+func WithDecoderDictionaries(dictionaries ...[]byte) Option {
+	return func(a *Algorithm) {
+		a.dictionaries = append(a.dictionaries, dictionaries...)
+	}
+}
+
+// WithMaxWindowSize sets the maximum window size which is accepted by the decoder (default 8MiB). A frame
+// that declares a larger window is rejected without allocating the window, which protects against frames
+// crafted to exhaust memory.
+func WithMaxWindowSize(value uint64) Option {
+	return func(a *Algorithm) {
+		a.maxWindow = value
+	}
+}
+
+// New creates new zstd algorithm instance. The encoder and decoder are created on first use, so an
+// error in the options (e.g. an invalid dictionary) is returned by Compress/Decompress.
+func New(opts ...Option) *Algorithm {
+	a := &Algorithm{
+		name:      algName,
+		level:     SpeedDefault,
+		maxWindow: defaultMaxWindowSize,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Compress will compress data using zstd.
+func (a *Algorithm) Compress(data []byte) ([]byte, error) {
+	encoder, _, err := a.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	defer a.mutex.RUnlock()
+
+	return encoder.EncodeAll(data, nil), nil
+}
+
+// Decompress will decompress compressed data.
+func (a *Algorithm) Decompress(data []byte) ([]byte, error) {
+	_, decoder, err := a.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	defer a.mutex.RUnlock()
+
+	content, err := decoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+
+	return content, nil
+}
+
+// NewReader returns a reader which decompresses the zstd data read from the given reader. The data is
+// decoded in blocks as it is read, so the caller controls how much decompressed content is allocated.
+// The returned reader must be closed in order to release the decoder.
+func (a *Algorithm) NewReader(r io.Reader) (io.ReadCloser, error) {
+	a.mutex.RLock()
+	closed := a.closed
+	a.mutex.RUnlock()
+
+	if closed {
+		return nil, errors.New("zstd algorithm is closed")
+	}
+
+	decoder, err := zstd.NewReader(r, append(a.decoderOptions(), zstd.WithDecoderConcurrency(1))...)
+	if err != nil {
+		return nil, fmt.Errorf("create zstd decoder: %w", err)
+	}
+
+	return decoder.IOReadCloser(), nil
+}
+
+// Accept algorithm.
+func (a *Algorithm) Accept(alg string) bool {
+	return alg == a.name
+}
+
+// Close releases the pooled encoders and decoders. Close waits for the compress/decompress operations which
+// are in progress. The algorithm may not be used after it is closed.
+func (a *Algorithm) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.closed = true
+
+	if a.decoder != nil {
+		a.decoder.Close()
+		a.decoder = nil
+	}
+
+	if a.encoder != nil {
+		err := a.encoder.Close()
+
+		a.encoder = nil
+
+		if err != nil {
+			return fmt.Errorf("close zstd encoder: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// acquire returns the encoder and decoder (which are created on first use) with the read lock held, so that
+// they aren't closed while they're in use. The caller must release the read lock when it's done.
+func (a *Algorithm) acquire() (*zstd.Encoder, *zstd.Decoder, error) {
+	for {
+		a.mutex.RLock()
+
+		if a.closed {
+			a.mutex.RUnlock()
+
+			return nil, nil, errors.New("zstd algorithm is closed")
+		}
+
+		if a.encoder != nil {
+			return a.encoder, a.decoder, nil
+		}
+
+		a.mutex.RUnlock()
+
+		if err := a.init(); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// init creates the encoder and decoder.
+func (a *Algorithm) init() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.closed {
+		return errors.New("zstd algorithm is closed")
+	}
+
+	if a.encoder != nil {
+		return nil
+	}
+
+	encoderOpts := []zstd.EOption{
+		zstd.WithEncoderLevel(a.level),
+	}
+
+	decoderOpts := a.decoderOptions()
+
+	if a.concurrency > 0 {
+		encoderOpts = append(encoderOpts, zstd.WithEncoderConcurrency(a.concurrency))
+		decoderOpts = append(decoderOpts, zstd.WithDecoderConcurrency(a.concurrency))
+	}
+
+	if len(a.dictionary) > 0 {
+		encoderOpts = append(encoderOpts, zstd.WithEncoderDict(a.dictionary))
+	}
+
+	encoder, err := zstd.NewWriter(nil, encoderOpts...)
+	if err != nil {
+		return fmt.Errorf("create zstd encoder: %w", err)
+	}
+
+	decoder, err := zstd.NewReader(nil, decoderOpts...)
+	if err != nil {
+		// Release the encoder that was already created.
+		_ = encoder.Close() //nolint:errcheck
+
+		return fmt.Errorf("create zstd decoder: %w", err)
+	}
+
+	a.encoder = encoder
+	a.decoder = decoder
+
+	return nil
+}
+
+// decoderOptions returns the decoder options for the configured window size and dictionaries.
+func (a *Algorithm) decoderOptions() []zstd.DOption {
+	opts := []zstd.DOption{zstd.WithDecoderMaxWindow(a.maxWindow)}
+
+	dictionaries := a.dictionaries
+
+	if len(a.dictionary) > 0 {
+		dictionaries = append([][]byte{a.dictionary}, dictionaries...)
+	}
+
+	if len(dictionaries) > 0 {
+		opts = append(opts, zstd.WithDecoderDicts(dictionaries...))
+	}
+
+	return opts
+}
@@ -0,0 +1,271 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlgorithm_Accept(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		alg := New()
+		require.True(t, alg.Accept("ZSTD"))
+		require.False(t, alg.Accept("GZIP"))
+	})
+
+	t.Run("custom name", func(t *testing.T) {
+		alg := New(WithName("ZSTD-D1"))
+		require.True(t, alg.Accept("ZSTD-D1"))
+		require.False(t, alg.Accept("ZSTD"))
+	})
+}
+
+func TestAlgorithm_Compress(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		alg := New(WithLevel(SpeedBestCompression), WithConcurrency(2))
+		defer func() { require.NoError(t, alg.Close()) }()
+
+		test := []byte("test data")
+		compressed, err := alg.Compress(test)
+		require.NoError(t, err)
+		require.NotEmpty(t, compressed)
+
+		data, err := alg.Decompress(compressed)
+		require.NoError(t, err)
+		require.Equal(t, test, data)
+	})
+
+	t.Run("batch files", func(t *testing.T) {
+		alg := New()
+		defer func() { require.NoError(t, alg.Close()) }()
+
+		for _, file := range newBatchFiles(t, 3, 100) {
+			compressed, err := alg.Compress(file)
+			require.NoError(t, err)
+			require.Less(t, len(compressed), len(file))
+
+			data, err := alg.Decompress(compressed)
+			require.NoError(t, err)
+			require.Equal(t, file, data)
+		}
+	})
+
+	t.Run("error - invalid dictionary", func(t *testing.T) {
+		alg := New(WithDictionary([]byte("invalid")))
+
+		_, err := alg.Compress([]byte("test data"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create zstd encoder")
+
+		require.NoError(t, alg.Close())
+	})
+
+	t.Run("error - closed", func(t *testing.T) {
+		alg := New()
+		require.NoError(t, alg.Close())
+
+		_, err := alg.Compress([]byte("test data"))
+		require.EqualError(t, err, "zstd algorithm is closed")
+	})
+}
+
+func TestAlgorithm_Close(t *testing.T) {
+	t.Run("concurrent use", func(t *testing.T) {
+		alg := New(WithConcurrency(2))
+
+		data := bytes.Repeat([]byte("sidetree "), 1000)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for {
+					compressed, err := alg.Compress(data)
+					if err != nil {
+						require.Contains(t, err.Error(), "closed")
+
+						return
+					}
+
+					content, err := alg.Decompress(compressed)
+					if err != nil {
+						require.Contains(t, err.Error(), "closed")
+
+						return
+					}
+
+					require.Equal(t, data, content)
+				}
+			}()
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		require.NoError(t, alg.Close())
+
+		wg.Wait()
+	})
+}
+
+func TestAlgorithm_Decompress(t *testing.T) {
+	t.Run("error - data not compressed", func(t *testing.T) {
+		alg := New()
+		defer func() { require.NoError(t, alg.Close()) }()
+
+		data, err := alg.Decompress([]byte("test data"))
+		require.Error(t, err)
+		require.Empty(t, data)
+		require.Contains(t, err.Error(), "failed to decompress data")
+	})
+
+	t.Run("error - closed", func(t *testing.T) {
+		alg := New()
+		require.NoError(t, alg.Close())
+
+		_, err := alg.Decompress([]byte("test data"))
+		require.EqualError(t, err, "zstd algorithm is closed")
+	})
+}
+
+func TestAlgorithm_NewReader(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		alg := New()
+		defer func() { require.NoError(t, alg.Close()) }()
+
+		test := bytes.Repeat([]byte("test data"), 1000)
+		compressed, err := alg.Compress(test)
+		require.NoError(t, err)
+
+		r, err := alg.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, test, data)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("error - data not compressed", func(t *testing.T) {
+		alg := New()
+		defer func() { require.NoError(t, alg.Close()) }()
+
+		r, err := alg.NewReader(bytes.NewReader([]byte("test data")))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, r.Close()) }()
+
+		_, err = io.ReadAll(r)
+		require.Error(t, err)
+	})
+
+	t.Run("error - window too large", func(t *testing.T) {
+		alg := New(WithMaxWindowSize(1 << 10))
+		defer func() { require.NoError(t, alg.Close()) }()
+
+		compressed, err := New().Compress(bytes.Repeat([]byte("test data"), 100000))
+		require.NoError(t, err)
+
+		r, err := alg.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, r.Close()) }()
+
+		data, err := io.ReadAll(r)
+		require.Error(t, err)
+		require.Empty(t, data)
+	})
+
+	t.Run("error - closed", func(t *testing.T) {
+		alg := New()
+		require.NoError(t, alg.Close())
+
+		_, err := alg.NewReader(bytes.NewReader([]byte("test data")))
+		require.EqualError(t, err, "zstd algorithm is closed")
+	})
+}
+
+func TestAlgorithm_Dictionary(t *testing.T) {
+	samples := newDeltas(t, 200)
+
+	dict1, err := TrainDictionary(samples, WithDictionaryID(1001), WithMaxDictionarySize(16*1024))
+	require.NoError(t, err)
+
+	dict2, err := TrainDictionary(samples, WithDictionaryID(1002))
+	require.NoError(t, err)
+
+	delta := newDeltas(t, 1)[0]
+
+	t.Run("success", func(t *testing.T) {
+		alg := New(WithDictionary(dict1))
+		defer func() { require.NoError(t, alg.Close()) }()
+
+		compressed, err := alg.Compress(delta)
+		require.NoError(t, err)
+
+		noDict := New()
+		defer func() { require.NoError(t, noDict.Close()) }()
+
+		compressedNoDict, err := noDict.Compress(delta)
+		require.NoError(t, err)
+
+		// Small files benefit the most from a dictionary.
+		require.Less(t, len(compressed), len(compressedNoDict))
+
+		data, err := alg.Decompress(compressed)
+		require.NoError(t, err)
+		require.Equal(t, delta, data)
+
+		// Content that was compressed without a dictionary can still be decompressed.
+		data, err = alg.Decompress(compressedNoDict)
+		require.NoError(t, err)
+		require.Equal(t, delta, data)
+
+		// A decoder without the dictionary is unable to decompress the content.
+		_, err = noDict.Decompress(compressed)
+		require.Error(t, err)
+	})
+
+	t.Run("dictionary rotation", func(t *testing.T) {
+		alg1 := New(WithDictionary(dict1))
+		defer func() { require.NoError(t, alg1.Close()) }()
+
+		compressed, err := alg1.Compress(delta)
+		require.NoError(t, err)
+
+		alg2 := New(WithDictionary(dict2), WithDecoderDictionaries(dict1))
+		defer func() { require.NoError(t, alg2.Close()) }()
+
+		data, err := alg2.Decompress(compressed)
+		require.NoError(t, err)
+		require.Equal(t, delta, data)
+	})
+}
+
+func TestTrainDictionary(t *testing.T) {
+	t.Run("error - no samples", func(t *testing.T) {
+		_, err := TrainDictionary(nil)
+		require.EqualError(t, err, "at least one sample of 8 bytes or more is required to train a dictionary")
+
+		_, err = TrainDictionary([][]byte{[]byte("abc")})
+		require.EqualError(t, err, "at least one sample of 8 bytes or more is required to train a dictionary")
+	})
+
+	t.Run("error - invalid maximum size", func(t *testing.T) {
+		_, err := TrainDictionary(newDeltas(t, 1), WithMaxDictionarySize(0))
+		require.EqualError(t, err, "invalid maximum dictionary size: 0")
+	})
+}
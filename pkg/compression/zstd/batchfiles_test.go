@@ -0,0 +1,97 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zstd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchFiles returns the given number of chunk files, each containing the deltas of the
+// given number of create operations.
+func newBatchFiles(t testing.TB, numFiles, numOps int) [][]byte {
+	t.Helper()
+
+	files := make([][]byte, numFiles)
+
+	for i := range files {
+		var deltas []json.RawMessage
+
+		for _, delta := range newDeltas(t, numOps) {
+			deltas = append(deltas, delta)
+		}
+
+		file, err := json.Marshal(map[string]interface{}{"deltas": deltas})
+		require.NoError(t, err)
+
+		files[i] = file
+	}
+
+	return files
+}
+
+// newDeltas returns the given number of typical create operation deltas (a replace patch containing
+// a public key and a service along with the update commitment).
+func newDeltas(t testing.TB, n int) [][]byte {
+	t.Helper()
+
+	deltas := make([][]byte, n)
+
+	for i := range deltas {
+		delta, err := json.Marshal(map[string]interface{}{
+			"patches": []interface{}{
+				map[string]interface{}{
+					"action": "replace",
+					"document": map[string]interface{}{
+						"publicKeys": []interface{}{
+							map[string]interface{}{
+								"id":       "auth-key",
+								"type":     "JsonWebKey2020",
+								"purposes": []string{"authentication", "assertionMethod"},
+								"publicKeyJwk": map[string]interface{}{
+									"kty": "EC",
+									"crv": "P-256",
+									"x":   randomString(t, 32),
+									"y":   randomString(t, 32),
+								},
+							},
+						},
+						"services": []interface{}{
+							map[string]interface{}{
+								"id":              "domain",
+								"type":            "LinkedDomains",
+								"serviceEndpoint": fmt.Sprintf("https://example-%d.com", i),
+							},
+						},
+					},
+				},
+			},
+			"updateCommitment": "EiA" + randomString(t, 32),
+		})
+		require.NoError(t, err)
+
+		deltas[i] = delta
+	}
+
+	return deltas
+}
+
+func randomString(t testing.TB, n int) string {
+	t.Helper()
+
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zstd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression/gzip"
+)
+
+type algorithm interface {
+	Compress(value []byte) ([]byte, error)
+	Decompress(value []byte) ([]byte, error)
+}
+
+// BenchmarkCompress compares gzip and zstd compression of chunk files (with 1000 create operations)
+// and of single deltas. The compression ratio is reported as a custom metric.
+func BenchmarkCompress(b *testing.B) {
+	chunkFile := newBatchFiles(b, 1, 1000)[0]
+	delta := newDeltas(b, 1)[0]
+
+	dictionary, err := TrainDictionary(newDeltas(b, 500))
+	require.NoError(b, err)
+
+	for _, bm := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "chunk", data: chunkFile},
+		{name: "delta", data: delta},
+	} {
+		for _, alg := range newAlgorithms(b, dictionary) {
+			b.Run(bm.name+"/"+alg.name, func(b *testing.B) {
+				var compressed []byte
+
+				b.SetBytes(int64(len(bm.data)))
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					compressed, err = alg.Compress(bm.data)
+					require.NoError(b, err)
+				}
+
+				b.ReportMetric(float64(len(bm.data))/float64(len(compressed)), "ratio")
+			})
+		}
+	}
+}
+
+// BenchmarkDecompress compares gzip and zstd decompression of chunk files (with 1000 create operations).
+func BenchmarkDecompress(b *testing.B) {
+	chunkFile := newBatchFiles(b, 1, 1000)[0]
+
+	for _, alg := range newAlgorithms(b, nil) {
+		compressed, err := alg.Compress(chunkFile)
+		require.NoError(b, err)
+
+		b.Run(alg.name, func(b *testing.B) {
+			b.SetBytes(int64(len(chunkFile)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := alg.Decompress(compressed)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+type namedAlgorithm struct {
+	algorithm
+
+	name string
+}
+
+func newAlgorithms(b *testing.B, dictionary []byte) []*namedAlgorithm {
+	b.Helper()
+
+	zstdDefault := New()
+	zstdBest := New(WithLevel(SpeedBestCompression))
+
+	b.Cleanup(func() {
+		require.NoError(b, zstdDefault.Close())
+		require.NoError(b, zstdBest.Close())
+	})
+
+	algs := []*namedAlgorithm{
+		{algorithm: gzip.New(), name: "gzip"},
+		{algorithm: zstdDefault, name: "zstd"},
+		{algorithm: zstdBest, name: "zstd-best"},
+	}
+
+	if dictionary != nil {
+		zstdDict := New(WithDictionary(dictionary))
+
+		b.Cleanup(func() {
+			require.NoError(b, zstdDict.Close())
+		})
+
+		algs = append(algs, &namedAlgorithm{algorithm: zstdDict, name: "zstd-dict"})
+	}
+
+	return algs
+}
@@ -0,0 +1,87 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zstd
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/dict"
+)
+
+const (
+	defaultMaxDictionarySize = 64 * 1024
+	defaultHashBytes         = 6
+
+	// minSampleSize is the minimum size of a sample that is indexed by the dictionary builder.
+	minSampleSize = 8
+)
+
+type trainOptions struct {
+	maxSize int
+	id      uint32
+}
+
+// TrainOption is an option for training a dictionary.
+type TrainOption func(opts *trainOptions)
+
+// WithMaxDictionarySize sets the maximum size of the dictionary (default 64KB).
+func WithMaxDictionarySize(value int) TrainOption {
+	return func(opts *trainOptions) {
+		opts.maxSize = value
+	}
+}
+
+// WithDictionaryID sets the ID of the dictionary. The ID is written to the header of each compressed frame
+// so that the decoder is able to select the correct dictionary. A random ID is generated by default.
+func WithDictionaryID(id uint32) TrainOption {
+	return func(opts *trainOptions) {
+		opts.id = id
+	}
+}
+
+// TrainDictionary builds a zstd dictionary from the given samples. The samples should be typical content
+// that is written to CAS, such as (uncompressed) chunk files and the deltas of create operations. The more
+// representative the samples, the better the compression of small files.
+func TrainDictionary(samples [][]byte, opts ...TrainOption) ([]byte, error) {
+	options := &trainOptions{
+		maxSize: defaultMaxDictionarySize,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.maxSize <= 0 {
+		return nil, fmt.Errorf("invalid maximum dictionary size: %d", options.maxSize)
+	}
+
+	if !hasSample(samples) {
+		return nil, fmt.Errorf("at least one sample of %d bytes or more is required to train a dictionary",
+			minSampleSize)
+	}
+
+	d, err := dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: options.maxSize,
+		HashBytes:   defaultHashBytes,
+		ZstdDictID:  options.id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build zstd dictionary: %w", err)
+	}
+
+	return d, nil
+}
+
+func hasSample(samples [][]byte) bool {
+	for _, sample := range samples {
+		if len(sample) >= minSampleSize {
+			return true
+		}
+	}
+
+	return false
+}
@@ -19,24 +19,34 @@ SPDX-License-Identifier: Apache-2.0
 package dochandler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/trustbloc/logutil-go/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
 	coreoperation "github.com/trustbloc/sidetree-go/pkg/api/operation"
 	coreprotocol "github.com/trustbloc/sidetree-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-go/pkg/canonicalizer"
 	"github.com/trustbloc/sidetree-go/pkg/document"
 	"github.com/trustbloc/sidetree-go/pkg/docutil"
 
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
 )
 
-var logger = log.New("sidetree-svc-dochandler")
+var (
+	logger = log.New("sidetree-svc-dochandler")
+	tracer = otel.Tracer("sidetree-svc-dochandler")
+)
 
 const (
 	keyID = "id"
@@ -58,6 +68,9 @@ type DocumentHandler struct {
 	unpublishedOperationStore unpublishedOperationStore
 	unpublishedOperationTypes []coreoperation.Type
 
+	authorizer      auth.Authorizer
+	eventPublishers []eventPublisher
+
 	metrics metricsProvider
 }
 
@@ -78,6 +91,11 @@ type operationProcessor interface {
 	Resolve(uniqueSuffix string, opts ...document.ResolutionOption) (*coreprotocol.ResolutionModel, error)
 }
 
+// eventPublisher is notified of the operations that were added to the batch.
+type eventPublisher interface {
+	Publish(events ...*notification.ChangeEvent)
+}
+
 // batchWriter is an interface to add an operation to the batch.
 type batchWriter interface {
 	Add(operation *operation.QueuedOperation, protocolVersion uint64) error
@@ -115,6 +133,22 @@ func WithOperationDecorator(decorator operationDecorator) Option {
 	}
 }
 
+// WithAuthorizer sets an optional authorizer which decides whether or not the caller may submit an operation.
+// The authorizer is invoked after the operation has been validated and decorated.
+func WithAuthorizer(authorizer auth.Authorizer) Option {
+	return func(opts *DocumentHandler) {
+		opts.authorizer = authorizer
+	}
+}
+
+// WithEventPublisher adds a publisher which is notified when an operation is added to the batch.
+// This option may be specified multiple times.
+func WithEventPublisher(publisher eventPublisher) Option {
+	return func(opts *DocumentHandler) {
+		opts.eventPublishers = append(opts.eventPublishers, publisher)
+	}
+}
+
 type metricsProvider interface {
 	ProcessOperation(duration time.Duration)
 	GetProtocolVersionTime(since time.Duration)
@@ -154,19 +188,104 @@ func (r *DocumentHandler) Namespace() string {
 	return r.namespace
 }
 
+// Aliases returns the namespace aliases of the document handler.
+func (r *DocumentHandler) Aliases() []string {
+	return r.aliases
+}
+
+// Protocol returns the protocol client of the document handler.
+func (r *DocumentHandler) Protocol() protocol.Client {
+	return r.protocol
+}
+
 // ProcessOperation validates operation and adds it to the batch.
-func (r *DocumentHandler) ProcessOperation(operationBuffer []byte, protocolVersion uint64) (*document.ResolutionResult, error) {
+func (r *DocumentHandler) ProcessOperation(operationBuffer []byte, protocolVersion uint64,
+	opts ...operation.ProcessOption) (*document.ResolutionResult, error) {
 	startTime := time.Now()
 
 	defer func() {
 		r.metrics.ProcessOperation(time.Since(startTime))
 	}()
 
+	options := operation.GetProcessOptions(opts...)
+
+	ctx, span := tracer.Start(options.Context, "DocumentHandler.ProcessOperation",
+		trace.WithAttributes(tracing.WithNamespace(r.namespace)))
+	defer span.End()
+
+	op, pv, err := r.prepareOperation(operationBuffer, protocolVersion, opts...)
+	if err != nil {
+		return nil, tracing.RecordError(span, err)
+	}
+
+	span.SetAttributes(tracing.WithSuffix(op.UniqueSuffix), tracing.WithOperationType(string(op.Type)))
+
+	unpublishedOp := r.getUnpublishedOperation(op, pv)
+
+	addUnpublishedOperationStartTime := time.Now()
+
+	err = r.addOperationToUnpublishedOpsStore(unpublishedOp)
+	if err != nil {
+		return nil, tracing.RecordError(span, fmt.Errorf("failed to add operation for suffix[%s] to unpublished operation store: %s",
+			op.UniqueSuffix, err.Error()))
+	}
+
+	r.metrics.AddUnpublishedOperationTime(time.Since(addUnpublishedOperationStartTime))
+
+	addToBatchStartTime := time.Now()
+
+	// validated operation will be added to the batch
+	if err := r.addToBatch(ctx, op, pv.Protocol().GenesisTime, options.Identity); err != nil {
+		logger.Error("Failed to add operation to batch", log.WithError(err))
+
+		r.deleteOperationFromUnpublishedOpsStore(unpublishedOp)
+
+		return nil, tracing.RecordError(span, err)
+	}
+
+	r.metrics.AddOperationToBatchTime(time.Since(addToBatchStartTime))
+
+	logger.Debug("Operation added to the batch", logfields.WithOperationID(op.ID))
+
+	r.publishEvent(op)
+
+	// create operation will also return document
+	if op.Type == coreoperation.TypeCreate {
+		return r.getCreateResponse(op, pv)
+	}
+
+	return nil, nil
+}
+
+// DryRunOperation performs the same parsing, validation and decoration as ProcessOperation and returns
+// the document that would result from applying the operation to the current state of the document.
+// The operation is neither added to the unpublished operation store nor to the batch. If the operation
+// is rejected then an *operation.ValidationError is returned which contains the stage at which the operation failed.
+func (r *DocumentHandler) DryRunOperation(operationBuffer []byte, protocolVersion uint64,
+	opts ...operation.ProcessOption) (*document.ResolutionResult, error) {
+	op, pv, err := r.prepareOperation(operationBuffer, protocolVersion, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("Operation passed dry run validation", logfields.WithSuffix(op.UniqueSuffix),
+		logfields.WithOperationType(string(op.Type)))
+
+	if op.Type == coreoperation.TypeCreate {
+		return r.getCreateResponse(op, pv)
+	}
+
+	return r.getDryRunResponse(op, pv)
+}
+
+// prepareOperation parses, validates, decorates and authorizes the given operation request.
+func (r *DocumentHandler) prepareOperation(operationBuffer []byte, protocolVersion uint64,
+	opts ...operation.ProcessOption) (*coreoperation.Operation, protocol.Version, error) {
 	getProtocolVersionTime := time.Now()
 
 	pv, err := r.protocol.Get(protocolVersion)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	r.metrics.GetProtocolVersionTime(time.Since(getProtocolVersionTime))
@@ -175,7 +294,7 @@ func (r *DocumentHandler) ProcessOperation(operationBuffer []byte, protocolVersi
 
 	op, err := pv.OperationParser().Parse(r.namespace, operationBuffer)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s", badRequest, err.Error())
+		return nil, nil, operation.NewValidationError(operation.StageParse, nil, err)
 	}
 
 	r.metrics.ParseOperationTime(time.Since(parseOperationStartTime))
@@ -185,52 +304,92 @@ func (r *DocumentHandler) ProcessOperation(operationBuffer []byte, protocolVersi
 	// perform validation for operation request
 	err = r.validateOperation(op, pv)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s", badRequest, err.Error())
+		return nil, nil, operation.NewValidationError(operation.StageValidate, op, err)
 	}
 
 	r.metrics.ValidateOperationTime(time.Since(validateOperationStartTime))
 
 	decorateOperationStartTime := time.Now()
 
-	op, err = r.decorator.Decorate(op)
+	decoratedOp, err := r.decorator.Decorate(op)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s", badRequest, err.Error())
+		return nil, nil, operation.NewValidationError(operation.StageDecorate, op, err)
 	}
 
 	r.metrics.DecorateOperationTime(time.Since(decorateOperationStartTime))
 
-	unpublishedOp := r.getUnpublishedOperation(op, pv)
+	if r.authorizer != nil {
+		identity := operation.GetProcessOptions(opts...).Identity
 
-	addUnpublishedOperationStartTime := time.Now()
+		err = r.authorizer.Authorize(identity, decoratedOp)
+		if err != nil {
+			logger.Info("Operation not authorized", logfields.WithSuffix(decoratedOp.UniqueSuffix),
+				logfields.WithOperationType(string(decoratedOp.Type)), log.WithError(err))
 
-	err = r.addOperationToUnpublishedOpsStore(unpublishedOp)
+			return nil, nil, err
+		}
+	}
+
+	return decoratedOp, pv, nil
+}
+
+// getDryRunResponse applies the given (update, recover or deactivate) operation to the current state of
+// the document and returns the resulting document. The operation is resolved along with the existing operations
+// of the document, so it's only applied if it would be applied once anchored (e.g. its reveal value matches the
+// current commitment of the document).
+func (r *DocumentHandler) getDryRunResponse(op *coreoperation.Operation, pv protocol.Version) (*document.ResolutionResult, error) {
+	current, err := r.processor.Resolve(op.UniqueSuffix)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add operation for suffix[%s] to unpublished operation store: %s", op.UniqueSuffix, err.Error())
+		return nil, operation.NewValidationError(operation.StageApply, op, err)
 	}
 
-	r.metrics.AddUnpublishedOperationTime(time.Since(addUnpublishedOperationStartTime))
+	anchoredOp := &coreoperation.AnchoredOperation{
+		Type:             op.Type,
+		UniqueSuffix:     op.UniqueSuffix,
+		OperationRequest: op.OperationRequest,
+		TransactionTime:  uint64(time.Now().Unix()),
+		ProtocolVersion:  pv.Protocol().GenesisTime,
+		AnchorOrigin:     op.AnchorOrigin,
+	}
 
-	addToBatchStartTime := time.Now()
+	rm, err := r.processor.Resolve(op.UniqueSuffix,
+		document.WithAdditionalOperations([]*coreoperation.AnchoredOperation{anchoredOp}))
+	if err != nil {
+		return nil, operation.NewValidationError(operation.StageApply, op, err)
+	}
 
-	// validated operation will be added to the batch
-	if err := r.addToBatch(op, pv.Protocol().GenesisTime); err != nil {
-		logger.Error("Failed to add operation to batch", log.WithError(err))
+	if !stateChanged(current, rm) {
+		return nil, operation.NewValidationError(operation.StageApply, op, notAppliedError(anchoredOp, pv, current))
+	}
 
-		r.deleteOperationFromUnpublishedOpsStore(unpublishedOp)
+	var ti coreprotocol.TransformationInfo
 
-		return nil, err
+	if len(rm.PublishedOperations) == 0 {
+		ti = docutil.GetTransformationInfoForUnpublished(r.namespace, r.domain, r.label, op.UniqueSuffix, "")
+	} else {
+		ti = docutil.GetTransformationInfoForPublished(r.namespace,
+			r.namespace+docutil.NamespaceDelimiter+op.UniqueSuffix, op.UniqueSuffix, rm)
 	}
 
-	r.metrics.AddOperationToBatchTime(time.Since(addToBatchStartTime))
+	return pv.DocumentTransformer().TransformDocument(rm, ti)
+}
 
-	logger.Debug("Operation added to the batch", logfields.WithOperationID(op.ID))
+// stateChanged returns true if an operation was applied to the current state of the document (every applied
+// update, recover or deactivate operation advances a commitment or deactivates the document).
+func stateChanged(current, rm *coreprotocol.ResolutionModel) bool {
+	return rm.UpdateCommitment != current.UpdateCommitment ||
+		rm.RecoveryCommitment != current.RecoveryCommitment ||
+		rm.Deactivated != current.Deactivated
+}
 
-	// create operation will also return document
-	if op.Type == coreoperation.TypeCreate {
-		return r.getCreateResponse(op, pv)
+// notAppliedError returns the reason why the operation wasn't applied to the current state of the document.
+func notAppliedError(op *coreoperation.AnchoredOperation, pv protocol.Version, current *coreprotocol.ResolutionModel) error {
+	if _, err := pv.OperationApplier().Apply(op, current); err != nil {
+		return err
 	}
 
-	return nil, nil
+	// The operation is valid, so it wasn't applied since its reveal value doesn't match the current commitment.
+	return fmt.Errorf("reveal value doesn't match the current commitment for %s operation", op.Type)
 }
 
 func (r *DocumentHandler) getUnpublishedOperation(op *coreoperation.Operation, pv coreprotocol.Version) *coreoperation.AnchoredOperation {
@@ -439,8 +598,28 @@ func (r *DocumentHandler) resolveRequestWithInitialState(uniqueSuffix, longFormD
 	return externalResult, nil
 }
 
+func (r *DocumentHandler) publishEvent(op *coreoperation.Operation) {
+	if len(r.eventPublishers) == 0 {
+		return
+	}
+
+	event := notification.NewUnpublishedEvent(r.namespace, op)
+
+	for _, publisher := range r.eventPublishers {
+		publisher.Publish(event)
+	}
+}
+
 // helper for adding operations to the batch.
-func (r *DocumentHandler) addToBatch(op *coreoperation.Operation, versionTime uint64) error {
+// The trace context of the given context is stored with the queued operation so that the span
+// of the batch may be linked to this operation.
+func (r *DocumentHandler) addToBatch(ctx context.Context, op *coreoperation.Operation, versionTime uint64,
+	identity *auth.Identity) error {
+	var clientID string
+	if identity != nil {
+		clientID = identity.Subject
+	}
+
 	return r.writer.Add(
 		&operation.QueuedOperation{
 			Type:             op.Type,
@@ -449,6 +628,8 @@ func (r *DocumentHandler) addToBatch(op *coreoperation.Operation, versionTime ui
 			OperationRequest: op.OperationRequest,
 			AnchorOrigin:     op.AnchorOrigin,
 			Properties:       op.Properties,
+			ClientID:         clientID,
+			TraceContext:     tracing.Inject(ctx),
 		}, versionTime)
 }
 
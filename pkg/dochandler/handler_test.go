@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package dochandler
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -14,6 +15,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	coreoperation "github.com/trustbloc/sidetree-go/pkg/api/operation"
@@ -36,8 +38,12 @@ import (
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/model"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationapplier"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-svc-go/pkg/batch"
@@ -45,6 +51,8 @@ import (
 	"github.com/trustbloc/sidetree-svc-go/pkg/batch/opqueue"
 	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
 	docmocks "github.com/trustbloc/sidetree-svc-go/pkg/dochandler/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing/tracingtest"
 	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
 	"github.com/trustbloc/sidetree-svc-go/pkg/processor"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider"
@@ -800,6 +808,272 @@ func TestGetHint(t *testing.T) {
 	})
 }
 
+func TestDocumentHandler_DryRunOperation(t *testing.T) {
+	t.Run("success - create", func(t *testing.T) {
+		unpublishedStore := &mockUnpublishedOpsStore{PutErr: errors.New("should not be called")}
+
+		dochandler := New(namespace, nil, newMockProtocolClient(), &mockBatchWriter{Err: errors.New("should not be called")},
+			processor.New("test", mocks.NewMockOperationStore(nil), newMockProtocolClient()), &mocks.MetricsProvider{},
+			WithUnpublishedOperationStore(unpublishedStore, []coreoperation.Type{coreoperation.TypeCreate}))
+
+		createOp := getCreateOperation()
+
+		result, err := dochandler.DryRunOperation(createOp.OperationRequest, 0)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		require.Equal(t, createOp.ID, result.Document.ID())
+	})
+
+	t.Run("success - update", func(t *testing.T) {
+		store := mocks.NewMockOperationStore(nil)
+
+		updateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		createOp, err := getCreateOperationWithUpdateKey(&updateKey.PublicKey)
+		require.NoError(t, err)
+
+		err = store.Put(getAnchoredOperation(createOp))
+		require.NoError(t, err)
+
+		pc := newMockProtocolClient()
+
+		dochandler := New(namespace, nil, pc, &mockBatchWriter{Err: errors.New("should not be called")},
+			processor.New("test", store, pc), &mocks.MetricsProvider{})
+
+		info, err := generateUpdateRequestInfo(createOp.UniqueSuffix)
+		require.NoError(t, err)
+
+		info.Signer = ecsigner.New(updateKey, "ES256", "")
+		info.UpdateKey, err = pubkey.GetPublicKeyJWK(&updateKey.PublicKey)
+		require.NoError(t, err)
+		info.RevealValue, err = commitment.GetRevealValue(info.UpdateKey, sha2_256)
+		require.NoError(t, err)
+
+		updateOp, err := client.NewUpdateRequest(info)
+		require.NoError(t, err)
+
+		result, err := dochandler.DryRunOperation(updateOp, 0)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		require.Equal(t, "Jane", result.Document["name"])
+
+		// the operation was not persisted so the document is unchanged
+		doc, err := dochandler.ResolveDocument(createOp.ID)
+		require.NoError(t, err)
+		require.Empty(t, doc.Document["name"])
+	})
+
+	t.Run("error - parse", func(t *testing.T) {
+		dochandler, cleanup := getDocumentHandler(mocks.NewMockOperationStore(nil))
+		defer cleanup()
+
+		result, err := dochandler.DryRunOperation([]byte("{}"), 0)
+		require.Error(t, err)
+		require.Nil(t, result)
+		require.Contains(t, err.Error(), badRequest)
+
+		var validationErr *operation.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, operation.StageParse, validationErr.Stage)
+	})
+
+	t.Run("error - decorate (document not found)", func(t *testing.T) {
+		dochandler, cleanup := getDocumentHandler(mocks.NewMockOperationStore(nil))
+		defer cleanup()
+
+		updateOp, err := generateUpdateOperation(getCreateOperation().UniqueSuffix)
+		require.NoError(t, err)
+
+		result, err := dochandler.DryRunOperation(updateOp, 0)
+		require.Error(t, err)
+		require.Nil(t, result)
+
+		var validationErr *operation.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, operation.StageDecorate, validationErr.Stage)
+		require.Equal(t, coreoperation.TypeUpdate, validationErr.Type)
+	})
+
+	t.Run("error - apply (commitment mismatch)", func(t *testing.T) {
+		store := mocks.NewMockOperationStore(nil)
+
+		createOp := getCreateOperation()
+
+		err := store.Put(getAnchoredOperation(createOp))
+		require.NoError(t, err)
+
+		dochandler, cleanup := getDocumentHandler(store)
+		defer cleanup()
+
+		updateOp, err := generateUpdateOperation(createOp.UniqueSuffix)
+		require.NoError(t, err)
+
+		result, err := dochandler.DryRunOperation(updateOp, 0)
+		require.Error(t, err)
+		require.Nil(t, result)
+		require.Contains(t, err.Error(), "reveal value doesn't match the current commitment")
+
+		var validationErr *operation.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, operation.StageApply, validationErr.Stage)
+		require.Equal(t, createOp.UniqueSuffix, validationErr.UniqueSuffix)
+	})
+
+	t.Run("error - protocol", func(t *testing.T) {
+		pc := newMockProtocolClient()
+		pc.Err = errors.New("protocol error")
+
+		dochandler, cleanup := getDocumentHandlerWithProtocolClient(mocks.NewMockOperationStore(nil), pc)
+		defer cleanup()
+
+		result, err := dochandler.DryRunOperation(getCreateOperation().OperationRequest, 0)
+		require.EqualError(t, err, "protocol error")
+		require.Nil(t, result)
+	})
+}
+
+func TestDocumentHandler_Authorizer(t *testing.T) {
+	identity := &auth.Identity{Subject: "alice", Method: auth.MethodBearer}
+
+	t.Run("allowed", func(t *testing.T) {
+		authorizer := &mockAuthorizer{}
+
+		dochandler, cleanup := getDocumentHandler(mocks.NewMockOperationStore(nil), WithAuthorizer(authorizer))
+		defer cleanup()
+
+		doc, err := dochandler.ProcessOperation(getCreateOperation().OperationRequest, 0, operation.WithIdentity(identity))
+		require.NoError(t, err)
+		require.NotNil(t, doc)
+		require.Equal(t, identity, authorizer.identity)
+		require.Equal(t, coreoperation.TypeCreate, authorizer.op.Type)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		authorizer := &mockAuthorizer{}
+
+		dochandler, cleanup := getDocumentHandler(mocks.NewMockOperationStore(nil), WithAuthorizer(authorizer))
+		defer cleanup()
+
+		doc, err := dochandler.DryRunOperation(getCreateOperation().OperationRequest, 0)
+		require.NoError(t, err)
+		require.NotNil(t, doc)
+		require.Nil(t, authorizer.identity)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		authorizer := &mockAuthorizer{err: fmt.Errorf("%w: not allowed", auth.ErrForbidden)}
+
+		dochandler, cleanup := getDocumentHandler(mocks.NewMockOperationStore(nil), WithAuthorizer(authorizer))
+		defer cleanup()
+
+		doc, err := dochandler.ProcessOperation(getCreateOperation().OperationRequest, 0, operation.WithIdentity(identity))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, auth.ErrForbidden))
+		require.Nil(t, doc)
+
+		doc, err = dochandler.DryRunOperation(getCreateOperation().OperationRequest, 0, operation.WithIdentity(identity))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, auth.ErrForbidden))
+		require.Nil(t, doc)
+	})
+}
+
+func TestDocumentHandler_AddToBatch(t *testing.T) {
+	identity := &auth.Identity{Subject: "alice", Method: auth.MethodBearer}
+
+	t.Run("client ID", func(t *testing.T) {
+		writer := &mockBatchWriter{}
+
+		dochandler := New(namespace, nil, newMockProtocolClient(), writer,
+			processor.New("test", mocks.NewMockOperationStore(nil), newMockProtocolClient()), &mocks.MetricsProvider{})
+
+		_, err := dochandler.ProcessOperation(getCreateOperation().OperationRequest, 0, operation.WithIdentity(identity))
+		require.NoError(t, err)
+		require.NotNil(t, writer.Op)
+		require.Equal(t, "alice", writer.Op.ClientID)
+	})
+
+	t.Run("event published", func(t *testing.T) {
+		publisher := &mockEventPublisher{}
+
+		dochandler := New(namespace, nil, newMockProtocolClient(), &mockBatchWriter{},
+			processor.New("test", mocks.NewMockOperationStore(nil), newMockProtocolClient()), &mocks.MetricsProvider{},
+			WithEventPublisher(publisher))
+
+		createOp := getCreateOperation()
+
+		_, err := dochandler.ProcessOperation(createOp.OperationRequest, 0)
+		require.NoError(t, err)
+		require.Len(t, publisher.events, 1)
+		require.Equal(t, createOp.UniqueSuffix, publisher.events[0].UniqueSuffix)
+		require.Equal(t, coreoperation.TypeCreate, publisher.events[0].OperationType)
+		require.Equal(t, notification.StatusUnpublished, publisher.events[0].Status)
+		require.Equal(t, namespace, publisher.events[0].Namespace)
+
+		// Dry runs don't publish events.
+		_, err = dochandler.DryRunOperation(createOp.OperationRequest, 0)
+		require.NoError(t, err)
+		require.Len(t, publisher.events, 1)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		publisher := &mockEventPublisher{}
+		writer := &mockBatchWriter{Err: operation.NewQueueFullError(time.Second, "maximum queue depth %d reached", 1)}
+
+		dochandler := New(namespace, nil, newMockProtocolClient(), writer,
+			processor.New("test", mocks.NewMockOperationStore(nil), newMockProtocolClient()), &mocks.MetricsProvider{},
+			WithEventPublisher(publisher))
+
+		_, err := dochandler.ProcessOperation(getCreateOperation().OperationRequest, 0, operation.WithIdentity(identity))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, operation.ErrQueueFull))
+		require.Empty(t, publisher.events)
+	})
+
+	t.Run("trace context", func(t *testing.T) {
+		exporter := tracingtest.Exporter()
+
+		writer := &mockBatchWriter{}
+
+		dochandler := New(namespace, nil, newMockProtocolClient(), writer,
+			processor.New("test", mocks.NewMockOperationStore(nil), newMockProtocolClient()), &mocks.MetricsProvider{})
+
+		reqCtx, reqSpan := otel.Tracer("test").Start(context.Background(), "request")
+
+		createOp := getCreateOperation()
+
+		_, err := dochandler.ProcessOperation(createOp.OperationRequest, 0, operation.WithContext(reqCtx))
+		require.NoError(t, err)
+
+		reqSpan.End()
+
+		spans := tracingtest.Spans(exporter, "DocumentHandler.ProcessOperation")
+		require.Len(t, spans, 1)
+		require.Equal(t, reqSpan.SpanContext().SpanID(), spans[0].Parent.SpanID())
+		require.Contains(t, spans[0].Attributes, tracing.WithSuffix(createOp.UniqueSuffix))
+		require.Contains(t, spans[0].Attributes, tracing.WithOperationType(string(coreoperation.TypeCreate)))
+
+		// The queued operation carries the context of the document handler span.
+		require.NotNil(t, writer.Op)
+		require.Equal(t, spans[0].SpanContext.SpanID(), tracing.Extract(writer.Op.TraceContext).SpanID())
+	})
+
+	t.Run("trace error", func(t *testing.T) {
+		exporter := tracingtest.Exporter()
+
+		dochandler := New(namespace, nil, newMockProtocolClient(), &mockBatchWriter{Err: errors.New("injected error")},
+			processor.New("test", mocks.NewMockOperationStore(nil), newMockProtocolClient()), &mocks.MetricsProvider{})
+
+		_, err := dochandler.ProcessOperation(getCreateOperation().OperationRequest, 0)
+		require.Error(t, err)
+
+		spans := tracingtest.Spans(exporter, "DocumentHandler.ProcessOperation")
+		require.Len(t, spans, 1)
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+}
+
 // BatchContext implements batch writer context.
 type BatchContext struct {
 	ProtocolClient *mocks.MockProtocolClient
@@ -895,6 +1169,30 @@ func getCreateOperationWithInitialState(suffixData *model.SuffixDataModel, delta
 	}, nil
 }
 
+func getCreateOperationWithUpdateKey(updateKey *ecdsa.PublicKey) (*model.Operation, error) {
+	delta, err := getDeltaWithDoc(validDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	updatePubKey, err := pubkey.GetPublicKeyJWK(updateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	delta.UpdateCommitment, err = commitment.GetCommitment(updatePubKey, sha2_256)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixData, err := getSuffixData(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	return getCreateOperationWithInitialState(suffixData, delta)
+}
+
 func getAnchoredCreateOperation() *coreoperation.AnchoredOperation {
 	op := getCreateOperation()
 
@@ -1184,8 +1482,32 @@ func (m *mockOperationDecorator) Decorate(op *coreoperation.Operation) (*coreope
 
 type mockBatchWriter struct {
 	Err error
+	Op  *operation.QueuedOperation
 }
 
-func (mbw *mockBatchWriter) Add(_ *operation.QueuedOperation, _ uint64) error {
+func (mbw *mockBatchWriter) Add(op *operation.QueuedOperation, _ uint64) error {
+	mbw.Op = op
+
 	return mbw.Err
 }
+
+type mockAuthorizer struct {
+	err      error
+	identity *auth.Identity
+	op       *coreoperation.Operation
+}
+
+func (m *mockAuthorizer) Authorize(identity *auth.Identity, op *coreoperation.Operation) error {
+	m.identity = identity
+	m.op = op
+
+	return m.err
+}
+
+type mockEventPublisher struct {
+	events []*notification.ChangeEvent
+}
+
+func (m *mockEventPublisher) Publish(events ...*notification.ChangeEvent) {
+	m.events = append(m.events, events...)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dochandler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/trustbloc/sidetree-go/pkg/document"
+	"github.com/trustbloc/sidetree-go/pkg/docutil"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+)
+
+// NamespaceConfig contains the components of a namespace which is served by the registry.
+type NamespaceConfig struct {
+	// Namespace is the DID prefix of the namespace (e.g. "did:sidetree").
+	Namespace string
+	// Aliases are additional DID prefixes which are resolved by the namespace.
+	Aliases []string
+	// Path is the path (e.g. "sidetree") under which the namespace is served.
+	Path string
+	// Protocol is the protocol client of the namespace.
+	Protocol protocol.Client
+	// Writer is the batch writer of the namespace. Namespaces may share a batch writer if they also share
+	// the protocol client (and therefore the operation store), since all of the operations in a batch are
+	// anchored in a single transaction and processed using the protocol of that transaction.
+	Writer batchWriter
+	// Processor resolves the documents of the namespace.
+	Processor operationProcessor
+	// Options are the options of the document handler.
+	Options []Option
+}
+
+// Registry owns the document handlers of several namespaces and selects the handler for a request by
+// path or by DID prefix.
+//
+// The registry also implements protocol.ClientProvider so that a single observer may process the
+// transactions of all of the registered namespaces.
+type Registry struct {
+	paths      []string
+	byPath     map[string]*DocumentHandler
+	byPrefix   map[string]*DocumentHandler
+	namespaces map[string]protocol.Client
+}
+
+// NewRegistry creates a document handler for each of the given namespaces. An error is returned if a path,
+// namespace or alias is registered more than once or if a batch writer is shared by namespaces that have
+// different protocol clients.
+func NewRegistry(metrics metricsProvider, namespaces ...*NamespaceConfig) (*Registry, error) {
+	r := &Registry{
+		byPath:     make(map[string]*DocumentHandler),
+		byPrefix:   make(map[string]*DocumentHandler),
+		namespaces: make(map[string]protocol.Client),
+	}
+
+	for i, cfg := range namespaces {
+		if err := checkNamespaceConfig(cfg, namespaces[:i]); err != nil {
+			return nil, err
+		}
+
+		if _, ok := r.byPath[cfg.Path]; ok {
+			return nil, fmt.Errorf("namespace [%s]: path [%s] is already registered", cfg.Namespace, cfg.Path)
+		}
+
+		handler := New(cfg.Namespace, cfg.Aliases, cfg.Protocol, cfg.Writer, cfg.Processor, metrics, cfg.Options...)
+
+		for _, prefix := range append([]string{cfg.Namespace}, cfg.Aliases...) {
+			if _, ok := r.byPrefix[prefix]; ok {
+				return nil, fmt.Errorf("namespace [%s]: namespace or alias [%s] is already registered",
+					cfg.Namespace, prefix)
+			}
+
+			r.byPrefix[prefix] = handler
+			r.namespaces[prefix] = cfg.Protocol
+		}
+
+		r.paths = append(r.paths, cfg.Path)
+		r.byPath[cfg.Path] = handler
+	}
+
+	return r, nil
+}
+
+func checkNamespaceConfig(cfg *NamespaceConfig, previous []*NamespaceConfig) error {
+	switch {
+	case cfg.Namespace == "":
+		return errors.New("namespace is required")
+	case cfg.Path == "":
+		return fmt.Errorf("namespace [%s]: path is required", cfg.Namespace)
+	case cfg.Protocol == nil:
+		return fmt.Errorf("namespace [%s]: protocol client is required", cfg.Namespace)
+	case cfg.Writer == nil:
+		return fmt.Errorf("namespace [%s]: batch writer is required", cfg.Namespace)
+	case cfg.Processor == nil:
+		return fmt.Errorf("namespace [%s]: operation processor is required", cfg.Namespace)
+	}
+
+	for _, other := range previous {
+		if other.Writer == cfg.Writer && other.Protocol != cfg.Protocol {
+			return fmt.Errorf("namespaces [%s] and [%s] share a batch writer but not a protocol client",
+				other.Namespace, cfg.Namespace)
+		}
+	}
+
+	return nil
+}
+
+// Paths returns the paths of the registered namespaces in the order in which they were registered.
+func (r *Registry) Paths() []string {
+	return r.paths
+}
+
+// ForPath returns the document handler which is registered for the given path.
+func (r *Registry) ForPath(path string) (*DocumentHandler, error) {
+	handler, ok := r.byPath[path]
+	if !ok {
+		return nil, fmt.Errorf("document handler not found for path [%s]", path)
+	}
+
+	return handler, nil
+}
+
+// ForDID returns the document handler of the namespace or alias which is the longest prefix of the given
+// short or long-form DID.
+func (r *Registry) ForDID(did string) (*DocumentHandler, error) {
+	var (
+		handler *DocumentHandler
+		longest string
+	)
+
+	for prefix, h := range r.byPrefix {
+		if len(prefix) > len(longest) && strings.HasPrefix(did, prefix+docutil.NamespaceDelimiter) {
+			handler = h
+			longest = prefix
+		}
+	}
+
+	if handler == nil {
+		return nil, fmt.Errorf("no document handler is registered for DID [%s]", did)
+	}
+
+	return handler, nil
+}
+
+// ResolveDocument resolves the given short or long-form DID using the document handler of its namespace.
+func (r *Registry) ResolveDocument(shortOrLongFormDID string,
+	opts ...document.ResolutionOption) (*document.ResolutionResult, error) {
+	handler, err := r.ForDID(shortOrLongFormDID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", badRequest, err.Error())
+	}
+
+	return handler.ResolveDocument(shortOrLongFormDID, opts...)
+}
+
+// ForNamespace returns the protocol client of the given namespace or alias.
+func (r *Registry) ForNamespace(namespace string) (protocol.Client, error) {
+	pc, ok := r.namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("protocol client not found for namespace [%s]", namespace)
+	}
+
+	return pc, nil
+}
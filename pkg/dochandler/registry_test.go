@@ -0,0 +1,169 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dochandler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	docmocks "github.com/trustbloc/sidetree-svc-go/pkg/dochandler/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+)
+
+func TestNewRegistry(t *testing.T) {
+	const testnet = namespace + ":test"
+
+	pc := newMockProtocolClient()
+	writer := &mockBatchWriter{}
+
+	processor := &docmocks.OperationProcessor{}
+	processor.ResolveReturns(nil, errors.New("uniqueSuffix not found in the store"))
+
+	t.Run("success", func(t *testing.T) {
+		testnetPC := newMockProtocolClient()
+
+		r, err := NewRegistry(&mocks.MetricsProvider{},
+			&NamespaceConfig{
+				Namespace: namespace,
+				Aliases:   []string{alias},
+				Path:      "sidetree",
+				Protocol:  pc,
+				Writer:    writer,
+				Processor: processor,
+			},
+			&NamespaceConfig{
+				Namespace: testnet,
+				Path:      "sidetree-test",
+				Protocol:  testnetPC,
+				Writer:    &mockBatchWriter{},
+				Processor: processor,
+				Options:   []Option{WithDomain("domain.com")},
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, []string{"sidetree", "sidetree-test"}, r.Paths())
+
+		h, err := r.ForPath("sidetree")
+		require.NoError(t, err)
+		require.Equal(t, namespace, h.Namespace())
+		require.Equal(t, []string{alias}, h.Aliases())
+		require.Equal(t, pc, h.Protocol())
+
+		h, err = r.ForPath("sidetree-test")
+		require.NoError(t, err)
+		require.Equal(t, testnet, h.Namespace())
+		require.Equal(t, "domain.com", h.domain)
+
+		_, err = r.ForPath("unknown")
+		require.EqualError(t, err, "document handler not found for path [unknown]")
+
+		// The longest matching prefix is selected.
+		h, err = r.ForDID(testnet + ":EiDahaOGH")
+		require.NoError(t, err)
+		require.Equal(t, testnet, h.Namespace())
+
+		h, err = r.ForDID(namespace + ":EiDahaOGH")
+		require.NoError(t, err)
+		require.Equal(t, namespace, h.Namespace())
+
+		h, err = r.ForDID(alias + ":EiDahaOGH")
+		require.NoError(t, err)
+		require.Equal(t, namespace, h.Namespace())
+
+		_, err = r.ForDID("did:other:EiDahaOGH")
+		require.EqualError(t, err, "no document handler is registered for DID [did:other:EiDahaOGH]")
+
+		client, err := r.ForNamespace(testnet)
+		require.NoError(t, err)
+		require.Equal(t, testnetPC, client)
+
+		client, err = r.ForNamespace(alias)
+		require.NoError(t, err)
+		require.Equal(t, pc, client)
+
+		_, err = r.ForNamespace("did:other")
+		require.EqualError(t, err, "protocol client not found for namespace [did:other]")
+	})
+
+	t.Run("resolve document", func(t *testing.T) {
+		r, err := NewRegistry(&mocks.MetricsProvider{}, &NamespaceConfig{
+			Namespace: namespace,
+			Path:      "sidetree",
+			Protocol:  pc,
+			Writer:    writer,
+			Processor: processor,
+		})
+		require.NoError(t, err)
+
+		_, err = r.ResolveDocument(namespace + ":" + getCreateOperation().UniqueSuffix)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+
+		_, err = r.ResolveDocument("did:other:EiDahaOGH")
+		require.EqualError(t, err, "bad request: no document handler is registered for DID [did:other:EiDahaOGH]")
+	})
+
+	t.Run("shared batch writer", func(t *testing.T) {
+		_, err := NewRegistry(&mocks.MetricsProvider{},
+			&NamespaceConfig{Namespace: namespace, Path: "a", Protocol: pc, Writer: writer, Processor: processor},
+			&NamespaceConfig{Namespace: testnet, Path: "b", Protocol: pc, Writer: writer, Processor: processor},
+		)
+		require.NoError(t, err)
+
+		_, err = NewRegistry(&mocks.MetricsProvider{},
+			&NamespaceConfig{Namespace: namespace, Path: "a", Protocol: pc, Writer: writer, Processor: processor},
+			&NamespaceConfig{
+				Namespace: testnet, Path: "b", Protocol: newMockProtocolClient(), Writer: writer,
+				Processor: processor,
+			},
+		)
+		require.EqualError(t, err,
+			"namespaces [did:sidetree] and [did:sidetree:test] share a batch writer but not a protocol client")
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		_, err := NewRegistry(&mocks.MetricsProvider{},
+			&NamespaceConfig{Namespace: namespace, Path: "a", Protocol: pc, Writer: writer, Processor: processor},
+			&NamespaceConfig{Namespace: testnet, Path: "a", Protocol: pc, Writer: writer, Processor: processor},
+		)
+		require.EqualError(t, err, "namespace [did:sidetree:test]: path [a] is already registered")
+
+		_, err = NewRegistry(&mocks.MetricsProvider{},
+			&NamespaceConfig{Namespace: namespace, Path: "a", Protocol: pc, Writer: writer, Processor: processor},
+			&NamespaceConfig{
+				Namespace: testnet, Aliases: []string{namespace}, Path: "b", Protocol: pc, Writer: writer,
+				Processor: processor,
+			},
+		)
+		require.EqualError(t, err,
+			"namespace [did:sidetree:test]: namespace or alias [did:sidetree] is already registered")
+	})
+
+	t.Run("missing components", func(t *testing.T) {
+		for _, test := range []struct {
+			cfg *NamespaceConfig
+			err string
+		}{
+			{&NamespaceConfig{}, "namespace is required"},
+			{&NamespaceConfig{Namespace: namespace}, "namespace [did:sidetree]: path is required"},
+			{&NamespaceConfig{Namespace: namespace, Path: "a"}, "namespace [did:sidetree]: protocol client is required"},
+			{
+				&NamespaceConfig{Namespace: namespace, Path: "a", Protocol: pc},
+				"namespace [did:sidetree]: batch writer is required",
+			},
+			{
+				&NamespaceConfig{Namespace: namespace, Path: "a", Protocol: pc, Writer: writer},
+				"namespace [did:sidetree]: operation processor is required",
+			},
+		} {
+			_, err := NewRegistry(&mocks.MetricsProvider{}, test.cfg)
+			require.EqualError(t, err, test.err)
+		}
+	})
+}
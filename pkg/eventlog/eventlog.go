@@ -0,0 +1,252 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package eventlog implements a bounded, in-process log of document change events. Each event is assigned
+// a sequential ID so that a consumer which was disconnected may resume from the last event it received,
+// provided that the event is still retained in the log.
+//
+// Since the sequence restarts when the process restarts, the event ID is prefixed with the epoch of the log
+// (i.e. "<epoch>-<sequence>"). An ID from a different epoch can't be resumed from, so all of the retained
+// entries are replayed and the subscription is marked as having missed entries.
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
+)
+
+var logger = log.New("sidetree-svc-eventlog")
+
+const (
+	defaultCapacity   = 10000
+	defaultBufferSize = 1000
+)
+
+// ErrInvalidID is returned if an event ID is malformed.
+var ErrInvalidID = errors.New("invalid event ID")
+
+// Entry is an event in the log.
+type Entry struct {
+	ID    string                    `json:"id"`
+	Event *notification.ChangeEvent `json:"event"`
+
+	seq uint64
+}
+
+// Log is a bounded in-memory event log. When the log reaches its capacity, the oldest entries are evicted.
+type Log struct {
+	capacity   int
+	bufferSize int
+	epoch      string
+
+	mutex       sync.RWMutex
+	entries     []*Entry
+	nextID      uint64
+	subscribers map[*Subscription]struct{}
+}
+
+// Option is an event log option.
+type Option func(l *Log)
+
+// WithCapacity sets the maximum number of entries retained in the log.
+func WithCapacity(value int) Option {
+	return func(l *Log) {
+		l.capacity = value
+	}
+}
+
+// WithSubscriberBufferSize sets the number of entries that may be buffered for a subscriber. A subscriber
+// that falls further behind is closed (and may resume using the ID of the last entry it received).
+func WithSubscriberBufferSize(value int) Option {
+	return func(l *Log) {
+		l.bufferSize = value
+	}
+}
+
+// WithEpoch sets the epoch which prefixes the event IDs (default is the time at which the log was created).
+// The epoch must be unique for each instance of the log.
+func WithEpoch(value string) Option {
+	return func(l *Log) {
+		l.epoch = value
+	}
+}
+
+// New returns a new event log.
+func New(opts ...Option) *Log {
+	l := &Log{
+		capacity:    defaultCapacity,
+		bufferSize:  defaultBufferSize,
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		nextID:      1,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Publish appends the given events to the log and forwards them to the subscribers.
+func (l *Log) Publish(events ...*notification.ChangeEvent) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, event := range events {
+		entry := &Entry{ID: l.id(l.nextID), Event: event, seq: l.nextID}
+
+		l.nextID++
+
+		l.entries = append(l.entries, entry)
+
+		if len(l.entries) > l.capacity {
+			l.entries = l.entries[len(l.entries)-l.capacity:]
+		}
+
+		for s := range l.subscribers {
+			select {
+			case s.ch <- entry:
+			default:
+				logger.Warn("Event log subscriber is too slow. Closing subscription.")
+
+				l.unsubscribe(s)
+			}
+		}
+	}
+}
+
+// LastID returns the ID of the most recent entry. If no events were published then an ID which precedes the
+// first entry is returned.
+func (l *Log) LastID() string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return l.id(l.nextID - 1)
+}
+
+// Subscription receives the entries that are published to the log.
+type Subscription struct {
+	// Backlog contains the retained entries after the requested ID (which were published before the
+	// subscription was created).
+	Backlog []*Entry
+
+	// Missed is true if some of the entries after the requested ID are no longer retained in the log, or if
+	// the requested ID is from a different epoch (i.e. it was issued before the log was restarted).
+	Missed bool
+
+	ch  chan *Entry
+	log *Log
+}
+
+// C returns the channel on which new entries are received. The channel is closed when the subscription is
+// closed, either by the consumer or because the consumer fell too far behind.
+func (s *Subscription) C() <-chan *Entry {
+	return s.ch
+}
+
+// Close closes the subscription.
+func (s *Subscription) Close() {
+	s.log.mutex.Lock()
+	defer s.log.mutex.Unlock()
+
+	s.log.unsubscribe(s)
+}
+
+// Subscribe subscribes to new entries. If replay is true then the subscription's backlog contains the
+// retained entries after the entry with the given ID. ErrInvalidID is returned if the ID is malformed.
+func (l *Log) Subscribe(afterID string, replay bool) (*Subscription, error) {
+	var (
+		epoch string
+		seq   uint64
+	)
+
+	if replay {
+		var err error
+
+		epoch, seq, err = parseID(afterID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	s := &Subscription{
+		ch:  make(chan *Entry, l.bufferSize),
+		log: l,
+	}
+
+	if replay {
+		s.Backlog, s.Missed = l.entriesAfter(epoch, seq)
+	}
+
+	l.subscribers[s] = struct{}{}
+
+	return s, nil
+}
+
+// entriesAfter returns the retained entries after the given sequence and whether some entries were missed. An ID
+// from a different epoch was issued by a previous instance of the log, in which case all retained entries are
+// returned and the entries which were published in between are missed.
+func (l *Log) entriesAfter(epoch string, id uint64) ([]*Entry, bool) {
+	if epoch != l.epoch || id > l.nextID-1 {
+		return append([]*Entry{}, l.entries...), true
+	}
+
+	if len(l.entries) == 0 {
+		return nil, id+1 < l.nextID
+	}
+
+	oldest := l.entries[0].seq
+
+	if id+1 < oldest {
+		return append([]*Entry{}, l.entries...), true
+	}
+
+	if id == l.nextID-1 {
+		return nil, false
+	}
+
+	return append([]*Entry{}, l.entries[id+1-oldest:]...), false
+}
+
+func (l *Log) id(seq uint64) string {
+	return fmt.Sprintf("%s-%d", l.epoch, seq)
+}
+
+func parseID(id string) (string, uint64, error) {
+	i := strings.LastIndex(id, "-")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("%w [%s]", ErrInvalidID, id)
+	}
+
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w [%s]", ErrInvalidID, id)
+	}
+
+	return id[:i], seq, nil
+}
+
+func (l *Log) unsubscribe(s *Subscription) {
+	if _, ok := l.subscribers[s]; !ok {
+		return
+	}
+
+	delete(l.subscribers, s)
+
+	close(s.ch)
+}
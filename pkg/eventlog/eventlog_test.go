@@ -0,0 +1,164 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
+)
+
+func TestLog(t *testing.T) {
+	t.Run("subscribe - live", func(t *testing.T) {
+		l := New(WithEpoch("e1"))
+
+		l.Publish(newEvents(2)...)
+
+		sub, err := l.Subscribe("", false)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.Empty(t, sub.Backlog)
+		require.False(t, sub.Missed)
+
+		l.Publish(newEvents(1)...)
+
+		entry := <-sub.C()
+		require.Equal(t, "e1-3", entry.ID)
+		require.Equal(t, "e1-3", l.LastID())
+	})
+
+	t.Run("subscribe - replay", func(t *testing.T) {
+		l := New(WithEpoch("e1"))
+
+		sub, err := l.Subscribe(l.LastID(), true)
+		require.NoError(t, err)
+		require.Empty(t, sub.Backlog)
+		require.False(t, sub.Missed)
+		sub.Close()
+
+		l.Publish(newEvents(5)...)
+
+		sub, err = l.Subscribe("e1-0", true)
+		require.NoError(t, err)
+		require.Len(t, sub.Backlog, 5)
+		require.False(t, sub.Missed)
+		sub.Close()
+
+		sub, err = l.Subscribe("e1-3", true)
+		require.NoError(t, err)
+		require.Len(t, sub.Backlog, 2)
+		require.Equal(t, "e1-4", sub.Backlog[0].ID)
+		require.Equal(t, "suffix4", sub.Backlog[0].Event.UniqueSuffix)
+		require.False(t, sub.Missed)
+		sub.Close()
+
+		sub, err = l.Subscribe("e1-5", true)
+		require.NoError(t, err)
+		require.Empty(t, sub.Backlog)
+		require.False(t, sub.Missed)
+		sub.Close()
+
+		// Closing multiple times is allowed.
+		sub.Close()
+	})
+
+	t.Run("subscribe - missed events", func(t *testing.T) {
+		l := New(WithEpoch("e1"), WithCapacity(3))
+
+		l.Publish(newEvents(5)...)
+
+		sub, err := l.Subscribe("e1-1", true)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.True(t, sub.Missed)
+		require.Len(t, sub.Backlog, 3)
+		require.Equal(t, "e1-3", sub.Backlog[0].ID)
+
+		sub2, err := l.Subscribe("e1-2", true)
+		require.NoError(t, err)
+		defer sub2.Close()
+
+		require.False(t, sub2.Missed)
+		require.Len(t, sub2.Backlog, 3)
+	})
+
+	t.Run("subscribe - ID from before restart", func(t *testing.T) {
+		previous := New()
+		previous.Publish(newEvents(7)...)
+
+		l := New()
+		require.NotEqual(t, previous.LastID(), l.LastID())
+
+		sub, err := l.Subscribe(previous.LastID(), true)
+		require.NoError(t, err)
+		require.True(t, sub.Missed)
+		require.Empty(t, sub.Backlog)
+		sub.Close()
+
+		l.Publish(newEvents(5)...)
+
+		// The ID is below the last ID of the new log but it's from a different epoch, so all of the
+		// retained entries are replayed.
+		sub, err = l.Subscribe("previous-3", true)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.True(t, sub.Missed)
+		require.Len(t, sub.Backlog, 5)
+		require.Equal(t, "suffix1", sub.Backlog[0].Event.UniqueSuffix)
+	})
+
+	t.Run("subscribe - invalid ID", func(t *testing.T) {
+		l := New()
+
+		for _, id := range []string{"", "3", "-3", "e1-", "e1-abc"} {
+			_, err := l.Subscribe(id, true)
+			require.Error(t, err, id)
+			require.True(t, errors.Is(err, ErrInvalidID), id)
+		}
+	})
+
+	t.Run("slow subscriber", func(t *testing.T) {
+		l := New(WithEpoch("e1"), WithSubscriberBufferSize(1))
+
+		sub, err := l.Subscribe("", false)
+		require.NoError(t, err)
+
+		l.Publish(newEvents(2)...)
+
+		entry, ok := <-sub.C()
+		require.True(t, ok)
+		require.Equal(t, "e1-1", entry.ID)
+
+		_, ok = <-sub.C()
+		require.False(t, ok)
+
+		// The subscriber may resume from the last entry it received.
+		sub, err = l.Subscribe(entry.ID, true)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.Len(t, sub.Backlog, 1)
+		require.Equal(t, "e1-2", sub.Backlog[0].ID)
+	})
+}
+
+func newEvents(n int) []*notification.ChangeEvent {
+	events := make([]*notification.ChangeEvent, n)
+
+	for i := range events {
+		events[i] = &notification.ChangeEvent{UniqueSuffix: fmt.Sprintf("suffix%d", i+1)}
+	}
+
+	return events
+}
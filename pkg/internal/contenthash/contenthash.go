@@ -0,0 +1,58 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package contenthash verifies that content matches its content address (an encoded multihash).
+package contenthash
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/trustbloc/sidetree-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
+)
+
+// ErrMismatch is returned by Verify if the multihash of the content doesn't match the address.
+var ErrMismatch = errors.New("content hash doesn't match address")
+
+// IsMultihash returns true if the address is an encoded multihash whose hash algorithm is supported
+// (i.e. the content of the address may be verified).
+func IsMultihash(address string) bool {
+	return CheckMultihash(address) == nil
+}
+
+// CheckMultihash returns an error if the address isn't an encoded multihash or if its hash algorithm
+// isn't supported.
+func CheckMultihash(address string) error {
+	mh, err := hashing.GetMultihash(address)
+	if err != nil {
+		return err
+	}
+
+	_, err = hashing.GetHashFromMultihash(uint(mh.Code))
+
+	return err
+}
+
+// Verify ensures that the multihash of the content matches the given encoded multihash. An error wrapping
+// ErrMismatch is returned if the content doesn't match.
+func Verify(encodedMultihash string, content []byte) error {
+	code, err := hashing.GetMultihashCode(encodedMultihash)
+	if err != nil {
+		return fmt.Errorf("invalid multihash [%s]: %w", encodedMultihash, err)
+	}
+
+	mh, err := hashing.ComputeMultihash(uint(code), content)
+	if err != nil {
+		return fmt.Errorf("compute multihash: %w", err)
+	}
+
+	if encoder.EncodeToString(mh) != encodedMultihash {
+		return fmt.Errorf("%w: %s", ErrMismatch, encodedMultihash)
+	}
+
+	return nil
+}
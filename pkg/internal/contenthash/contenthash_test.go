@@ -0,0 +1,58 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contenthash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
+)
+
+const sha2_256 = 18
+
+func TestVerify(t *testing.T) {
+	content := []byte("content")
+
+	mh, err := hashing.ComputeMultihash(sha2_256, content)
+	require.NoError(t, err)
+
+	address := encoder.EncodeToString(mh)
+
+	t.Run("success", func(t *testing.T) {
+		require.True(t, IsMultihash(address))
+		require.NoError(t, Verify(address, content))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		err := Verify(address, []byte("other content"))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrMismatch))
+	})
+
+	t.Run("invalid multihash", func(t *testing.T) {
+		require.False(t, IsMultihash("address"))
+
+		err := Verify("address", content)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid multihash [address]")
+		require.False(t, errors.Is(err, ErrMismatch))
+	})
+
+	t.Run("unsupported hash algorithm", func(t *testing.T) {
+		unsupported := encoder.EncodeToString(append([]byte{55, byte(len(mh) - 2)}, mh[2:]...))
+
+		require.False(t, IsMultihash(unsupported))
+		require.Error(t, CheckMultihash(unsupported))
+
+		err := Verify(unsupported, content)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "compute multihash")
+	})
+}
@@ -53,6 +53,11 @@ const (
 	FieldContent                   = "content"
 	FieldSources                   = "sources"
 	FieldAlias                     = "alias"
+	FieldSubscriptionID            = "subscriptionID"
+	FieldEventID                   = "eventID"
+	FieldAttempt                   = "attempt"
+	FieldReplica                   = "replica"
+	FieldReason                    = "reason"
 )
 
 // WithURIString sets the uri field.
@@ -240,6 +245,31 @@ func WithAlias(value string) zap.Field {
 	return zap.String(FieldAlias, value)
 }
 
+// WithSubscriptionID sets the subscriptionID field.
+func WithSubscriptionID(value string) zap.Field {
+	return zap.String(FieldSubscriptionID, value)
+}
+
+// WithEventID sets the eventID field.
+func WithEventID(value string) zap.Field {
+	return zap.String(FieldEventID, value)
+}
+
+// WithAttempt sets the attempt field.
+func WithAttempt(value int) zap.Field {
+	return zap.Int(FieldAttempt, value)
+}
+
+// WithReplica sets the replica field.
+func WithReplica(value string) zap.Field {
+	return zap.String(FieldReplica, value)
+}
+
+// WithReason sets the reason field.
+func WithReason(value string) zap.Field {
+	return zap.String(FieldReason, value)
+}
+
 type jsonMarshaller struct {
 	key string
 	obj interface{}
@@ -71,6 +71,8 @@ func TestStandardFields(t *testing.T) {
 			WithDocument(map[string]interface{}{"field1": 1234}), WithDeactivated(true), WithOperations([]*mockObject{op}),
 			WithVersionTime("12"), WithContent([]byte("content1")),
 			WithSources("source1", "source2"), WithAlias("alias1"),
+			WithSubscriptionID("sub1"), WithEventID("event1"), WithAttempt(3),
+			WithReplica("replica1"), WithReason("reason1"),
 		)
 
 		l := unmarshalLogData(t, stdOut.Bytes())
@@ -102,6 +104,11 @@ func TestStandardFields(t *testing.T) {
 		require.Equal(t, "content1", l.Content)
 		require.Equal(t, []string{"source1", "source2"}, l.Sources)
 		require.Equal(t, "alias1", l.Alias)
+		require.Equal(t, "sub1", l.SubscriptionID)
+		require.Equal(t, "event1", l.EventID)
+		require.Equal(t, 3, l.Attempt)
+		require.Equal(t, "replica1", l.Replica)
+		require.Equal(t, "reason1", l.Reason)
 	})
 }
 
@@ -155,6 +162,11 @@ type logData struct {
 	Content                   string        `json:"content"`
 	Sources                   []string      `json:"sources"`
 	Alias                     string        `json:"alias"`
+	SubscriptionID            string        `json:"subscriptionID"`
+	EventID                   string        `json:"eventID"`
+	Attempt                   int           `json:"attempt"`
+	Replica                   string        `json:"replica"`
+	Reason                    string        `json:"reason"`
 }
 
 func unmarshalLogData(t *testing.T, b []byte) *logData {
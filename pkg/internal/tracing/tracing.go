@@ -0,0 +1,133 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package tracing contains the span attributes and helpers used to trace an operation through the
+// Sidetree pipeline with OpenTelemetry. Spans are created with the global tracer provider, so tracing
+// is disabled unless the application registers a provider using otel.SetTracerProvider.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attribute keys.
+const (
+	NamespaceKey         = attribute.Key("sidetree.namespace")
+	SuffixKey            = attribute.Key("sidetree.suffix")
+	OperationTypeKey     = attribute.Key("sidetree.operation.type")
+	AnchorStringKey      = attribute.Key("sidetree.anchor_string")
+	TransactionNumberKey = attribute.Key("sidetree.transaction.number")
+	TotalKey             = attribute.Key("sidetree.total")
+	CASURIKey            = attribute.Key("sidetree.cas.uri")
+	CASDataTypeKey       = attribute.Key("sidetree.cas.type")
+)
+
+// WithNamespace returns the namespace attribute.
+func WithNamespace(value string) attribute.KeyValue {
+	return NamespaceKey.String(value)
+}
+
+// WithSuffix returns the suffix attribute.
+func WithSuffix(value string) attribute.KeyValue {
+	return SuffixKey.String(value)
+}
+
+// WithOperationType returns the operation type attribute.
+func WithOperationType(value string) attribute.KeyValue {
+	return OperationTypeKey.String(value)
+}
+
+// WithAnchorString returns the anchor string attribute.
+func WithAnchorString(value string) attribute.KeyValue {
+	return AnchorStringKey.String(value)
+}
+
+// WithTransactionNumber returns the transaction number attribute.
+func WithTransactionNumber(value uint64) attribute.KeyValue {
+	return TransactionNumberKey.Int64(int64(value))
+}
+
+// WithTotal returns the total attribute.
+func WithTotal(value int) attribute.KeyValue {
+	return TotalKey.Int(value)
+}
+
+// WithCASURI returns the CAS URI attribute.
+func WithCASURI(value string) attribute.KeyValue {
+	return CASURIKey.String(value)
+}
+
+// WithCASDataType returns the CAS data type (e.g. "core", "chunk") attribute.
+func WithCASDataType(value string) attribute.KeyValue {
+	return CASDataTypeKey.String(value)
+}
+
+// propagator is used to carry the trace context on queued operations. The W3C trace context propagator
+// is always used (rather than the global propagator) so that the batch span can be linked to the
+// originating requests regardless of how the application configures propagation.
+var propagator = propagation.TraceContext{}
+
+// Inject returns the trace context of the span in the given context as a map which may be stored
+// along with an operation. Nil is returned if the context doesn't contain a valid span.
+func Inject(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+
+	carrier := propagation.MapCarrier{}
+
+	propagator.Inject(ctx, carrier)
+
+	return carrier
+}
+
+// Extract returns the span context from a trace context that was created with Inject.
+func Extract(traceContext map[string]string) trace.SpanContext {
+	if len(traceContext) == 0 {
+		return trace.SpanContext{}
+	}
+
+	return trace.SpanContextFromContext(
+		propagator.Extract(context.Background(), propagation.MapCarrier(traceContext)),
+	)
+}
+
+// Links returns a span link for each of the given trace contexts. Invalid and duplicate span contexts
+// are ignored.
+func Links(traceContexts ...map[string]string) []trace.Link {
+	var links []trace.Link
+
+	linked := make(map[trace.SpanID]bool)
+
+	for _, tc := range traceContexts {
+		sc := Extract(tc)
+		if !sc.IsValid() || linked[sc.SpanID()] {
+			continue
+		}
+
+		linked[sc.SpanID()] = true
+
+		links = append(links, trace.Link{SpanContext: sc})
+	}
+
+	return links
+}
+
+// RecordError records the given error on the span and sets the status of the span to Error.
+// The error is returned so that the function may be used in a return statement.
+func RecordError(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
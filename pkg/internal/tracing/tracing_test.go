@@ -0,0 +1,79 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInjectExtract(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+
+	t.Run("Success", func(t *testing.T) {
+		ctx, span := tp.Tracer("test").Start(context.Background(), "test")
+		defer span.End()
+
+		traceContext := Inject(ctx)
+		require.NotEmpty(t, traceContext)
+
+		sc := Extract(traceContext)
+		require.True(t, sc.IsValid())
+		require.Equal(t, span.SpanContext().TraceID(), sc.TraceID())
+		require.Equal(t, span.SpanContext().SpanID(), sc.SpanID())
+	})
+
+	t.Run("No span", func(t *testing.T) {
+		require.Nil(t, Inject(context.Background()))
+		require.False(t, Extract(nil).IsValid())
+		require.False(t, Extract(map[string]string{"traceparent": "invalid"}).IsValid())
+	})
+}
+
+func TestLinks(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+
+	ctx1, span1 := tp.Tracer("test").Start(context.Background(), "span1")
+	defer span1.End()
+
+	ctx2, span2 := tp.Tracer("test").Start(context.Background(), "span2")
+	defer span2.End()
+
+	links := Links(Inject(ctx1), nil, Inject(ctx2), Inject(ctx1))
+	require.Len(t, links, 2)
+	require.Equal(t, span1.SpanContext().SpanID(), links[0].SpanContext.SpanID())
+	require.Equal(t, span2.SpanContext().SpanID(), links[1].SpanContext.SpanID())
+
+	require.Empty(t, Links())
+}
+
+func TestRecordError(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "test")
+
+	require.NoError(t, RecordError(span, nil))
+
+	errExpected := errors.New("injected error")
+
+	require.Equal(t, errExpected, RecordError(span, errExpected))
+
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status.Code)
+	require.Equal(t, errExpected.Error(), spans[0].Status.Description)
+	require.Len(t, spans[0].Events, 1)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package tracingtest contains test helpers for verifying the spans created by the Sidetree pipeline.
+package tracingtest
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	once     sync.Once
+	exporter *tracetest.InMemoryExporter
+)
+
+// Exporter registers (on first use) a global tracer provider which synchronously exports all spans
+// to an in-memory exporter. The exporter is reset before it is returned.
+//
+// The global tracer provider may only be registered once per process, so tests must not register
+// their own provider.
+func Exporter() *tracetest.InMemoryExporter {
+	once.Do(func() {
+		exporter = tracetest.NewInMemoryExporter()
+
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	})
+
+	exporter.Reset()
+
+	return exporter
+}
+
+// Spans returns the exported spans with the given name.
+func Spans(exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStubs {
+	var spans tracetest.SpanStubs
+
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+
+	return spans
+}
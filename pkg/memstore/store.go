@@ -0,0 +1,233 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package memstore contains in-memory implementations of the anchored operation store and the
+// unpublished operation store. The operations are lost when the process exits, so the stores are mainly
+// intended for tests, tools and single-node deployments which rebuild their state from the anchoring system.
+package memstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+)
+
+// ErrNotFound is returned if no operations were found.
+var ErrNotFound = errors.New("not found")
+
+// opKey identifies an anchored operation. A suffix may only appear once in a transaction.
+type opKey struct {
+	suffix            string
+	transactionTime   uint64
+	transactionNumber uint64
+}
+
+type txnKey struct {
+	transactionTime   uint64
+	transactionNumber uint64
+}
+
+// Store is an in-memory store of anchored operations which is indexed by unique suffix, by transaction
+// (transaction time and number) and by canonical reference.
+type Store struct {
+	mutex    sync.RWMutex
+	ops      map[opKey]*operation.AnchoredOperation
+	bySuffix map[string][]*operation.AnchoredOperation
+	byTxn    map[txnKey][]*operation.AnchoredOperation
+	byRef    map[string][]*operation.AnchoredOperation
+}
+
+// New returns a new operation store.
+func New() *Store {
+	return &Store{
+		ops:      make(map[opKey]*operation.AnchoredOperation),
+		bySuffix: make(map[string][]*operation.AnchoredOperation),
+		byTxn:    make(map[txnKey][]*operation.AnchoredOperation),
+		byRef:    make(map[string][]*operation.AnchoredOperation),
+	}
+}
+
+// Put stores the given operations. Either all of the operations are stored or, if an error is returned,
+// none of them. Storing an operation which already exists (same suffix, transaction time and transaction
+// number) has no effect, unless the operation request differs, in which case an error is returned.
+func (s *Store) Put(ops []*operation.AnchoredOperation) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	added := make(map[opKey]*operation.AnchoredOperation)
+
+	var newOps []*operation.AnchoredOperation
+
+	for _, op := range ops {
+		if op.UniqueSuffix == "" {
+			return errors.New("unique suffix is required")
+		}
+
+		key := keyOf(op)
+
+		existing, ok := s.ops[key]
+		if !ok {
+			existing, ok = added[key]
+		}
+
+		if ok {
+			if existing.Type != op.Type || !bytes.Equal(existing.OperationRequest, op.OperationRequest) {
+				return fmt.Errorf("conflicting %s operation for suffix [%s] in transaction %d-%d",
+					op.Type, op.UniqueSuffix, op.TransactionTime, op.TransactionNumber)
+			}
+
+			continue
+		}
+
+		c := *op
+
+		added[key] = &c
+		newOps = append(newOps, &c)
+	}
+
+	for _, op := range newOps {
+		s.ops[keyOf(op)] = op
+		s.bySuffix[op.UniqueSuffix] = insertSorted(s.bySuffix[op.UniqueSuffix], op)
+
+		tk := txnKey{transactionTime: op.TransactionTime, transactionNumber: op.TransactionNumber}
+		s.byTxn[tk] = append(s.byTxn[tk], op)
+
+		if op.CanonicalReference != "" {
+			s.byRef[op.CanonicalReference] = append(s.byRef[op.CanonicalReference], op)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the operations of the given suffix ordered by transaction time and number.
+func (s *Store) Get(uniqueSuffix string) ([]*operation.AnchoredOperation, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ops, ok := s.bySuffix[uniqueSuffix]
+	if !ok {
+		return nil, fmt.Errorf("operations for suffix [%s]: %w", uniqueSuffix, ErrNotFound)
+	}
+
+	return copyOps(ops), nil
+}
+
+// GetByTransaction returns the operations which were anchored in the given transaction.
+func (s *Store) GetByTransaction(transactionTime, transactionNumber uint64) ([]*operation.AnchoredOperation, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ops, ok := s.byTxn[txnKey{transactionTime: transactionTime, transactionNumber: transactionNumber}]
+	if !ok {
+		return nil, fmt.Errorf("operations for transaction %d-%d: %w", transactionTime, transactionNumber, ErrNotFound)
+	}
+
+	return copyOps(ops), nil
+}
+
+// GetByCanonicalReference returns the operations which were anchored with the given canonical reference.
+func (s *Store) GetByCanonicalReference(ref string) ([]*operation.AnchoredOperation, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ops, ok := s.byRef[ref]
+	if !ok {
+		return nil, fmt.Errorf("operations for canonical reference [%s]: %w", ref, ErrNotFound)
+	}
+
+	return copyOps(ops), nil
+}
+
+// Delete deletes the given operations (e.g. when the transaction which anchored them is rolled back).
+// Operations which don't exist are ignored.
+func (s *Store) Delete(ops []*operation.AnchoredOperation) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, op := range ops {
+		key := keyOf(op)
+
+		existing, ok := s.ops[key]
+		if !ok {
+			continue
+		}
+
+		delete(s.ops, key)
+
+		s.bySuffix[existing.UniqueSuffix] = remove(s.bySuffix[existing.UniqueSuffix], existing)
+		if len(s.bySuffix[existing.UniqueSuffix]) == 0 {
+			delete(s.bySuffix, existing.UniqueSuffix)
+		}
+
+		tk := txnKey{transactionTime: existing.TransactionTime, transactionNumber: existing.TransactionNumber}
+
+		s.byTxn[tk] = remove(s.byTxn[tk], existing)
+		if len(s.byTxn[tk]) == 0 {
+			delete(s.byTxn, tk)
+		}
+
+		if existing.CanonicalReference != "" {
+			s.byRef[existing.CanonicalReference] = remove(s.byRef[existing.CanonicalReference], existing)
+			if len(s.byRef[existing.CanonicalReference]) == 0 {
+				delete(s.byRef, existing.CanonicalReference)
+			}
+		}
+	}
+
+	return nil
+}
+
+func keyOf(op *operation.AnchoredOperation) opKey {
+	return opKey{
+		suffix:            op.UniqueSuffix,
+		transactionTime:   op.TransactionTime,
+		transactionNumber: op.TransactionNumber,
+	}
+}
+
+// insertSorted inserts the given operation after all of the operations with the same or an earlier
+// transaction time and number.
+func insertSorted(ops []*operation.AnchoredOperation, op *operation.AnchoredOperation) []*operation.AnchoredOperation {
+	i := sort.Search(len(ops), func(i int) bool {
+		if ops[i].TransactionTime != op.TransactionTime {
+			return ops[i].TransactionTime > op.TransactionTime
+		}
+
+		return ops[i].TransactionNumber > op.TransactionNumber
+	})
+
+	ops = append(ops, nil)
+	copy(ops[i+1:], ops[i:])
+	ops[i] = op
+
+	return ops
+}
+
+func remove(ops []*operation.AnchoredOperation, op *operation.AnchoredOperation) []*operation.AnchoredOperation {
+	for i, o := range ops {
+		if o == op {
+			return append(ops[:i], ops[i+1:]...)
+		}
+	}
+
+	return ops
+}
+
+func copyOps(ops []*operation.AnchoredOperation) []*operation.AnchoredOperation {
+	result := make([]*operation.AnchoredOperation, len(ops))
+
+	for i, op := range ops {
+		c := *op
+		result[i] = &c
+	}
+
+	return result
+}
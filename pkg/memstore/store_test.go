@@ -0,0 +1,198 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/processor"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprocessor"
+)
+
+func TestStore_Put(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s := New()
+
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{
+			newOp("suffix1", operation.TypeUpdate, 20, 1, "ref2"),
+			newOp("suffix1", operation.TypeCreate, 10, 1, "ref1"),
+			newOp("suffix2", operation.TypeCreate, 10, 1, "ref1"),
+		}))
+
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{
+			newOp("suffix1", operation.TypeUpdate, 10, 2, ""),
+		}))
+
+		ops, err := s.Get("suffix1")
+		require.NoError(t, err)
+		require.Len(t, ops, 3)
+		require.Equal(t, operation.TypeCreate, ops[0].Type)
+		require.Equal(t, uint64(2), ops[1].TransactionNumber)
+		require.Equal(t, uint64(20), ops[2].TransactionTime)
+
+		ops, err = s.GetByTransaction(10, 1)
+		require.NoError(t, err)
+		require.Len(t, ops, 2)
+
+		ops, err = s.GetByCanonicalReference("ref1")
+		require.NoError(t, err)
+		require.Len(t, ops, 2)
+
+		_, err = s.Get("suffix3")
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.EqualError(t, err, "operations for suffix [suffix3]: not found")
+
+		_, err = s.GetByTransaction(30, 1)
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		_, err = s.GetByCanonicalReference("ref3")
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		s := New()
+
+		op := newOp("suffix1", operation.TypeCreate, 10, 1, "ref1")
+
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{op, op}))
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{op}))
+
+		ops, err := s.Get("suffix1")
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+
+		ops, err = s.GetByCanonicalReference("ref1")
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		s := New()
+
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{newOp("suffix1", operation.TypeCreate, 10, 1, "ref1")}))
+
+		conflicting := newOp("suffix1", operation.TypeCreate, 10, 1, "ref1")
+		conflicting.OperationRequest = []byte("other")
+
+		err := s.Put([]*operation.AnchoredOperation{
+			newOp("suffix2", operation.TypeCreate, 10, 1, "ref1"),
+			conflicting,
+		})
+		require.EqualError(t, err, "conflicting create operation for suffix [suffix1] in transaction 10-1")
+
+		// None of the operations were stored.
+		_, err = s.Get("suffix2")
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		err = s.Put([]*operation.AnchoredOperation{
+			newOp("suffix2", operation.TypeCreate, 10, 1, "ref1"),
+			newOp("", operation.TypeCreate, 10, 1, "ref1"),
+		})
+		require.EqualError(t, err, "unique suffix is required")
+
+		_, err = s.Get("suffix2")
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("stored operations are copies", func(t *testing.T) {
+		s := New()
+
+		op := newOp("suffix1", operation.TypeCreate, 10, 1, "ref1")
+		require.NoError(t, s.Put([]*operation.AnchoredOperation{op}))
+
+		op.ProtocolVersion = 100
+
+		ops, err := s.Get("suffix1")
+		require.NoError(t, err)
+		require.Zero(t, ops[0].ProtocolVersion)
+
+		ops[0].ProtocolVersion = 100
+
+		ops, err = s.Get("suffix1")
+		require.NoError(t, err)
+		require.Zero(t, ops[0].ProtocolVersion)
+	})
+}
+
+func TestStore_Delete(t *testing.T) {
+	s := New()
+
+	require.NoError(t, s.Put([]*operation.AnchoredOperation{
+		newOp("suffix1", operation.TypeCreate, 10, 1, "ref1"),
+		newOp("suffix2", operation.TypeCreate, 10, 1, "ref1"),
+		newOp("suffix1", operation.TypeUpdate, 20, 1, "ref2"),
+	}))
+
+	// Roll back the transaction with canonical reference ref2.
+	ops, err := s.GetByCanonicalReference("ref2")
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(ops))
+
+	ops, err = s.Get("suffix1")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, operation.TypeCreate, ops[0].Type)
+
+	_, err = s.GetByCanonicalReference("ref2")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = s.GetByTransaction(20, 1)
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	// Roll back the transaction 10-1.
+	ops, err = s.GetByTransaction(10, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(ops))
+
+	_, err = s.Get("suffix1")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = s.GetByCanonicalReference("ref1")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	// Deleting operations that don't exist is ignored.
+	require.NoError(t, s.Delete(ops))
+
+	// The operations may be stored again after they were deleted.
+	require.NoError(t, s.Put(ops))
+
+	ops, err = s.Get("suffix2")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+}
+
+func TestStore_Providers(t *testing.T) {
+	store := New()
+	unpublishedStore := NewUnpublishedStore()
+
+	txnprocessor.New(&txnprocessor.Providers{OpStore: store},
+		txnprocessor.WithUnpublishedOperationStore(unpublishedStore, []operation.Type{operation.TypeCreate}))
+
+	p := processor.New("test", store, mocks.NewMockProtocolClient(),
+		processor.WithUnpublishedOperationStore(unpublishedStore))
+
+	// A suffix which isn't in the stores isn't treated as a store error.
+	_, err := p.Resolve("suffix1")
+	require.EqualError(t, err, "create operation not found")
+}
+
+func newOp(suffix string, opType operation.Type, txnTime, txnNumber uint64,
+	ref string) *operation.AnchoredOperation {
+	return &operation.AnchoredOperation{
+		Type:               opType,
+		UniqueSuffix:       suffix,
+		OperationRequest:   []byte(suffix + "-" + string(opType)),
+		TransactionTime:    txnTime,
+		TransactionNumber:  txnNumber,
+		CanonicalReference: ref,
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+)
+
+const defaultUnpublishedTTL = time.Hour
+
+type unpublishedEntry struct {
+	op      *operation.AnchoredOperation
+	key     string
+	expires time.Time
+}
+
+// UnpublishedStore is an in-memory store of operations which were accepted but not yet anchored. An operation
+// is removed when the transaction which anchors it is processed or when its time-to-live expires (e.g. because
+// the batch could not be anchored).
+type UnpublishedStore struct {
+	mutex      sync.Mutex
+	ops        map[string][]*unpublishedEntry
+	ttl        time.Duration
+	now        func() time.Time
+	lastPurged time.Time
+}
+
+// UnpublishedOption is an unpublished store option.
+type UnpublishedOption func(s *UnpublishedStore)
+
+// WithTTL sets the time-to-live of unpublished operations (default one hour).
+func WithTTL(value time.Duration) UnpublishedOption {
+	return func(s *UnpublishedStore) {
+		s.ttl = value
+	}
+}
+
+// NewUnpublishedStore returns a new unpublished operation store.
+func NewUnpublishedStore(opts ...UnpublishedOption) *UnpublishedStore {
+	s := &UnpublishedStore{
+		ops: make(map[string][]*unpublishedEntry),
+		ttl: defaultUnpublishedTTL,
+		now: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Put stores the given unpublished operation. Storing the same operation (see operationKey) again for a suffix
+// replaces the existing operation and resets its time-to-live.
+func (s *UnpublishedStore) Put(op *operation.AnchoredOperation) error {
+	if op.UniqueSuffix == "" {
+		return errors.New("unique suffix is required")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.purge()
+
+	c := *op
+	key := operationKey(op)
+
+	entries := removeUnpublished(s.unexpired(op.UniqueSuffix), key)
+
+	s.ops[op.UniqueSuffix] = append(entries, &unpublishedEntry{op: &c, key: key, expires: s.now().Add(s.ttl)})
+
+	return nil
+}
+
+// Get returns the unexpired unpublished operations of the given suffix.
+func (s *UnpublishedStore) Get(uniqueSuffix string) ([]*operation.AnchoredOperation, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries := s.unexpired(uniqueSuffix)
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("unpublished operations for suffix [%s]: %w", uniqueSuffix, ErrNotFound)
+	}
+
+	ops := make([]*operation.AnchoredOperation, len(entries))
+
+	for i, e := range entries {
+		c := *e.op
+		ops[i] = &c
+	}
+
+	return ops, nil
+}
+
+// Delete deletes the unpublished operation with the same suffix, type and reveal value as the given operation.
+func (s *UnpublishedStore) Delete(op *operation.AnchoredOperation) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.delete(op)
+
+	return nil
+}
+
+// DeleteAll deletes the unpublished operations with the same suffixes, types and reveal values as the given
+// operations (e.g. the operations of a transaction which was processed).
+func (s *UnpublishedStore) DeleteAll(ops []*operation.AnchoredOperation) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, op := range ops {
+		s.delete(op)
+	}
+
+	return nil
+}
+
+func (s *UnpublishedStore) delete(op *operation.AnchoredOperation) {
+	entries := removeUnpublished(s.ops[op.UniqueSuffix], operationKey(op))
+	if len(entries) == 0 {
+		delete(s.ops, op.UniqueSuffix)
+
+		return
+	}
+
+	s.ops[op.UniqueSuffix] = entries
+}
+
+// unexpired removes the expired operations of the given suffix and returns the remaining ones.
+func (s *UnpublishedStore) unexpired(uniqueSuffix string) []*unpublishedEntry {
+	now := s.now()
+
+	var entries []*unpublishedEntry
+
+	for _, e := range s.ops[uniqueSuffix] {
+		if now.Before(e.expires) {
+			entries = append(entries, e)
+		}
+	}
+
+	if len(entries) == 0 {
+		delete(s.ops, uniqueSuffix)
+	} else {
+		s.ops[uniqueSuffix] = entries
+	}
+
+	return entries
+}
+
+// purge removes the expired operations of all suffixes, at most once per time-to-live period, so that the
+// operations of suffixes which are never accessed again don't accumulate.
+func (s *UnpublishedStore) purge() {
+	now := s.now()
+
+	if now.Sub(s.lastPurged) < s.ttl {
+		return
+	}
+
+	s.lastPurged = now
+
+	for suffix := range s.ops {
+		s.unexpired(suffix)
+	}
+}
+
+func removeUnpublished(entries []*unpublishedEntry, key string) []*unpublishedEntry {
+	var remaining []*unpublishedEntry
+
+	for _, e := range entries {
+		if e.key != key {
+			remaining = append(remaining, e)
+		}
+	}
+
+	return remaining
+}
+
+// operationKey identifies an operation of a suffix regardless of the encoding of the operation request (the
+// unpublished operation contains the request as submitted by the client whereas the operations of a processed
+// transaction contain the canonicalized request). There is only one create operation for a suffix and the reveal
+// value of an update, recover or deactivate operation can't be reused, so the type and reveal value identify the
+// operation. The request itself is used if it can't be parsed.
+func operationKey(op *operation.AnchoredOperation) string {
+	req := &struct {
+		RevealValue string `json:"revealValue"`
+	}{}
+
+	if err := json.Unmarshal(op.OperationRequest, req); err != nil {
+		return string(op.OperationRequest)
+	}
+
+	return string(op.Type) + ":" + req.RevealValue
+}
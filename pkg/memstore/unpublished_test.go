@@ -0,0 +1,118 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+)
+
+func TestUnpublishedStore(t *testing.T) {
+	t.Run("put, get and delete", func(t *testing.T) {
+		s := NewUnpublishedStore()
+
+		create := newOp("suffix1", operation.TypeCreate, 100, 0, "")
+		update := newOp("suffix1", operation.TypeUpdate, 101, 0, "")
+
+		require.NoError(t, s.Put(create))
+		require.NoError(t, s.Put(update))
+		require.NoError(t, s.Put(update))
+
+		ops, err := s.Get("suffix1")
+		require.NoError(t, err)
+		require.Len(t, ops, 2)
+		require.Equal(t, operation.TypeCreate, ops[0].Type)
+		require.Equal(t, operation.TypeUpdate, ops[1].Type)
+
+		require.NoError(t, s.Delete(create))
+
+		ops, err = s.Get("suffix1")
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, operation.TypeUpdate, ops[0].Type)
+
+		// The operations of a transaction have a different transaction time than the unpublished operations.
+		anchored := newOp("suffix1", operation.TypeUpdate, 200, 1, "ref1")
+
+		require.NoError(t, s.DeleteAll([]*operation.AnchoredOperation{anchored}))
+
+		_, err = s.Get("suffix1")
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.EqualError(t, err, "unpublished operations for suffix [suffix1]: not found")
+
+		require.NoError(t, s.Delete(anchored))
+
+		require.EqualError(t, s.Put(newOp("", operation.TypeCreate, 100, 0, "")), "unique suffix is required")
+	})
+
+	t.Run("delete all - non-canonical request", func(t *testing.T) {
+		s := NewUnpublishedStore()
+
+		create := newOp("suffix1", operation.TypeCreate, 100, 0, "")
+		create.OperationRequest = []byte(`{ "type": "create", "suffixData": {"deltaHash": "hash"} }`)
+
+		update := newOp("suffix1", operation.TypeUpdate, 101, 0, "")
+		update.OperationRequest = []byte(`{ "type": "update", "didSuffix": "suffix1", "revealValue": "reveal1" }`)
+
+		otherUpdate := newOp("suffix1", operation.TypeUpdate, 102, 0, "")
+		otherUpdate.OperationRequest = []byte(`{"type":"update","didSuffix":"suffix1","revealValue":"reveal2"}`)
+
+		require.NoError(t, s.Put(create))
+		require.NoError(t, s.Put(update))
+		require.NoError(t, s.Put(otherUpdate))
+
+		// The operations of a processed transaction contain the canonicalized requests.
+		anchoredCreate := newOp("suffix1", operation.TypeCreate, 200, 1, "ref1")
+		anchoredCreate.OperationRequest = []byte(`{"suffixData":{"deltaHash":"hash"},"type":"create"}`)
+
+		anchoredUpdate := newOp("suffix1", operation.TypeUpdate, 200, 1, "ref1")
+		anchoredUpdate.OperationRequest = []byte(`{"didSuffix":"suffix1","revealValue":"reveal1","type":"update"}`)
+
+		require.NoError(t, s.DeleteAll([]*operation.AnchoredOperation{anchoredCreate, anchoredUpdate}))
+
+		ops, err := s.Get("suffix1")
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, otherUpdate.OperationRequest, ops[0].OperationRequest)
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		now := time.Now()
+
+		s := NewUnpublishedStore(WithTTL(time.Minute))
+		s.now = func() time.Time { return now }
+
+		require.NoError(t, s.Put(newOp("suffix1", operation.TypeCreate, 100, 0, "")))
+		require.NoError(t, s.Put(newOp("suffix2", operation.TypeCreate, 100, 0, "")))
+
+		now = now.Add(30 * time.Second)
+
+		// Putting the same operation again resets its TTL.
+		require.NoError(t, s.Put(newOp("suffix2", operation.TypeCreate, 100, 0, "")))
+
+		_, err := s.Get("suffix1")
+		require.NoError(t, err)
+
+		now = now.Add(45 * time.Second)
+
+		_, err = s.Get("suffix1")
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		_, err = s.Get("suffix2")
+		require.NoError(t, err)
+
+		now = now.Add(time.Minute)
+
+		// Expired operations are purged when another operation is stored.
+		require.NoError(t, s.Put(newOp("suffix3", operation.TypeCreate, 100, 0, "")))
+		require.Len(t, s.ops, 1)
+	})
+}
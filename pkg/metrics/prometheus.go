@@ -0,0 +1,387 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package metrics contains a Prometheus implementation of the metrics providers used by the Sidetree
+// building blocks (document handler, REST handlers, batch writer, observer and transaction provider).
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/common"
+)
+
+const (
+	defaultNamespace = "sidetree"
+
+	operationSubsystem = "operation"
+	httpSubsystem      = "http"
+	casSubsystem       = "cas"
+	batchSubsystem     = "batch"
+	observerSubsystem  = "observer"
+
+	dataTypeLabel = "type"
+	tierLabel     = "tier"
+	sourceLabel   = "source"
+	resultLabel   = "result"
+
+	// otherSource is the source label of the alternate sources which aren't allowed as label values.
+	otherSource = "other"
+
+	defaultPath = "/metrics"
+)
+
+// Provider is a Prometheus metrics provider.
+type Provider struct {
+	namespace      string
+	registry       *prometheus.Registry
+	allowedSources map[string]bool
+
+	processOperationTime         prometheus.Histogram
+	getProtocolVersionTime       prometheus.Histogram
+	parseOperationTime           prometheus.Histogram
+	validateOperationTime        prometheus.Histogram
+	decorateOperationTime        prometheus.Histogram
+	addUnpublishedOperationTime  prometheus.Histogram
+	addOperationToBatchTime      prometheus.Histogram
+	getCreateOperationResultTime prometheus.Histogram
+
+	httpCreateUpdateTime     prometheus.Histogram
+	httpBulkCreateUpdateTime prometheus.Histogram
+	httpResolveTime          prometheus.Histogram
+
+	casWriteSize   *prometheus.HistogramVec
+	casReadTime    prometheus.Histogram
+	casCacheHits   *prometheus.CounterVec
+	casCacheMisses *prometheus.CounterVec
+
+	casAlternateSourceReads      *prometheus.CounterVec
+	casAlternateSourceReadTime   *prometheus.HistogramVec
+	casAlternateSourceBackingOff *prometheus.GaugeVec
+
+	batchQueueDepth        prometheus.Gauge
+	batchPendingOperations prometheus.Gauge
+	observerLag            prometheus.Gauge
+}
+
+// Option is a metrics provider option.
+type Option func(p *Provider)
+
+// WithNamespace sets the namespace which prefixes all metric names (default "sidetree").
+func WithNamespace(value string) Option {
+	return func(p *Provider) {
+		p.namespace = value
+	}
+}
+
+// WithRegistry sets the registry into which the metrics are registered. By default, a new registry is created.
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(p *Provider) {
+		p.registry = registry
+	}
+}
+
+// WithAlternateSources sets the alternate CAS sources which are recorded with their own source label. Since
+// the alternate sources are supplied by the anchors, the reads from all other sources are recorded with
+// the source label "other" so that the number of label values is bounded.
+func WithAlternateSources(sources ...string) Option {
+	return func(p *Provider) {
+		for _, source := range sources {
+			p.allowedSources[source] = true
+		}
+	}
+}
+
+// New returns a new Prometheus metrics provider. An error is returned if the metrics could not be
+// registered (for example, if a metric with the same name already exists in the given registry).
+func New(opts ...Option) (*Provider, error) {
+	p := &Provider{
+		namespace:      defaultNamespace,
+		allowedSources: make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.registry == nil {
+		p.registry = prometheus.NewRegistry()
+	}
+
+	p.processOperationTime = p.newTimer(operationSubsystem, "process_seconds",
+		"The overall time to process an operation.")
+	p.getProtocolVersionTime = p.newTimer(operationSubsystem, "get_protocol_version_seconds",
+		"The time to get the protocol version.")
+	p.parseOperationTime = p.newTimer(operationSubsystem, "parse_seconds",
+		"The time to parse an operation.")
+	p.validateOperationTime = p.newTimer(operationSubsystem, "validate_seconds",
+		"The time to validate an operation.")
+	p.decorateOperationTime = p.newTimer(operationSubsystem, "decorate_seconds",
+		"The time to decorate an operation.")
+	p.addUnpublishedOperationTime = p.newTimer(operationSubsystem, "add_unpublished_seconds",
+		"The time to add an unpublished operation.")
+	p.addOperationToBatchTime = p.newTimer(operationSubsystem, "add_to_batch_seconds",
+		"The time to add an operation to the batch.")
+	p.getCreateOperationResultTime = p.newTimer(operationSubsystem, "create_result_seconds",
+		"The time to create the operation result response.")
+
+	p.httpCreateUpdateTime = p.newTimer(httpSubsystem, "create_update_seconds",
+		"The time of a REST call to create or update a document.")
+	p.httpBulkCreateUpdateTime = p.newTimer(httpSubsystem, "bulk_create_update_seconds",
+		"The time of a REST call to create or update documents in bulk.")
+	p.httpResolveTime = p.newTimer(httpSubsystem, "resolve_seconds",
+		"The time of a REST call to resolve a document.")
+
+	p.casWriteSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: p.namespace,
+		Subsystem: casSubsystem,
+		Name:      "write_size_bytes",
+		Help:      "The size of the data written to CAS.",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 10), //nolint:gomnd
+	}, []string{dataTypeLabel})
+	p.casReadTime = p.newTimer(casSubsystem, "read_seconds",
+		"The time to read data from CAS.")
+	p.casCacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: p.namespace,
+		Subsystem: casSubsystem,
+		Name:      "cache_hits_total",
+		Help:      "The number of CAS reads which were served from the cache.",
+	}, []string{dataTypeLabel, tierLabel})
+	p.casCacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: p.namespace,
+		Subsystem: casSubsystem,
+		Name:      "cache_misses_total",
+		Help:      "The number of CAS reads which were not found in the cache.",
+	}, []string{dataTypeLabel})
+	p.casAlternateSourceReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: p.namespace,
+		Subsystem: casSubsystem,
+		Name:      "alternate_source_reads_total",
+		Help:      "The number of reads from an alternate CAS source by result (success, failure or invalid).",
+	}, []string{sourceLabel, resultLabel})
+	p.casAlternateSourceReadTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: p.namespace,
+		Subsystem: casSubsystem,
+		Name:      "alternate_source_read_seconds",
+		Help:      "The time to read data from an alternate CAS source.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{sourceLabel})
+	p.casAlternateSourceBackingOff = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: p.namespace,
+		Subsystem: casSubsystem,
+		Name:      "alternate_source_backing_off",
+		Help:      "Whether (1) or not (0) reads from an alternate CAS source are backing off due to failures.",
+	}, []string{sourceLabel})
+
+	p.batchQueueDepth = p.newGauge(batchSubsystem, "queue_depth",
+		"The number of operations in the batch queue.")
+	p.batchPendingOperations = p.newGauge(batchSubsystem, "pending_operations",
+		"The number of operations remaining in the queue after a batch is cut.")
+	p.observerLag = p.newGauge(observerSubsystem, "lag",
+		"The number of observed transactions which have not yet been processed.")
+
+	collectors := []prometheus.Collector{
+		p.processOperationTime, p.getProtocolVersionTime, p.parseOperationTime, p.validateOperationTime,
+		p.decorateOperationTime, p.addUnpublishedOperationTime, p.addOperationToBatchTime,
+		p.getCreateOperationResultTime, p.httpCreateUpdateTime, p.httpBulkCreateUpdateTime, p.httpResolveTime,
+		p.casWriteSize, p.casReadTime, p.casCacheHits, p.casCacheMisses, p.casAlternateSourceReads,
+		p.casAlternateSourceReadTime, p.casAlternateSourceBackingOff, p.batchQueueDepth, p.batchPendingOperations,
+		p.observerLag,
+	}
+
+	for _, c := range collectors {
+		if err := p.registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Registry returns the registry containing the metrics.
+func (p *Provider) Registry() *prometheus.Registry {
+	return p.registry
+}
+
+// ProcessOperation records the overall time to process operation.
+func (p *Provider) ProcessOperation(value time.Duration) {
+	p.processOperationTime.Observe(value.Seconds())
+}
+
+// GetProtocolVersionTime records the time to get protocol version.
+func (p *Provider) GetProtocolVersionTime(value time.Duration) {
+	p.getProtocolVersionTime.Observe(value.Seconds())
+}
+
+// ParseOperationTime records the time to parse operations.
+func (p *Provider) ParseOperationTime(value time.Duration) {
+	p.parseOperationTime.Observe(value.Seconds())
+}
+
+// ValidateOperationTime records the time to validate operation.
+func (p *Provider) ValidateOperationTime(value time.Duration) {
+	p.validateOperationTime.Observe(value.Seconds())
+}
+
+// DecorateOperationTime records the time to decorate operation.
+func (p *Provider) DecorateOperationTime(value time.Duration) {
+	p.decorateOperationTime.Observe(value.Seconds())
+}
+
+// AddUnpublishedOperationTime records the time to add unpublished operation.
+func (p *Provider) AddUnpublishedOperationTime(value time.Duration) {
+	p.addUnpublishedOperationTime.Observe(value.Seconds())
+}
+
+// AddOperationToBatchTime records the time to add operation to batch.
+func (p *Provider) AddOperationToBatchTime(value time.Duration) {
+	p.addOperationToBatchTime.Observe(value.Seconds())
+}
+
+// GetCreateOperationResultTime records the time to create operation result response.
+func (p *Provider) GetCreateOperationResultTime(value time.Duration) {
+	p.getCreateOperationResultTime.Observe(value.Seconds())
+}
+
+// HTTPCreateUpdateTime records the time rest call for create or update.
+func (p *Provider) HTTPCreateUpdateTime(value time.Duration) {
+	p.httpCreateUpdateTime.Observe(value.Seconds())
+}
+
+// HTTPBulkCreateUpdateTime records the time rest call for bulk create or update.
+func (p *Provider) HTTPBulkCreateUpdateTime(value time.Duration) {
+	p.httpBulkCreateUpdateTime.Observe(value.Seconds())
+}
+
+// HTTPResolveTime records the time rest call for resolve.
+func (p *Provider) HTTPResolveTime(value time.Duration) {
+	p.httpResolveTime.Observe(value.Seconds())
+}
+
+// CASWriteSize records the size of the data written to CAS.
+func (p *Provider) CASWriteSize(dataType string, size int) {
+	p.casWriteSize.WithLabelValues(dataType).Observe(float64(size))
+}
+
+// CASReadTime records the time to read data from CAS.
+func (p *Provider) CASReadTime(value time.Duration) {
+	p.casReadTime.Observe(value.Seconds())
+}
+
+// CASCacheHit records a CAS read of the given data type which was served from the given cache tier.
+func (p *Provider) CASCacheHit(dataType, tier string) {
+	p.casCacheHits.WithLabelValues(dataType, tier).Inc()
+}
+
+// CASCacheMiss records a CAS read of the given data type which was not found in the cache.
+func (p *Provider) CASCacheMiss(dataType string) {
+	p.casCacheMisses.WithLabelValues(dataType).Inc()
+}
+
+// CASAlternateSourceRead records a read from an alternate CAS source with the given result (success,
+// failure or invalid) and the time of the read.
+// Sources which weren't configured with WithAlternateSources are recorded as "other".
+func (p *Provider) CASAlternateSourceRead(source, result string, value time.Duration) {
+	source = p.sourceLabel(source)
+
+	p.casAlternateSourceReads.WithLabelValues(source, result).Inc()
+	p.casAlternateSourceReadTime.WithLabelValues(source).Observe(value.Seconds())
+}
+
+// CASAlternateSourceBackoff records whether or not reads from an alternate CAS source are backing off.
+// The backoff of sources which weren't configured with WithAlternateSources isn't recorded.
+func (p *Provider) CASAlternateSourceBackoff(source string, backingOff bool) {
+	if !p.allowedSources[source] {
+		return
+	}
+
+	value := 0.0
+	if backingOff {
+		value = 1
+	}
+
+	p.casAlternateSourceBackingOff.WithLabelValues(source).Set(value)
+}
+
+func (p *Provider) sourceLabel(source string) string {
+	if p.allowedSources[source] {
+		return source
+	}
+
+	return otherSource
+}
+
+// BatchQueueDepth records the number of operations in the batch queue.
+func (p *Provider) BatchQueueDepth(value uint) {
+	p.batchQueueDepth.Set(float64(value))
+}
+
+// BatchPendingOperations records the number of operations remaining in the queue after a batch is cut.
+func (p *Provider) BatchPendingOperations(value uint) {
+	p.batchPendingOperations.Set(float64(value))
+}
+
+// ObserverLag records the number of transactions which have been observed but not yet processed.
+func (p *Provider) ObserverLag(value int) {
+	p.observerLag.Set(float64(value))
+}
+
+// Handler returns an HTTP handler which serves the metrics in the Prometheus exposition format.
+func (p *Provider) Handler() *Handler {
+	return NewHandler(defaultPath, p.registry)
+}
+
+func (p *Provider) newTimer(subsystem, name, help string) prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: p.namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+		Buckets:   prometheus.DefBuckets,
+	})
+}
+
+func (p *Provider) newGauge(subsystem, name, help string) prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: p.namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	})
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+type Handler struct {
+	path    string
+	handler http.Handler
+}
+
+// NewHandler returns a new handler which serves the metrics in the given registry at the given path.
+func NewHandler(path string, gatherer prometheus.Gatherer) *Handler {
+	return &Handler{
+		path:    path,
+		handler: promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}),
+	}
+}
+
+// Path returns the context path.
+func (h *Handler) Path() string {
+	return h.path
+}
+
+// Method returns the HTTP method.
+func (h *Handler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the handler.
+func (h *Handler) Handler() common.HTTPRequestHandler {
+	return h.handler.ServeHTTP
+}
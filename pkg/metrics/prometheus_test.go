@@ -0,0 +1,98 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		p, err := New()
+		require.NoError(t, err)
+		require.NotNil(t, p.Registry())
+	})
+
+	t.Run("Duplicate registration", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+
+		_, err := New(WithRegistry(registry))
+		require.NoError(t, err)
+
+		_, err = New(WithRegistry(registry))
+		require.Error(t, err)
+
+		_, err = New(WithRegistry(registry), WithNamespace("other"))
+		require.NoError(t, err)
+	})
+}
+
+func TestProvider(t *testing.T) {
+	p, err := New(WithAlternateSources("https:orb.domain1.com"))
+	require.NoError(t, err)
+
+	p.ProcessOperation(time.Millisecond)
+	p.GetProtocolVersionTime(time.Millisecond)
+	p.ParseOperationTime(time.Millisecond)
+	p.ValidateOperationTime(time.Millisecond)
+	p.DecorateOperationTime(time.Millisecond)
+	p.AddUnpublishedOperationTime(time.Millisecond)
+	p.AddOperationToBatchTime(time.Millisecond)
+	p.GetCreateOperationResultTime(time.Millisecond)
+	p.HTTPCreateUpdateTime(time.Millisecond)
+	p.HTTPBulkCreateUpdateTime(time.Millisecond)
+	p.HTTPResolveTime(time.Millisecond)
+	p.CASWriteSize("core", 1024)
+	p.CASReadTime(time.Millisecond)
+	p.CASCacheHit("chunk", "memory")
+	p.CASCacheMiss("chunk")
+	p.CASAlternateSourceRead("https:orb.domain1.com", "failure", time.Millisecond)
+	p.CASAlternateSourceBackoff("https:orb.domain1.com", true)
+	p.CASAlternateSourceRead("https:unknown1.com", "success", time.Millisecond)
+	p.CASAlternateSourceRead("https:unknown2.com", "success", time.Millisecond)
+	p.CASAlternateSourceBackoff("https:unknown1.com", true)
+	p.BatchQueueDepth(7)
+	p.BatchPendingOperations(3)
+	p.ObserverLag(5)
+
+	h := p.Handler()
+	require.Equal(t, "/metrics", h.Path())
+	require.Equal(t, http.MethodGet, h.Method())
+
+	rw := httptest.NewRecorder()
+	h.Handler()(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	body, err := io.ReadAll(rw.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, string(body), "sidetree_operation_process_seconds_count 1")
+	require.Contains(t, string(body), "sidetree_http_resolve_seconds_count 1")
+	require.Contains(t, string(body), `sidetree_cas_write_size_bytes_count{type="core"} 1`)
+	require.Contains(t, string(body), "sidetree_cas_read_seconds_count 1")
+	require.Contains(t, string(body), `sidetree_cas_cache_hits_total{tier="memory",type="chunk"} 1`)
+	require.Contains(t, string(body), `sidetree_cas_cache_misses_total{type="chunk"} 1`)
+	require.Contains(t, string(body),
+		`sidetree_cas_alternate_source_reads_total{result="failure",source="https:orb.domain1.com"} 1`)
+	require.Contains(t, string(body),
+		`sidetree_cas_alternate_source_read_seconds_count{source="https:orb.domain1.com"} 1`)
+	require.Contains(t, string(body), `sidetree_cas_alternate_source_backing_off{source="https:orb.domain1.com"} 1`)
+	require.Contains(t, string(body),
+		`sidetree_cas_alternate_source_reads_total{result="success",source="other"} 2`)
+	require.NotContains(t, string(body), "unknown")
+	require.Contains(t, string(body), "sidetree_batch_queue_depth 7")
+	require.Contains(t, string(body), "sidetree_batch_pending_operations 3")
+	require.Contains(t, string(body), "sidetree_observer_lag 5")
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/trustbloc/sidetree-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-go/pkg/document"
@@ -19,6 +20,7 @@ import (
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doccomposer"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/model"
 
+	svcoperation "github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 )
 
@@ -38,6 +40,7 @@ type MockDocumentHandler struct {
 	namespace string
 	client    protocol.Client
 	store     map[string]document.Document
+	mutex     sync.RWMutex
 }
 
 // WithNamespace sets the namespace.
@@ -87,7 +90,21 @@ type Operation struct {
 }
 
 // ProcessOperation mocks process operation.
-func (m *MockDocumentHandler) ProcessOperation(operationBuffer []byte, _ uint64) (*document.ResolutionResult, error) {
+func (m *MockDocumentHandler) ProcessOperation(operationBuffer []byte, _ uint64,
+	_ ...svcoperation.ProcessOption) (*document.ResolutionResult, error) {
+	return m.processOperation(operationBuffer, true)
+}
+
+// DryRunOperation mocks a dry run of an operation. The resulting document is returned but not stored.
+func (m *MockDocumentHandler) DryRunOperation(operationBuffer []byte, _ uint64,
+	_ ...svcoperation.ProcessOption) (*document.ResolutionResult, error) {
+	return m.processOperation(operationBuffer, false)
+}
+
+func (m *MockDocumentHandler) processOperation(operationBuffer []byte, commit bool) (*document.ResolutionResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -116,16 +133,21 @@ func (m *MockDocumentHandler) ProcessOperation(operationBuffer []byte, _ uint64)
 	if op.Operation == operation.TypeDeactivate {
 		empty := applyID(make(document.Document), id)
 		empty[deleted] = true
-		m.store[id] = empty
+
+		if commit {
+			m.store[id] = empty
+		}
 
 		return &document.ResolutionResult{
 			Document: empty,
 		}, nil
 	}
 
-	doc, ok := m.store[id]
-	if !ok { // create operation
-		doc = make(document.Document)
+	doc := make(document.Document)
+	if existing, ok := m.store[id]; ok {
+		for k, v := range existing {
+			doc[k] = v
+		}
 	}
 
 	doc, err = doccomposer.New().ApplyPatches(doc, op.Delta.Patches)
@@ -135,7 +157,9 @@ func (m *MockDocumentHandler) ProcessOperation(operationBuffer []byte, _ uint64)
 
 	doc = applyID(doc, id)
 
-	m.store[id] = doc
+	if commit {
+		m.store[id] = doc
+	}
 
 	return &document.ResolutionResult{
 		Document: doc,
@@ -167,6 +191,9 @@ func (m *MockDocumentHandler) ResolveDocument(didOrDocument string, opts ...docu
 		return m.resolveWithInitialState(did, initial)
 	}
 
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
 	if _, ok := m.store[didOrDocument]; !ok {
 		return nil, errors.New("not found")
 	}
@@ -47,6 +47,10 @@ func (m *MetricsProvider) GetCreateOperationResultTime(value time.Duration) {
 func (m *MetricsProvider) HTTPCreateUpdateTime(value time.Duration) {
 }
 
+// HTTPBulkCreateUpdateTime records the time rest call for bulk create or update.
+func (m *MetricsProvider) HTTPBulkCreateUpdateTime(value time.Duration) {
+}
+
 // HTTPResolveTime records the time rest call for resolve.
 func (m *MetricsProvider) HTTPResolveTime(value time.Duration) {
 }
@@ -54,3 +58,35 @@ func (m *MetricsProvider) HTTPResolveTime(value time.Duration) {
 // CASWriteSize records the size of the data written to CAS.
 func (m *MetricsProvider) CASWriteSize(dataType string, size int) {
 }
+
+// CASReadTime records the time to read data from CAS.
+func (m *MetricsProvider) CASReadTime(value time.Duration) {
+}
+
+// CASCacheHit records a CAS read which was served from the cache.
+func (m *MetricsProvider) CASCacheHit(dataType, tier string) {
+}
+
+// CASCacheMiss records a CAS read which was not found in the cache.
+func (m *MetricsProvider) CASCacheMiss(dataType string) {
+}
+
+// CASAlternateSourceRead records a read from an alternate CAS source.
+func (m *MetricsProvider) CASAlternateSourceRead(source, result string, value time.Duration) {
+}
+
+// CASAlternateSourceBackoff records whether or not reads from an alternate CAS source are backing off.
+func (m *MetricsProvider) CASAlternateSourceBackoff(source string, backingOff bool) {
+}
+
+// BatchQueueDepth records the number of operations in the batch queue.
+func (m *MetricsProvider) BatchQueueDepth(value uint) {
+}
+
+// BatchPendingOperations records the number of operations remaining in the queue after a batch is cut.
+func (m *MetricsProvider) BatchPendingOperations(value uint) {
+}
+
+// ObserverLag records the number of transactions which have been observed but not yet processed.
+func (m *MetricsProvider) ObserverLag(value int) {
+}
@@ -7,16 +7,24 @@ SPDX-License-Identifier: Apache-2.0
 package observer
 
 import (
+	"context"
+
 	"github.com/trustbloc/logutil-go/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-go/pkg/api/operation"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
 	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
 )
 
-var logger = log.New("sidetree-svc-observer")
+var (
+	logger = log.New("sidetree-svc-observer")
+	tracer = otel.Tracer("sidetree-svc-observer")
+)
 
 // Ledger interface to access ledger txn.
 type Ledger interface {
@@ -33,6 +41,10 @@ type OperationFilter interface {
 	Filter(uniqueSuffix string, ops []*operation.AnchoredOperation) ([]*operation.AnchoredOperation, error)
 }
 
+type metricsProvider interface {
+	ObserverLag(value int)
+}
+
 // Providers contains all of the providers required by the TxnProcessor.
 type Providers struct {
 	Ledger                 Ledger
@@ -43,15 +55,34 @@ type Providers struct {
 type Observer struct {
 	*Providers
 
-	stopCh chan struct{}
+	stopCh  chan struct{}
+	metrics metricsProvider
+}
+
+// Option is an observer option.
+type Option func(o *Observer)
+
+// WithMetricsProvider sets the provider which records the observer lag, i.e. the number of
+// transactions which were received but not yet processed.
+func WithMetricsProvider(metrics metricsProvider) Option {
+	return func(o *Observer) {
+		o.metrics = metrics
+	}
 }
 
 // New returns a new observer.
-func New(providers *Providers) *Observer {
-	return &Observer{
+func New(providers *Providers, opts ...Option) *Observer {
+	o := &Observer{
 		Providers: providers,
 		stopCh:    make(chan struct{}, 1),
+		metrics:   &noopMetricsProvider{},
+	}
+
+	for _, opt := range opts {
+		opt(o)
 	}
+
+	return o
 }
 
 // Start starts observer routines.
@@ -85,29 +116,61 @@ func (o *Observer) listen(txnsCh <-chan []txn.SidetreeTxn) {
 }
 
 func (o *Observer) process(txns []txn.SidetreeTxn) {
-	for _, txn := range txns {
-		pc, err := o.ProtocolClientProvider.ForNamespace(txn.Namespace)
-		if err != nil {
-			logger.Warn("Failed to get protocol client for namespace", logfields.WithNamespace(txn.Namespace), log.WithError(err))
+	defer o.metrics.ObserverLag(0)
 
-			continue
-		}
+	for i, txn := range txns {
+		o.metrics.ObserverLag(len(txns) - i)
+
+		o.processTxn(txn)
+	}
+}
 
-		v, err := pc.Get(txn.ProtocolVersion)
-		if err != nil {
-			logger.Warn("Failed to get processor for transaction time", logfields.WithGenesisTime(txn.ProtocolVersion),
-				log.WithError(err))
+// processTxn processes the given transaction. Each transaction is processed in a new trace.
+func (o *Observer) processTxn(sidetreeTxn txn.SidetreeTxn) {
+	ctx, span := tracer.Start(context.Background(), "Observer.processTxn",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(tracing.WithNamespace(sidetreeTxn.Namespace), tracing.WithAnchorString(sidetreeTxn.AnchorString),
+			tracing.WithTransactionNumber(sidetreeTxn.TransactionNumber)))
+	defer span.End()
 
-			continue
-		}
+	pc, err := o.ProtocolClientProvider.ForNamespace(sidetreeTxn.Namespace)
+	if err != nil {
+		logger.Warn("Failed to get protocol client for namespace", logfields.WithNamespace(sidetreeTxn.Namespace),
+			log.WithError(tracing.RecordError(span, err)))
 
-		_, err = v.TransactionProcessor().Process(txn)
-		if err != nil {
-			logger.Warn("Failed to process anchor", logfields.WithAnchorString(txn.AnchorString), log.WithError(err))
+		return
+	}
 
-			continue
-		}
+	v, err := pc.Get(sidetreeTxn.ProtocolVersion)
+	if err != nil {
+		logger.Warn("Failed to get processor for transaction time", logfields.WithGenesisTime(sidetreeTxn.ProtocolVersion),
+			log.WithError(tracing.RecordError(span, err)))
+
+		return
+	}
+
+	_, err = processTxn(ctx, v.TransactionProcessor(), sidetreeTxn)
+	if err != nil {
+		logger.Warn("Failed to process anchor", logfields.WithAnchorString(sidetreeTxn.AnchorString),
+			log.WithError(tracing.RecordError(span, err)))
 
-		logger.Debug("Successfully processed anchor", logfields.WithAnchorString(txn.AnchorString))
+		return
 	}
+
+	logger.Debug("Successfully processed anchor", logfields.WithAnchorString(sidetreeTxn.AnchorString))
 }
+
+// processTxn processes the transaction, passing the context to the transaction processor if it accepts one.
+//
+//nolint:gocritic
+func processTxn(ctx context.Context, tp protocol.TxnProcessor, sidetreeTxn txn.SidetreeTxn) (int, error) {
+	if p, ok := tp.(protocol.TxnProcessorWithContext); ok {
+		return p.ProcessWithContext(ctx, sidetreeTxn)
+	}
+
+	return tp.Process(sidetreeTxn)
+}
+
+type noopMetricsProvider struct{}
+
+func (m *noopMetricsProvider) ObserverLag(int) {}
@@ -7,15 +7,20 @@ SPDX-License-Identifier: Apache-2.0
 package observer
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-go/pkg/api/operation"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing/tracingtest"
 	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprocessor"
 )
@@ -73,6 +78,84 @@ func TestStartObserver(t *testing.T) {
 
 		require.Equal(t, 1, tp.ProcessCallCount())
 	})
+
+	t.Run("observer lag", func(t *testing.T) {
+		tp := &mocks.TxnProcessor{}
+
+		pc := mocks.NewMockProtocolClient()
+		pc.Versions[0].TransactionProcessorReturns(tp)
+
+		providers := &Providers{
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider().WithProtocolClient(namespace1, pc),
+		}
+
+		metrics := &mockMetrics{}
+
+		o := New(providers, WithMetricsProvider(metrics))
+
+		o.process([]txn.SidetreeTxn{
+			{Namespace: namespace1, AnchorString: "1.address"},
+			{Namespace: namespace1, TransactionNumber: 1, AnchorString: "2.address"},
+		})
+
+		require.Equal(t, []int{2, 1, 0}, metrics.lag)
+	})
+
+	t.Run("tracing", func(t *testing.T) {
+		exporter := tracingtest.Exporter()
+
+		tp := &txnProcessorWithContext{
+			TxnProcessor:            &mocks.TxnProcessor{},
+			TxnProcessorWithContext: &mocks.TxnProcessorWithContext{},
+		}
+		tp.ProcessWithContextReturnsOnCall(1, 0, errors.New("injected error"))
+
+		pc := mocks.NewMockProtocolClient()
+		pc.Versions[0].TransactionProcessorReturns(tp)
+
+		providers := &Providers{
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider().WithProtocolClient(namespace1, pc),
+		}
+
+		o := New(providers)
+
+		o.process([]txn.SidetreeTxn{
+			{Namespace: namespace1, AnchorString: "1.address"},
+			{Namespace: namespace1, TransactionNumber: 1, AnchorString: "2.address"},
+		})
+
+		// The context-aware method is preferred.
+		require.Zero(t, tp.ProcessCallCount())
+		require.Equal(t, 2, tp.ProcessWithContextCallCount())
+
+		spans := tracingtest.Spans(exporter, "Observer.processTxn")
+		require.Len(t, spans, 2)
+		require.Contains(t, spans[0].Attributes, tracing.WithAnchorString("1.address"))
+		require.Equal(t, codes.Unset, spans[0].Status.Code)
+		require.Contains(t, spans[1].Attributes, tracing.WithAnchorString("2.address"))
+		require.Equal(t, codes.Error, spans[1].Status.Code)
+
+		// Each transaction is processed in its own trace.
+		require.NotEqual(t, spans[0].SpanContext.TraceID(), spans[1].SpanContext.TraceID())
+
+		// The transaction processor is invoked with the context of the span.
+		ctx, _, _ := tp.ProcessWithContextArgsForCall(0)
+		require.Equal(t, spans[0].SpanContext.SpanID(), trace.SpanContextFromContext(ctx).SpanID())
+	})
+}
+
+// txnProcessorWithContext is a transaction processor which accepts a context.
+type txnProcessorWithContext struct {
+	*mocks.TxnProcessor
+	*mocks.TxnProcessorWithContext
+}
+
+type mockMetrics struct {
+	lag []int
+}
+
+func (m *mockMetrics) ObserverLag(value int) {
+	m.lag = append(m.lag, value)
 }
 
 func TestTxnProcessor_Process(t *testing.T) {
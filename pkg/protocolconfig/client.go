@@ -0,0 +1,119 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package protocolconfig
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/factory"
+)
+
+// Client returns the protocol versions of a namespace.
+type Client struct {
+	namespace string
+	versions  []protocol.Version // ordered by genesis time
+}
+
+// Current returns the latest protocol version.
+func (c *Client) Current() (protocol.Version, error) {
+	return c.versions[len(c.versions)-1], nil
+}
+
+// Get returns the protocol version at the given transaction time.
+func (c *Client) Get(transactionTime uint64) (protocol.Version, error) {
+	for i := len(c.versions) - 1; i >= 0; i-- {
+		if transactionTime >= c.versions[i].Protocol().GenesisTime {
+			return c.versions[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("protocol parameters are not defined for namespace [%s] and anchoring time: %d",
+		c.namespace, transactionTime)
+}
+
+// ClientProvider returns the protocol client of a configured namespace.
+type ClientProvider struct {
+	clients map[string]*Client
+}
+
+// New validates the configuration and creates the protocol versions of all namespaces. The given providers
+// are shared by all versions and the given options apply to all versions (before the components configured
+// for each version).
+func New(cfg *Config, providers *factory.Providers, opts ...factory.Option) (*ClientProvider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	clients := make(map[string]*Client, len(cfg.Namespaces))
+
+	for _, ns := range cfg.Namespaces {
+		c := &Client{namespace: ns.Namespace}
+
+		for _, vc := range ns.Versions {
+			vopts := append(append([]factory.Option{}, opts...), vc.Components.options()...)
+
+			v, err := factory.New(vc.Protocol, providers, vopts...)
+			if err != nil {
+				return nil, fmt.Errorf("create protocol version [%s] for namespace [%s] at genesis time %d: %w",
+					vc.Version, ns.Namespace, vc.GenesisTime, err)
+			}
+
+			c.versions = append(c.versions, v)
+		}
+
+		clients[ns.Namespace] = c
+	}
+
+	return &ClientProvider{clients: clients}, nil
+}
+
+// ForNamespace returns the protocol client for the given namespace.
+func (p *ClientProvider) ForNamespace(namespace string) (protocol.Client, error) {
+	c, ok := p.clients[namespace]
+	if !ok {
+		return nil, fmt.Errorf("protocol client not found for namespace [%s]", namespace)
+	}
+
+	return c, nil
+}
+
+// Namespaces returns the configured namespaces in alphabetical order.
+func (p *ClientProvider) Namespaces() []string {
+	namespaces := make([]string, 0, len(p.clients))
+
+	for ns := range p.clients {
+		namespaces = append(namespaces, ns)
+	}
+
+	sort.Strings(namespaces)
+
+	return namespaces
+}
+
+func (c *ComponentsConfig) options() []factory.Option {
+	var opts []factory.Option
+
+	if c.DocumentType != "" {
+		opts = append(opts, factory.WithDocumentType(c.DocumentType))
+	}
+
+	if len(c.MethodContext) > 0 {
+		opts = append(opts, factory.WithMethodContext(c.MethodContext))
+	}
+
+	if c.IncludeBase {
+		opts = append(opts, factory.WithBase(true))
+	}
+
+	if c.MaxChunkFiles > 0 {
+		opts = append(opts, factory.WithMaxChunkFiles(c.MaxChunkFiles))
+	}
+
+	return opts
+}
@@ -0,0 +1,90 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package protocolconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doctransformer/doctransformer"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/factory"
+)
+
+func TestNew(t *testing.T) {
+	providers := &factory.Providers{
+		CAS:         mocks.NewMockCasClient(nil),
+		OpStore:     &mockOperationStore{},
+		Compression: compression.New(compression.WithDefaultAlgorithms()),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		cp, err := New(newTestConfig(t), providers)
+		require.NoError(t, err)
+		require.Equal(t, []string{"did:generic", "did:sidetree"}, cp.Namespaces())
+
+		pc, err := cp.ForNamespace("did:sidetree")
+		require.NoError(t, err)
+
+		current, err := pc.Current()
+		require.NoError(t, err)
+		require.Equal(t, uint64(500), current.Protocol().GenesisTime)
+		require.Equal(t, factory.Version, current.Version())
+
+		v, err := pc.Get(499)
+		require.NoError(t, err)
+		require.Equal(t, uint(100), v.Protocol().MaxOperationCount)
+
+		v, err = pc.Get(500)
+		require.NoError(t, err)
+		require.Equal(t, uint(1000), v.Protocol().MaxOperationCount)
+
+		pc, err = cp.ForNamespace("did:generic")
+		require.NoError(t, err)
+
+		_, err = pc.Get(9)
+		require.EqualError(t, err,
+			"protocol parameters are not defined for namespace [did:generic] and anchoring time: 9")
+
+		v, err = pc.Get(10)
+		require.NoError(t, err)
+		require.IsType(t, &doctransformer.Transformer{}, v.DocumentTransformer())
+	})
+
+	t.Run("unknown namespace", func(t *testing.T) {
+		cp, err := New(newTestConfig(t), providers)
+		require.NoError(t, err)
+
+		_, err = cp.ForNamespace("did:unknown")
+		require.EqualError(t, err, "protocol client not found for namespace [did:unknown]")
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Namespaces[0].Versions[0].MaxOperationCount = 0
+
+		_, err := New(cfg, providers)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "maxOperationCount must be greater than zero")
+	})
+
+	t.Run("factory error", func(t *testing.T) {
+		_, err := New(newTestConfig(t), providers, factory.WithDocumentType("unknown"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create protocol version [1.0] for namespace [did:sidetree] at genesis time 0")
+		require.Contains(t, err.Error(), "unsupported document type [unknown]")
+	})
+}
+
+type mockOperationStore struct{}
+
+func (m *mockOperationStore) Put([]*operation.AnchoredOperation) error {
+	return nil
+}
@@ -0,0 +1,276 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package protocolconfig loads the protocol versions of one or more namespaces from a YAML or JSON file and
+// builds a protocol client provider from them.
+//
+// Example (YAML):
+//
+//	namespaces:
+//	  - namespace: did:sidetree
+//	    versions:
+//	      - version: "1.0"
+//	        genesisTime: 0
+//	        multihashAlgorithms: [18]
+//	        maxOperationCount: 10000
+//	        ...
+//	        components:
+//	          documentType: did
+//	          maxChunkFiles: 4
+//
+// The protocol parameters of a version have the same names as the JSON fields of the Sidetree protocol.
+package protocolconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	coreprotocol "github.com/trustbloc/sidetree-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/factory"
+)
+
+// Format is the format of a configuration file.
+type Format string
+
+const (
+	// FormatJSON is the JSON format.
+	FormatJSON Format = "json"
+	// FormatYAML is the YAML format.
+	FormatYAML Format = "yaml"
+)
+
+// Config contains the protocol versions of one or more namespaces.
+type Config struct {
+	Namespaces []*NamespaceConfig `json:"namespaces"`
+}
+
+// NamespaceConfig contains the protocol versions of a namespace.
+type NamespaceConfig struct {
+	Namespace string           `json:"namespace"`
+	Versions  []*VersionConfig `json:"versions"`
+}
+
+// VersionConfig contains the parameters of a protocol version and the components which implement it.
+type VersionConfig struct {
+	// Version is the implementation of the protocol (e.g. "1.0").
+	Version string `json:"version"`
+
+	coreprotocol.Protocol
+
+	Components ComponentsConfig `json:"components"`
+}
+
+// ComponentsConfig configures the components of a protocol version.
+type ComponentsConfig struct {
+	// DocumentType is either "did" (default) or "generic".
+	DocumentType factory.DocumentType `json:"documentType,omitempty"`
+	// MethodContext contains additional JSON-LD contexts of resolved DID documents.
+	MethodContext []string `json:"methodContext,omitempty"`
+	// IncludeBase includes @base in the context of resolved DID documents.
+	IncludeBase bool `json:"includeBase,omitempty"`
+	// MaxChunkFiles is the maximum number of chunk files per batch (default 1).
+	MaxChunkFiles int `json:"maxChunkFiles,omitempty"`
+}
+
+// Load loads the configuration from the given file. The format is determined by the file extension
+// (.json, .yaml or .yml).
+func Load(path string) (*Config, error) {
+	var format Format
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = FormatJSON
+	case ".yaml", ".yml":
+		format = FormatYAML
+	default:
+		return nil, fmt.Errorf("unsupported protocol configuration file extension [%s]", filepath.Ext(path))
+	}
+
+	content, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("read protocol configuration: %w", err)
+	}
+
+	return Parse(content, format)
+}
+
+// Parse parses and validates the given configuration. Unknown fields are rejected so that misspelled
+// parameters aren't silently ignored.
+func Parse(content []byte, format Format) (*Config, error) {
+	switch format {
+	case FormatJSON:
+	case FormatYAML:
+		var err error
+
+		content, err = yamlToJSON(content)
+		if err != nil {
+			return nil, fmt.Errorf("parse protocol configuration: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported protocol configuration format [%s]", format)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	cfg := &Config{}
+
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("parse protocol configuration: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// yamlToJSON converts YAML to JSON so that the JSON field names of the protocol parameters apply to both formats.
+func yamlToJSON(content []byte) ([]byte, error) {
+	var value interface{}
+
+	if err := yaml.Unmarshal(content, &value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// Validate checks that the configuration is complete and consistent: namespaces are unique, the versions of
+// each namespace are ordered by genesis time and the parameters of each version are usable.
+func (c *Config) Validate() error {
+	var errs []string
+
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if len(c.Namespaces) == 0 {
+		addErr("no namespaces defined")
+	}
+
+	namespaces := make(map[string]bool)
+
+	for i, ns := range c.Namespaces {
+		if ns.Namespace == "" {
+			addErr("namespaces[%d]: namespace is required", i)
+
+			continue
+		}
+
+		if namespaces[ns.Namespace] {
+			addErr("namespace [%s]: duplicate namespace", ns.Namespace)
+
+			continue
+		}
+
+		namespaces[ns.Namespace] = true
+
+		if len(ns.Versions) == 0 {
+			addErr("namespace [%s]: no protocol versions defined", ns.Namespace)
+		}
+
+		for j, v := range ns.Versions {
+			if j > 0 && v.GenesisTime <= ns.Versions[j-1].GenesisTime {
+				addErr("namespace [%s] versions[%d]: genesis time %d must be greater than genesis time %d of the previous version",
+					ns.Namespace, j, v.GenesisTime, ns.Versions[j-1].GenesisTime)
+			}
+
+			for _, err := range v.validate() {
+				addErr("namespace [%s] versions[%d]: %s", ns.Namespace, j, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid protocol configuration: [%s]", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (v *VersionConfig) validate() []error {
+	var errs []error
+
+	if v.Version != factory.Version {
+		errs = append(errs, fmt.Errorf("unsupported version [%s]", v.Version))
+	}
+
+	if len(v.MultihashAlgorithms) == 0 {
+		errs = append(errs, errors.New("multihashAlgorithms is required"))
+	}
+
+	for _, code := range v.MultihashAlgorithms {
+		if _, err := hashing.GetHashFromMultihash(code); err != nil {
+			errs = append(errs, fmt.Errorf("multihashAlgorithms[%d]: %w", code, err))
+		}
+	}
+
+	required := []struct {
+		name  string
+		value uint
+	}{
+		{"maxOperationCount", v.MaxOperationCount},
+		{"maxOperationSize", v.MaxOperationSize},
+		{"maxOperationHashLength", v.MaxOperationHashLength},
+		{"maxDeltaSize", v.MaxDeltaSize},
+		{"maxCasUriLength", v.MaxCasURILength},
+		{"maxCoreIndexFileSize", v.MaxCoreIndexFileSize},
+		{"maxProofFileSize", v.MaxProofFileSize},
+		{"maxProvisionalIndexFileSize", v.MaxProvisionalIndexFileSize},
+		{"maxChunkFileSize", v.MaxChunkFileSize},
+		{"maxMemoryDecompressionFactor", v.MaxMemoryDecompressionFactor},
+	}
+
+	for _, r := range required {
+		if r.value == 0 {
+			errs = append(errs, fmt.Errorf("%s must be greater than zero", r.name))
+		}
+	}
+
+	if v.MaxDeltaSize > 0 && v.MaxDeltaSize >= v.MaxOperationSize {
+		errs = append(errs, fmt.Errorf("maxDeltaSize[%d] must be less than maxOperationSize[%d]",
+			v.MaxDeltaSize, v.MaxOperationSize))
+	}
+
+	if v.CompressionAlgorithm == "" {
+		errs = append(errs, errors.New("compressionAlgorithm is required"))
+	}
+
+	if len(v.SignatureAlgorithms) == 0 {
+		errs = append(errs, errors.New("signatureAlgorithms is required"))
+	}
+
+	if len(v.KeyAlgorithms) == 0 {
+		errs = append(errs, errors.New("keyAlgorithms is required"))
+	}
+
+	if len(v.Patches) == 0 {
+		errs = append(errs, errors.New("patches is required"))
+	}
+
+	switch v.Components.DocumentType {
+	case "", factory.DIDDocument, factory.GenericDocument:
+	default:
+		errs = append(errs, fmt.Errorf("components: unsupported document type [%s]", v.Components.DocumentType))
+	}
+
+	if v.Components.MaxChunkFiles < 0 {
+		errs = append(errs, errors.New("components: maxChunkFiles must not be negative"))
+	}
+
+	return errs
+}
@@ -0,0 +1,265 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package protocolconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/factory"
+)
+
+const yamlConfig = `
+namespaces:
+  - namespace: did:sidetree
+    versions:
+      - version: "1.0"
+        genesisTime: 0
+        multihashAlgorithms: [18]
+        maxOperationCount: 100
+        maxOperationSize: 2500
+        maxOperationHashLength: 100
+        maxDeltaSize: 1000
+        maxCasUriLength: 100
+        compressionAlgorithm: GZIP
+        maxCoreIndexFileSize: 1000000
+        maxProofFileSize: 2500000
+        maxProvisionalIndexFileSize: 1000000
+        maxChunkFileSize: 10000000
+        patches: [add-public-keys, remove-public-keys, add-services, remove-services, ietf-json-patch]
+        signatureAlgorithms: [EdDSA, ES256]
+        keyAlgorithms: [Ed25519, P-256]
+        maxMemoryDecompressionFactor: 3
+      - version: "1.0"
+        genesisTime: 500
+        multihashAlgorithms: [18]
+        maxOperationCount: 1000
+        maxOperationSize: 2500
+        maxOperationHashLength: 100
+        maxDeltaSize: 1000
+        maxCasUriLength: 100
+        compressionAlgorithm: ZSTD
+        maxCoreIndexFileSize: 1000000
+        maxProofFileSize: 2500000
+        maxProvisionalIndexFileSize: 1000000
+        maxChunkFileSize: 10000000
+        patches: [add-public-keys, remove-public-keys, add-services, remove-services, ietf-json-patch]
+        signatureAlgorithms: [EdDSA, ES256]
+        keyAlgorithms: [Ed25519, P-256]
+        maxMemoryDecompressionFactor: 3
+        components:
+          maxChunkFiles: 4
+  - namespace: did:generic
+    versions:
+      - version: "1.0"
+        genesisTime: 10
+        multihashAlgorithms: [18, 19]
+        maxOperationCount: 10
+        maxOperationSize: 2500
+        maxOperationHashLength: 100
+        maxDeltaSize: 1000
+        maxCasUriLength: 100
+        compressionAlgorithm: GZIP
+        maxCoreIndexFileSize: 1000000
+        maxProofFileSize: 2500000
+        maxProvisionalIndexFileSize: 1000000
+        maxChunkFileSize: 10000000
+        patches: [ietf-json-patch]
+        signatureAlgorithms: [EdDSA]
+        keyAlgorithms: [Ed25519]
+        maxMemoryDecompressionFactor: 3
+        components:
+          documentType: generic
+`
+
+const jsonConfig = `{
+  "namespaces": [{
+    "namespace": "did:sidetree",
+    "versions": [{
+      "version": "1.0",
+      "genesisTime": 0,
+      "multihashAlgorithms": [18],
+      "maxOperationCount": 100,
+      "maxOperationSize": 2500,
+      "maxOperationHashLength": 100,
+      "maxDeltaSize": 1000,
+      "maxCasUriLength": 100,
+      "compressionAlgorithm": "GZIP",
+      "maxCoreIndexFileSize": 1000000,
+      "maxProofFileSize": 2500000,
+      "maxProvisionalIndexFileSize": 1000000,
+      "maxChunkFileSize": 10000000,
+      "patches": ["ietf-json-patch"],
+      "signatureAlgorithms": ["EdDSA"],
+      "keyAlgorithms": ["Ed25519"],
+      "maxMemoryDecompressionFactor": 3,
+      "components": {"methodContext": ["https://example.com/ctx"], "includeBase": true}
+    }]
+  }]
+}`
+
+func TestParse(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		cfg, err := Parse([]byte(yamlConfig), FormatYAML)
+		require.NoError(t, err)
+		require.Len(t, cfg.Namespaces, 2)
+
+		ns := cfg.Namespaces[0]
+		require.Equal(t, "did:sidetree", ns.Namespace)
+		require.Len(t, ns.Versions, 2)
+		require.Equal(t, "1.0", ns.Versions[0].Version)
+		require.Equal(t, uint(100), ns.Versions[0].MaxOperationCount)
+		require.Equal(t, []uint{18}, ns.Versions[0].MultihashAlgorithms)
+		require.Equal(t, uint64(500), ns.Versions[1].GenesisTime)
+		require.Equal(t, "ZSTD", ns.Versions[1].CompressionAlgorithm)
+		require.Equal(t, 4, ns.Versions[1].Components.MaxChunkFiles)
+		require.Equal(t, factory.GenericDocument, cfg.Namespaces[1].Versions[0].Components.DocumentType)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		cfg, err := Parse([]byte(jsonConfig), FormatJSON)
+		require.NoError(t, err)
+		require.Len(t, cfg.Namespaces, 1)
+		require.Equal(t, uint(10000000), cfg.Namespaces[0].Versions[0].MaxChunkFileSize)
+		require.True(t, cfg.Namespaces[0].Versions[0].Components.IncludeBase)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := Parse([]byte(`{"namespaces":[{"namespace":"ns","versionz":[]}]}`), FormatJSON)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown field "versionz"`)
+	})
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		_, err := Parse([]byte("namespaces: ["), FormatYAML)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse protocol configuration")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Parse([]byte(jsonConfig), "xml")
+		require.EqualError(t, err, "unsupported protocol configuration format [xml]")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("YAML", func(t *testing.T) {
+		path := filepath.Join(dir, "protocol.yml")
+		require.NoError(t, os.WriteFile(path, []byte(yamlConfig), 0o600))
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		require.Len(t, cfg.Namespaces, 2)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		path := filepath.Join(dir, "protocol.json")
+		require.NoError(t, os.WriteFile(path, []byte(jsonConfig), 0o600))
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		require.Len(t, cfg.Namespaces, 1)
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		_, err := Load(filepath.Join(dir, "protocol.txt"))
+		require.EqualError(t, err, "unsupported protocol configuration file extension [.txt]")
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		_, err := Load(filepath.Join(dir, "missing.yaml"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "read protocol configuration")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("no namespaces", func(t *testing.T) {
+		require.EqualError(t, (&Config{}).Validate(), "invalid protocol configuration: [no namespaces defined]")
+	})
+
+	t.Run("genesis times not ordered", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Namespaces[0].Versions[1].GenesisTime = 0
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(),
+			"namespace [did:sidetree] versions[1]: genesis time 0 must be greater than genesis time 0 of the previous version")
+	})
+
+	t.Run("duplicate namespace", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Namespaces[1].Namespace = cfg.Namespaces[0].Namespace
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "namespace [did:sidetree]: duplicate namespace")
+	})
+
+	t.Run("missing namespace and versions", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Namespaces[0].Namespace = ""
+		cfg.Namespaces[1].Versions = nil
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "namespaces[0]: namespace is required")
+		require.Contains(t, err.Error(), "namespace [did:generic]: no protocol versions defined")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		v := cfg.Namespaces[0].Versions[0]
+		v.Version = "2.0"
+		v.MultihashAlgorithms = []uint{18, 999}
+		v.MaxOperationCount = 0
+		v.MaxDeltaSize = 3000
+		v.CompressionAlgorithm = ""
+		v.SignatureAlgorithms = nil
+		v.KeyAlgorithms = nil
+		v.Patches = nil
+		v.Components.DocumentType = "xml"
+		v.Components.MaxChunkFiles = -1
+
+		err := cfg.Validate()
+		require.Error(t, err)
+
+		for _, msg := range []string{
+			"unsupported version [2.0]",
+			"multihashAlgorithms[999]: algorithm not supported, unable to compute hash",
+			"maxOperationCount must be greater than zero",
+			"maxDeltaSize[3000] must be less than maxOperationSize[2500]",
+			"compressionAlgorithm is required",
+			"signatureAlgorithms is required",
+			"keyAlgorithms is required",
+			"patches is required",
+			"components: unsupported document type [xml]",
+			"components: maxChunkFiles must not be negative",
+		} {
+			require.Contains(t, err.Error(), "namespace [did:sidetree] versions[0]: "+msg)
+		}
+
+		// Only the invalid version is reported.
+		require.NotContains(t, err.Error(), "versions[1]")
+	})
+}
+
+func newTestConfig(t *testing.T) *Config {
+	t.Helper()
+
+	cfg, err := Parse([]byte(yamlConfig), FormatYAML)
+	require.NoError(t, err)
+
+	return cfg
+}
@@ -29,3 +29,8 @@ func (e *HTTPError) Error() string {
 func (e *HTTPError) Status() int {
 	return e.status
 }
+
+// Unwrap returns the underlying error.
+func (e *HTTPError) Unwrap() error {
+	return e.err
+}
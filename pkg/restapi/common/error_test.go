@@ -20,4 +20,5 @@ func TestNewHTTPError(t *testing.T) {
 	require.NotNil(t, err)
 	require.Equal(t, http.StatusBadRequest, err.Status())
 	require.Equal(t, errExpected.Error(), err.Error())
+	require.True(t, errors.Is(err, errExpected))
 }
@@ -0,0 +1,38 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diddochandler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/dochandler"
+)
+
+// BulkUpdateHandler handles the creation and update of multiple DID documents in a single request.
+type BulkUpdateHandler struct {
+	*handler
+}
+
+type bulkMetricsProvider interface {
+	HTTPBulkCreateUpdateTime(duration time.Duration)
+}
+
+// NewBulkUpdateHandler returns a new DID document bulk update handler. The handler is served
+// at the 'bulk' sub-path of the given base path.
+func NewBulkUpdateHandler(basePath string, processor dochandler.Processor, pc protocol.Client,
+	metrics bulkMetricsProvider, opts ...dochandler.BulkOption) *BulkUpdateHandler {
+	return &BulkUpdateHandler{
+		handler: newHandler(
+			fmt.Sprintf("%s/bulk", basePath),
+			http.MethodPost,
+			dochandler.NewBulkUpdateHandler(processor, pc, metrics, opts...).Update,
+		),
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diddochandler
+
+import (
+	"fmt"
+	"strings"
+
+	svcdochandler "github.com/trustbloc/sidetree-svc-go/pkg/dochandler"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/common"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/dochandler"
+)
+
+const (
+	operationsSegment  = "operations"
+	identifiersSegment = "identifiers"
+)
+
+type routesMetricsProvider interface {
+	metricsProvider
+	bulkMetricsProvider
+	metricsResolveProvider
+}
+
+// NewRoutes returns the DID document handlers of all of the namespaces in the given registry. For each
+// namespace the following routes are served under the path of the namespace:
+//
+//	POST {basePath}/{path}/operations
+//	POST {basePath}/{path}/operations/bulk
+//	GET  {basePath}/{path}/identifiers/{id}
+//
+// In addition, GET {basePath}/identifiers/{id} resolves a DID of any of the namespaces by its prefix.
+func NewRoutes(basePath string, registry *svcdochandler.Registry, metrics routesMetricsProvider,
+	updateOpts []dochandler.UpdateOption, bulkOpts []dochandler.BulkOption) ([]common.HTTPHandler, error) {
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	var routes []common.HTTPHandler
+
+	for _, path := range registry.Paths() {
+		handler, err := registry.ForPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		namespacePath := fmt.Sprintf("%s/%s", basePath, strings.Trim(path, "/"))
+
+		routes = append(routes,
+			NewUpdateHandler(fmt.Sprintf("%s/%s", namespacePath, operationsSegment), handler, handler.Protocol(),
+				metrics, updateOpts...),
+			NewBulkUpdateHandler(fmt.Sprintf("%s/%s", namespacePath, operationsSegment), handler, handler.Protocol(),
+				metrics, bulkOpts...),
+			NewResolveHandler(fmt.Sprintf("%s/%s", namespacePath, identifiersSegment), handler, metrics),
+		)
+	}
+
+	routes = append(routes, NewResolveHandler(fmt.Sprintf("%s/%s", basePath, identifiersSegment), registry, metrics))
+
+	return routes, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diddochandler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-go/pkg/jws"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/client"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+	svcdochandler "github.com/trustbloc/sidetree-svc-go/pkg/dochandler"
+	docmocks "github.com/trustbloc/sidetree-svc-go/pkg/dochandler/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/common"
+)
+
+func TestNewRoutes(t *testing.T) {
+	processor := &docmocks.OperationProcessor{}
+	processor.ResolveReturns(nil, errors.New("uniqueSuffix not found in the store"))
+
+	writer := &mockBatchWriter{}
+
+	registry, err := svcdochandler.NewRegistry(&mocks.MetricsProvider{},
+		&svcdochandler.NamespaceConfig{
+			Namespace: namespace,
+			Path:      "sidetree",
+			Protocol:  newMockProtocolClient(),
+			Writer:    writer,
+			Processor: processor,
+		},
+		&svcdochandler.NamespaceConfig{
+			Namespace: "did:other",
+			Path:      "/other/",
+			Protocol:  newMockProtocolClient(),
+			Writer:    &mockBatchWriter{},
+			Processor: processor,
+		},
+	)
+	require.NoError(t, err)
+
+	routes, err := NewRoutes("/sidetree/v1/", registry, &mocks.MetricsProvider{}, nil, nil)
+	require.NoError(t, err)
+
+	var paths []string
+
+	for _, route := range routes {
+		paths = append(paths, route.Method()+" "+route.Path())
+	}
+
+	require.Equal(t, []string{
+		"POST /sidetree/v1/sidetree/operations",
+		"POST /sidetree/v1/sidetree/operations/bulk",
+		"GET /sidetree/v1/sidetree/identifiers/{id}",
+		"POST /sidetree/v1/other/operations",
+		"POST /sidetree/v1/other/operations/bulk",
+		"GET /sidetree/v1/other/identifiers/{id}",
+		"GET /sidetree/v1/identifiers/{id}",
+	}, paths)
+
+	router := newRouter(routes)
+
+	t.Run("update", func(t *testing.T) {
+		recoveryCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x"}, sha2_256)
+		require.NoError(t, err)
+
+		updateCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x", Y: "y"}, sha2_256)
+		require.NoError(t, err)
+
+		request, err := client.NewCreateRequest(&client.CreateRequestInfo{
+			OpaqueDocument:     `{"test":1}`,
+			RecoveryCommitment: recoveryCommitment,
+			UpdateCommitment:   updateCommitment,
+			MultihashCode:      sha2_256,
+		})
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/sidetree/v1/sidetree/operations",
+			bytes.NewReader(request)))
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Len(t, writer.ops, 1)
+		require.Equal(t, namespace, writer.ops[0].Namespace)
+	})
+
+	t.Run("resolve by prefix", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/sidetree/v1/identifiers/did:other:EiDahaOGH", nil))
+		require.Equal(t, http.StatusNotFound, rw.Code)
+
+		rw = httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/sidetree/v1/identifiers/did:unknown:EiDahaOGH", nil))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "no document handler is registered for DID [did:unknown:EiDahaOGH]")
+	})
+
+	t.Run("resolve by path", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/sidetree/v1/other/identifiers/did:other:EiDahaOGH", nil))
+		require.Equal(t, http.StatusNotFound, rw.Code)
+
+		// The namespace of the DID must match the namespace of the path.
+		rw = httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/sidetree/v1/other/identifiers/did:sidetree:EiDahaOGH", nil))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+}
+
+func newRouter(handlers []common.HTTPHandler) *mux.Router {
+	router := mux.NewRouter()
+
+	for _, handler := range handlers {
+		router.HandleFunc(handler.Path(), handler.Handler()).Methods(handler.Method())
+	}
+
+	return router
+}
+
+type mockBatchWriter struct {
+	ops []*operation.QueuedOperation
+}
+
+func (m *mockBatchWriter) Add(op *operation.QueuedOperation, _ uint64) error {
+	m.ops = append(m.ops, op)
+
+	return nil
+}
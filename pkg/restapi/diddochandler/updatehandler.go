@@ -25,12 +25,12 @@ type metricsProvider interface {
 
 // NewUpdateHandler returns a new DID document update handler.
 func NewUpdateHandler(basePath string, processor dochandler.Processor, pc protocol.Client,
-	metrics metricsProvider) *UpdateHandler {
+	metrics metricsProvider, opts ...dochandler.UpdateOption) *UpdateHandler {
 	return &UpdateHandler{
 		handler: newHandler(
 			basePath,
 			http.MethodPost,
-			dochandler.NewUpdateHandler(processor, pc, metrics).Update,
+			dochandler.NewUpdateHandler(processor, pc, metrics, opts...).Update,
 		),
 	}
 }
@@ -33,6 +33,7 @@ import (
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/dochandler"
 )
 
 const (
@@ -192,3 +193,26 @@ func newMockProtocolClient() *mocks.MockProtocolClient {
 
 	return pc
 }
+
+func TestBulkUpdateHandler_Update(t *testing.T) {
+	pc := newMockProtocolClient()
+	docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+	handler := NewBulkUpdateHandler(operationsPath, docHandler, pc, &mocks.MetricsProvider{})
+	require.Equal(t, operationsPath+"/bulk", handler.Path())
+	require.Equal(t, http.MethodPost, handler.Method())
+	require.NotNil(t, handler.Handler())
+
+	createRequest, err := getCreateRequest()
+	require.NoError(t, err)
+	request, err := json.Marshal([]interface{}{createRequest})
+	require.NoError(t, err)
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, operationsPath+"/bulk", bytes.NewReader(request))
+	handler.Handler()(rw, req)
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	var resp dochandler.BulkResponse
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.Accepted)
+}
@@ -0,0 +1,428 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dochandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-go/pkg/document"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/common"
+)
+
+const (
+	// ndjsonContentType is the content type of a newline-delimited JSON request (one operation request per line).
+	ndjsonContentType = "application/x-ndjson"
+
+	defaultMaxBulkOperations  = 1000
+	defaultMaxBulkRequestSize = 10 * 1024 * 1024
+	defaultBulkConcurrency    = 10
+)
+
+// PartialSuccessPolicy defines how a bulk request is handled when some of the operations are invalid.
+type PartialSuccessPolicy string
+
+const (
+	// AllowPartialSuccess processes all valid operations and reports the invalid ones in the response.
+	AllowPartialSuccess PartialSuccessPolicy = "partial"
+
+	// ValidateFirst validates all operations (using a dry run) before any of them are processed. If any operation
+	// is invalid, or if the request contains more than one operation for the same document, then none of the
+	// operations are processed. Note that the request isn't atomic: the valid operations are then processed
+	// individually, so an operation may still fail (e.g. if the operation queue is full or the document was
+	// modified in the meantime) after other operations in the request were accepted.
+	ValidateFirst PartialSuccessPolicy = "validate-first"
+)
+
+// BulkItemStatus is the status of an operation in a bulk request.
+type BulkItemStatus string
+
+const (
+	// BulkItemAccepted indicates that the operation was validated and added to the batch.
+	BulkItemAccepted BulkItemStatus = "accepted"
+
+	// BulkItemValid indicates that the operation is valid but was not added to the batch since another
+	// operation in the request was rejected (validate-first policy).
+	BulkItemValid BulkItemStatus = "valid"
+
+	// BulkItemRejected indicates that the operation failed validation.
+	BulkItemRejected BulkItemStatus = "rejected"
+
+	// BulkItemForbidden indicates that the caller is not allowed to submit the operation.
+	BulkItemForbidden BulkItemStatus = "forbidden"
+
+	// BulkItemThrottled indicates that the operation was not admitted since the operation queue is full.
+	// The operation may be resubmitted after the duration specified in the Retry-After header.
+	BulkItemThrottled BulkItemStatus = "throttled"
+
+	// BulkItemFailed indicates that the operation could not be processed due to a server error.
+	BulkItemFailed BulkItemStatus = "failed"
+)
+
+// BulkItemResult contains the result of a single operation in a bulk request.
+type BulkItemResult struct {
+	Index    int                        `json:"index"`
+	Status   BulkItemStatus             `json:"status"`
+	Document *document.ResolutionResult `json:"document,omitempty"`
+	Error    string                     `json:"error,omitempty"`
+
+	// ValidationError contains the details of the validation error (if available).
+	ValidationError *operation.ValidationError `json:"validationError,omitempty"`
+}
+
+// BulkResponse is the response of a bulk request.
+type BulkResponse struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Failed   int               `json:"failed"`
+	Results  []*BulkItemResult `json:"results"`
+}
+
+type bulkMetricsProvider interface {
+	HTTPBulkCreateUpdateTime(duration time.Duration)
+}
+
+// BulkUpdateHandler handles the submission of multiple document operations in a single request.
+// The request body is either a JSON array of operation requests or, if the content type is
+// application/x-ndjson, one operation request per line.
+type BulkUpdateHandler struct {
+	processor      Processor
+	protocol       protocol.Client
+	metrics        bulkMetricsProvider
+	maxOperations  int
+	maxRequestSize int64
+	concurrency    int
+	policy         PartialSuccessPolicy
+	authenticator  Authenticator
+}
+
+// BulkOption is an option for the bulk update handler.
+type BulkOption func(h *BulkUpdateHandler)
+
+// WithMaxBulkOperations sets the maximum number of operations allowed in a single bulk request.
+func WithMaxBulkOperations(value int) BulkOption {
+	return func(h *BulkUpdateHandler) {
+		h.maxOperations = value
+	}
+}
+
+// WithMaxBulkRequestSize sets the maximum size (in bytes) of a bulk request body.
+func WithMaxBulkRequestSize(value int64) BulkOption {
+	return func(h *BulkUpdateHandler) {
+		h.maxRequestSize = value
+	}
+}
+
+// WithBulkConcurrency sets the maximum number of operations that are processed concurrently.
+func WithBulkConcurrency(value int) BulkOption {
+	return func(h *BulkUpdateHandler) {
+		h.concurrency = value
+	}
+}
+
+// WithPartialSuccessPolicy sets the policy for handling bulk requests with invalid operations.
+func WithPartialSuccessPolicy(policy PartialSuccessPolicy) BulkOption {
+	return func(h *BulkUpdateHandler) {
+		h.policy = policy
+	}
+}
+
+// WithBulkAuthenticator sets an optional authenticator. The identity of the caller is passed to the
+// processor along with each operation.
+func WithBulkAuthenticator(authenticator Authenticator) BulkOption {
+	return func(h *BulkUpdateHandler) {
+		h.authenticator = authenticator
+	}
+}
+
+// NewBulkUpdateHandler returns a new bulk document update handler.
+func NewBulkUpdateHandler(processor Processor, pc protocol.Client, metrics bulkMetricsProvider,
+	opts ...BulkOption) *BulkUpdateHandler {
+	h := &BulkUpdateHandler{
+		processor:      processor,
+		protocol:       pc,
+		metrics:        metrics,
+		maxOperations:  defaultMaxBulkOperations,
+		maxRequestSize: defaultMaxBulkRequestSize,
+		concurrency:    defaultBulkConcurrency,
+		policy:         AllowPartialSuccess,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Update processes a bulk request of create/update operations.
+func (h *BulkUpdateHandler) Update(rw http.ResponseWriter, req *http.Request) {
+	startTime := time.Now()
+
+	defer func() {
+		h.metrics.HTTPBulkCreateUpdateTime(time.Since(startTime))
+	}()
+
+	ctx, span := startSpan(req, "BulkUpdateHandler.Update")
+	defer span.End()
+
+	identity, err := authenticate(h.authenticator, req)
+	if err != nil {
+		common.WriteError(rw, http.StatusUnauthorized, tracing.RecordError(span, err))
+
+		return
+	}
+
+	requests, err := h.readRequests(req)
+	if err != nil {
+		common.WriteError(rw, err.(*common.HTTPError).Status(), tracing.RecordError(span, err))
+
+		return
+	}
+
+	span.SetAttributes(tracing.WithTotal(len(requests)))
+
+	logger.Debug("Processing bulk update request", logfields.WithTotal(len(requests)))
+
+	currentProtocol, err := h.protocol.Current()
+	if err != nil {
+		common.WriteError(rw, http.StatusInternalServerError, tracing.RecordError(span, err))
+
+		return
+	}
+
+	protocolVersion := currentProtocol.Protocol().GenesisTime
+
+	if h.policy == ValidateFirst {
+		duplicates := h.duplicateSuffixes(currentProtocol, requests)
+
+		results := h.processAll(requests, func(request []byte) (*document.ResolutionResult, error) {
+			return h.processor.DryRunOperation(request, protocolVersion, operation.WithIdentity(identity),
+				operation.WithContext(ctx))
+		})
+
+		for i, err := range duplicates {
+			results[i] = newBulkItemResult(i, nil, err)
+		}
+
+		resp := newBulkResponse(results)
+		if resp.Rejected+resp.Failed > 0 {
+			for _, result := range results {
+				if result.Status == BulkItemAccepted {
+					result.Status = BulkItemValid
+					result.Document = nil
+				}
+			}
+
+			resp.Accepted = 0
+
+			common.WriteResponse(rw, http.StatusBadRequest, resp)
+
+			return
+		}
+	}
+
+	var retryAfterErr error
+
+	var mutex sync.Mutex
+
+	results := h.processAll(requests, func(request []byte) (*document.ResolutionResult, error) {
+		doc, err := h.processor.ProcessOperation(request, protocolVersion, operation.WithIdentity(identity),
+			operation.WithContext(ctx))
+		if errors.Is(err, operation.ErrQueueFull) {
+			mutex.Lock()
+			retryAfterErr = err
+			mutex.Unlock()
+		}
+
+		return doc, err
+	})
+
+	if retryAfterErr != nil {
+		setRetryAfter(rw, retryAfterErr)
+	}
+
+	common.WriteResponse(rw, http.StatusOK, newBulkResponse(results))
+}
+
+// duplicateSuffixes returns a validation error (by index) for each request which is for the same document as a
+// preceding request. Since the operations are validated independently of each other, each of them may be valid
+// on its own even though they can't all be applied. Requests which fail to parse are ignored since they're
+// rejected by the dry run.
+func (h *BulkUpdateHandler) duplicateSuffixes(pv protocol.Version, requests []json.RawMessage) map[int]error {
+	duplicates := make(map[int]error)
+	suffixes := make(map[string]struct{})
+
+	for i, request := range requests {
+		op, err := pv.OperationParser().Parse(h.processor.Namespace(), request)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := suffixes[op.UniqueSuffix]; ok {
+			duplicates[i] = operation.NewValidationError(operation.StageValidate, op,
+				fmt.Errorf("bulk request contains more than one operation for suffix [%s]", op.UniqueSuffix))
+
+			continue
+		}
+
+		suffixes[op.UniqueSuffix] = struct{}{}
+	}
+
+	return duplicates
+}
+
+func (h *BulkUpdateHandler) readRequests(req *http.Request) ([]json.RawMessage, error) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, h.maxRequestSize+1))
+	if err != nil {
+		return nil, common.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	if int64(len(body)) > h.maxRequestSize {
+		return nil, common.NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Errorf("request size exceeds maximum size %d", h.maxRequestSize))
+	}
+
+	var requests []json.RawMessage
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), ndjsonContentType) {
+		requests, err = parseNDJSON(body)
+	} else {
+		err = json.Unmarshal(body, &requests)
+	}
+
+	if err != nil {
+		return nil, common.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid bulk request: %w", err))
+	}
+
+	if len(requests) == 0 {
+		return nil, common.NewHTTPError(http.StatusBadRequest, errors.New("bulk request contains no operations"))
+	}
+
+	if len(requests) > h.maxOperations {
+		return nil, common.NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Errorf("number of operations %d exceeds maximum %d", len(requests), h.maxOperations))
+	}
+
+	return requests, nil
+}
+
+func parseNDJSON(body []byte) ([]json.RawMessage, error) {
+	var requests []json.RawMessage
+
+	for i, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !json.Valid([]byte(line)) {
+			return nil, fmt.Errorf("line %d is not valid JSON", i+1)
+		}
+
+		requests = append(requests, json.RawMessage(line))
+	}
+
+	return requests, nil
+}
+
+type processFunc func(request []byte) (*document.ResolutionResult, error)
+
+// processAll invokes the given function for each request using a bounded number of goroutines.
+// The results are returned in the same order as the requests.
+func (h *BulkUpdateHandler) processAll(requests []json.RawMessage, process processFunc) []*BulkItemResult {
+	results := make([]*BulkItemResult, len(requests))
+
+	concurrency := h.concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+
+	for i, request := range requests {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(i int, request []byte) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			doc, err := process(request)
+
+			results[i] = newBulkItemResult(i, doc, err)
+		}(i, request)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func newBulkItemResult(index int, doc *document.ResolutionResult, err error) *BulkItemResult {
+	if err == nil {
+		return &BulkItemResult{Index: index, Status: BulkItemAccepted, Document: doc}
+	}
+
+	result := &BulkItemResult{Index: index, Error: err.Error()}
+
+	var validationErr *operation.ValidationError
+
+	switch {
+	case errors.Is(err, auth.ErrForbidden):
+		result.Status = BulkItemForbidden
+	case errors.Is(err, operation.ErrQueueFull):
+		result.Status = BulkItemThrottled
+	case errors.As(err, &validationErr):
+		result.Status = BulkItemRejected
+		result.ValidationError = validationErr
+	case strings.Contains(err.Error(), "bad request"):
+		result.Status = BulkItemRejected
+	default:
+		logger.Warn("Error processing operation in bulk request", log.WithError(err))
+
+		result.Status = BulkItemFailed
+	}
+
+	return result
+}
+
+func newBulkResponse(results []*BulkItemResult) *BulkResponse {
+	resp := &BulkResponse{Results: results}
+
+	for _, result := range results {
+		switch result.Status {
+		case BulkItemAccepted:
+			resp.Accepted++
+		case BulkItemRejected, BulkItemForbidden:
+			resp.Rejected++
+		default:
+			resp.Failed++
+		}
+	}
+
+	return resp
+}
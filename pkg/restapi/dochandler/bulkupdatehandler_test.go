@@ -0,0 +1,300 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dochandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-go/pkg/docutil"
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/client"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/model"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+)
+
+func TestBulkUpdateHandler_Update(t *testing.T) {
+	pc := newMockProtocolClient()
+
+	req, err := getCreateRequestInfo()
+	require.NoError(t, err)
+
+	create, err := client.NewCreateRequest(req)
+	require.NoError(t, err)
+
+	var createReq model.CreateRequest
+	require.NoError(t, json.Unmarshal(create, &createReq))
+
+	uniqueSuffix, err := hashing.CalculateModelMultihash(createReq.SuffixData, sha2_256)
+	require.NoError(t, err)
+
+	update, err := client.NewUpdateRequest(getUpdateRequestInfo(uniqueSuffix))
+	require.NoError(t, err)
+
+	t.Run("JSON array - partial success", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{}, WithBulkConcurrency(2))
+
+		body := newJSONArray(t, create, getUnsupportedRequest(), update)
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 2, resp.Accepted)
+		require.Equal(t, 1, resp.Rejected)
+		require.Equal(t, 0, resp.Failed)
+		require.Len(t, resp.Results, 3)
+
+		for i, result := range resp.Results {
+			require.Equal(t, i, result.Index)
+		}
+
+		require.Equal(t, BulkItemAccepted, resp.Results[0].Status)
+		require.NotNil(t, resp.Results[0].Document)
+		require.Equal(t, BulkItemRejected, resp.Results[1].Status)
+		require.Contains(t, resp.Results[1].Error, badRequest)
+		require.Equal(t, BulkItemAccepted, resp.Results[2].Status)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		body := bytes.Join([][]byte{create, update}, []byte("\n"))
+
+		httpReq := httptest.NewRequest(http.MethodPost, "/document/bulk", bytes.NewReader(append(body, '\n')))
+		httpReq.Header.Set("Content-Type", ndjsonContentType)
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httpReq)
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 2, resp.Accepted)
+	})
+
+	t.Run("NDJSON - invalid line", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		httpReq := httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(append(append(create, '\n'), []byte("{invalid")...)))
+		httpReq.Header.Set("Content-Type", ndjsonContentType)
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httpReq)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "line 2 is not valid JSON")
+	})
+
+	t.Run("Validate first - rejected", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{},
+			WithPartialSuccessPolicy(ValidateFirst))
+
+		body := newJSONArray(t, create, getUnsupportedRequest())
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk", bytes.NewReader(body)))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 0, resp.Accepted)
+		require.Equal(t, 1, resp.Rejected)
+		require.Equal(t, BulkItemValid, resp.Results[0].Status)
+		require.Nil(t, resp.Results[0].Document)
+		require.Equal(t, BulkItemRejected, resp.Results[1].Status)
+
+		// the valid operation should not have been processed
+		id, err := docutil.CalculateID(namespace, createReq.SuffixData, sha2_256)
+		require.NoError(t, err)
+
+		_, err = docHandler.ResolveDocument(id)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("Validate first - duplicate suffix", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{},
+			WithPartialSuccessPolicy(ValidateFirst))
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create, create))))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 0, resp.Accepted)
+		require.Equal(t, 1, resp.Rejected)
+		require.Equal(t, BulkItemValid, resp.Results[0].Status)
+		require.Equal(t, BulkItemRejected, resp.Results[1].Status)
+		require.Contains(t, resp.Results[1].Error, "more than one operation for suffix")
+		require.NotNil(t, resp.Results[1].ValidationError)
+		require.Equal(t, uniqueSuffix, resp.Results[1].ValidationError.UniqueSuffix)
+	})
+
+	t.Run("Validate first - accepted", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{},
+			WithPartialSuccessPolicy(ValidateFirst))
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create))))
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 1, resp.Accepted)
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithError(errors.New("server error"))
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create))))
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 1, resp.Failed)
+		require.Equal(t, BulkItemFailed, resp.Results[0].Status)
+		require.Equal(t, "server error", resp.Results[0].Error)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).
+			WithError(fmt.Errorf("%w: not allowed", auth.ErrForbidden))
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create))))
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 1, resp.Rejected)
+		require.Equal(t, BulkItemForbidden, resp.Results[0].Status)
+	})
+
+	t.Run("Queue full", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).
+			WithError(operation.NewQueueFullError(5*time.Second, "maximum queue depth %d reached", 10))
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create))))
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "5", rw.Header().Get("Retry-After"))
+
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, 1, resp.Failed)
+		require.Equal(t, BulkItemThrottled, resp.Results[0].Status)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{},
+			WithBulkAuthenticator(&mockAuthenticator{err: auth.ErrUnauthenticated}))
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create))))
+		require.Equal(t, http.StatusUnauthorized, rw.Code)
+	})
+
+	t.Run("Too many operations", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{}, WithMaxBulkOperations(1))
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create, update))))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		require.Contains(t, rw.Body.String(), "number of operations 2 exceeds maximum 1")
+	})
+
+	t.Run("Request too large", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{}, WithMaxBulkRequestSize(10))
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create))))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk", bytes.NewReader(create)))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "invalid bulk request")
+	})
+
+	t.Run("No operations", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk", bytes.NewReader([]byte("[]"))))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "bulk request contains no operations")
+	})
+
+	t.Run("Protocol error", func(t *testing.T) {
+		pcWithErr := newMockProtocolClient()
+		pcWithErr.Err = errors.New("protocol error")
+
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewBulkUpdateHandler(docHandler, pcWithErr, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document/bulk",
+			bytes.NewReader(newJSONArray(t, create))))
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+}
+
+func newJSONArray(t *testing.T, requests ...[]byte) []byte {
+	t.Helper()
+
+	items := make([]json.RawMessage, len(requests))
+	for i, r := range requests {
+		items[i] = r
+	}
+
+	b, err := json.Marshal(items)
+	require.NoError(t, err)
+
+	return b
+}
@@ -7,24 +7,45 @@ SPDX-License-Identifier: Apache-2.0
 package dochandler
 
 import (
+	"context"
+	"errors"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/trustbloc/logutil-go/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-go/pkg/document"
 
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
 	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/common"
 )
 
+var tracer = otel.Tracer("sidetree-svc-restapi-dochandler")
+
+const dryRunParam = "dryRun"
+
 // Processor processes document operations.
 type Processor interface {
 	Namespace() string
-	ProcessOperation(operation []byte, protocolVersion uint64) (*document.ResolutionResult, error)
+	ProcessOperation(operation []byte, protocolVersion uint64, opts ...operation.ProcessOption) (*document.ResolutionResult, error)
+	DryRunOperation(operation []byte, protocolVersion uint64, opts ...operation.ProcessOption) (*document.ResolutionResult, error)
+}
+
+// Authenticator authenticates the caller of an HTTP request. A nil identity (and nil error) is returned
+// if the request doesn't contain any credentials.
+type Authenticator interface {
+	Authenticate(req *http.Request) (*auth.Identity, error)
 }
 
 type metricsProvider interface {
@@ -33,21 +54,40 @@ type metricsProvider interface {
 
 // UpdateHandler handles the creation and update of documents.
 type UpdateHandler struct {
-	processor Processor
-	protocol  protocol.Client
-	metrics   metricsProvider
+	processor     Processor
+	protocol      protocol.Client
+	metrics       metricsProvider
+	authenticator Authenticator
+}
+
+// UpdateOption is an option for the update handler.
+type UpdateOption func(h *UpdateHandler)
+
+// WithAuthenticator sets an optional authenticator. The identity of the caller is passed to the
+// processor along with the operation.
+func WithAuthenticator(authenticator Authenticator) UpdateOption {
+	return func(h *UpdateHandler) {
+		h.authenticator = authenticator
+	}
 }
 
 // NewUpdateHandler returns a new document update handler.
-func NewUpdateHandler(processor Processor, pc protocol.Client, metrics metricsProvider) *UpdateHandler {
-	return &UpdateHandler{
+func NewUpdateHandler(processor Processor, pc protocol.Client, metrics metricsProvider, opts ...UpdateOption) *UpdateHandler {
+	h := &UpdateHandler{
 		processor: processor,
 		protocol:  pc,
 		metrics:   metrics,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
-// Update creates or updates a document.
+// Update creates or updates a document. If the 'dryRun' query parameter is set to 'true' then the operation
+// is only validated and the resulting document is returned; the operation is not added to the batch.
 func (h *UpdateHandler) Update(rw http.ResponseWriter, req *http.Request) {
 	startTime := time.Now()
 
@@ -55,6 +95,16 @@ func (h *UpdateHandler) Update(rw http.ResponseWriter, req *http.Request) {
 		h.metrics.HTTPCreateUpdateTime(time.Since(startTime))
 	}()
 
+	ctx, span := startSpan(req, "UpdateHandler.Update")
+	defer span.End()
+
+	identity, err := authenticate(h.authenticator, req)
+	if err != nil {
+		common.WriteError(rw, http.StatusUnauthorized, tracing.RecordError(span, err))
+
+		return
+	}
+
 	request, err := io.ReadAll(req.Body)
 	if err != nil {
 		common.WriteError(rw, http.StatusBadRequest, err)
@@ -62,10 +112,20 @@ func (h *UpdateHandler) Update(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if req.URL.Query().Get(dryRunParam) == "true" {
+		h.dryRun(ctx, rw, request, identity)
+
+		return
+	}
+
 	logger.Debug("Processing update request", logfields.WithRequestBody(request))
 
-	response, err := h.doUpdate(request)
+	response, err := h.doUpdate(ctx, request, identity)
 	if err != nil {
+		tracing.RecordError(span, err)
+
+		setRetryAfter(rw, err)
+
 		common.WriteError(rw, err.(*common.HTTPError).Status(), err)
 
 		return
@@ -73,14 +133,26 @@ func (h *UpdateHandler) Update(rw http.ResponseWriter, req *http.Request) {
 	common.WriteResponse(rw, http.StatusOK, response)
 }
 
-func (h *UpdateHandler) doUpdate(operation []byte) (*document.ResolutionResult, error) {
+func (h *UpdateHandler) doUpdate(ctx context.Context, request []byte,
+	identity *auth.Identity) (*document.ResolutionResult, error) {
 	currentProtocol, err := h.protocol.Current()
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := h.processor.ProcessOperation(operation, currentProtocol.Protocol().GenesisTime)
+	result, err := h.processor.ProcessOperation(request, currentProtocol.Protocol().GenesisTime,
+		operation.WithIdentity(identity), operation.WithContext(ctx))
 	if err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			return nil, common.NewHTTPError(http.StatusForbidden, err)
+		}
+
+		if errors.Is(err, operation.ErrQueueFull) {
+			logger.Warn("Operation rejected since the queue is full", log.WithError(err))
+
+			return nil, common.NewHTTPError(http.StatusServiceUnavailable, err)
+		}
+
 		if strings.Contains(err.Error(), "bad request") {
 			logger.Warn("Operation validation error", log.WithError(err))
 
@@ -94,3 +166,78 @@ func (h *UpdateHandler) doUpdate(operation []byte) (*document.ResolutionResult,
 
 	return result, nil
 }
+
+func (h *UpdateHandler) dryRun(ctx context.Context, rw http.ResponseWriter, request []byte, identity *auth.Identity) {
+	logger.Debug("Processing dry run request", logfields.WithRequestBody(request))
+
+	currentProtocol, err := h.protocol.Current()
+	if err != nil {
+		common.WriteError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	result, err := h.processor.DryRunOperation(request, currentProtocol.Protocol().GenesisTime,
+		operation.WithIdentity(identity), operation.WithContext(ctx))
+	if err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			common.WriteError(rw, http.StatusForbidden, err)
+
+			return
+		}
+
+		var validationErr *operation.ValidationError
+		if errors.As(err, &validationErr) {
+			common.WriteResponse(rw, http.StatusBadRequest, validationErr)
+
+			return
+		}
+
+		if strings.Contains(err.Error(), "bad request") {
+			common.WriteError(rw, http.StatusBadRequest, err)
+
+			return
+		}
+
+		logger.Error("Internal server error", log.WithError(err))
+
+		common.WriteError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	common.WriteResponse(rw, http.StatusOK, result)
+}
+
+func authenticate(authenticator Authenticator, req *http.Request) (*auth.Identity, error) {
+	if authenticator == nil {
+		return nil, nil
+	}
+
+	identity, err := authenticator.Authenticate(req)
+	if err != nil {
+		logger.Debug("Failed to authenticate caller", log.WithError(err))
+
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// startSpan starts a server span for the given request. The span is a child of the trace context in
+// the request headers (if any), as extracted by the global propagator.
+func startSpan(req *http.Request, name string) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// setRetryAfter sets the Retry-After header if the given error is due to a full operation queue.
+func setRetryAfter(rw http.ResponseWriter, err error) {
+	var queueFullErr *operation.QueueFullError
+	if !errors.As(err, &queueFullErr) {
+		return
+	}
+
+	rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(queueFullErr.RetryAfter.Seconds()))))
+}
@@ -8,19 +8,26 @@ package dochandler
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
-	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+	coreoperation "github.com/trustbloc/sidetree-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-go/pkg/commitment"
 	"github.com/trustbloc/sidetree-go/pkg/document"
 	"github.com/trustbloc/sidetree-go/pkg/docutil"
@@ -35,6 +42,9 @@ import (
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationapplier"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
 
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing/tracingtest"
 	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
 )
 
@@ -139,6 +149,218 @@ func TestUpdateHandler_Update(t *testing.T) {
 	})
 }
 
+func TestUpdateHandler_DryRun(t *testing.T) {
+	pc := newMockProtocolClient()
+
+	req, err := getCreateRequestInfo()
+	require.NoError(t, err)
+
+	create, err := client.NewCreateRequest(req)
+	require.NoError(t, err)
+
+	var createReq model.CreateRequest
+	err = json.Unmarshal(create, &createReq)
+	require.NoError(t, err)
+
+	id, err := docutil.CalculateID(namespace, createReq.SuffixData, sha2_256)
+	require.NoError(t, err)
+
+	t.Run("Create", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document?dryRun=true", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var result document.ResolutionResult
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+		require.Equal(t, id, result.Document.ID())
+
+		// nothing should have been stored
+		_, err := docHandler.ResolveDocument(id)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+	t.Run("Validation error", func(t *testing.T) {
+		validationErr := operation.NewValidationError(operation.StageApply,
+			&coreoperation.Operation{Type: coreoperation.TypeUpdate, UniqueSuffix: "suffix"}, errors.New("invalid signature"))
+
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithError(validationErr)
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document?dryRun=true", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var result operation.ValidationError
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+		require.Equal(t, operation.StageApply, result.Stage)
+		require.Equal(t, coreoperation.TypeUpdate, result.Type)
+		require.Equal(t, "suffix", result.UniqueSuffix)
+		require.Equal(t, "invalid signature", result.Message)
+	})
+	t.Run("Bad request", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document?dryRun=true", bytes.NewReader([]byte(badRequest)))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+	t.Run("Error", func(t *testing.T) {
+		errExpected := errors.New("dry run error")
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithError(errExpected)
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document?dryRun=true", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Contains(t, rw.Body.String(), errExpected.Error())
+	})
+	t.Run("Protocol error", func(t *testing.T) {
+		pcWithErr := newMockProtocolClient()
+		pcWithErr.Err = errors.New("protocol error")
+
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewUpdateHandler(docHandler, pcWithErr, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document?dryRun=true", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+}
+
+func TestUpdateHandler_Auth(t *testing.T) {
+	pc := newMockProtocolClient()
+
+	req, err := getCreateRequestInfo()
+	require.NoError(t, err)
+
+	create, err := client.NewCreateRequest(req)
+	require.NoError(t, err)
+
+	t.Run("Authenticated", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{},
+			WithAuthenticator(&mockAuthenticator{identity: &auth.Identity{Subject: "alice"}}))
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusOK, rw.Code)
+	})
+	t.Run("Unauthenticated", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc)
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{},
+			WithAuthenticator(&mockAuthenticator{err: fmt.Errorf("%w: invalid API key", auth.ErrUnauthenticated)}))
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusUnauthorized, rw.Code)
+		require.Contains(t, rw.Body.String(), "invalid API key")
+	})
+	t.Run("Forbidden", func(t *testing.T) {
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).
+			WithError(fmt.Errorf("%w: not allowed", auth.ErrForbidden))
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/document", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusForbidden, rw.Code)
+
+		rw = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/document?dryRun=true", bytes.NewReader(create))
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusForbidden, rw.Code)
+	})
+}
+
+func TestUpdateHandler_QueueFull(t *testing.T) {
+	pc := newMockProtocolClient()
+
+	req, err := getCreateRequestInfo()
+	require.NoError(t, err)
+
+	create, err := client.NewCreateRequest(req)
+	require.NoError(t, err)
+
+	docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).
+		WithError(operation.NewQueueFullError(1500*time.Millisecond, "maximum queue depth %d reached", 10))
+	handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+	rw := httptest.NewRecorder()
+	handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document", bytes.NewReader(create)))
+	require.Equal(t, http.StatusServiceUnavailable, rw.Code)
+	require.Equal(t, "2", rw.Header().Get("Retry-After"))
+	require.Contains(t, rw.Body.String(), "operation queue is full: maximum queue depth 10 reached")
+}
+
+func TestUpdateHandler_Tracing(t *testing.T) {
+	exporter := tracingtest.Exporter()
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	pc := newMockProtocolClient()
+
+	req, err := getCreateRequestInfo()
+	require.NoError(t, err)
+
+	create, err := client.NewCreateRequest(req)
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		exporter.Reset()
+
+		docHandler := &mockTracingProcessor{
+			MockDocumentHandler: mocks.NewMockDocumentHandler().WithNamespace(namespace).WithProtocolClient(pc),
+		}
+
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		clientCtx, clientSpan := otel.Tracer("test").Start(context.Background(), "client")
+		defer clientSpan.End()
+
+		req := httptest.NewRequest(http.MethodPost, "/document", bytes.NewReader(create))
+		otel.GetTextMapPropagator().Inject(clientCtx, propagation.HeaderCarrier(req.Header))
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, req)
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		spans := tracingtest.Spans(exporter, "UpdateHandler.Update")
+		require.Len(t, spans, 1)
+		require.Equal(t, clientSpan.SpanContext().TraceID(), spans[0].SpanContext.TraceID())
+		require.Equal(t, clientSpan.SpanContext().SpanID(), spans[0].Parent.SpanID())
+
+		// The span is passed to the processor.
+		require.Equal(t, spans[0].SpanContext.SpanID(), trace.SpanContextFromContext(docHandler.ctx).SpanID())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		exporter.Reset()
+
+		docHandler := mocks.NewMockDocumentHandler().WithNamespace(namespace).WithError(errors.New("injected error"))
+		handler := NewUpdateHandler(docHandler, pc, &mocks.MetricsProvider{})
+
+		rw := httptest.NewRecorder()
+		handler.Update(rw, httptest.NewRequest(http.MethodPost, "/document", bytes.NewReader(create)))
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+
+		spans := tracingtest.Spans(exporter, "UpdateHandler.Update")
+		require.Len(t, spans, 1)
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+}
+
 func getCreateRequestInfo() (*client.CreateRequestInfo, error) {
 	recoveryCommitment, err := commitment.GetCommitment(recoverJWK, sha2_256)
 	if err != nil {
@@ -285,7 +507,7 @@ func getUnsupportedRequest() []byte {
 type operationSchema struct {
 
 	// operation
-	Operation operation.Type `json:"type"`
+	Operation coreoperation.Type `json:"type"`
 }
 
 const validDoc = `{
@@ -329,3 +551,25 @@ func newMockProtocolClient() *mocks.MockProtocolClient {
 
 	return pc
 }
+
+type mockTracingProcessor struct {
+	*mocks.MockDocumentHandler
+
+	ctx context.Context
+}
+
+func (m *mockTracingProcessor) ProcessOperation(request []byte, protocolVersion uint64,
+	opts ...operation.ProcessOption) (*document.ResolutionResult, error) {
+	m.ctx = operation.GetProcessOptions(opts...).Context
+
+	return m.MockDocumentHandler.ProcessOperation(request, protocolVersion, opts...)
+}
+
+type mockAuthenticator struct {
+	identity *auth.Identity
+	err      error
+}
+
+func (m *mockAuthenticator) Authenticate(*http.Request) (*auth.Identity, error) {
+	return m.identity, m.err
+}
@@ -0,0 +1,248 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package eventhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/eventlog"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/common"
+)
+
+var logger = log.New("sidetree-svc-restapi-eventhandler")
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	lastEventIDParam  = "lastEventId"
+
+	eventStreamContentType = "text/event-stream"
+	ndjsonContentType      = "application/x-ndjson"
+
+	gapEventType = "gap"
+
+	defaultKeepAliveInterval = 15 * time.Second
+)
+
+type eventLog interface {
+	Subscribe(afterID string, replay bool) (*eventlog.Subscription, error)
+}
+
+// Gap is sent to the consumer if some of the events after the requested event ID are no longer retained,
+// or if the requested event ID was issued before the server was restarted.
+type Gap struct {
+	MissedAfter string `json:"missedAfter"`
+	ResumedFrom string `json:"resumedFrom,omitempty"`
+}
+
+// StreamHandler streams the events in the event log to the consumer as Server-Sent Events or, if the
+// request accepts application/x-ndjson, as newline-delimited JSON.
+//
+// A consumer may resume the stream by providing the ID of the last event it received in the Last-Event-ID
+// header (set automatically by SSE clients on reconnect) or in the 'lastEventId' query parameter. If no
+// event ID is provided then only new events are streamed.
+type StreamHandler struct {
+	path              string
+	log               eventLog
+	keepAliveInterval time.Duration
+}
+
+// Option is a stream handler option.
+type Option func(h *StreamHandler)
+
+// WithKeepAliveInterval sets the interval at which keep-alive messages are sent to an idle consumer.
+func WithKeepAliveInterval(value time.Duration) Option {
+	return func(h *StreamHandler) {
+		h.keepAliveInterval = value
+	}
+}
+
+// NewStreamHandler returns a new event stream handler.
+func NewStreamHandler(path string, log eventLog, opts ...Option) *StreamHandler {
+	h := &StreamHandler{
+		path:              path,
+		log:               log,
+		keepAliveInterval: defaultKeepAliveInterval,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Path returns the context path.
+func (h *StreamHandler) Path() string {
+	return h.path
+}
+
+// Method returns the HTTP method.
+func (h *StreamHandler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the handler.
+func (h *StreamHandler) Handler() common.HTTPRequestHandler {
+	return h.stream
+}
+
+func (h *StreamHandler) stream(rw http.ResponseWriter, req *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		common.WriteError(rw, http.StatusInternalServerError, errors.New("streaming is not supported"))
+
+		return
+	}
+
+	lastEventID := getLastEventID(req)
+
+	sub, err := h.log.Subscribe(lastEventID, lastEventID != "")
+	if err != nil {
+		common.WriteError(rw, http.StatusBadRequest, fmt.Errorf("invalid last event ID [%s]", lastEventID))
+
+		return
+	}
+
+	defer sub.Close()
+
+	w := newEventWriter(rw, strings.Contains(req.Header.Get("Accept"), ndjsonContentType))
+
+	rw.Header().Set("Content-Type", w.contentType())
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+
+	if sub.Missed {
+		gap := &Gap{MissedAfter: lastEventID}
+		if len(sub.Backlog) > 0 {
+			gap.ResumedFrom = sub.Backlog[0].ID
+		}
+
+		if err := w.writeGap(gap); err != nil {
+			return
+		}
+	}
+
+	for _, entry := range sub.Backlog {
+		if err := w.writeEntry(entry); err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(h.keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case entry, ok := <-sub.C():
+			if !ok {
+				// The consumer fell too far behind. It may reconnect and resume from the last event it received.
+				return
+			}
+
+			if err := w.writeEntry(entry); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := w.writeKeepAlive(); err != nil {
+				return
+			}
+		case <-req.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+func getLastEventID(req *http.Request) string {
+	value := req.Header.Get(lastEventIDHeader)
+	if value == "" {
+		value = req.URL.Query().Get(lastEventIDParam)
+	}
+
+	return value
+}
+
+type eventWriter struct {
+	rw     http.ResponseWriter
+	ndjson bool
+}
+
+func newEventWriter(rw http.ResponseWriter, ndjson bool) *eventWriter {
+	return &eventWriter{rw: rw, ndjson: ndjson}
+}
+
+func (w *eventWriter) contentType() string {
+	if w.ndjson {
+		return ndjsonContentType
+	}
+
+	return eventStreamContentType
+}
+
+func (w *eventWriter) writeEntry(entry *eventlog.Entry) error {
+	if w.ndjson {
+		return w.writeJSONLine(entry)
+	}
+
+	data, err := json.Marshal(entry.Event)
+	if err != nil {
+		return err
+	}
+
+	return w.write(fmt.Sprintf("id: %s\ndata: %s\n\n", entry.ID, data))
+}
+
+func (w *eventWriter) writeGap(gap *Gap) error {
+	if w.ndjson {
+		return w.writeJSONLine(map[string]*Gap{gapEventType: gap})
+	}
+
+	data, err := json.Marshal(gap)
+	if err != nil {
+		return err
+	}
+
+	return w.write(fmt.Sprintf("event: %s\ndata: %s\n\n", gapEventType, data))
+}
+
+func (w *eventWriter) writeKeepAlive() error {
+	if w.ndjson {
+		return w.write("\n")
+	}
+
+	return w.write(": keep-alive\n\n")
+}
+
+func (w *eventWriter) writeJSONLine(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return w.write(string(data) + "\n")
+}
+
+func (w *eventWriter) write(s string) error {
+	if _, err := w.rw.Write([]byte(s)); err != nil {
+		logger.Debug("Error writing to event stream", log.WithError(err))
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package eventhandler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
+	"github.com/trustbloc/sidetree-svc-go/pkg/eventlog"
+)
+
+const path = "/events"
+
+func TestStreamHandler(t *testing.T) {
+	h := NewStreamHandler(path, eventlog.New())
+	require.Equal(t, path, h.Path())
+	require.Equal(t, http.MethodGet, h.Method())
+	require.NotNil(t, h.Handler())
+
+	t.Run("SSE - live", func(t *testing.T) {
+		l := eventlog.New(eventlog.WithEpoch("e1"))
+		l.Publish(newEvent("suffix1"))
+
+		server := httptest.NewServer(http.HandlerFunc(NewStreamHandler(path, l).Handler()))
+		defer server.Close()
+
+		resp, reader := get(t, server.URL, nil)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, eventStreamContentType, resp.Header.Get("Content-Type"))
+
+		// The response headers are sent after the stream has subscribed to the log.
+		l.Publish(newEvent("suffix2"))
+
+		require.Equal(t, "id: e1-2", readLine(t, reader))
+
+		event := &notification.ChangeEvent{}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(readLine(t, reader), "data: ")), event))
+		require.Equal(t, "suffix2", event.UniqueSuffix)
+		require.Equal(t, "canonical-ref", event.CanonicalReference)
+		require.Equal(t, uint64(12), event.TransactionNumber)
+	})
+
+	t.Run("SSE - resume using Last-Event-ID", func(t *testing.T) {
+		l := eventlog.New(eventlog.WithEpoch("e1"))
+		l.Publish(newEvent("suffix1"), newEvent("suffix2"), newEvent("suffix3"))
+
+		server := httptest.NewServer(http.HandlerFunc(NewStreamHandler(path, l).Handler()))
+		defer server.Close()
+
+		resp, reader := get(t, server.URL, map[string]string{lastEventIDHeader: "e1-1"})
+		defer resp.Body.Close()
+
+		require.Equal(t, "id: e1-2", readLine(t, reader))
+		require.Contains(t, readLine(t, reader), "suffix2")
+		require.Equal(t, "", readLine(t, reader))
+		require.Equal(t, "id: e1-3", readLine(t, reader))
+	})
+
+	t.Run("SSE - gap", func(t *testing.T) {
+		l := eventlog.New(eventlog.WithEpoch("e1"), eventlog.WithCapacity(2))
+		l.Publish(newEvent("suffix1"), newEvent("suffix2"), newEvent("suffix3"), newEvent("suffix4"))
+
+		server := httptest.NewServer(http.HandlerFunc(NewStreamHandler(path, l).Handler()))
+		defer server.Close()
+
+		resp, reader := get(t, server.URL+"?lastEventId=e1-1", nil)
+		defer resp.Body.Close()
+
+		require.Equal(t, "event: gap", readLine(t, reader))
+		require.Equal(t, `data: {"missedAfter":"e1-1","resumedFrom":"e1-3"}`, readLine(t, reader))
+		require.Equal(t, "", readLine(t, reader))
+		require.Equal(t, "id: e1-3", readLine(t, reader))
+	})
+
+	t.Run("SSE - gap after restart", func(t *testing.T) {
+		// The consumer received event 3 from the previous instance whereas the new instance has already
+		// published 5 events.
+		l := eventlog.New(eventlog.WithEpoch("e2"))
+		l.Publish(newEvent("suffix1"), newEvent("suffix2"), newEvent("suffix3"), newEvent("suffix4"),
+			newEvent("suffix5"))
+
+		server := httptest.NewServer(http.HandlerFunc(NewStreamHandler(path, l).Handler()))
+		defer server.Close()
+
+		resp, reader := get(t, server.URL, map[string]string{lastEventIDHeader: "e1-3"})
+		defer resp.Body.Close()
+
+		require.Equal(t, "event: gap", readLine(t, reader))
+		require.Equal(t, `data: {"missedAfter":"e1-3","resumedFrom":"e2-1"}`, readLine(t, reader))
+		require.Equal(t, "", readLine(t, reader))
+		require.Equal(t, "id: e2-1", readLine(t, reader))
+		require.Contains(t, readLine(t, reader), "suffix1")
+	})
+
+	t.Run("SSE - keep alive", func(t *testing.T) {
+		l := eventlog.New()
+
+		server := httptest.NewServer(http.HandlerFunc(
+			NewStreamHandler(path, l, WithKeepAliveInterval(10*time.Millisecond)).Handler()))
+		defer server.Close()
+
+		resp, reader := get(t, server.URL, nil)
+		defer resp.Body.Close()
+
+		require.Equal(t, ": keep-alive", readLine(t, reader))
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		l := eventlog.New(eventlog.WithEpoch("e1"), eventlog.WithCapacity(2))
+		l.Publish(newEvent("suffix1"), newEvent("suffix2"), newEvent("suffix3"))
+
+		server := httptest.NewServer(http.HandlerFunc(NewStreamHandler(path, l).Handler()))
+		defer server.Close()
+
+		resp, reader := get(t, server.URL+"?lastEventId=e1-0", map[string]string{"Accept": ndjsonContentType})
+		defer resp.Body.Close()
+
+		require.Equal(t, ndjsonContentType, resp.Header.Get("Content-Type"))
+
+		require.Equal(t, `{"gap":{"missedAfter":"e1-0","resumedFrom":"e1-2"}}`, readLine(t, reader))
+
+		entry := &eventlog.Entry{}
+		require.NoError(t, json.Unmarshal([]byte(readLine(t, reader)), entry))
+		require.Equal(t, "e1-2", entry.ID)
+		require.Equal(t, "suffix2", entry.Event.UniqueSuffix)
+
+		// The response headers are sent after the stream has subscribed to the log.
+		l.Publish(newEvent("suffix4"))
+
+		require.NoError(t, json.Unmarshal([]byte(readLine(t, reader)), entry))
+		require.Equal(t, "e1-3", entry.ID)
+
+		require.NoError(t, json.Unmarshal([]byte(readLine(t, reader)), entry))
+		require.Equal(t, "e1-4", entry.ID)
+		require.Equal(t, "suffix4", entry.Event.UniqueSuffix)
+	})
+
+	t.Run("invalid last event ID", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		h.Handler()(rw, httptest.NewRequest(http.MethodGet, path+"?lastEventId=abc", nil))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "invalid last event ID [abc]")
+	})
+
+	t.Run("streaming not supported", func(t *testing.T) {
+		rw := &nonFlushingWriter{ResponseWriter: httptest.NewRecorder()}
+		h.Handler()(rw, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusInternalServerError, rw.ResponseWriter.(*httptest.ResponseRecorder).Code)
+	})
+}
+
+func get(t *testing.T, url string, headers map[string]string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func readLine(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+
+	return strings.TrimSuffix(line, "\n")
+}
+
+func newEvent(suffix string) *notification.ChangeEvent {
+	return &notification.ChangeEvent{
+		ID:                 fmt.Sprintf("published:%s:12", suffix),
+		UniqueSuffix:       suffix,
+		OperationType:      operation.TypeUpdate,
+		Status:             notification.StatusPublished,
+		TransactionTime:    11,
+		TransactionNumber:  12,
+		CanonicalReference: "canonical-ref",
+	}
+}
+
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
@@ -0,0 +1,281 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package webhookhandler contains the REST handlers for managing webhook subscriptions. Since a subscription
+// causes this node to post requests to the registered URL, these endpoints should only be exposed to
+// trusted callers (see WithAuthenticator).
+package webhookhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/restapi/common"
+	"github.com/trustbloc/sidetree-svc-go/pkg/webhook"
+)
+
+var logger = log.New("sidetree-svc-restapi-webhookhandler")
+
+const idParam = "id"
+
+var errNotAuthenticated = errors.New("not authenticated")
+
+// requestHandler handles a request on behalf of the (optional) authenticated caller.
+type requestHandler func(rw http.ResponseWriter, req *http.Request, identity *auth.Identity)
+
+// Authenticator authenticates the caller of an HTTP request. A nil identity (and nil error) is returned
+// if the request doesn't contain any credentials.
+type Authenticator interface {
+	Authenticate(req *http.Request) (*auth.Identity, error)
+}
+
+// Option is an option for the webhook handlers.
+type Option func(h *handler)
+
+// WithAuthenticator sets the authenticator. If set then requests are rejected unless the caller is
+// authenticated, and a subscription may only be accessed by the caller that registered it.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(h *handler) {
+		h.authenticator = authenticator
+	}
+}
+
+type subscriptionManager interface {
+	Subscribe(subscription *webhook.Subscription) (*webhook.Subscription, error)
+	Unsubscribe(id string) error
+	Get(id string) (*webhook.Subscription, error)
+}
+
+type deliveryLog interface {
+	Get(subscriptionID string) []*webhook.DeliveryRecord
+}
+
+type handler struct {
+	path          string
+	method        string
+	reqHandler    common.HTTPRequestHandler
+	authenticator Authenticator
+}
+
+func newHandler(path, method string, reqHandler requestHandler, opts ...Option) *handler {
+	h := &handler{path: path, method: method}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.reqHandler = func(rw http.ResponseWriter, req *http.Request) {
+		identity, ok := h.authenticate(rw, req)
+		if !ok {
+			return
+		}
+
+		reqHandler(rw, req, identity)
+	}
+
+	return h
+}
+
+// Path returns the context path.
+func (h *handler) Path() string {
+	return h.path
+}
+
+// Method returns the HTTP method.
+func (h *handler) Method() string {
+	return h.method
+}
+
+// Handler returns the handler.
+func (h *handler) Handler() common.HTTPRequestHandler {
+	return h.reqHandler
+}
+
+// authenticate returns the identity of the caller (nil if no authenticator is set). False is returned (and an
+// error response is written) if an authenticator is set and the caller isn't authenticated.
+func (h *handler) authenticate(rw http.ResponseWriter, req *http.Request) (*auth.Identity, bool) {
+	if h.authenticator == nil {
+		return nil, true
+	}
+
+	identity, err := h.authenticator.Authenticate(req)
+	if err != nil {
+		logger.Debug("Failed to authenticate caller", log.WithError(err))
+
+		common.WriteError(rw, http.StatusUnauthorized, err)
+
+		return nil, false
+	}
+
+	if identity == nil {
+		common.WriteError(rw, http.StatusUnauthorized, errNotAuthenticated)
+
+		return nil, false
+	}
+
+	return identity, true
+}
+
+// SubscribeHandler registers a webhook subscription.
+type SubscribeHandler struct {
+	*handler
+
+	manager subscriptionManager
+}
+
+// NewSubscribeHandler returns a new handler which registers a webhook subscription. The response contains
+// the ID of the subscription and the secret which is used to sign the notifications.
+func NewSubscribeHandler(basePath string, manager subscriptionManager, opts ...Option) *SubscribeHandler {
+	h := &SubscribeHandler{manager: manager}
+
+	h.handler = newHandler(basePath, http.MethodPost, h.subscribe, opts...)
+
+	return h
+}
+
+func (h *SubscribeHandler) subscribe(rw http.ResponseWriter, req *http.Request, identity *auth.Identity) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		common.WriteError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	sub := &webhook.Subscription{}
+
+	if err := json.Unmarshal(body, sub); err != nil {
+		common.WriteError(rw, http.StatusBadRequest, fmt.Errorf("invalid subscription: %w", err))
+
+		return
+	}
+
+	// The owner can't be provided by the caller.
+	sub.Owner = subject(identity)
+
+	registered, err := h.manager.Subscribe(sub)
+	if err != nil {
+		logger.Debug("Error registering subscription", log.WithError(err))
+
+		common.WriteError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	common.WriteResponse(rw, http.StatusCreated, registered)
+}
+
+// UnsubscribeHandler removes a webhook subscription.
+type UnsubscribeHandler struct {
+	*handler
+
+	manager subscriptionManager
+}
+
+// NewUnsubscribeHandler returns a new handler which removes a webhook subscription.
+func NewUnsubscribeHandler(basePath string, manager subscriptionManager, opts ...Option) *UnsubscribeHandler {
+	h := &UnsubscribeHandler{manager: manager}
+
+	h.handler = newHandler(fmt.Sprintf("%s/{%s}", basePath, idParam), http.MethodDelete, h.unsubscribe, opts...)
+
+	return h
+}
+
+func (h *UnsubscribeHandler) unsubscribe(rw http.ResponseWriter, req *http.Request, identity *auth.Identity) {
+	id := mux.Vars(req)[idParam]
+
+	if _, err := getSubscription(h.manager, id, identity); err != nil {
+		writeError(rw, err)
+
+		return
+	}
+
+	if err := h.manager.Unsubscribe(id); err != nil {
+		writeError(rw, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// DeliveriesHandler returns the delivery log of a webhook subscription.
+type DeliveriesHandler struct {
+	*handler
+
+	manager     subscriptionManager
+	deliveryLog deliveryLog
+}
+
+// NewDeliveriesHandler returns a new handler which returns the delivery log of a webhook subscription.
+func NewDeliveriesHandler(basePath string, manager subscriptionManager, dl deliveryLog,
+	opts ...Option) *DeliveriesHandler {
+	h := &DeliveriesHandler{manager: manager, deliveryLog: dl}
+
+	h.handler = newHandler(fmt.Sprintf("%s/{%s}/deliveries", basePath, idParam), http.MethodGet, h.deliveries, opts...)
+
+	return h
+}
+
+func (h *DeliveriesHandler) deliveries(rw http.ResponseWriter, req *http.Request, identity *auth.Identity) {
+	id := mux.Vars(req)[idParam]
+
+	if _, err := getSubscription(h.manager, id, identity); err != nil {
+		writeError(rw, err)
+
+		return
+	}
+
+	common.WriteResponse(rw, http.StatusOK, h.deliveryLog.Get(id))
+}
+
+// getSubscription returns the subscription with the given ID. An error wrapping auth.ErrForbidden is returned
+// if the subscription wasn't registered by the given caller.
+func getSubscription(manager subscriptionManager, id string, identity *auth.Identity) (*webhook.Subscription, error) {
+	sub, err := manager.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.Owner != subject(identity) {
+		logger.Debug("Caller is not the owner of the subscription", logfields.WithSubscriptionID(id))
+
+		return nil, fmt.Errorf("subscription [%s]: %w", id, auth.ErrForbidden)
+	}
+
+	return sub, nil
+}
+
+func subject(identity *auth.Identity) string {
+	if identity == nil {
+		return ""
+	}
+
+	return identity.Subject
+}
+
+func writeError(rw http.ResponseWriter, err error) {
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		common.WriteError(rw, http.StatusNotFound, err)
+
+		return
+	}
+
+	if errors.Is(err, auth.ErrForbidden) {
+		common.WriteError(rw, http.StatusForbidden, err)
+
+		return
+	}
+
+	common.WriteError(rw, http.StatusInternalServerError, err)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhookhandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/auth"
+	"github.com/trustbloc/sidetree-svc-go/pkg/webhook"
+)
+
+const basePath = "/webhooks"
+
+func TestWebhookHandlers(t *testing.T) {
+	notifier := webhook.New()
+	dl := webhook.NewMemDeliveryLog(0)
+
+	subscribeHandler := NewSubscribeHandler(basePath, notifier)
+	require.Equal(t, basePath, subscribeHandler.Path())
+	require.Equal(t, http.MethodPost, subscribeHandler.Method())
+	require.NotNil(t, subscribeHandler.Handler())
+
+	unsubscribeHandler := NewUnsubscribeHandler(basePath, notifier)
+	require.Equal(t, basePath+"/{id}", unsubscribeHandler.Path())
+	require.Equal(t, http.MethodDelete, unsubscribeHandler.Method())
+
+	deliveriesHandler := NewDeliveriesHandler(basePath, notifier, dl)
+	require.Equal(t, basePath+"/{id}/deliveries", deliveriesHandler.Path())
+	require.Equal(t, http.MethodGet, deliveriesHandler.Method())
+
+	var sub *webhook.Subscription
+
+	t.Run("subscribe", func(t *testing.T) {
+		body, err := json.Marshal(&webhook.Subscription{URL: "https://example.com/hook", Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		subscribeHandler.Handler()(rw, httptest.NewRequest(http.MethodPost, basePath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rw.Code)
+
+		sub = &webhook.Subscription{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), sub))
+		require.NotEmpty(t, sub.ID)
+		require.NotEmpty(t, sub.Secret)
+	})
+
+	t.Run("subscribe - invalid request", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		subscribeHandler.Handler()(rw, httptest.NewRequest(http.MethodPost, basePath, bytes.NewReader([]byte("{"))))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		rw = httptest.NewRecorder()
+		subscribeHandler.Handler()(rw, httptest.NewRequest(http.MethodPost, basePath,
+			bytes.NewReader([]byte(`{"url":"https://example.com/hook"}`))))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "at least one suffix must be provided")
+	})
+
+	t.Run("subscribe - private target", func(t *testing.T) {
+		body, err := json.Marshal(&webhook.Subscription{URL: "http://169.254.169.254/hook", Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		subscribeHandler.Handler()(rw, httptest.NewRequest(http.MethodPost, basePath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "webhook target not allowed")
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		dl.Put(&webhook.DeliveryRecord{SubscriptionID: sub.ID, EventID: "event1", Status: webhook.DeliverySucceeded})
+
+		rw := httptest.NewRecorder()
+		deliveriesHandler.Handler()(rw, newRequestWithID(http.MethodGet, sub.ID))
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var records []*webhook.DeliveryRecord
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &records))
+		require.Len(t, records, 1)
+		require.Equal(t, "event1", records[0].EventID)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		unsubscribeHandler.Handler()(rw, newRequestWithID(http.MethodDelete, sub.ID))
+		require.Equal(t, http.StatusNoContent, rw.Code)
+
+		rw = httptest.NewRecorder()
+		unsubscribeHandler.Handler()(rw, newRequestWithID(http.MethodDelete, sub.ID))
+		require.Equal(t, http.StatusNotFound, rw.Code)
+
+		rw = httptest.NewRecorder()
+		deliveriesHandler.Handler()(rw, newRequestWithID(http.MethodGet, sub.ID))
+		require.Equal(t, http.StatusNotFound, rw.Code)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		writeError(rw, errors.New("injected error"))
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+}
+
+func TestWebhookHandlers_Authenticator(t *testing.T) {
+	notifier := webhook.New()
+
+	body, err := json.Marshal(&webhook.Subscription{URL: "https://example.com/hook", Suffixes: []string{"suffix1"}})
+	require.NoError(t, err)
+
+	t.Run("authenticated", func(t *testing.T) {
+		h := NewSubscribeHandler(basePath, notifier,
+			WithAuthenticator(&mockAuthenticator{identity: &auth.Identity{Subject: "alice"}}))
+
+		rw := httptest.NewRecorder()
+		h.Handler()(rw, httptest.NewRequest(http.MethodPost, basePath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rw.Code)
+	})
+
+	t.Run("authentication error", func(t *testing.T) {
+		h := NewUnsubscribeHandler(basePath, notifier,
+			WithAuthenticator(&mockAuthenticator{err: errors.New("invalid API key")}))
+
+		rw := httptest.NewRecorder()
+		h.Handler()(rw, newRequestWithID(http.MethodDelete, "sub1"))
+		require.Equal(t, http.StatusUnauthorized, rw.Code)
+		require.Contains(t, rw.Body.String(), "invalid API key")
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		h := NewDeliveriesHandler(basePath, notifier, webhook.NewMemDeliveryLog(0),
+			WithAuthenticator(&mockAuthenticator{}))
+
+		rw := httptest.NewRecorder()
+		h.Handler()(rw, newRequestWithID(http.MethodGet, "sub1"))
+		require.Equal(t, http.StatusUnauthorized, rw.Code)
+		require.Contains(t, rw.Body.String(), "not authenticated")
+	})
+}
+
+func TestWebhookHandlers_Owner(t *testing.T) {
+	notifier := webhook.New()
+	dl := webhook.NewMemDeliveryLog(0)
+
+	alice := WithAuthenticator(&mockAuthenticator{identity: &auth.Identity{Subject: "alice"}})
+	bob := WithAuthenticator(&mockAuthenticator{identity: &auth.Identity{Subject: "bob"}})
+
+	subscribe := func(t *testing.T, opt Option, body string) *webhook.Subscription {
+		t.Helper()
+
+		rw := httptest.NewRecorder()
+		NewSubscribeHandler(basePath, notifier, opt).Handler()(rw,
+			httptest.NewRequest(http.MethodPost, basePath, bytes.NewReader([]byte(body))))
+		require.Equal(t, http.StatusCreated, rw.Code)
+
+		sub := &webhook.Subscription{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), sub))
+
+		return sub
+	}
+
+	// The owner provided by the caller is ignored.
+	aliceSub := subscribe(t, alice, `{"url":"https://example.com/alice","suffixes":["suffix1"],"owner":"bob"}`)
+	require.Equal(t, "alice", aliceSub.Owner)
+
+	bobSub := subscribe(t, bob, `{"url":"https://example.com/bob","suffixes":["suffix1"]}`)
+	require.Equal(t, "bob", bobSub.Owner)
+
+	t.Run("deliveries", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		NewDeliveriesHandler(basePath, notifier, dl, bob).Handler()(rw, newRequestWithID(http.MethodGet, aliceSub.ID))
+		require.Equal(t, http.StatusForbidden, rw.Code)
+
+		rw = httptest.NewRecorder()
+		NewDeliveriesHandler(basePath, notifier, dl, alice).Handler()(rw, newRequestWithID(http.MethodGet, bobSub.ID))
+		require.Equal(t, http.StatusForbidden, rw.Code)
+
+		rw = httptest.NewRecorder()
+		NewDeliveriesHandler(basePath, notifier, dl, alice).Handler()(rw, newRequestWithID(http.MethodGet, aliceSub.ID))
+		require.Equal(t, http.StatusOK, rw.Code)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		NewUnsubscribeHandler(basePath, notifier, bob).Handler()(rw, newRequestWithID(http.MethodDelete, aliceSub.ID))
+		require.Equal(t, http.StatusForbidden, rw.Code)
+
+		_, err := notifier.Get(aliceSub.ID)
+		require.NoError(t, err)
+
+		rw = httptest.NewRecorder()
+		NewUnsubscribeHandler(basePath, notifier, alice).Handler()(rw, newRequestWithID(http.MethodDelete, bobSub.ID))
+		require.Equal(t, http.StatusForbidden, rw.Code)
+
+		rw = httptest.NewRecorder()
+		NewUnsubscribeHandler(basePath, notifier, bob).Handler()(rw, newRequestWithID(http.MethodDelete, bobSub.ID))
+		require.Equal(t, http.StatusNoContent, rw.Code)
+	})
+}
+
+type mockAuthenticator struct {
+	identity *auth.Identity
+	err      error
+}
+
+func (m *mockAuthenticator) Authenticate(*http.Request) (*auth.Identity, error) {
+	return m.identity, m.err
+}
+
+func newRequestWithID(method, id string) *http.Request {
+	return mux.SetURLVars(httptest.NewRequest(method, basePath+"/"+id, nil), map[string]string{idParam: id})
+}
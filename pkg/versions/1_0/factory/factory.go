@@ -0,0 +1,234 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package factory wires the version 1.0 components (operation parser and applier, document composer,
+// transformer and validator, transaction processor and batch file handler/provider) into a protocol version.
+package factory
+
+import (
+	"fmt"
+
+	coreprotocol "github.com/trustbloc/sidetree-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doccomposer"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doctransformer/didtransformer"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doctransformer/doctransformer"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/docvalidator/didvalidator"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/docvalidator/docvalidator"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationapplier"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprocessor"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider"
+)
+
+// Version is the protocol version created by this factory.
+const Version = "1.0"
+
+// DocumentType selects the document transformer and validator.
+type DocumentType string
+
+const (
+	// DIDDocument transforms documents into DID documents (default).
+	DIDDocument DocumentType = "did"
+	// GenericDocument leaves documents as they are.
+	GenericDocument DocumentType = "generic"
+)
+
+type compressionProvider interface {
+	Compress(alg string, data []byte) ([]byte, error)
+	DecompressWithLimit(alg string, data []byte, maxSize uint64) ([]byte, error)
+}
+
+type metricsProvider interface {
+	CASWriteSize(dataType string, size int)
+}
+
+// Providers contains the providers which are shared by all protocol versions.
+type Providers struct {
+	CAS         cas.Client
+	OpStore     txnprocessor.OperationStore
+	Compression compressionProvider
+	Metrics     metricsProvider
+}
+
+type options struct {
+	documentType  DocumentType
+	methodContext []string
+	includeBase   bool
+	maxChunkFiles int
+	providerOpts  []txnprovider.Opt
+	handlerOpts   []txnprovider.HandlerOpt
+	processorOpts []txnprocessor.Option
+}
+
+// Option is a factory option.
+type Option func(opts *options)
+
+// WithDocumentType sets the type of document (default DIDDocument).
+func WithDocumentType(value DocumentType) Option {
+	return func(opts *options) {
+		opts.documentType = value
+	}
+}
+
+// WithMethodContext sets the additional JSON-LD contexts of resolved DID documents.
+func WithMethodContext(value []string) Option {
+	return func(opts *options) {
+		opts.methodContext = value
+	}
+}
+
+// WithBase includes @base in the context of resolved DID documents.
+func WithBase(enabled bool) Option {
+	return func(opts *options) {
+		opts.includeBase = enabled
+	}
+}
+
+// WithMaxChunkFiles sets the maximum number of chunk files per batch (zero uses the default).
+func WithMaxChunkFiles(value int) Option {
+	return func(opts *options) {
+		opts.maxChunkFiles = value
+	}
+}
+
+// WithOperationProviderOptions adds options for the operation provider. This option may be specified
+// multiple times.
+func WithOperationProviderOptions(opts ...txnprovider.Opt) Option {
+	return func(o *options) {
+		o.providerOpts = append(o.providerOpts, opts...)
+	}
+}
+
+// WithOperationHandlerOptions adds options for the operation handler. This option may be specified
+// multiple times.
+func WithOperationHandlerOptions(opts ...txnprovider.HandlerOpt) Option {
+	return func(o *options) {
+		o.handlerOpts = append(o.handlerOpts, opts...)
+	}
+}
+
+// WithTxnProcessorOptions adds options for the transaction processor. This option may be specified
+// multiple times.
+func WithTxnProcessorOptions(opts ...txnprocessor.Option) Option {
+	return func(o *options) {
+		o.processorOpts = append(o.processorOpts, opts...)
+	}
+}
+
+// New creates a version 1.0 protocol version with the given protocol parameters.
+//
+//nolint:gocritic
+func New(p coreprotocol.Protocol, providers *Providers, opts ...Option) (protocol.Version, error) {
+	o := &options{documentType: DIDDocument}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var metrics metricsProvider = &noopMetricsProvider{}
+	if providers.Metrics != nil {
+		metrics = providers.Metrics
+	}
+
+	v := &version{protocol: p}
+
+	switch o.documentType {
+	case DIDDocument:
+		v.transformer = didtransformer.New(
+			didtransformer.WithMethodContext(o.methodContext),
+			didtransformer.WithBase(o.includeBase),
+		)
+		v.validator = didvalidator.New()
+	case GenericDocument:
+		v.transformer = doctransformer.New()
+		v.validator = docvalidator.New()
+	default:
+		return nil, fmt.Errorf("unsupported document type [%s]", o.documentType)
+	}
+
+	if o.maxChunkFiles > 0 {
+		o.providerOpts = append([]txnprovider.Opt{txnprovider.WithMaxChunkFiles(o.maxChunkFiles)}, o.providerOpts...)
+		o.handlerOpts = append([]txnprovider.HandlerOpt{txnprovider.WithHandlerMaxChunkFiles(o.maxChunkFiles)},
+			o.handlerOpts...)
+	}
+
+	parser := operationparser.New(p)
+
+	v.parser = parser
+	v.composer = doccomposer.New()
+	v.applier = operationapplier.New(p, parser, v.composer)
+	v.handler = txnprovider.NewOperationHandler(p, providers.CAS, providers.Compression, parser, metrics,
+		o.handlerOpts...)
+	v.provider = txnprovider.NewOperationProvider(p, parser, providers.CAS, providers.Compression, o.providerOpts...)
+	v.processor = txnprocessor.New(
+		&txnprocessor.Providers{
+			OpStore:                   providers.OpStore,
+			OperationProtocolProvider: v.provider,
+		},
+		o.processorOpts...,
+	)
+
+	return v, nil
+}
+
+type version struct {
+	protocol    coreprotocol.Protocol
+	parser      coreprotocol.OperationParser
+	applier     coreprotocol.OperationApplier
+	composer    coreprotocol.DocumentComposer
+	transformer coreprotocol.DocumentTransformer
+	validator   coreprotocol.DocumentValidator
+	processor   protocol.TxnProcessor
+	handler     protocol.OperationHandler
+	provider    protocol.OperationProvider
+}
+
+func (v *version) Version() string {
+	return Version
+}
+
+func (v *version) Protocol() coreprotocol.Protocol {
+	return v.protocol
+}
+
+func (v *version) OperationParser() coreprotocol.OperationParser {
+	return v.parser
+}
+
+func (v *version) OperationApplier() coreprotocol.OperationApplier {
+	return v.applier
+}
+
+func (v *version) DocumentComposer() coreprotocol.DocumentComposer {
+	return v.composer
+}
+
+func (v *version) DocumentTransformer() coreprotocol.DocumentTransformer {
+	return v.transformer
+}
+
+func (v *version) DocumentValidator() coreprotocol.DocumentValidator {
+	return v.validator
+}
+
+func (v *version) TransactionProcessor() protocol.TxnProcessor {
+	return v.processor
+}
+
+func (v *version) OperationHandler() protocol.OperationHandler {
+	return v.handler
+}
+
+func (v *version) OperationProvider() protocol.OperationProvider {
+	return v.provider
+}
+
+type noopMetricsProvider struct{}
+
+func (m *noopMetricsProvider) CASWriteSize(string, int) {}
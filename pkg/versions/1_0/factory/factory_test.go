@@ -0,0 +1,134 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package factory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-go/pkg/jws"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/client"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doctransformer/didtransformer"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doctransformer/doctransformer"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/docvalidator/didvalidator"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/docvalidator/docvalidator"
+
+	svcoperation "github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+)
+
+const sha2_256 = 18
+
+func TestNew(t *testing.T) {
+	p := mocks.GetDefaultProtocolParameters()
+
+	providers := &Providers{
+		CAS:         mocks.NewMockCasClient(nil),
+		OpStore:     &mockOperationStore{},
+		Compression: compression.New(compression.WithDefaultAlgorithms()),
+	}
+
+	t.Run("DID document (default)", func(t *testing.T) {
+		v, err := New(p, providers, WithMethodContext([]string{"https://example.com/ctx"}), WithBase(true))
+		require.NoError(t, err)
+		require.Equal(t, Version, v.Version())
+		require.Equal(t, p, v.Protocol())
+		require.NotNil(t, v.OperationParser())
+		require.NotNil(t, v.OperationApplier())
+		require.NotNil(t, v.DocumentComposer())
+		require.IsType(t, &didtransformer.Transformer{}, v.DocumentTransformer())
+		require.IsType(t, &didvalidator.Validator{}, v.DocumentValidator())
+		require.NotNil(t, v.TransactionProcessor())
+		require.NotNil(t, v.OperationHandler())
+		require.NotNil(t, v.OperationProvider())
+	})
+
+	t.Run("generic document", func(t *testing.T) {
+		v, err := New(p, providers, WithDocumentType(GenericDocument))
+		require.NoError(t, err)
+		require.IsType(t, &doctransformer.Transformer{}, v.DocumentTransformer())
+		require.IsType(t, &docvalidator.Validator{}, v.DocumentValidator())
+	})
+
+	t.Run("unsupported document type", func(t *testing.T) {
+		v, err := New(p, providers, WithDocumentType("unknown"))
+		require.EqualError(t, err, "unsupported document type [unknown]")
+		require.Nil(t, v)
+	})
+
+	t.Run("components are wired", func(t *testing.T) {
+		opStore := &mockOperationStore{}
+
+		v, err := New(p, &Providers{
+			CAS:         mocks.NewMockCasClient(nil),
+			OpStore:     opStore,
+			Compression: compression.New(compression.WithDefaultAlgorithms()),
+			Metrics:     &mocks.MetricsProvider{},
+		}, WithMaxChunkFiles(2))
+		require.NoError(t, err)
+
+		request := newCreateRequest(t)
+
+		op, err := v.OperationParser().Parse(mocks.DefaultNS, request)
+		require.NoError(t, err)
+
+		anchoringInfo, err := v.OperationHandler().PrepareTxnFiles(
+			[]*svcoperation.QueuedOperation{{
+				Type:             operation.TypeCreate,
+				OperationRequest: request,
+				UniqueSuffix:     op.UniqueSuffix,
+				Namespace:        mocks.DefaultNS,
+			}})
+		require.NoError(t, err)
+
+		ops, err := v.OperationProvider().GetTxnOperations(
+			&txn.SidetreeTxn{AnchorString: anchoringInfo.AnchorString, Namespace: mocks.DefaultNS})
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, op.UniqueSuffix, ops[0].UniqueSuffix)
+
+		n, err := v.TransactionProcessor().Process(
+			txn.SidetreeTxn{AnchorString: anchoringInfo.AnchorString, Namespace: mocks.DefaultNS})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Len(t, opStore.ops, 1)
+	})
+}
+
+func newCreateRequest(t *testing.T) []byte {
+	t.Helper()
+
+	recoveryCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x"}, sha2_256)
+	require.NoError(t, err)
+
+	updateCommitment, err := commitment.GetCommitment(&jws.JWK{Crv: "crv", Kty: "kty", X: "x", Y: "y"}, sha2_256)
+	require.NoError(t, err)
+
+	request, err := client.NewCreateRequest(&client.CreateRequestInfo{
+		OpaqueDocument:     `{"test":1}`,
+		RecoveryCommitment: recoveryCommitment,
+		UpdateCommitment:   updateCommitment,
+		MultihashCode:      sha2_256,
+	})
+	require.NoError(t, err)
+
+	return request
+}
+
+type mockOperationStore struct {
+	ops []*operation.AnchoredOperation
+}
+
+func (m *mockOperationStore) Put(ops []*operation.AnchoredOperation) error {
+	m.ops = append(m.ops, ops...)
+
+	return nil
+}
@@ -7,20 +7,28 @@ SPDX-License-Identifier: Apache-2.0
 package txnprocessor
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pkg/errors"
 
 	"github.com/trustbloc/logutil-go/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-go/pkg/api/operation"
 
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
 	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
 )
 
-var logger = log.New("sidetree-svc-observer")
+var (
+	logger = log.New("sidetree-svc-observer")
+	tracer = otel.Tracer("sidetree-svc-observer")
+)
 
 // OperationStore interface to access operation store.
 type OperationStore interface {
@@ -32,6 +40,10 @@ type unpublishedOperationStore interface {
 	DeleteAll(ops []*operation.AnchoredOperation) error
 }
 
+type eventPublisher interface {
+	Publish(events ...*notification.ChangeEvent)
+}
+
 // Providers contains the providers required by the TxnProcessor.
 type Providers struct {
 	OpStore                   OperationStore
@@ -44,6 +56,7 @@ type TxnProcessor struct {
 
 	unpublishedOperationStore unpublishedOperationStore
 	unpublishedOperationTypes []operation.Type
+	eventPublishers           []eventPublisher
 }
 
 // New returns a new document operation processor.
@@ -74,23 +87,67 @@ func WithUnpublishedOperationStore(store unpublishedOperationStore, opTypes []op
 	}
 }
 
+// WithEventPublisher adds a publisher which is notified of the operations that were processed.
+// This option may be specified multiple times.
+func WithEventPublisher(publisher eventPublisher) Option {
+	return func(opts *TxnProcessor) {
+		opts.eventPublishers = append(opts.eventPublishers, publisher)
+	}
+}
+
 // Process persists all the operations for the given anchor.
 //
 //nolint:gocritic
 func (p *TxnProcessor) Process(sidetreeTxn txn.SidetreeTxn, suffixes ...string) (int, error) {
+	return p.ProcessWithContext(context.Background(), sidetreeTxn, suffixes...)
+}
+
+// ProcessWithContext persists all the operations for the given anchor. The context carries the (optional)
+// trace span of the caller.
+//
+//nolint:gocritic
+func (p *TxnProcessor) ProcessWithContext(ctx context.Context, sidetreeTxn txn.SidetreeTxn,
+	suffixes ...string) (int, error) {
 	logger.Debug("Processing sidetree txn for suffixes", logfields.WithSidetreeTxn(sidetreeTxn), logfields.WithSuffixes(suffixes...))
 
-	txnOps, err := p.OperationProtocolProvider.GetTxnOperations(&sidetreeTxn)
+	ctx, span := tracer.Start(ctx, "TxnProcessor.Process",
+		trace.WithAttributes(tracing.WithNamespace(sidetreeTxn.Namespace), tracing.WithAnchorString(sidetreeTxn.AnchorString),
+			tracing.WithTransactionNumber(sidetreeTxn.TransactionNumber)))
+	defer span.End()
+
+	txnOps, err := p.getTxnOperations(ctx, &sidetreeTxn)
+	if err != nil {
+		return 0, tracing.RecordError(span,
+			fmt.Errorf("failed to retrieve operations for anchor string[%s]: %s", sidetreeTxn.AnchorString, err))
+	}
+
+	n, err := p.processTxnOperations(ctx, txnOps, &sidetreeTxn)
 	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve operations for anchor string[%s]: %s", sidetreeTxn.AnchorString, err)
+		return 0, tracing.RecordError(span, err)
 	}
 
-	return p.processTxnOperations(txnOps, &sidetreeTxn)
+	span.SetAttributes(tracing.WithTotal(n))
+
+	return n, nil
 }
 
-func (p *TxnProcessor) processTxnOperations(txnOps []*operation.AnchoredOperation, sidetreeTxn *txn.SidetreeTxn) (int, error) {
+// getTxnOperations retrieves the operations of the given transaction, passing the context to the operation
+// provider if it accepts one.
+func (p *TxnProcessor) getTxnOperations(ctx context.Context,
+	sidetreeTxn *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error) {
+	if op, ok := p.OperationProtocolProvider.(protocol.OperationProviderWithContext); ok {
+		return op.GetTxnOperationsWithContext(ctx, sidetreeTxn)
+	}
+
+	return p.OperationProtocolProvider.GetTxnOperations(sidetreeTxn)
+}
+
+func (p *TxnProcessor) processTxnOperations(ctx context.Context, txnOps []*operation.AnchoredOperation,
+	sidetreeTxn *txn.SidetreeTxn) (int, error) {
 	logger.Debug("Processing transaction operations", logfields.WithTotal(len(txnOps)))
 
+	span := trace.SpanFromContext(ctx)
+
 	batchSuffixes := make(map[string]bool)
 
 	var unpublishedOps []*operation.AnchoredOperation
@@ -111,6 +168,9 @@ func (p *TxnProcessor) processTxnOperations(txnOps []*operation.AnchoredOperatio
 
 		ops = append(ops, updatedOp)
 
+		span.AddEvent("operation", trace.WithAttributes(tracing.WithSuffix(op.UniqueSuffix),
+			tracing.WithOperationType(string(op.Type))))
+
 		batchSuffixes[op.UniqueSuffix] = true
 
 		if containsOperationType(p.unpublishedOperationTypes, op.Type) {
@@ -128,9 +188,31 @@ func (p *TxnProcessor) processTxnOperations(txnOps []*operation.AnchoredOperatio
 		return 0, fmt.Errorf("failed to delete unpublished operations for anchor string[%s]: %w", sidetreeTxn.AnchorString, err)
 	}
 
+	p.publishEvents(ops, sidetreeTxn)
+
 	return len(ops), nil
 }
 
+func (p *TxnProcessor) publishEvents(ops []*operation.AnchoredOperation, sidetreeTxn *txn.SidetreeTxn) {
+	if len(p.eventPublishers) == 0 || len(ops) == 0 {
+		return
+	}
+
+	events := make([]*notification.ChangeEvent, len(ops))
+
+	for i, op := range ops {
+		events[i] = notification.NewPublishedEvent(sidetreeTxn.Namespace, op)
+
+		if events[i].CanonicalReference == "" {
+			events[i].CanonicalReference = sidetreeTxn.CanonicalReference
+		}
+	}
+
+	for _, publisher := range p.eventPublishers {
+		publisher.Publish(events...)
+	}
+}
+
 func updateAnchoredOperation(op *operation.AnchoredOperation, sidetreeTxn *txn.SidetreeTxn) *operation.AnchoredOperation {
 	//  The logical anchoring time that this operation was anchored on
 	op.TransactionTime = sidetreeTxn.TransactionTime
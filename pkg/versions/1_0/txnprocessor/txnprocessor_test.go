@@ -7,14 +7,21 @@ SPDX-License-Identifier: Apache-2.0
 package txnprocessor
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-go/pkg/api/operation"
 
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing/tracingtest"
 )
 
 const anchorString = "1.coreIndexURI"
@@ -37,6 +44,71 @@ func TestTxnProcessor_Process(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), errExpected.Error())
 	})
+
+	t.Run("tracing", func(t *testing.T) {
+		exporter := tracingtest.Exporter()
+
+		providers := &Providers{
+			OpStore:                   &mockOperationStore{},
+			OperationProtocolProvider: &mockTxnOpsProvider{},
+		}
+
+		ctx, parentSpan := otel.Tracer("test").Start(context.Background(), "parent")
+
+		p := New(providers)
+		n, err := p.ProcessWithContext(ctx, txn.SidetreeTxn{AnchorString: anchorString, TransactionNumber: 12})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		parentSpan.End()
+
+		spans := tracingtest.Spans(exporter, "TxnProcessor.Process")
+		require.Len(t, spans, 1)
+		require.Equal(t, parentSpan.SpanContext().SpanID(), spans[0].Parent.SpanID())
+		require.Contains(t, spans[0].Attributes, tracing.WithAnchorString(anchorString))
+		require.Contains(t, spans[0].Attributes, tracing.WithTransactionNumber(12))
+		require.Len(t, spans[0].Events, 1)
+		require.Contains(t, spans[0].Events[0].Attributes, tracing.WithSuffix("abc"))
+		require.Contains(t, spans[0].Events[0].Attributes, tracing.WithOperationType(string(operation.TypeUpdate)))
+	})
+
+	t.Run("tracing error", func(t *testing.T) {
+		exporter := tracingtest.Exporter()
+
+		providers := &Providers{
+			OpStore:                   &mockOperationStore{},
+			OperationProtocolProvider: &mockTxnOpsProvider{err: fmt.Errorf("injected error")},
+		}
+
+		p := New(providers)
+		_, err := p.Process(txn.SidetreeTxn{AnchorString: anchorString})
+		require.Error(t, err)
+
+		spans := tracingtest.Spans(exporter, "TxnProcessor.Process")
+		require.Len(t, spans, 1)
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+
+	t.Run("operation provider with context", func(t *testing.T) {
+		exporter := tracingtest.Exporter()
+
+		opp := &mockTxnOpsProviderWithContext{}
+
+		providers := &Providers{
+			OpStore:                   &mockOperationStore{},
+			OperationProtocolProvider: opp,
+		}
+
+		p := New(providers)
+		n, err := p.Process(txn.SidetreeTxn{AnchorString: anchorString})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		// The operation provider is invoked with the context of the span.
+		spans := tracingtest.Spans(exporter, "TxnProcessor.Process")
+		require.Len(t, spans, 1)
+		require.Equal(t, spans[0].SpanContext.SpanID(), trace.SpanContextFromContext(opp.ctx).SpanID())
+	})
 }
 
 func TestProcessTxnOperations(t *testing.T) {
@@ -48,7 +120,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		}
 
 		p := New(providers)
-		_, err := p.processTxnOperations([]*operation.AnchoredOperation{{UniqueSuffix: "abc"}}, &txn.SidetreeTxn{AnchorString: anchorString})
+		_, err := p.processTxnOperations(context.Background(), []*operation.AnchoredOperation{{UniqueSuffix: "abc"}}, &txn.SidetreeTxn{AnchorString: anchorString})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "failed to store operation from anchor string")
 	})
@@ -63,7 +135,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		batchOps, err := p.OperationProtocolProvider.GetTxnOperations(&txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 
-		numProcessed, err := p.processTxnOperations(batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
+		numProcessed, err := p.processTxnOperations(context.Background(), batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 		require.Equal(t, 1, numProcessed)
 	})
@@ -80,7 +152,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		batchOps, err := p.OperationProtocolProvider.GetTxnOperations(&txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 
-		_, err = p.processTxnOperations(batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
+		_, err = p.processTxnOperations(context.Background(), batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 	})
 
@@ -98,7 +170,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		batchOps, err := p.OperationProtocolProvider.GetTxnOperations(&txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 
-		_, err = p.processTxnOperations(batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
+		_, err = p.processTxnOperations(context.Background(), batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "failed to delete unpublished operations for anchor string[1.coreIndexURI]: delete all error")
 	})
@@ -117,11 +189,60 @@ func TestProcessTxnOperations(t *testing.T) {
 		// only first operation will be processed, subsequent operations will be discarded
 		batchOps = append(batchOps, batchOps...)
 
-		_, err = p.processTxnOperations(batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
+		_, err = p.processTxnOperations(context.Background(), batchOps, &txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 	})
 }
 
+func TestProcessTxnOperations_EventPublisher(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		publisher := &mockEventPublisher{}
+		publisher2 := &mockEventPublisher{}
+
+		p := New(&Providers{
+			OperationProtocolProvider: &mockTxnOpsProvider{},
+			OpStore:                   &mockOperationStore{},
+		}, WithEventPublisher(publisher), WithEventPublisher(publisher2))
+
+		_, err := p.Process(txn.SidetreeTxn{
+			AnchorString:       anchorString,
+			Namespace:          "did:sidetree",
+			TransactionTime:    20,
+			TransactionNumber:  2,
+			CanonicalReference: "canonical-ref",
+		})
+		require.NoError(t, err)
+
+		require.Len(t, publisher.events, 1)
+		require.Equal(t, publisher.events, publisher2.events)
+
+		event := publisher.events[0]
+		require.Equal(t, "published:abc:2", event.ID)
+		require.Equal(t, "did:sidetree", event.Namespace)
+		require.Equal(t, "abc", event.UniqueSuffix)
+		require.Equal(t, operation.TypeUpdate, event.OperationType)
+		require.Equal(t, notification.StatusPublished, event.Status)
+		require.Equal(t, uint64(20), event.TransactionTime)
+		require.Equal(t, uint64(2), event.TransactionNumber)
+		require.Equal(t, "canonical-ref", event.CanonicalReference)
+	})
+
+	t.Run("not published on error", func(t *testing.T) {
+		publisher := &mockEventPublisher{}
+
+		p := New(&Providers{
+			OperationProtocolProvider: &mockTxnOpsProvider{},
+			OpStore: &mockOperationStore{putFunc: func(ops []*operation.AnchoredOperation) error {
+				return fmt.Errorf("put error")
+			}},
+		}, WithEventPublisher(publisher))
+
+		_, err := p.Process(txn.SidetreeTxn{AnchorString: anchorString})
+		require.Error(t, err)
+		require.Empty(t, publisher.events)
+	})
+}
+
 func TestUpdateOperation(t *testing.T) {
 	t.Run("test success", func(t *testing.T) {
 		updatedOps := updateAnchoredOperation(&operation.AnchoredOperation{UniqueSuffix: "abc"},
@@ -152,6 +273,20 @@ func (m *mockOperationStore) Get(suffix string) ([]*operation.AnchoredOperation,
 	return nil, nil
 }
 
+// mockTxnOpsProviderWithContext records the context which is passed to GetTxnOperationsWithContext.
+type mockTxnOpsProviderWithContext struct {
+	mockTxnOpsProvider
+
+	ctx context.Context
+}
+
+func (m *mockTxnOpsProviderWithContext) GetTxnOperationsWithContext(ctx context.Context,
+	txn *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error) {
+	m.ctx = ctx
+
+	return m.GetTxnOperations(txn)
+}
+
 type mockTxnOpsProvider struct {
 	err error
 }
@@ -176,3 +311,11 @@ type mockUnpublishedOpsStore struct {
 func (m *mockUnpublishedOpsStore) DeleteAll(_ []*operation.AnchoredOperation) error {
 	return m.DeleteAllErr
 }
+
+type mockEventPublisher struct {
+	events []*notification.ChangeEvent
+}
+
+func (m *mockEventPublisher) Publish(events ...*notification.ChangeEvent) {
+	m.events = append(m.events, events...)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"context"
+)
+
+const defaultMaxConcurrentFetches = 4
+
+// fetchGroup runs the independent batch file fetches of a transaction concurrently while reporting
+// errors as if the fetches were run sequentially. The fetches are ordered by the sequence in which they
+// are started. A failed fetch cancels all of the fetches that were started after it (since their errors
+// would be superseded anyway) but not the fetches that were started before it (since their errors take
+// precedence). The number of concurrent fetches is bounded. Fetches must be started from a single goroutine.
+type fetchGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   context.CancelFunc
+	sem    chan struct{}
+}
+
+// fetchTask is a fetch which was started by a fetch group.
+type fetchTask struct {
+	done chan struct{}
+	err  error
+}
+
+func newFetchGroup(ctx context.Context, maxConcurrent int) *fetchGroup {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &fetchGroup{
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   cancel,
+		sem:    make(chan struct{}, maxConcurrent),
+	}
+}
+
+// goFetch runs the fetch in a new goroutine.
+func (g *fetchGroup) goFetch(fetch func(ctx context.Context) error) *fetchTask {
+	ctx, cancelSubsequent := g.next()
+
+	task := &fetchTask{done: make(chan struct{})}
+
+	go func() {
+		defer close(task.done)
+
+		task.err = g.run(ctx, cancelSubsequent, fetch)
+	}()
+
+	return task
+}
+
+// fetch runs the fetch in the current goroutine.
+func (g *fetchGroup) fetch(fetch func(ctx context.Context) error) *fetchTask {
+	ctx, cancelSubsequent := g.next()
+
+	return g.completed(g.run(ctx, cancelSubsequent, fetch))
+}
+
+// completed returns a task which completed with the given error. A failed task cancels the fetches
+// that are started after it.
+func (g *fetchGroup) completed(err error) *fetchTask {
+	task := &fetchTask{done: make(chan struct{}), err: err}
+
+	close(task.done)
+
+	if err != nil {
+		_, cancelSubsequent := g.next()
+		cancelSubsequent()
+	}
+
+	return task
+}
+
+// next returns the context for the next fetch along with a function which cancels all of the fetches
+// that are started after it.
+func (g *fetchGroup) next() (context.Context, context.CancelFunc) {
+	ctx := g.ctx
+
+	g.ctx, g.cancel = context.WithCancel(ctx)
+
+	return ctx, g.cancel
+}
+
+func (g *fetchGroup) run(ctx context.Context, cancelSubsequent context.CancelFunc,
+	fetch func(ctx context.Context) error) error {
+	select {
+	case g.sem <- struct{}{}:
+	case <-ctx.Done():
+		cancelSubsequent()
+
+		return ctx.Err()
+	}
+
+	err := fetch(ctx)
+
+	<-g.sem
+
+	if err != nil {
+		cancelSubsequent()
+	}
+
+	return err
+}
+
+// wait waits for the given tasks to complete and returns the error of the first task (in the given
+// order) which failed. Nil tasks are ignored.
+func (g *fetchGroup) wait(tasks ...*fetchTask) error {
+	for _, task := range tasks {
+		if task == nil {
+			continue
+		}
+
+		<-task.done
+
+		if task.err != nil {
+			return task.err
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package txnprovider
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -15,18 +16,37 @@ import (
 	"github.com/trustbloc/sidetree-go/pkg/util/json"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/model"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/cas"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider/models"
 )
 
+// Aliases of the batch files. The alias is passed to a CAS client which implements aliasedCASWriter
+// or aliasedDCAS (e.g. a caching client) so that it may handle each file type differently.
+const (
+	coreIndexAlias        = "core index"
+	coreProofAlias        = "core proof"
+	provisionalIndexAlias = "provisional index"
+	provisionalProofAlias = "provisional proof"
+	chunkAlias            = "chunk"
+)
+
+const defaultMaxChunkFiles = 1
+
 type compressionProvider interface {
 	Compress(alg string, data []byte) ([]byte, error)
 }
 
+// aliasedCASWriter is implemented by a CAS client which handles content differently depending on the file type.
+type aliasedCASWriter interface {
+	WriteWithAlias(alias string, content []byte) (string, error)
+}
+
 type metricsProvider interface {
 	CASWriteSize(dataType string, size int)
 }
@@ -38,50 +58,127 @@ type OperationHandler struct {
 	parser   OperationParser
 	cp       compressionProvider
 	metrics  metricsProvider
+
+	maxChunkFiles int
+}
+
+// HandlerOpt is an option for the operation handler.
+type HandlerOpt func(h *OperationHandler)
+
+// WithHandlerMaxChunkFiles sets the maximum number of chunk files per batch which the protocol version
+// allows. If the deltas of a batch don't fit into the maximum number of chunk files then the remaining
+// operations are deferred to the next batch.
+func WithHandlerMaxChunkFiles(value int) HandlerOpt {
+	return func(h *OperationHandler) {
+		h.maxChunkFiles = value
+	}
 }
 
 // NewOperationHandler returns new operations handler.
 //
 //nolint:gocritic
 func NewOperationHandler(p coreprotocol.Protocol, cas cas.Client, cp compressionProvider, parser OperationParser,
-	metrics metricsProvider) *OperationHandler {
-	return &OperationHandler{
-		cas:      cas,
-		protocol: p,
-		parser:   parser,
-		cp:       cp,
-		metrics:  metrics,
+	metrics metricsProvider, opts ...HandlerOpt) *OperationHandler {
+	h := &OperationHandler{
+		cas:           cas,
+		protocol:      p,
+		parser:        parser,
+		cp:            cp,
+		metrics:       metrics,
+		maxChunkFiles: defaultMaxChunkFiles,
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
 // PrepareTxnFiles will create batch files(core index, core proof, provisional index, provisional proof and chunk)
 // from batch operation and return anchor string, batch files information and operations.
 func (h *OperationHandler) PrepareTxnFiles(ops []*operation.QueuedOperation) (*protocol.AnchoringInfo, error) {
+	return h.PrepareTxnFilesWithContext(context.Background(), ops)
+}
+
+// PrepareTxnFilesWithContext will create batch files(core index, core proof, provisional index, provisional proof
+// and chunk) from batch operation and return anchor string, batch files information and operations. The context
+// carries the (optional) trace span of the batch.
+func (h *OperationHandler) PrepareTxnFilesWithContext(ctx context.Context,
+	ops []*operation.QueuedOperation) (*protocol.AnchoringInfo, error) {
+	ctx, span := tracer.Start(ctx, "OperationHandler.PrepareTxnFiles", trace.WithAttributes(tracing.WithTotal(len(ops))))
+	defer span.End()
+
+	anchoringInfo, err := h.prepareTxnFiles(ctx, ops)
+	if err != nil {
+		return nil, tracing.RecordError(span, err)
+	}
+
+	span.SetAttributes(tracing.WithAnchorString(anchoringInfo.AnchorString))
+
+	return anchoringInfo, nil
+}
+
+func (h *OperationHandler) prepareTxnFiles(ctx context.Context,
+	ops []*operation.QueuedOperation) (*protocol.AnchoringInfo, error) {
 	parsedOps, info, err := h.parseOperations(ops)
 	if err != nil {
 		return nil, err
 	}
 
+	chunks, err := h.chunkOperations(parsedOps, info)
+	if err != nil {
+		return nil, err
+	}
+
+	// If no operations remain in the batch (e.g. all operations were rejected or expired) then no batch files
+	// are written since there's nothing to anchor.
+	if parsedOps.Size() == 0 {
+		logger.Info("No operations remain in the batch - nothing to anchor",
+			logfields.WithTotal(len(info.RejectedOperations)+len(info.AdditionalOperations)+len(info.ExpiredOperations)))
+
+		return &protocol.AnchoringInfo{
+			ExpiredOperations:    info.ExpiredOperations,
+			AdditionalOperations: info.AdditionalOperations,
+			RejectedOperations:   info.RejectedOperations,
+		}, nil
+	}
+
 	var artifacts []*protocol.AnchorDocument
 
-	// special case: if all ops are deactivate don't create chunk and provisional files
-	provisionalIndexURI := ""
-	if len(parsedOps.Deactivate) != len(ops) {
-		chunkURI, innerErr := h.createChunkFile(parsedOps)
-		if innerErr != nil {
-			return nil, innerErr
+	// failed returns the error along with the files which were already written so that they may be cleaned up.
+	failed := func(err error) (*protocol.AnchoringInfo, error) {
+		if len(artifacts) == 0 {
+			return nil, err
 		}
 
-		artifacts = append(artifacts,
-			&protocol.AnchorDocument{
-				ID:   chunkURI,
-				Desc: "chunk file",
-				Type: protocol.TypeProvisional,
-			})
+		return nil, &protocol.TxnFilesError{Artifacts: artifacts, Err: err}
+	}
 
-		provisionalProofURI, innerErr := h.createProvisionalProofFile(parsedOps.Update)
+	// special case: if there are no deltas (i.e. all ops are deactivate) don't create chunk and provisional files
+	provisionalIndexURI := ""
+	if len(chunks) > 0 {
+		chunkURIs := make([]string, 0, len(chunks))
+
+		for _, deltas := range chunks {
+			chunkURI, innerErr := h.writeModelToCAS(ctx, &models.ChunkFile{Deltas: deltas}, chunkAlias)
+			if innerErr != nil {
+				return failed(innerErr)
+			}
+
+			chunkURIs = append(chunkURIs, chunkURI)
+
+			artifacts = append(artifacts,
+				&protocol.AnchorDocument{
+					ID:   chunkURI,
+					Desc: "chunk file",
+					Type: protocol.TypeProvisional,
+				})
+		}
+
+		provisionalProofURI, innerErr := h.createProvisionalProofFile(ctx, parsedOps.Update)
 		if innerErr != nil {
-			return nil, innerErr
+			return failed(innerErr)
 		}
 
 		if provisionalProofURI != "" {
@@ -93,9 +190,10 @@ func (h *OperationHandler) PrepareTxnFiles(ops []*operation.QueuedOperation) (*p
 				})
 		}
 
-		provisionalIndexURI, innerErr = h.createProvisionalIndexFile([]string{chunkURI}, provisionalProofURI, parsedOps.Update)
+		provisionalIndexURI, innerErr = h.createProvisionalIndexFile(ctx, chunkURIs, provisionalProofURI,
+			parsedOps.Update)
 		if innerErr != nil {
-			return nil, innerErr
+			return failed(innerErr)
 		}
 
 		artifacts = append(artifacts,
@@ -106,9 +204,9 @@ func (h *OperationHandler) PrepareTxnFiles(ops []*operation.QueuedOperation) (*p
 			})
 	}
 
-	coreProofURI, err := h.createCoreProofFile(parsedOps.Recover, parsedOps.Deactivate)
+	coreProofURI, err := h.createCoreProofFile(ctx, parsedOps.Recover, parsedOps.Deactivate)
 	if err != nil {
-		return nil, err
+		return failed(err)
 	}
 
 	if coreProofURI != "" {
@@ -120,9 +218,9 @@ func (h *OperationHandler) PrepareTxnFiles(ops []*operation.QueuedOperation) (*p
 			})
 	}
 
-	coreIndexURI, err := h.createCoreIndexFile(coreProofURI, provisionalIndexURI, parsedOps)
+	coreIndexURI, err := h.createCoreIndexFile(ctx, coreProofURI, provisionalIndexURI, parsedOps)
 	if err != nil {
-		return nil, err
+		return failed(err)
 	}
 
 	artifacts = append(artifacts,
@@ -143,6 +241,7 @@ func (h *OperationHandler) PrepareTxnFiles(ops []*operation.QueuedOperation) (*p
 		OperationReferences:  info.OperationReferences,
 		ExpiredOperations:    info.ExpiredOperations,
 		AdditionalOperations: info.AdditionalOperations,
+		RejectedOperations:   info.RejectedOperations,
 	}, nil
 }
 
@@ -152,6 +251,7 @@ func (h *OperationHandler) parseOperations(ops []*operation.QueuedOperation) (*m
 	}
 
 	batchSuffixes := make(map[string]*operation.Reference)
+	queuedOps := make(map[string]*operation.QueuedOperation)
 
 	var expiredOperations []*operation.QueuedOperation
 	var additionalOperations []*operation.QueuedOperation
@@ -221,6 +321,7 @@ func (h *OperationHandler) parseOperations(ops []*operation.QueuedOperation) (*m
 		}
 
 		batchSuffixes[op.UniqueSuffix] = opRef
+		queuedOps[op.UniqueSuffix] = queuedOperation
 	}
 
 	opRefs := make([]*operation.Reference, 0, len(batchSuffixes))
@@ -232,59 +333,217 @@ func (h *OperationHandler) parseOperations(ops []*operation.QueuedOperation) (*m
 		OperationReferences:  opRefs,
 		ExpiredOperations:    expiredOperations,
 		AdditionalOperations: additionalOperations,
+		queuedOps:            queuedOps,
 	}, nil
 }
 
 // createCoreIndexFile will create core index file from operations, proof files and provisional index file and write it to CAS
 // returns core index file address.
-func (h *OperationHandler) createCoreIndexFile(coreProofURI, mapURI string, ops *models.SortedOperations) (string, error) {
+func (h *OperationHandler) createCoreIndexFile(ctx context.Context, coreProofURI, mapURI string,
+	ops *models.SortedOperations) (string, error) {
 	coreIndexFile := models.CreateCoreIndexFile(coreProofURI, mapURI, ops)
 
-	return h.writeModelToCAS(coreIndexFile, "core index")
+	return h.writeModelToCAS(ctx, coreIndexFile, coreIndexAlias)
 }
 
 // createCoreProofFile will create core proof file from recover and deactivate operations and write it to CAS
 // returns core proof file address.
-func (h *OperationHandler) createCoreProofFile(recoverOps, deactivateOps []*model.Operation) (string, error) {
+func (h *OperationHandler) createCoreProofFile(ctx context.Context, recoverOps, deactivateOps []*model.Operation) (string, error) {
 	if len(recoverOps)+len(deactivateOps) == 0 {
 		return "", nil
 	}
 
 	chunkFile := models.CreateCoreProofFile(recoverOps, deactivateOps)
 
-	return h.writeModelToCAS(chunkFile, "core proof")
+	return h.writeModelToCAS(ctx, chunkFile, coreProofAlias)
 }
 
 // createProvisionalProofFile will create provisional proof file from update operations and write it to CAS
 // returns provisional proof file address.
-func (h *OperationHandler) createProvisionalProofFile(updateOps []*model.Operation) (string, error) {
+func (h *OperationHandler) createProvisionalProofFile(ctx context.Context, updateOps []*model.Operation) (string, error) {
 	if len(updateOps) == 0 {
 		return "", nil
 	}
 
 	chunkFile := models.CreateProvisionalProofFile(updateOps)
 
-	return h.writeModelToCAS(chunkFile, "provisional proof")
+	return h.writeModelToCAS(ctx, chunkFile, provisionalProofAlias)
+}
+
+// chunkOperations assigns the deltas of the operations (in chunk file order) to chunk files. If the protocol
+// version allows multiple chunk files per batch then the deltas are split across as many chunk files as are
+// required to keep each chunk file within the maximum chunk file size. Operations whose deltas don't fit into
+// the maximum number of chunk files are removed from the batch and deferred to the next batch. An operation
+// whose delta doesn't fit into a chunk file on its own can never be anchored, so it's rejected.
+func (h *OperationHandler) chunkOperations(ops *models.SortedOperations,
+	info *additionalAnchoringInfo) ([][]*model.DeltaModel, error) {
+	deltas := models.CreateChunkFile(ops).Deltas
+	if len(deltas) == 0 {
+		return nil, nil
+	}
+
+	if h.maxChunkFiles <= 1 {
+		return [][]*model.DeltaModel{deltas}, nil
+	}
+
+	var deltaOps []*model.Operation
+
+	deltaOps = append(deltaOps, ops.Create...)
+	deltaOps = append(deltaOps, ops.Recover...)
+	deltaOps = append(deltaOps, ops.Update...)
+
+	var chunks [][]*model.DeltaModel
+
+	var deferred, rejected []*operation.QueuedOperation
+
+	removed := make(map[string]bool)
+
+	for len(deltas) > 0 {
+		if len(chunks) == h.maxChunkFiles {
+			logger.Info("Deltas of batch exceed the maximum number of chunk files - deferring remaining operations to the next batch",
+				logfields.WithTotal(len(deltas)))
+
+			for _, op := range deltaOps {
+				removed[op.UniqueSuffix] = true
+				deferred = append(deferred, info.queuedOps[op.UniqueSuffix])
+			}
+
+			break
+		}
+
+		n, err := h.maxDeltasInChunk(deltas)
+		if err != nil {
+			return nil, err
+		}
+
+		if n == 0 {
+			logger.Warn("Delta of operation doesn't fit into a chunk file - rejecting operation",
+				logfields.WithSuffix(deltaOps[0].UniqueSuffix), logfields.WithMaxSize(int(h.protocol.MaxChunkFileSize)))
+
+			removed[deltaOps[0].UniqueSuffix] = true
+			rejected = append(rejected, info.queuedOps[deltaOps[0].UniqueSuffix])
+
+			deltas, deltaOps = deltas[1:], deltaOps[1:]
+
+			continue
+		}
+
+		chunks = append(chunks, deltas[:n])
+		deltas, deltaOps = deltas[n:], deltaOps[n:]
+	}
+
+	if len(removed) > 0 {
+		ops.Create = withoutOperations(ops.Create, removed)
+		ops.Recover = withoutOperations(ops.Recover, removed)
+		ops.Update = withoutOperations(ops.Update, removed)
+
+		info.remove(removed)
+
+		// The deferred operations precede any additional operations for the same suffixes.
+		info.AdditionalOperations = append(deferred, info.AdditionalOperations...)
+		info.RejectedOperations = rejected
+	}
+
+	return chunks, nil
 }
 
-// createChunkFile will create chunk file from operations and write it to CAS
-// returns chunk file address.
-func (h *OperationHandler) createChunkFile(ops *models.SortedOperations) (string, error) {
-	chunkFile := models.CreateChunkFile(ops)
+// maxDeltasInChunk returns the number of leading deltas which fit into a single chunk file (zero if the first
+// delta doesn't fit on its own). Since compressing a chunk is expensive, the number is found using an
+// exponential search followed by a binary search.
+func (h *OperationHandler) maxDeltasInChunk(deltas []*model.DeltaModel) (int, error) {
+	fits, err := h.fitsInChunk(deltas)
+	if err != nil || fits {
+		return len(deltas), err
+	}
+
+	// Invariant: deltas[:lo] fit into a chunk file and deltas[:hi] don't.
+	lo, hi := 0, 1
+
+	for hi < len(deltas) {
+		fits, err = h.fitsInChunk(deltas[:hi])
+		if err != nil {
+			return 0, err
+		}
+
+		if !fits {
+			break
+		}
+
+		lo, hi = hi, hi*2 //nolint:gomnd
+	}
+
+	if hi > len(deltas) {
+		hi = len(deltas)
+	}
+
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2 //nolint:gomnd
+
+		fits, err = h.fitsInChunk(deltas[:mid])
+		if err != nil {
+			return 0, err
+		}
+
+		if fits {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo, nil
+}
+
+// fitsInChunk returns true if the compressed chunk file containing the given deltas doesn't exceed the
+// maximum chunk file size and the uncompressed chunk file doesn't exceed the maximum decompressed size.
+func (h *OperationHandler) fitsInChunk(deltas []*model.DeltaModel) (bool, error) {
+	if h.protocol.MaxChunkFileSize == 0 {
+		return true, nil
+	}
+
+	bytes, err := json.MarshalCanonical(&models.ChunkFile{Deltas: deltas})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal %s file: %s", chunkAlias, err.Error())
+	}
 
-	return h.writeModelToCAS(chunkFile, "chunk")
+	if h.protocol.MaxMemoryDecompressionFactor > 0 &&
+		len(bytes) > int(h.protocol.MaxChunkFileSize*h.protocol.MaxMemoryDecompressionFactor) {
+		return false, nil
+	}
+
+	compressedBytes, err := h.cp.Compress(h.protocol.CompressionAlgorithm, bytes)
+	if err != nil {
+		return false, err
+	}
+
+	return len(compressedBytes) <= int(h.protocol.MaxChunkFileSize), nil
 }
 
 // createProvisionalIndexFile will create provisional index file from operations, provisional proof URI
 // and chunk file URIs. The provisional index file is then written to CAS.
 // returns the address of the provisional index file in the CAS.
-func (h *OperationHandler) createProvisionalIndexFile(chunks []string, provisionalURI string, ops []*model.Operation) (string, error) {
+func (h *OperationHandler) createProvisionalIndexFile(ctx context.Context, chunks []string, provisionalURI string,
+	ops []*model.Operation) (string, error) {
 	provisionalIndexFile := models.CreateProvisionalIndexFile(chunks, provisionalURI, ops)
 
-	return h.writeModelToCAS(provisionalIndexFile, "provisional index")
+	return h.writeModelToCAS(ctx, provisionalIndexFile, provisionalIndexAlias)
 }
 
-func (h *OperationHandler) writeModelToCAS(m interface{}, alias string) (string, error) {
+func (h *OperationHandler) writeModelToCAS(ctx context.Context, m interface{}, alias string) (string, error) {
+	_, span := tracer.Start(ctx, "CAS.Write", trace.WithAttributes(tracing.WithCASDataType(alias)))
+	defer span.End()
+
+	address, err := h.doWriteModelToCAS(m, alias)
+	if err != nil {
+		return "", tracing.RecordError(span, err)
+	}
+
+	span.SetAttributes(tracing.WithCASURI(address))
+
+	return address, nil
+}
+
+func (h *OperationHandler) doWriteModelToCAS(m interface{}, alias string) (string, error) {
 	bytes, err := json.MarshalCanonical(m)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal %s file: %s", alias, err.Error())
@@ -298,7 +557,7 @@ func (h *OperationHandler) writeModelToCAS(m interface{}, alias string) (string,
 	}
 
 	// make file available in CAS
-	address, err := h.cas.Write(compressedBytes)
+	address, err := h.writeToCAS(alias, compressedBytes)
 	if err != nil {
 		return "", fmt.Errorf("failed to store %s file: %s", alias, err.Error())
 	}
@@ -308,8 +567,44 @@ func (h *OperationHandler) writeModelToCAS(m interface{}, alias string) (string,
 	return address, nil
 }
 
+func (h *OperationHandler) writeToCAS(alias string, content []byte) (string, error) {
+	if w, ok := h.cas.(aliasedCASWriter); ok {
+		return w.WriteWithAlias(alias, content)
+	}
+
+	return h.cas.Write(content)
+}
+
 type additionalAnchoringInfo struct {
 	OperationReferences  []*operation.Reference
 	ExpiredOperations    []*operation.QueuedOperation
 	AdditionalOperations []*operation.QueuedOperation
+	RejectedOperations   []*operation.QueuedOperation
+
+	queuedOps map[string]*operation.QueuedOperation
+}
+
+// remove removes the references of the given suffixes.
+func (info *additionalAnchoringInfo) remove(suffixes map[string]bool) {
+	var opRefs []*operation.Reference
+
+	for _, opRef := range info.OperationReferences {
+		if !suffixes[opRef.UniqueSuffix] {
+			opRefs = append(opRefs, opRef)
+		}
+	}
+
+	info.OperationReferences = opRefs
+}
+
+func withoutOperations(ops []*model.Operation, suffixes map[string]bool) []*model.Operation {
+	var result []*model.Operation
+
+	for _, op := range ops {
+		if !suffixes[op.UniqueSuffix] {
+			result = append(result, op)
+		}
+	}
+
+	return result
 }
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package txnprovider
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -29,6 +30,7 @@ import (
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
+	svcprotocol "github.com/trustbloc/sidetree-svc-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
 	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider/models"
@@ -320,6 +322,168 @@ func TestOperationHandler_PrepareTxnFiles(t *testing.T) {
 		require.Contains(t, err.Error(), "failed to store chunk file: CAS error")
 	})
 
+	t.Run("success - multiple chunk files", func(t *testing.T) {
+		p := protocol
+		p.MaxChunkFileSize = 230
+
+		ops := getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum)
+
+		handler := NewOperationHandler(
+			p,
+			mocks.NewMockCasClient(nil),
+			compression,
+			operationparser.New(p),
+			&mocks.MetricsProvider{},
+			WithHandlerMaxChunkFiles(10))
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.NoError(t, err)
+
+		var chunkURIs []string
+
+		for _, artifact := range anchoringInfo.Artifacts {
+			if artifact.Desc == "chunk file" {
+				chunkURIs = append(chunkURIs, artifact.ID)
+			}
+		}
+
+		require.Greater(t, len(chunkURIs), 1)
+
+		deltas := 0
+
+		for _, chunkURI := range chunkURIs {
+			bytes, err := handler.cas.Read(chunkURI)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(bytes), int(p.MaxChunkFileSize))
+
+			content, err := compression.Decompress(compressionAlgorithm, bytes)
+			require.NoError(t, err)
+
+			cf, err := models.ParseChunkFile(content)
+			require.NoError(t, err)
+			require.NotEmpty(t, cf.Deltas)
+
+			deltas += len(cf.Deltas)
+		}
+
+		require.Equal(t, createOpsNum+recoverOpsNum+updateOpsNum, deltas)
+	})
+
+	t.Run("success - deltas exceed maximum number of chunk files", func(t *testing.T) {
+		p := protocol
+		p.MaxChunkFileSize = 180
+
+		ops := getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum)
+
+		handler := NewOperationHandler(
+			p,
+			mocks.NewMockCasClient(nil),
+			compression,
+			operationparser.New(p),
+			&mocks.MetricsProvider{},
+			WithHandlerMaxChunkFiles(2))
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.NoError(t, err)
+		require.Empty(t, anchoringInfo.RejectedOperations)
+		require.NotEmpty(t, anchoringInfo.AdditionalOperations)
+		require.Len(t, anchoringInfo.OperationReferences, len(ops)-len(anchoringInfo.AdditionalOperations))
+
+		var chunkURIs []string
+
+		for _, artifact := range anchoringInfo.Artifacts {
+			if artifact.Desc == "chunk file" {
+				chunkURIs = append(chunkURIs, artifact.ID)
+			}
+		}
+
+		require.Len(t, chunkURIs, 2)
+
+		// The deferred operations aren't referenced by the batch.
+		anchored := make(map[string]bool)
+		for _, opRef := range anchoringInfo.OperationReferences {
+			anchored[opRef.UniqueSuffix] = true
+		}
+
+		for _, op := range anchoringInfo.AdditionalOperations {
+			require.False(t, anchored[op.UniqueSuffix])
+		}
+
+		ad, err := ParseAnchorData(anchoringInfo.AnchorString)
+		require.NoError(t, err)
+		require.Equal(t, len(anchoringInfo.OperationReferences), ad.NumberOfOperations)
+	})
+
+	t.Run("success - delta exceeds maximum chunk file size", func(t *testing.T) {
+		p := protocol
+		p.MaxChunkFileSize = 100
+
+		ops := getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum)
+
+		handler := NewOperationHandler(
+			p,
+			mocks.NewMockCasClient(nil),
+			compression,
+			operationparser.New(p),
+			&mocks.MetricsProvider{},
+			WithHandlerMaxChunkFiles(10))
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.NoError(t, err)
+		require.Empty(t, anchoringInfo.AdditionalOperations)
+		require.Len(t, anchoringInfo.RejectedOperations, createOpsNum+updateOpsNum+recoverOpsNum)
+		require.Len(t, anchoringInfo.OperationReferences, deactivateOpsNum)
+
+		// Only the deactivate operations are anchored so there are no provisional files.
+		require.Len(t, anchoringInfo.Artifacts, 2)
+	})
+
+	t.Run("success - all operations rejected", func(t *testing.T) {
+		p := protocol
+		p.MaxChunkFileSize = 100
+
+		ops := getTestOperations(createOpsNum, updateOpsNum, 0, recoverOpsNum)
+
+		handler := NewOperationHandler(
+			p,
+			mocks.NewMockCasClient(errors.New("no files should be written")),
+			compression,
+			operationparser.New(p),
+			&mocks.MetricsProvider{},
+			WithHandlerMaxChunkFiles(10))
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.NoError(t, err)
+		require.Empty(t, anchoringInfo.AnchorString)
+		require.Empty(t, anchoringInfo.Artifacts)
+		require.Empty(t, anchoringInfo.OperationReferences)
+		require.Empty(t, anchoringInfo.AdditionalOperations)
+		require.Len(t, anchoringInfo.RejectedOperations, createOpsNum+updateOpsNum+recoverOpsNum)
+	})
+
+	t.Run("error - write to CAS error for core proof file returns written files", func(t *testing.T) {
+		ops := getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum)
+
+		handler := NewOperationHandler(
+			protocol,
+			&mockAliasedCASWriter{MockCasClient: mocks.NewMockCasClient(nil), failAlias: coreProofAlias},
+			compression,
+			operationparser.New(protocol),
+			&mocks.MetricsProvider{})
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.Error(t, err)
+		require.Empty(t, anchoringInfo)
+		require.Contains(t, err.Error(), "failed to store core proof file: CAS error")
+
+		var filesErr *svcprotocol.TxnFilesError
+		require.True(t, errors.As(err, &filesErr))
+		require.Len(t, filesErr.Artifacts, 3)
+		require.Equal(t, "chunk file", filesErr.Artifacts[0].Desc)
+		require.Equal(t, "provisional proof file", filesErr.Artifacts[1].Desc)
+		require.Equal(t, "provisional index file", filesErr.Artifacts[2].Desc)
+	})
+
 	t.Run("error - write to CAS error for core index file", func(t *testing.T) {
 		ops := getTestOperations(0, 0, deactivateOpsNum, 0)
 
@@ -348,13 +512,13 @@ func TestWriteModelToCAS(t *testing.T) {
 		&mocks.MetricsProvider{})
 
 	t.Run("success", func(t *testing.T) {
-		address, err := handler.writeModelToCAS(&models.CoreIndexFile{}, "alias")
+		address, err := handler.writeModelToCAS(context.Background(), &models.CoreIndexFile{}, "alias")
 		require.NoError(t, err)
 		require.NotEmpty(t, address)
 	})
 
 	t.Run("error - marshal fails", func(t *testing.T) {
-		address, err := handler.writeModelToCAS("test", "alias")
+		address, err := handler.writeModelToCAS(context.Background(), "test", "alias")
 		require.Error(t, err)
 		require.Empty(t, address)
 		require.Contains(t, err.Error(), "failed to marshal alias file")
@@ -368,7 +532,7 @@ func TestWriteModelToCAS(t *testing.T) {
 			operationparser.New(protocol),
 			&mocks.MetricsProvider{})
 
-		address, err := handlerWithCASError.writeModelToCAS(&models.CoreIndexFile{}, "alias")
+		address, err := handlerWithCASError.writeModelToCAS(context.Background(), &models.CoreIndexFile{}, "alias")
 		require.Error(t, err)
 		require.Empty(t, address)
 		require.Contains(t, err.Error(), "failed to store alias file: CAS error")
@@ -386,7 +550,7 @@ func TestWriteModelToCAS(t *testing.T) {
 			&mocks.MetricsProvider{},
 		)
 
-		address, err := handlerWithProtocolError.writeModelToCAS(&models.CoreIndexFile{}, "alias")
+		address, err := handlerWithProtocolError.writeModelToCAS(context.Background(), &models.CoreIndexFile{}, "alias")
 		require.Error(t, err)
 		require.Empty(t, address)
 		require.Contains(t, err.Error(), "compression algorithm 'invalid' not supported")
@@ -740,3 +904,18 @@ func (mtv *mockTimeValidator) Validate(from, until int64) error {
 
 	return nil
 }
+
+// mockAliasedCASWriter fails to write the files with the given alias.
+type mockAliasedCASWriter struct {
+	*mocks.MockCasClient
+
+	failAlias string
+}
+
+func (m *mockAliasedCASWriter) WriteWithAlias(alias string, content []byte) (string, error) {
+	if alias == m.failAlias {
+		return "", errors.New("CAS error")
+	}
+
+	return m.Write(content)
+}
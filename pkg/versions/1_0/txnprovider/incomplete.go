@@ -0,0 +1,95 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
+)
+
+// IncompleteTxn describes a transaction whose operations were assembled without its provisional files
+// (because the files were pruned or are invalid). The create, recover and deactivate operations of the
+// transaction were returned without deltas, and the update operations were not returned at all.
+type IncompleteTxn struct {
+	// Txn is the transaction which should be processed again once its provisional files are available.
+	Txn txn.SidetreeTxn `json:"txn"`
+	// Reason is the error which occurred while retrieving the provisional files.
+	Reason string `json:"reason"`
+	// UnavailableUpdates contains the suffixes of the update operations which weren't returned. The suffixes
+	// are only known if the provisional index file could be retrieved.
+	UnavailableUpdates []string `json:"unavailableUpdates,omitempty"`
+	// NumUnavailableUpdates is the number of update operations which weren't returned.
+	NumUnavailableUpdates int `json:"numUnavailableUpdates"`
+	// Recorded is the time at which the transaction was recorded.
+	Recorded time.Time `json:"recorded"`
+}
+
+// incompleteTxnRecorder records the transactions which were processed without their provisional files.
+type incompleteTxnRecorder interface {
+	RecordIncompleteTxn(t *IncompleteTxn) error
+}
+
+// MemIncompleteTxnStore is an in-memory store of incomplete transactions, keyed by anchor string.
+type MemIncompleteTxnStore struct {
+	mutex sync.RWMutex
+	txns  map[string]*IncompleteTxn
+}
+
+// NewMemIncompleteTxnStore returns a new in-memory store of incomplete transactions.
+func NewMemIncompleteTxnStore() *MemIncompleteTxnStore {
+	return &MemIncompleteTxnStore{txns: make(map[string]*IncompleteTxn)}
+}
+
+// RecordIncompleteTxn adds the given transaction to the store, replacing a previous record of the transaction.
+func (s *MemIncompleteTxnStore) RecordIncompleteTxn(t *IncompleteTxn) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.txns[t.Txn.AnchorString] = t
+
+	return nil
+}
+
+// List returns the incomplete transactions ordered by transaction time and number.
+func (s *MemIncompleteTxnStore) List() []*IncompleteTxn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	txns := make([]*IncompleteTxn, 0, len(s.txns))
+
+	for _, t := range s.txns {
+		txns = append(txns, t)
+	}
+
+	sort.Slice(txns, func(i, j int) bool {
+		if txns[i].Txn.TransactionTime != txns[j].Txn.TransactionTime {
+			return txns[i].Txn.TransactionTime < txns[j].Txn.TransactionTime
+		}
+
+		return txns[i].Txn.TransactionNumber < txns[j].Txn.TransactionNumber
+	})
+
+	return txns
+}
+
+// Delete removes the transaction with the given anchor string from the store (e.g. after it was processed
+// again successfully).
+func (s *MemIncompleteTxnStore) Delete(anchorString string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.txns, anchorString)
+}
+
+type noopIncompleteTxnRecorder struct{}
+
+func (r *noopIncompleteTxnRecorder) RecordIncompleteTxn(*IncompleteTxn) error {
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
+)
+
+// InspectedFile contains the parsed content of a batch file. The content is nil if the file couldn't be
+// read or parsed.
+type InspectedFile struct {
+	Type    string      `json:"type"`
+	URI     string      `json:"uri"`
+	Content interface{} `json:"content,omitempty"`
+}
+
+// InspectedOperation is an operation which was assembled from the batch files.
+type InspectedOperation struct {
+	Type    operation.Type  `json:"type"`
+	Request json.RawMessage `json:"request"`
+}
+
+// assembleOperations assembles the operations from the batch files and adds them to the report, grouped by
+// suffix. The operations aren't assembled if the core files are unavailable or invalid, or if the batch contains
+// duplicate suffixes. If the provisional files are unavailable or invalid then only the core operations (without
+// deltas) are assembled.
+func (v *validator) assembleOperations(files *batchFiles, report *ValidationReport) {
+	if v.findings.hasErrors(coreIndexAlias, coreProofAlias) || v.findings.hasRuleError(RuleDuplicate) ||
+		(files.CoreIndex.CoreProofFileURI != "" && files.CoreProof == nil) {
+		return
+	}
+
+	if files.CoreIndex.ProvisionalIndexFileURI != "" &&
+		(files.Chunk == nil || v.findings.hasErrors(provisionalIndexAlias, provisionalProofAlias, chunkAlias) ||
+			(files.ProvisionalIndex.ProvisionalProofFileURI != "" && files.ProvisionalProof == nil)) {
+		files.provisionalErr = errors.New("provisional files are unavailable or invalid")
+	}
+
+	ops, err := v.assembleAnchoredOperations(files, &txn.SidetreeTxn{AnchorString: report.AnchorString})
+	if err != nil {
+		v.findings.in("", "").error(RuleAssemble, "", fmt.Errorf("assemble operations: %w", err))
+
+		return
+	}
+
+	report.Operations = make(map[string][]*InspectedOperation)
+
+	for _, op := range ops {
+		report.Operations[op.UniqueSuffix] = append(report.Operations[op.UniqueSuffix],
+			&InspectedOperation{Type: op.Type, Request: op.OperationRequest})
+	}
+}
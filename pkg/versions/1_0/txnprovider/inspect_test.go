@@ -0,0 +1,179 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+)
+
+func TestOperationProvider_ValidateAnchor_Inspect(t *testing.T) {
+	const createOpsNum = 2
+	const updateOpsNum = 3
+	const deactivateOpsNum = 2
+	const recoverOpsNum = 2
+
+	const totalOpsNum = createOpsNum + updateOpsNum + deactivateOpsNum + recoverOpsNum
+
+	pc := mocks.NewMockProtocolClient()
+	parser := operationparser.New(pc.Protocol)
+	cp := compression.New(compression.WithDefaultAlgorithms())
+
+	prepare := func(t *testing.T, prunedFiles ...string) (*prunedCAS, string) {
+		t.Helper()
+
+		cas := mocks.NewMockCasClient(nil)
+
+		anchoringInfo, err := NewOperationHandler(pc.Protocol, cas, cp, parser, &mocks.MetricsProvider{}).
+			PrepareTxnFiles(getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum))
+		require.NoError(t, err)
+
+		pruned := &prunedCAS{DCAS: cas, pruned: make(map[string]bool)}
+
+		for _, artifact := range anchoringInfo.Artifacts {
+			for _, desc := range prunedFiles {
+				if artifact.Desc == desc {
+					pruned.pruned[artifact.ID] = true
+				}
+			}
+		}
+
+		return pruned, anchoringInfo.AnchorString
+	}
+
+	t.Run("success", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).ValidateAnchor(context.Background(), anchorString)
+		require.True(t, report.Valid(), "unexpected findings: %v", report.Findings)
+		require.Equal(t, anchorString, report.AnchorString)
+		require.Equal(t, totalOpsNum, report.NumberOfOperations)
+		require.Len(t, report.Files, 5)
+		require.Equal(t, totalOpsNum, countInspectedOps(report))
+
+		for _, file := range report.Files {
+			require.NotNil(t, file.Content)
+		}
+
+		_, err := json.Marshal(report)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - core index file", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		ad, err := ParseAnchorData(anchorString)
+		require.NoError(t, err)
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).
+			ValidateCoreIndexFile(context.Background(), ad.CoreIndexFileURI)
+		require.True(t, report.Valid(), "unexpected findings: %v", report.Findings)
+		require.Empty(t, report.AnchorString)
+		require.Equal(t, totalOpsNum, countInspectedOps(report))
+	})
+
+	t.Run("all errors reported", func(t *testing.T) {
+		cas, anchorString := prepare(t, "provisional proof file")
+
+		p := pc.Protocol
+		p.MaxChunkFileSize = 10
+
+		report := NewOperationProvider(p, operationparser.New(p), cas, cp).
+			ValidateAnchor(context.Background(), anchorString)
+		require.False(t, report.Valid())
+		require.Len(t, report.Errors(), 2)
+		require.Equal(t, provisionalProofAlias, report.Errors()[0].File)
+		require.Contains(t, report.Errors()[0].Message, "retrieve CAS content")
+		require.Equal(t, chunkAlias, report.Errors()[1].File)
+		require.Contains(t, report.Errors()[1].Message, "exceeded maximum size 10")
+
+		// The core operations are assembled without the provisional files.
+		require.Equal(t, createOpsNum+recoverOpsNum+deactivateOpsNum, countInspectedOps(report))
+	})
+
+	t.Run("invalid file content is included", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		p := pc.Protocol
+		p.MaxCasURILength = 20
+
+		report := NewOperationProvider(p, operationparser.New(p), cas, cp).
+			ValidateAnchor(context.Background(), anchorString)
+		require.False(t, report.Valid())
+		require.Equal(t, coreIndexAlias, report.Errors()[0].File)
+		require.Contains(t, report.Errors()[0].Message, "core proof URI: CAS URI length")
+		require.NotNil(t, report.Files[0].Content)
+
+		// Every error in the core index file is reported.
+		require.Equal(t, coreIndexAlias, report.Errors()[1].File)
+		require.Contains(t, report.Errors()[1].Message, "provisional index URI: CAS URI length")
+
+		// The operations aren't assembled from an invalid core index file.
+		require.Empty(t, report.Operations)
+	})
+
+	t.Run("number of operations doesn't match", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		ad, err := ParseAnchorData(anchorString)
+		require.NoError(t, err)
+
+		ad.NumberOfOperations++
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).
+			ValidateAnchor(context.Background(), ad.GetAnchorString())
+		require.Len(t, report.Errors(), 1)
+		require.Contains(t, report.Errors()[0].Message, "number of txn ops[9] doesn't match anchor string num of ops[10]")
+	})
+
+	t.Run("core index file unavailable", func(t *testing.T) {
+		cas, anchorString := prepare(t, "core index file")
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).ValidateAnchor(context.Background(), anchorString)
+		require.Len(t, report.Errors(), 1)
+		require.Equal(t, coreIndexAlias, report.Errors()[0].File)
+		require.Len(t, report.Files, 1)
+		require.Nil(t, report.Files[0].Content)
+		require.Empty(t, report.Operations)
+	})
+
+	t.Run("core proof file unavailable", func(t *testing.T) {
+		cas, anchorString := prepare(t, "core proof file")
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).ValidateAnchor(context.Background(), anchorString)
+		require.Len(t, report.Errors(), 1)
+		require.Equal(t, coreProofAlias, report.Errors()[0].File)
+		require.Len(t, report.Files, 5)
+		require.Empty(t, report.Operations)
+	})
+
+	t.Run("invalid anchor string", func(t *testing.T) {
+		report := NewOperationProvider(pc.Protocol, parser, mocks.NewMockCasClient(nil), cp).
+			ValidateAnchor(context.Background(), "invalid")
+		require.Len(t, report.Errors(), 1)
+		require.Contains(t, report.Errors()[0].Message, "parse anchor data[invalid] failed")
+		require.Empty(t, report.Files)
+	})
+}
+
+func countInspectedOps(report *ValidationReport) int {
+	n := 0
+
+	for _, ops := range report.Operations {
+		n += len(ops)
+	}
+
+	return n
+}
@@ -7,36 +7,70 @@ SPDX-License-Identifier: Apache-2.0
 package txnprovider
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/trustbloc/logutil-go/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/trustbloc/sidetree-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/model"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
 	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider/models"
 )
 
-var logger = log.New("sidetree-svc-txnhandler")
+var (
+	logger = log.New("sidetree-svc-txnhandler")
+	tracer = otel.Tracer("sidetree-svc-txnhandler")
+)
 
 // DCAS interface to access content addressable storage.
 type DCAS interface {
 	Read(key string) ([]byte, error)
 }
 
+// aliasedDCAS is implemented by a CAS client which handles content differently depending on the file type.
+type aliasedDCAS interface {
+	ReadWithAlias(alias, address string) ([]byte, error)
+}
+
 type decompressionProvider interface {
-	Decompress(alg string, data []byte) ([]byte, error)
+	DecompressWithLimit(alg string, data []byte, maxSize uint64) ([]byte, error)
 }
 
 type sourceURIFormatter func(casURI, source string) (string, error)
 
+type readMetricsProvider interface {
+	sourceMetricsProvider
+
+	CASReadTime(duration time.Duration)
+}
+
 type options struct {
 	formatCASURIForSource sourceURIFormatter
+	metrics               readMetricsProvider
+	extractURIHash        uriHashExtractor
+	sourcePenalty         time.Duration
+	sourceInitialBackoff  time.Duration
+	sourceMaxBackoff      time.Duration
+	maxTrackedSources     int
+	hedgedReads           bool
+	hedgeDelay            time.Duration
+	maxConcurrentFetches  int
+	maxChunkFiles         int
+	prunedProvisional     bool
+	incompleteTxns        incompleteTxnRecorder
 }
 
 // Opt is an OperationProvider option.
@@ -50,6 +84,92 @@ func WithSourceCASURIFormatter(formatter sourceURIFormatter) Opt {
 	}
 }
 
+// WithURIHashExtractor sets the function which returns the encoded multihash of the content referenced
+// by a CAS URI. The multihash is used to verify the content that is read from CAS (and from alternate
+// sources) before it's decompressed. By default, a CAS URI which is an encoded multihash is verified. The
+// extractor returns ErrUnsupportedURIFormat for URIs whose content can't be verified.
+func WithURIHashExtractor(extractor uriHashExtractor) Opt {
+	return func(ops *options) {
+		ops.extractURIHash = extractor
+	}
+}
+
+// WithSourcePenalty sets the duration for which an alternate source which returned content that doesn't
+// match the CAS URI is skipped (default 10 minutes).
+func WithSourcePenalty(duration time.Duration) Opt {
+	return func(ops *options) {
+		ops.sourcePenalty = duration
+	}
+}
+
+// WithSourceBackoff sets the period for which an alternate source is skipped after a failed read. The
+// period doubles with each consecutive failure of the source, up to the given maximum (default 5 seconds,
+// up to 5 minutes).
+func WithSourceBackoff(initial, max time.Duration) Opt {
+	return func(ops *options) {
+		ops.sourceInitialBackoff = initial
+		ops.sourceMaxBackoff = max
+	}
+}
+
+// WithMaxTrackedSources sets the maximum number of alternate sources whose health is tracked (default 1000).
+// The alternate sources are supplied by the anchors, so the least recently used source is evicted when
+// the limit is reached.
+func WithMaxTrackedSources(value int) Opt {
+	return func(ops *options) {
+		ops.maxTrackedSources = value
+	}
+}
+
+// WithHedgedSourceReads enables hedged reads from the alternate sources: if the healthiest source hasn't
+// responded within the given delay then the second healthiest source is also read, and the first valid
+// response is used. A delay of zero reads from both sources at once.
+func WithHedgedSourceReads(delay time.Duration) Opt {
+	return func(ops *options) {
+		ops.hedgedReads = true
+		ops.hedgeDelay = delay
+	}
+}
+
+// WithMaxConcurrentFetches sets the maximum number of batch files of a transaction that are fetched
+// concurrently (default 4). A value of 1 fetches the batch files sequentially.
+func WithMaxConcurrentFetches(value int) Opt {
+	return func(ops *options) {
+		ops.maxConcurrentFetches = value
+	}
+}
+
+// WithMaxChunkFiles sets the maximum number of chunk files per batch which the protocol version allows
+// (default 1). The operation provider accepts provisional index files which reference up to this number
+// of chunk files (see also WithHandlerMaxChunkFiles).
+func WithMaxChunkFiles(value int) Opt {
+	return func(ops *options) {
+		ops.maxChunkFiles = value
+	}
+}
+
+// WithPrunedProvisionalFiles enables the processing of transactions whose provisional files (provisional index,
+// provisional proof and chunk files) can't be retrieved or are invalid, which the Sidetree protocol allows since
+// provisional files may be pruned. The create, recover and deactivate operations of such a transaction are
+// returned without deltas (so a create or recover results in a DID with an empty document which may only be
+// recovered or deactivated) and its update operations are omitted. The transaction is recorded with the given
+// recorder so that it may be processed again later. By default, a transaction fails if any of its provisional
+// files can't be retrieved.
+func WithPrunedProvisionalFiles(recorder incompleteTxnRecorder) Opt {
+	return func(ops *options) {
+		ops.prunedProvisional = true
+		ops.incompleteTxns = recorder
+	}
+}
+
+// WithMetricsProvider sets the provider which records the latency of CAS reads and the health of the
+// alternate sources.
+func WithMetricsProvider(metrics readMetricsProvider) Opt {
+	return func(ops *options) {
+		ops.metrics = metrics
+	}
+}
+
 // OperationProvider is an operation provider.
 type OperationProvider struct {
 	*options
@@ -58,6 +178,8 @@ type OperationProvider struct {
 	parser OperationParser
 	cas    DCAS
 	dp     decompressionProvider
+
+	sources *sourceManager
 }
 
 // OperationParser defines the functions for parsing operations.
@@ -79,36 +201,74 @@ func NewOperationProvider(p protocol.Protocol, parser OperationParser, cas DCAS,
 		formatCASURIForSource: func(_, _ string) (string, error) {
 			return "", errors.New("CAS URI formatter not defined")
 		},
+		metrics:              &noopMetricsProvider{},
+		extractURIHash:       multihashURIExtractor,
+		sourcePenalty:        defaultSourcePenalty,
+		sourceInitialBackoff: defaultSourceInitialBackoff,
+		sourceMaxBackoff:     defaultSourceMaxBackoff,
+		maxTrackedSources:    defaultMaxTrackedSources,
+		maxConcurrentFetches: defaultMaxConcurrentFetches,
+		maxChunkFiles:        defaultMaxChunkFiles,
+		incompleteTxns:       &noopIncompleteTxnRecorder{},
 	}
 
 	for _, opt := range opts {
 		opt(o)
 	}
 
+	if o.incompleteTxns == nil {
+		o.incompleteTxns = &noopIncompleteTxnRecorder{}
+	}
+
 	return &OperationProvider{
 		options:  o,
 		Protocol: p,
 		parser:   parser,
 		cas:      cas,
 		dp:       dp,
+		sources: newSourceManager(o.sourceInitialBackoff, o.sourceMaxBackoff, o.sourcePenalty,
+			o.maxTrackedSources, o.metrics),
 	}
 }
 
 // GetTxnOperations will read batch files(core/provisional index, proof files and chunk file)
 // and assemble batch operations from those files.
 func (h *OperationProvider) GetTxnOperations(t *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error) {
+	return h.GetTxnOperationsWithContext(context.Background(), t)
+}
+
+// GetTxnOperationsWithContext will read batch files(core/provisional index, proof files and chunk file)
+// and assemble batch operations from those files. The context carries the (optional) trace span of the caller.
+func (h *OperationProvider) GetTxnOperationsWithContext(ctx context.Context,
+	t *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error) {
+	ctx, span := tracer.Start(ctx, "OperationProvider.GetTxnOperations",
+		trace.WithAttributes(tracing.WithNamespace(t.Namespace), tracing.WithAnchorString(t.AnchorString),
+			tracing.WithTransactionNumber(t.TransactionNumber)))
+	defer span.End()
+
+	txnOps, err := h.getTxnOperations(ctx, t)
+	if err != nil {
+		return nil, tracing.RecordError(span, err)
+	}
+
+	span.SetAttributes(tracing.WithTotal(len(txnOps)))
+
+	return txnOps, nil
+}
+
+func (h *OperationProvider) getTxnOperations(ctx context.Context, t *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error) {
 	// parse core index file URI and number of operations from anchor string
 	anchorData, err := ParseAnchorData(t.AnchorString)
 	if err != nil {
 		return nil, err
 	}
 
-	cif, err := h.getCoreIndexFile(anchorData.CoreIndexFileURI, t.AlternateSources...)
+	cif, err := h.getCoreIndexFile(ctx, anchorData.CoreIndexFileURI, t.AlternateSources...)
 	if err != nil {
 		return nil, err
 	}
 
-	batchFiles, err := h.getBatchFiles(cif, t.AlternateSources...)
+	batchFiles, err := h.getBatchFiles(ctx, cif, t.AlternateSources...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +278,10 @@ func (h *OperationProvider) GetTxnOperations(t *txn.SidetreeTxn) ([]*operation.A
 		return nil, err
 	}
 
+	if batchFiles.provisionalErr != nil {
+		return txnOps, h.recordIncompleteTxn(t, anchorData, batchFiles, txnOps)
+	}
+
 	if len(txnOps) != anchorData.NumberOfOperations {
 		return nil, fmt.Errorf("number of txn ops[%d] doesn't match anchor string num of ops[%d]", len(txnOps), anchorData.NumberOfOperations)
 	}
@@ -125,6 +289,43 @@ func (h *OperationProvider) GetTxnOperations(t *txn.SidetreeTxn) ([]*operation.A
 	return txnOps, nil
 }
 
+// recordIncompleteTxn records a transaction whose operations were assembled without its provisional files. The
+// operations which weren't assembled are assumed to be updates.
+func (h *OperationProvider) recordIncompleteTxn(t *txn.SidetreeTxn, anchorData *AnchorData, files *batchFiles,
+	txnOps []*operation.AnchoredOperation) error {
+	incomplete := &IncompleteTxn{
+		Txn:                   *t,
+		Reason:                files.provisionalErr.Error(),
+		NumUnavailableUpdates: anchorData.NumberOfOperations - len(txnOps),
+		Recorded:              time.Now(),
+	}
+
+	if files.ProvisionalIndex != nil {
+		incomplete.UnavailableUpdates = parseProvisionalIndexOperations(files.ProvisionalIndex).Suffixes
+
+		if len(txnOps)+len(incomplete.UnavailableUpdates) != anchorData.NumberOfOperations {
+			return fmt.Errorf("number of txn ops[%d] doesn't match anchor string num of ops[%d]",
+				len(txnOps)+len(incomplete.UnavailableUpdates), anchorData.NumberOfOperations)
+		}
+	}
+
+	if incomplete.NumUnavailableUpdates < 0 {
+		return fmt.Errorf("number of txn ops[%d] doesn't match anchor string num of ops[%d]",
+			len(txnOps), anchorData.NumberOfOperations)
+	}
+
+	logger.Warn("Provisional files of transaction are unavailable. Processing core operations without deltas.",
+		logfields.WithAnchorString(t.AnchorString), logfields.WithTransactionNumber(t.TransactionNumber),
+		logfields.WithTotalUpdateOperations(incomplete.NumUnavailableUpdates),
+		logfields.WithReason(incomplete.Reason))
+
+	if err := h.incompleteTxns.RecordIncompleteTxn(incomplete); err != nil {
+		return fmt.Errorf("record incomplete transaction: %w", err)
+	}
+
+	return nil
+}
+
 // batchFiles contains the content of all batch files that are referenced in core index file.
 type batchFiles struct {
 	CoreIndex        *models.CoreIndexFile
@@ -132,6 +333,10 @@ type batchFiles struct {
 	ProvisionalIndex *models.ProvisionalIndexFile
 	ProvisionalProof *models.ProvisionalProofFile
 	Chunk            *models.ChunkFile
+
+	// provisionalErr is set if the provisional files couldn't be retrieved (or are invalid) and the transaction
+	// is processed without them. The provisional index file is set if it could be retrieved.
+	provisionalErr error
 }
 
 type provisionalFiles struct {
@@ -140,80 +345,169 @@ type provisionalFiles struct {
 	Chunk            *models.ChunkFile
 }
 
-// getBatchFiles retrieves all batch files that are referenced in core index file.
-func (h *OperationProvider) getBatchFiles(cif *models.CoreIndexFile, alternateSources ...string) (*batchFiles, error) {
-	var err error
-
+// getBatchFiles retrieves all batch files that are referenced in core index file. The core proof file is
+// fetched concurrently with the provisional index file, and the provisional proof file is fetched
+// concurrently with the chunk file. Errors are reported in the same order as if the files were fetched
+// sequentially. If processing transactions with pruned provisional files is enabled then an error which occurs
+// while retrieving the provisional files doesn't fail the transaction.
+func (h *OperationProvider) getBatchFiles(ctx context.Context, cif *models.CoreIndexFile, alternateSources ...string) (*batchFiles, error) {
 	files := &batchFiles{CoreIndex: cif}
 
+	g := newFetchGroup(ctx, h.maxConcurrentFetches)
+	defer g.stop()
+
+	var coreProofTask, provisionalTask *fetchTask
+
 	// core proof file will not exist if we have only update operations in the batch
 	if cif.CoreProofFileURI != "" {
-		files.CoreProof, err = h.getCoreProofFile(cif.CoreProofFileURI, alternateSources...)
-		if err != nil {
-			return nil, err
-		}
+		coreProofTask = g.goFetch(func(ctx context.Context) (err error) {
+			files.CoreProof, err = h.getCoreProofFile(ctx, cif.CoreProofFileURI, alternateSources...)
+
+			return err
+		})
 	}
 
 	if cif.ProvisionalIndexFileURI != "" {
-		provisionalFiles, innerErr := h.getProvisionalFiles(cif.ProvisionalIndexFileURI, alternateSources...)
-		if innerErr != nil {
-			return nil, innerErr
+		provisionalFiles, err := h.getProvisionalFiles(g, cif.ProvisionalIndexFileURI, alternateSources...)
+
+		switch {
+		case err == nil:
+			files.ProvisionalIndex = provisionalFiles.ProvisionalIndex
+			files.ProvisionalProof = provisionalFiles.ProvisionalProof
+			files.Chunk = provisionalFiles.Chunk
+		case h.prunedProvisional:
+			files.ProvisionalIndex = provisionalFiles.ProvisionalIndex
+			files.provisionalErr = err
+			err = nil
 		}
 
-		files.ProvisionalIndex = provisionalFiles.ProvisionalIndex
-		files.ProvisionalProof = provisionalFiles.ProvisionalProof
-		files.Chunk = provisionalFiles.Chunk
+		provisionalTask = g.completed(err)
+	}
+
+	if err := g.wait(coreProofTask, provisionalTask); err != nil {
+		return nil, err
+	}
+
+	// The provisional files may have been abandoned because the transaction was canceled.
+	if files.provisionalErr != nil && ctx.Err() != nil {
+		return nil, files.provisionalErr
 	}
 
 	// validate batch file counts
-	err = validateBatchFileCounts(files)
-	if err != nil {
+	if err := validateCoreFileCounts(files); err != nil {
 		return nil, err
 	}
 
+	if cif.ProvisionalIndexFileURI != "" && files.provisionalErr == nil {
+		if err := validateProvisionalFileCounts(files); err != nil {
+			if !h.prunedProvisional {
+				return nil, err
+			}
+
+			files.provisionalErr = err
+		}
+	}
+
 	logger.Debug("Successfully downloaded and validated all batch files")
 
 	return files, nil
 }
 
-func (h *OperationProvider) getProvisionalFiles(provisionalIndexURI string, alternateSources ...string) (*provisionalFiles, error) {
-	var err error
+// getProvisionalFiles retrieves the provisional files. If an error occurs then the files which were retrieved
+// are returned along with the error.
+func (h *OperationProvider) getProvisionalFiles(g *fetchGroup, provisionalIndexURI string, alternateSources ...string) (*provisionalFiles, error) {
 	files := &provisionalFiles{}
 
-	files.ProvisionalIndex, err = h.getProvisionalIndexFile(provisionalIndexURI, alternateSources...)
+	err := g.wait(g.fetch(func(ctx context.Context) (err error) {
+		files.ProvisionalIndex, err = h.getProvisionalIndexFile(ctx, provisionalIndexURI, alternateSources...)
+
+		return err
+	}))
 	if err != nil {
-		return nil, err
+		return files, err
 	}
 
+	var provisionalProofTask *fetchTask
+
 	// provisional proof file will not exist if we don't have any update operations in the batch
 	if files.ProvisionalIndex.ProvisionalProofFileURI != "" {
-		files.ProvisionalProof, err = h.getProvisionalProofFile(files.ProvisionalIndex.ProvisionalProofFileURI, alternateSources...)
-		if err != nil {
-			return nil, err
-		}
+		provisionalProofTask = g.goFetch(func(ctx context.Context) (err error) {
+			files.ProvisionalProof, err = h.getProvisionalProofFile(ctx,
+				files.ProvisionalIndex.ProvisionalProofFileURI, alternateSources...)
+
+			return err
+		})
 	}
 
 	if len(files.ProvisionalIndex.Chunks) == 0 {
-		return nil, errors.Errorf("provisional index file is missing chunk file URI")
+		return files, g.wait(provisionalProofTask,
+			g.completed(errors.Errorf("provisional index file is missing chunk file URI")))
 	}
 
-	chunkURI := files.ProvisionalIndex.Chunks[0].ChunkFileURI
-	files.Chunk, err = h.getChunkFile(chunkURI, alternateSources...)
-	if err != nil {
-		return nil, err
+	chunks := make([]*models.ChunkFile, len(files.ProvisionalIndex.Chunks))
+	chunkTasks := make([]*fetchTask, len(files.ProvisionalIndex.Chunks))
+
+	for i, chunk := range files.ProvisionalIndex.Chunks {
+		i, chunkURI := i, chunk.ChunkFileURI
+
+		chunkTasks[i] = g.goFetch(func(ctx context.Context) (err error) {
+			chunks[i], err = h.getChunkFile(ctx, chunkURI, alternateSources...)
+
+			return err
+		})
 	}
 
+	if err := g.wait(append([]*fetchTask{provisionalProofTask}, chunkTasks...)...); err != nil {
+		return files, err
+	}
+
+	files.Chunk = mergeChunkFiles(chunks)
+
 	return files, nil
 }
 
+// mergeChunkFiles combines the deltas of the chunk files (in order) into a single chunk file.
+func mergeChunkFiles(chunks []*models.ChunkFile) *models.ChunkFile {
+	if len(chunks) == 1 {
+		return chunks[0]
+	}
+
+	merged := &models.ChunkFile{}
+
+	for _, chunk := range chunks {
+		merged.Deltas = append(merged.Deltas, chunk.Deltas...)
+	}
+
+	return merged
+}
+
 // validateBatchFileCounts validates that operation numbers match in batch files.
 func validateBatchFileCounts(batchFiles *batchFiles) error {
-	coreCreateNum := 0
+	if err := validateCoreFileCounts(batchFiles); err != nil {
+		return err
+	}
+
+	if batchFiles.CoreIndex.ProvisionalIndexFileURI == "" {
+		return nil
+	}
+
+	return validateProvisionalFileCounts(batchFiles)
+}
+
+// validateCoreFileCounts validates that operation numbers match in core index and core proof files.
+func validateCoreFileCounts(batchFiles *batchFiles) error {
+	f := &findings{}
+
+	checkCoreFileCounts(batchFiles, f)
+
+	return f.err()
+}
+
+func checkCoreFileCounts(batchFiles *batchFiles, f *findings) {
 	coreRecoverNum := 0
 	coreDeactivateNum := 0
 
 	if batchFiles.CoreIndex.Operations != nil {
-		coreCreateNum = len(batchFiles.CoreIndex.Operations.Create)
 		coreRecoverNum = len(batchFiles.CoreIndex.Operations.Recover)
 		coreDeactivateNum = len(batchFiles.CoreIndex.Operations.Deactivate)
 	}
@@ -222,41 +516,75 @@ func validateBatchFileCounts(batchFiles *batchFiles) error {
 		coreProofRecoverNum := len(batchFiles.CoreProof.Operations.Recover)
 		coreProofDeactivateNum := len(batchFiles.CoreProof.Operations.Deactivate)
 
+		f.in(coreProofAlias, batchFiles.CoreIndex.CoreProofFileURI)
+
 		if coreRecoverNum != coreProofRecoverNum {
-			return fmt.Errorf("number of recover ops[%d] in core index doesn't match number of recover ops[%d] in core proof",
-				coreRecoverNum, coreProofRecoverNum)
+			f.error(RuleCount, "/operations/recover",
+				fmt.Errorf("number of recover ops[%d] in core index doesn't match number of recover ops[%d] in core proof",
+					coreRecoverNum, coreProofRecoverNum))
 		}
 
 		if coreDeactivateNum != coreProofDeactivateNum {
-			return fmt.Errorf("number of deactivate ops[%d] in core index doesn't match number of deactivate ops[%d] in core proof",
-				coreDeactivateNum, coreProofDeactivateNum)
+			f.error(RuleCount, "/operations/deactivate",
+				fmt.Errorf("number of deactivate ops[%d] in core index doesn't match number of deactivate ops[%d] in core proof",
+					coreDeactivateNum, coreProofDeactivateNum))
 		}
 	}
+}
 
-	if batchFiles.CoreIndex.ProvisionalIndexFileURI != "" { //nolint:nestif
-		provisionalUpdateNum := 0
-		if batchFiles.ProvisionalIndex.Operations != nil {
-			provisionalUpdateNum = len(batchFiles.ProvisionalIndex.Operations.Update)
-		}
+// validateProvisionalFileCounts validates that operation numbers match in provisional index, provisional proof
+// and chunk files.
+func validateProvisionalFileCounts(batchFiles *batchFiles) error {
+	f := &findings{}
 
-		if batchFiles.ProvisionalIndex.ProvisionalProofFileURI != "" {
-			provisionalProofUpdateNum := len(batchFiles.ProvisionalProof.Operations.Update)
+	checkProvisionalFileCounts(batchFiles, f)
 
-			if provisionalUpdateNum != provisionalProofUpdateNum {
-				return fmt.Errorf("number of update ops[%d] in provisional index doesn't match number of update ops[%d] in provisional proof",
-					provisionalUpdateNum, provisionalProofUpdateNum)
-			}
-		}
+	return f.err()
+}
 
-		expectedDeltaCount := coreCreateNum + coreRecoverNum + provisionalUpdateNum
+func checkProvisionalFileCounts(batchFiles *batchFiles, f *findings) {
+	coreCreateNum := 0
+	coreRecoverNum := 0
+
+	if batchFiles.CoreIndex.Operations != nil {
+		coreCreateNum = len(batchFiles.CoreIndex.Operations.Create)
+		coreRecoverNum = len(batchFiles.CoreIndex.Operations.Recover)
+	}
+
+	provisionalUpdateNum := 0
+	if batchFiles.ProvisionalIndex.Operations != nil {
+		provisionalUpdateNum = len(batchFiles.ProvisionalIndex.Operations.Update)
+	}
 
-		if expectedDeltaCount != len(batchFiles.Chunk.Deltas) {
-			return fmt.Errorf("number of create+recover+update operations[%d] doesn't match number of deltas[%d]",
-				expectedDeltaCount, len(batchFiles.Chunk.Deltas))
+	if batchFiles.ProvisionalIndex.ProvisionalProofFileURI != "" {
+		provisionalProofUpdateNum := len(batchFiles.ProvisionalProof.Operations.Update)
+
+		if provisionalUpdateNum != provisionalProofUpdateNum {
+			f.in(provisionalProofAlias, batchFiles.ProvisionalIndex.ProvisionalProofFileURI).error(RuleCount,
+				"/operations/update",
+				fmt.Errorf("number of update ops[%d] in provisional index doesn't match number of update ops[%d] in provisional proof",
+					provisionalUpdateNum, provisionalProofUpdateNum))
 		}
 	}
 
-	return nil
+	expectedDeltaCount := coreCreateNum + coreRecoverNum + provisionalUpdateNum
+
+	if expectedDeltaCount != len(batchFiles.Chunk.Deltas) {
+		f.in(chunkAlias, chunkFileURIs(batchFiles.ProvisionalIndex)).error(RuleCount, "/deltas",
+			fmt.Errorf("number of create+recover+update operations[%d] doesn't match number of deltas[%d]",
+				expectedDeltaCount, len(batchFiles.Chunk.Deltas)))
+	}
+}
+
+// chunkFileURIs returns the URIs of the chunk files as a comma-separated string.
+func chunkFileURIs(pif *models.ProvisionalIndexFile) string {
+	uris := make([]string, len(pif.Chunks))
+
+	for i, chunk := range pif.Chunks {
+		uris[i] = chunk.ChunkFileURI
+	}
+
+	return strings.Join(uris, ",")
 }
 
 func createAnchoredOperations(ops []*model.Operation) ([]*operation.AnchoredOperation, error) {
@@ -291,7 +619,10 @@ func (h *OperationProvider) assembleAnchoredOperations(batchFiles *batchFiles, t
 		return createAnchoredOperations(cifOps.Deactivate)
 	}
 
-	pifOps := parseProvisionalIndexOperations(batchFiles.ProvisionalIndex)
+	pifOps := &provisionalOperations{}
+	if batchFiles.ProvisionalIndex != nil {
+		pifOps = parseProvisionalIndexOperations(batchFiles.ProvisionalIndex)
+	}
 
 	logger.Debug("Successfully parsed provisional index operations", logfields.WithTotalUpdateOperations(len(pifOps.Update)))
 
@@ -320,6 +651,14 @@ func (h *OperationProvider) assembleAnchoredOperations(batchFiles *batchFiles, t
 
 	operations = append(operations, cifOps.Recover...)
 
+	if batchFiles.provisionalErr != nil {
+		// Without the provisional files the update operations can't be processed and the create and recover
+		// operations don't have deltas.
+		operations = append(operations, cifOps.Deactivate...)
+
+		return createAnchoredOperations(operations)
+	}
+
 	// add signed data from provisional proof file
 	for i := range pifOps.Update {
 		pifOps.Update[i].SignedData = batchFiles.ProvisionalProof.Operations.Update[i]
@@ -363,8 +702,8 @@ func checkForDuplicates(values []string) error {
 }
 
 // getCoreIndexFile will download core index file from cas and parse it into core index file model.
-func (h *OperationProvider) getCoreIndexFile(uri string, alternateSources ...string) (*models.CoreIndexFile, error) { //nolint:dupl
-	content, err := h.readFromCAS(uri, h.MaxCoreIndexFileSize, alternateSources...)
+func (h *OperationProvider) getCoreIndexFile(ctx context.Context, uri string, alternateSources ...string) (*models.CoreIndexFile, error) { //nolint:dupl
+	content, err := h.readFromCAS(ctx, coreIndexAlias, uri, h.MaxCoreIndexFileSize, alternateSources...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error reading core index file")
 	}
@@ -385,6 +724,14 @@ func (h *OperationProvider) getCoreIndexFile(uri string, alternateSources ...str
 }
 
 func (h *OperationProvider) validateCoreIndexFile(cif *models.CoreIndexFile) error {
+	f := &findings{}
+
+	h.checkCoreIndexFile(cif, f)
+
+	return f.err()
+}
+
+func (h *OperationProvider) checkCoreIndexFile(cif *models.CoreIndexFile, f *findings) {
 	recoverNum := 0
 	deactivateNum := 0
 
@@ -394,85 +741,83 @@ func (h *OperationProvider) validateCoreIndexFile(cif *models.CoreIndexFile) err
 	}
 
 	if recoverNum+deactivateNum > 0 && cif.CoreProofFileURI == "" {
-		return errors.New("missing core proof file URI")
+		f.error(RuleRequired, "/coreProofFileUri", errors.New("missing core proof file URI"))
 	}
 
 	if recoverNum+deactivateNum == 0 && len(cif.CoreProofFileURI) > 0 {
-		return errors.New("core proof file URI should be empty if there are no recover and/or deactivate operations")
-	}
-
-	err := h.validateCoreIndexCASReferences(cif)
-	if err != nil {
-		return err
+		f.error(RuleRequired, "/coreProofFileUri",
+			errors.New("core proof file URI should be empty if there are no recover and/or deactivate operations"))
 	}
 
-	return h.validateCoreIndexOperations(cif.Operations)
+	h.checkCoreIndexCASReferences(cif, f)
+	h.checkCoreIndexOperations(cif.Operations, f)
 }
 
-func (h *OperationProvider) validateCoreIndexCASReferences(cif *models.CoreIndexFile) error {
-	if err := h.validateURI(cif.CoreProofFileURI); err != nil {
-		return errors.Wrapf(err, "core proof URI")
-	}
-
-	if err := h.validateURI(cif.ProvisionalIndexFileURI); err != nil {
-		return errors.Wrapf(err, "provisional index URI")
-	}
-
-	return nil
+func (h *OperationProvider) checkCoreIndexCASReferences(cif *models.CoreIndexFile, f *findings) {
+	h.checkURI(cif.CoreProofFileURI, "/coreProofFileUri", "core proof URI", f)
+	h.checkURI(cif.ProvisionalIndexFileURI, "/provisionalIndexFileUri", "provisional index URI", f)
 }
 
-func (h *OperationProvider) validateCoreIndexOperations(ops *models.CoreOperations) error {
+func (h *OperationProvider) checkCoreIndexOperations(ops *models.CoreOperations, f *findings) {
 	if ops == nil { // nothing to do
-		return nil
+		return
 	}
 
 	for i, op := range ops.Create {
 		err := h.parser.ValidateSuffixData(op.SuffixData)
 		if err != nil {
-			return fmt.Errorf("failed to validate suffix data for create[%d]: %s", i, err.Error())
+			f.error(RuleSuffixData, fmt.Sprintf("/operations/create/%d/suffixData", i),
+				fmt.Errorf("failed to validate suffix data for create[%d]: %s", i, err.Error()))
 		}
 	}
 
 	for i, op := range ops.Recover {
-		err := h.validateOperationReference(op)
-		if err != nil {
-			return fmt.Errorf("failed to validate operation reference for recover[%d]: %s", i, err.Error())
-		}
+		h.checkOperationReference(op, "recover", i, f)
 	}
 
 	for i, op := range ops.Deactivate {
-		err := h.validateOperationReference(op)
-		if err != nil {
-			return fmt.Errorf("failed to validate operation reference for deactivate[%d]: %s", i, err.Error())
-		}
+		h.checkOperationReference(op, "deactivate", i, f)
 	}
-
-	return nil
 }
 
-func (h *OperationProvider) validateOperationReference(op models.OperationReference) error {
-	if err := h.validateRequiredMultihash(op.DidSuffix, "did suffix"); err != nil {
-		return err
+func (h *OperationProvider) checkOperationReference(op models.OperationReference, opType string, i int, f *findings) {
+	pointer := fmt.Sprintf("/operations/%s/%d", opType, i)
+
+	check := func(mh, field, alias string) {
+		rule, err := h.validateRequiredMultihash(mh, alias)
+		if err != nil {
+			f.error(rule, pointer+"/"+field,
+				fmt.Errorf("failed to validate operation reference for %s[%d]: %s", opType, i, err.Error()))
+
+			return
+		}
+
+		if !hashing.IsComputedUsingMultihashAlgorithms(mh, h.MultihashAlgorithms) {
+			f.warning(RuleMultihash, pointer+"/"+field,
+				fmt.Errorf("%s for %s[%d] is not computed with the required hash algorithms: %d",
+					alias, opType, i, h.MultihashAlgorithms))
+		}
 	}
 
-	return h.validateRequiredMultihash(op.RevealValue, "reveal value")
+	check(op.DidSuffix, "didSuffix", "did suffix")
+	check(op.RevealValue, "revealValue", "reveal value")
 }
 
-func (h *OperationProvider) validateRequiredMultihash(mh, alias string) error {
+func (h *OperationProvider) validateRequiredMultihash(mh, alias string) (Rule, error) {
 	if mh == "" {
-		return fmt.Errorf("missing %s", alias)
+		return RuleRequired, fmt.Errorf("missing %s", alias)
 	}
 
 	if len(mh) > int(h.MaxOperationHashLength) {
-		return fmt.Errorf("%s length[%d] exceeds maximum hash length[%d]", alias, len(mh), h.MaxOperationHashLength)
+		return RuleHashLength, fmt.Errorf("%s length[%d] exceeds maximum hash length[%d]", alias, len(mh), h.MaxOperationHashLength)
 	}
 
-	return nil
+	return "", nil
 }
 
 // getCoreProofFile will download core proof file from cas and parse it into core proof file model.
-func (h *OperationProvider) getCoreProofFile(uri string, alternateSources ...string) (*models.CoreProofFile, error) { //nolint:dupl
-	content, err := h.readFromCAS(uri, h.MaxProofFileSize, alternateSources...)
+func (h *OperationProvider) getCoreProofFile(ctx context.Context, uri string, alternateSources ...string) (*models.CoreProofFile, error) { //nolint:dupl
+	content, err := h.readFromCAS(ctx, coreProofAlias, uri, h.MaxProofFileSize, alternateSources...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error reading core proof file")
 	}
@@ -493,28 +838,36 @@ func (h *OperationProvider) getCoreProofFile(uri string, alternateSources ...str
 }
 
 func (h *OperationProvider) validateCoreProofFile(cpf *models.CoreProofFile) error {
+	f := &findings{}
+
+	h.checkCoreProofFile(cpf, f)
+
+	return f.err()
+}
+
+func (h *OperationProvider) checkCoreProofFile(cpf *models.CoreProofFile, f *findings) {
 	for i, signedData := range cpf.Operations.Recover {
 		_, err := h.parser.ParseSignedDataForRecover(signedData)
 		if err != nil {
-			return fmt.Errorf("failed to validate signed data for recover[%d]: %s", i, err.Error())
+			f.error(RuleSignedData, fmt.Sprintf("/operations/recover/%d", i),
+				fmt.Errorf("failed to validate signed data for recover[%d]: %s", i, err.Error()))
 		}
 	}
 
 	for i, signedData := range cpf.Operations.Deactivate {
 		_, err := h.parser.ParseSignedDataForDeactivate(signedData)
 		if err != nil {
-			return fmt.Errorf("failed to validate signed data for deactivate[%d]: %s", i, err.Error())
+			f.error(RuleSignedData, fmt.Sprintf("/operations/deactivate/%d", i),
+				fmt.Errorf("failed to validate signed data for deactivate[%d]: %s", i, err.Error()))
 		}
 	}
-
-	return nil
 }
 
 // getProvisionalProofFile will download provisional proof file from cas and parse it into provisional proof file model.
 //
 //nolint:dupl
-func (h *OperationProvider) getProvisionalProofFile(uri string, alternateSources ...string) (*models.ProvisionalProofFile, error) {
-	content, err := h.readFromCAS(uri, h.MaxProofFileSize, alternateSources...)
+func (h *OperationProvider) getProvisionalProofFile(ctx context.Context, uri string, alternateSources ...string) (*models.ProvisionalProofFile, error) {
+	content, err := h.readFromCAS(ctx, provisionalProofAlias, uri, h.MaxProofFileSize, alternateSources...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error reading provisional proof file")
 	}
@@ -535,21 +888,28 @@ func (h *OperationProvider) getProvisionalProofFile(uri string, alternateSources
 }
 
 func (h *OperationProvider) validateProvisionalProofFile(ppf *models.ProvisionalProofFile) error {
+	f := &findings{}
+
+	h.checkProvisionalProofFile(ppf, f)
+
+	return f.err()
+}
+
+func (h *OperationProvider) checkProvisionalProofFile(ppf *models.ProvisionalProofFile, f *findings) {
 	for i, signedData := range ppf.Operations.Update {
 		_, err := h.parser.ParseSignedDataForUpdate(signedData)
 		if err != nil {
-			return fmt.Errorf("failed to validate signed data for update[%d]: %s", i, err.Error())
+			f.error(RuleSignedData, fmt.Sprintf("/operations/update/%d", i),
+				fmt.Errorf("failed to validate signed data for update[%d]: %s", i, err.Error()))
 		}
 	}
-
-	return nil
 }
 
 // getProvisionalIndexFile will download provisional index file from cas and parse it into provisional index file model.
 //
 //nolint:dupl
-func (h *OperationProvider) getProvisionalIndexFile(uri string, alternateSources ...string) (*models.ProvisionalIndexFile, error) {
-	content, err := h.readFromCAS(uri, h.MaxProvisionalIndexFileSize, alternateSources...)
+func (h *OperationProvider) getProvisionalIndexFile(ctx context.Context, uri string, alternateSources ...string) (*models.ProvisionalIndexFile, error) {
+	content, err := h.readFromCAS(ctx, provisionalIndexAlias, uri, h.MaxProvisionalIndexFileSize, alternateSources...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error reading provisional index file")
 	}
@@ -570,6 +930,14 @@ func (h *OperationProvider) getProvisionalIndexFile(uri string, alternateSources
 }
 
 func (h *OperationProvider) validateProvisionalIndexFile(pif *models.ProvisionalIndexFile) error {
+	f := &findings{}
+
+	h.checkProvisionalIndexFile(pif, f)
+
+	return f.err()
+}
+
+func (h *OperationProvider) checkProvisionalIndexFile(pif *models.ProvisionalIndexFile, f *findings) {
 	updateNum := 0
 
 	if pif.Operations != nil {
@@ -577,53 +945,44 @@ func (h *OperationProvider) validateProvisionalIndexFile(pif *models.Provisional
 	}
 
 	if updateNum > 0 && pif.ProvisionalProofFileURI == "" {
-		return errors.New("missing provisional proof file URI")
+		f.error(RuleRequired, "/provisionalProofFileUri", errors.New("missing provisional proof file URI"))
 	}
 
 	if updateNum == 0 && len(pif.ProvisionalProofFileURI) > 0 {
-		return errors.New("provisional proof file URI should be empty if there are no update operations")
+		f.error(RuleRequired, "/provisionalProofFileUri",
+			errors.New("provisional proof file URI should be empty if there are no update operations"))
 	}
 
-	err := h.validateProvisionalIndexCASReferences(pif)
-	if err != nil {
-		return err
+	if len(pif.Chunks) > h.maxChunkFiles {
+		f.error(RuleCount, "/chunks", fmt.Errorf("number of chunk files[%d] exceeds maximum number of chunk files[%d]",
+			len(pif.Chunks), h.maxChunkFiles))
 	}
 
-	return h.validateProvisionalIndexOperations(pif.Operations)
+	h.checkProvisionalIndexCASReferences(pif, f)
+	h.checkProvisionalIndexOperations(pif.Operations, f)
 }
 
-func (h *OperationProvider) validateProvisionalIndexCASReferences(pif *models.ProvisionalIndexFile) error {
-	if err := h.validateURI(pif.ProvisionalProofFileURI); err != nil {
-		return errors.Wrapf(err, "provisional proof URI")
-	}
+func (h *OperationProvider) checkProvisionalIndexCASReferences(pif *models.ProvisionalIndexFile, f *findings) {
+	h.checkURI(pif.ProvisionalProofFileURI, "/provisionalProofFileUri", "provisional proof URI", f)
 
-	if len(pif.Chunks) > 0 {
-		if err := h.validateURI(pif.Chunks[0].ChunkFileURI); err != nil {
-			return errors.Wrapf(err, "chunk URI")
-		}
+	for i, chunk := range pif.Chunks {
+		h.checkURI(chunk.ChunkFileURI, fmt.Sprintf("/chunks/%d/chunkFileUri", i), "chunk URI", f)
 	}
-
-	return nil
 }
 
-func (h *OperationProvider) validateProvisionalIndexOperations(ops *models.ProvisionalOperations) error {
+func (h *OperationProvider) checkProvisionalIndexOperations(ops *models.ProvisionalOperations, f *findings) {
 	if ops == nil { // nothing to do
-		return nil
+		return
 	}
 
 	for i, op := range ops.Update {
-		err := h.validateOperationReference(op)
-		if err != nil {
-			return fmt.Errorf("failed to validate operation reference for update[%d]: %s", i, err.Error())
-		}
+		h.checkOperationReference(op, "update", i, f)
 	}
-
-	return nil
 }
 
 // getChunkFile will download chunk file from cas and parse it into chunk file model.
-func (h *OperationProvider) getChunkFile(uri string, alternateSources ...string) (*models.ChunkFile, error) { //nolint:dupl
-	content, err := h.readFromCAS(uri, h.MaxChunkFileSize, alternateSources...)
+func (h *OperationProvider) getChunkFile(ctx context.Context, uri string, alternateSources ...string) (*models.ChunkFile, error) { //nolint:dupl
+	content, err := h.readFromCAS(ctx, chunkAlias, uri, h.MaxChunkFileSize, alternateSources...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error reading chunk file")
 	}
@@ -644,18 +1003,59 @@ func (h *OperationProvider) getChunkFile(uri string, alternateSources ...string)
 }
 
 func (h *OperationProvider) validateChunkFile(cf *models.ChunkFile) error {
+	f := &findings{}
+
+	h.checkChunkFile(cf, f)
+
+	return f.err()
+}
+
+func (h *OperationProvider) checkChunkFile(cf *models.ChunkFile, f *findings) {
 	for i, delta := range cf.Deltas {
 		err := h.parser.ValidateDelta(delta)
 		if err != nil {
-			return fmt.Errorf("failed to validate delta[%d]: %s", i, err.Error())
+			f.error(RuleDelta, fmt.Sprintf("/deltas/%d", i), fmt.Errorf("failed to validate delta[%d]: %s", i, err.Error()))
 		}
 	}
+}
 
-	return nil
+func (h *OperationProvider) readFromCAS(ctx context.Context, alias, uri string, maxSize uint,
+	alternateSources ...string) ([]byte, error) {
+	_, span := tracer.Start(ctx, "CAS.Read",
+		trace.WithAttributes(tracing.WithCASURI(uri), tracing.WithCASDataType(alias)))
+	defer span.End()
+
+	content, err := h.doReadFromCAS(ctx, alias, uri, maxSize, alternateSources...)
+	if err != nil {
+		return nil, tracing.RecordError(span, err)
+	}
+
+	return content, nil
 }
 
-func (h *OperationProvider) readFromCAS(uri string, maxSize uint, alternateSources ...string) ([]byte, error) {
-	bytes, err := h.cas.Read(uri)
+func (h *OperationProvider) doReadFromCAS(ctx context.Context, alias, uri string, maxSize uint,
+	alternateSources ...string) ([]byte, error) {
+	// Ensure that the URI is valid before reading anything.
+	if _, err := h.extractURIHash(uri); err != nil && !errors.Is(err, ErrUnsupportedURIFormat) {
+		return nil, err
+	}
+
+	// The read is abandoned if a sibling fetch failed.
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("retrieve CAS content at uri[%s]: %w", uri, err)
+	}
+
+	startTime := time.Now()
+
+	bytes, err := h.readCAS(alias, uri)
+
+	h.metrics.CASReadTime(time.Since(startTime))
+
+	if err == nil {
+		// The content is verified before it's decompressed.
+		err = h.verifyContent(uri, bytes)
+	}
+
 	if err != nil {
 		if len(alternateSources) == 0 {
 			return nil, fmt.Errorf("retrieve CAS content at uri[%s]: %w", uri, err)
@@ -664,8 +1064,12 @@ func (h *OperationProvider) readFromCAS(uri string, maxSize uint, alternateSourc
 		logger.Info("Failed to retrieve CAS content. Trying alternate sources.",
 			logfields.WithURIString(uri), log.WithError(err), logfields.WithSources(alternateSources...))
 
-		b, e := h.readFromAlternateCASSources(uri, alternateSources)
+		b, e := h.readFromAlternateCASSources(ctx, uri, alternateSources)
 		if e != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("retrieve CAS content at uri[%s]: %w", uri, e)
+			}
+
 			logger.Warn("Failed to retrieve CAS content from alternate sources.",
 				logfields.WithURIString(uri), log.WithError(e), logfields.WithSources(alternateSources...))
 
@@ -679,18 +1083,24 @@ func (h *OperationProvider) readFromCAS(uri string, maxSize uint, alternateSourc
 	}
 
 	if len(bytes) > int(maxSize) {
-		return nil, fmt.Errorf("uri[%s]: content size %d exceeded maximum size %d", uri, len(bytes), maxSize)
+		return nil, withRule(RuleSizeLimit,
+			fmt.Errorf("uri[%s]: content size %d exceeded maximum size %d", uri, len(bytes), maxSize))
 	}
 
-	content, err := h.dp.Decompress(h.CompressionAlgorithm, bytes)
+	// The content is decompressed as a stream which is aborted as soon as the decompressed size exceeds
+	// the maximum, so a small file that decompresses to a huge amount of data (a decompression bomb)
+	// doesn't exhaust memory.
+	maxDecompressedSize := maxSize * h.MaxMemoryDecompressionFactor
+
+	content, err := h.dp.DecompressWithLimit(h.CompressionAlgorithm, bytes, uint64(maxDecompressedSize))
 	if err != nil {
-		return nil, errors.Wrapf(err, "decompress CAS uri[%s] using '%s'", uri, h.CompressionAlgorithm)
-	}
+		if errors.Is(err, compression.ErrSizeLimitExceeded) {
+			return nil, withRule(RuleSizeLimit, fmt.Errorf(
+				"uri[%s]: decompressed content size exceeded maximum decompressed content size %d", uri, maxDecompressedSize))
+		}
 
-	maxDecompressedSize := maxSize * h.MaxMemoryDecompressionFactor
-	if len(content) > int(maxDecompressedSize) {
-		return nil, fmt.Errorf("uri[%s]: decompressed content size %d exceeded maximum decompressed content size %d",
-			uri, len(content), maxDecompressedSize)
+		return nil, withRule(RuleCompression,
+			errors.Wrapf(err, "decompress CAS uri[%s] using '%s'", uri, h.CompressionAlgorithm))
 	}
 
 	return content, nil
@@ -805,27 +1215,158 @@ func (h *OperationProvider) validateURI(uri string) error {
 	return nil
 }
 
+func (h *OperationProvider) checkURI(uri, pointer, alias string, f *findings) {
+	if err := h.validateURI(uri); err != nil {
+		f.error(RuleURILength, pointer, errors.Wrapf(err, alias))
+	}
+}
+
+func (h *OperationProvider) readCAS(alias, uri string) ([]byte, error) {
+	if r, ok := h.cas.(aliasedDCAS); ok {
+		return r.ReadWithAlias(alias, uri)
+	}
+
+	return h.cas.Read(uri)
+}
+
 // readFromAlternateCASSources reads the URI from alternate CAS sources. The URI of the alternate source
 // is composed using a provided CAS URI formatter, since the format of the URI is implementation-specific.
-func (h *OperationProvider) readFromAlternateCASSources(casURI string, sources []string) ([]byte, error) {
-	for _, source := range sources {
-		casURIForSource, err := h.formatCASURIForSource(casURI, source)
-		if err != nil {
-			logger.Warn("Error formatting CAS reference for alternate source",
-				logfields.WithSource(source), log.WithError(err))
+// The sources are tried in the order of their health and sources which are backing off are skipped.
+func (h *OperationProvider) readFromAlternateCASSources(ctx context.Context, casURI string, sources []string) ([]byte, error) {
+	candidates := h.sources.candidates(sources)
 
-			continue
+	if h.hedgedReads && len(candidates) > 1 {
+		b, err := h.hedgedReadFromAlternateCASSources(ctx, casURI, candidates[0], candidates[1])
+		if err == nil {
+			return b, nil
 		}
 
-		b, err := h.cas.Read(casURIForSource)
-		if err == nil {
-			logger.Debug("Successfully retrieved CAS content from alternate source", logfields.WithSource(casURIForSource))
+		candidates = candidates[2:]
+	}
 
+	for _, source := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		b, err := h.readFromAlternateCASSource(casURI, source)
+		if err == nil {
 			return b, nil
 		}
+	}
 
-		logger.Warn("Error retrieving CAS content from alternate source", logfields.WithSource(casURIForSource), log.WithError(err))
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	return nil, fmt.Errorf("retrieve CAS content from alternate source failed")
 }
+
+type sourceReadResult struct {
+	content []byte
+	err     error
+}
+
+// hedgedReadFromAlternateCASSources reads the URI from the first source and, if the first source hasn't
+// responded within the hedge delay (or failed), from the second source. The first valid response is returned.
+func (h *OperationProvider) hedgedReadFromAlternateCASSources(ctx context.Context, casURI, first, second string) ([]byte, error) {
+	results := make(chan *sourceReadResult, 2) //nolint:gomnd
+
+	read := func(source string) {
+		b, err := h.readFromAlternateCASSource(casURI, source)
+
+		results <- &sourceReadResult{content: b, err: err}
+	}
+
+	go read(first)
+
+	hedgeTimer := time.NewTimer(h.hedgeDelay)
+	defer hedgeTimer.Stop()
+
+	pending := 1
+	hedged := false
+
+	var err error
+
+	for pending > 0 || !hedged {
+		select {
+		case result := <-results:
+			pending--
+
+			if result.err == nil {
+				return result.content, nil
+			}
+
+			err = result.err
+
+			if !hedged {
+				hedged = true
+				pending++
+
+				go read(second)
+			}
+		case <-hedgeTimer.C:
+			if !hedged {
+				logger.Debug("Alternate source hasn't responded. Sending hedged request.",
+					logfields.WithSource(first), logfields.WithURIString(casURI))
+
+				hedged = true
+				pending++
+
+				go read(second)
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return nil, err
+}
+
+// readFromAlternateCASSource reads the URI from the given source, verifies the content and records the
+// health of the source.
+func (h *OperationProvider) readFromAlternateCASSource(casURI, source string) ([]byte, error) {
+	casURIForSource, err := h.formatCASURIForSource(casURI, source)
+	if err != nil {
+		logger.Warn("Error formatting CAS reference for alternate source",
+			logfields.WithSource(source), log.WithError(err))
+
+		return nil, err
+	}
+
+	startTime := time.Now()
+
+	b, err := h.cas.Read(casURIForSource)
+
+	latency := time.Since(startTime)
+
+	if err != nil {
+		logger.Warn("Error retrieving CAS content from alternate source", logfields.WithSource(casURIForSource), log.WithError(err))
+
+		h.sources.recordFailure(source, latency)
+
+		return nil, err
+	}
+
+	err = h.verifyContent(casURI, b)
+	if err != nil {
+		logger.Warn("Alternate source returned content which doesn't match the CAS URI. Penalizing source.",
+			logfields.WithSource(source), logfields.WithURIString(casURI), log.WithError(err))
+
+		h.sources.penalize(source, latency)
+
+		return nil, err
+	}
+
+	logger.Debug("Successfully retrieved CAS content from alternate source", logfields.WithSource(casURIForSource))
+
+	h.sources.recordSuccess(source, latency)
+
+	return b, nil
+}
+
+type noopMetricsProvider struct{}
+
+func (m *noopMetricsProvider) CASReadTime(time.Duration)                            {}
+func (m *noopMetricsProvider) CASAlternateSourceRead(string, string, time.Duration) {}
+func (m *noopMetricsProvider) CASAlternateSourceBackoff(string, bool)               {}
@@ -7,24 +7,33 @@ SPDX-License-Identifier: Apache-2.0
 package txnprovider
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	coreoperation "github.com/trustbloc/sidetree-go/pkg/api/operation"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/trustbloc/sidetree-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-go/pkg/canonicalizer"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/doccomposer"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/model"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationapplier"
 	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
 
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/cas"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-svc-go/pkg/api/txn"
 	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/contenthash"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing"
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/tracing/tracingtest"
 	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
 	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider/models"
 )
@@ -34,6 +43,7 @@ const (
 	maxFileSize          = 2000 // in bytes
 
 	sampleCasURI = "bafkreih6ot2yfqcerzp5l2qupc77it2vdmepfhszitmswnpdtk34m4ura4"
+	unknownURI   = "EiDkiD-FuKC5mcsY4m0pd3OMTP7FAfo690gzN7-6JxcN1g"
 	longValue    = "bafkreih6ot2yfqcerzp5l2qupc77it2vdmepfhszitmswnpdtk34m4ura4bafkreih6ot2yfqcerzp5l2qupc77it2vdmepfhszitmswnpdtk34m4ura4"
 )
 
@@ -85,6 +95,135 @@ func TestHandler_GetTxnOperations(t *testing.T) {
 		require.Equal(t, createOpsNum+updateOpsNum+deactivateOpsNum+recoverOpsNum, len(txnOps))
 	})
 
+	t.Run("success - multiple chunk files", func(t *testing.T) {
+		p := pc.Protocol
+		p.MaxChunkFileSize = 230
+
+		cas := mocks.NewMockCasClient(nil)
+		handler := NewOperationHandler(p, cas, cp, operationparser.New(p), &mocks.MetricsProvider{},
+			WithHandlerMaxChunkFiles(10))
+
+		ops := getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum)
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.NoError(t, err)
+		require.Greater(t, len(anchoringInfo.Artifacts), 5)
+
+		sidetreeTxn := &txn.SidetreeTxn{
+			Namespace:         defaultNS,
+			AnchorString:      anchoringInfo.AnchorString,
+			TransactionNumber: 1,
+			TransactionTime:   1,
+		}
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, WithMaxChunkFiles(10))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Len(t, txnOps, createOpsNum+updateOpsNum+deactivateOpsNum+recoverOpsNum)
+
+		// The protocol version doesn't allow multiple chunk files.
+		_, err = NewOperationProvider(p, operationparser.New(p), cas, cp).
+			GetTxnOperations(sidetreeTxn)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds maximum number of chunk files[1]")
+
+		// The operations are the same as if the deltas were written to a single chunk file.
+		singleChunkCAS := mocks.NewMockCasClient(nil)
+
+		anchoringInfo, err = NewOperationHandler(pc.Protocol, singleChunkCAS, cp, parser, &mocks.MetricsProvider{}).
+			PrepareTxnFiles(ops)
+		require.NoError(t, err)
+		require.Len(t, anchoringInfo.Artifacts, 5)
+
+		sidetreeTxn.AnchorString = anchoringInfo.AnchorString
+
+		expectedOps, err := NewOperationProvider(pc.Protocol, parser, singleChunkCAS, cp).
+			GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Equal(t, expectedOps, txnOps)
+	})
+
+	t.Run("success - aliased CAS", func(t *testing.T) {
+		cas := newMockAliasedCAS()
+		handler := NewOperationHandler(pc.Protocol, cas, cp, operationparser.New(pc.Protocol),
+			&mocks.MetricsProvider{})
+
+		ops := getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum)
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.NoError(t, err)
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp)
+
+		txnOps, err := provider.GetTxnOperations(&txn.SidetreeTxn{
+			Namespace:         defaultNS,
+			AnchorString:      anchoringInfo.AnchorString,
+			TransactionNumber: 1,
+			TransactionTime:   1,
+		})
+		require.NoError(t, err)
+		require.Len(t, txnOps, createOpsNum+updateOpsNum+deactivateOpsNum+recoverOpsNum)
+
+		aliases := []string{coreIndexAlias, coreProofAlias, provisionalIndexAlias, provisionalProofAlias, chunkAlias}
+
+		require.ElementsMatch(t, aliases, cas.writeAliases)
+		require.ElementsMatch(t, aliases, cas.readAliases)
+	})
+
+	t.Run("success - tracing", func(t *testing.T) {
+		exporter := tracingtest.Exporter()
+
+		cas := mocks.NewMockCasClient(nil)
+		handler := NewOperationHandler(pc.Protocol, cas, cp, operationparser.New(pc.Protocol),
+			&mocks.MetricsProvider{})
+
+		ops := getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum)
+
+		anchoringInfo, err := handler.PrepareTxnFiles(ops)
+		require.NoError(t, err)
+
+		prepareSpans := tracingtest.Spans(exporter, "OperationHandler.PrepareTxnFiles")
+		require.Len(t, prepareSpans, 1)
+		require.Contains(t, prepareSpans[0].Attributes, tracing.WithAnchorString(anchoringInfo.AnchorString))
+		require.Len(t, tracingtest.Spans(exporter, "CAS.Write"), len(anchoringInfo.Artifacts))
+
+		exporter.Reset()
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp)
+
+		_, err = provider.GetTxnOperations(&txn.SidetreeTxn{
+			Namespace:         defaultNS,
+			AnchorString:      anchoringInfo.AnchorString,
+			TransactionNumber: 1,
+			TransactionTime:   1,
+		})
+		require.NoError(t, err)
+
+		spans := tracingtest.Spans(exporter, "OperationProvider.GetTxnOperations")
+		require.Len(t, spans, 1)
+		require.Contains(t, spans[0].Attributes, tracing.WithAnchorString(anchoringInfo.AnchorString))
+
+		readSpans := tracingtest.Spans(exporter, "CAS.Read")
+		require.Len(t, readSpans, len(anchoringInfo.Artifacts))
+
+		for _, span := range readSpans {
+			require.Equal(t, spans[0].SpanContext.SpanID(), span.Parent.SpanID())
+		}
+
+		exporter.Reset()
+
+		_, err = provider.GetTxnOperations(&txn.SidetreeTxn{
+			Namespace:    defaultNS,
+			AnchorString: "1.invalid",
+		})
+		require.Error(t, err)
+
+		spans = tracingtest.Spans(exporter, "OperationProvider.GetTxnOperations")
+		require.Len(t, spans, 1)
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+
 	t.Run("error - delta exceeds maximum delta size in chunk file", func(t *testing.T) {
 		cas := mocks.NewMockCasClient(nil)
 		handler := NewOperationHandler(pc.Protocol, cas, cp, operationparser.New(pc.Protocol),
@@ -152,14 +291,14 @@ func TestHandler_GetTxnOperations(t *testing.T) {
 
 		txnOps, err := handler.GetTxnOperations(&txn.SidetreeTxn{
 			Namespace:         defaultNS,
-			AnchorString:      "1" + delimiter + "coreIndexURI",
+			AnchorString:      "1" + delimiter + unknownURI,
 			TransactionNumber: 1,
 			TransactionTime:   1,
 		})
 
 		require.Error(t, err)
 		require.Nil(t, txnOps)
-		require.Contains(t, err.Error(), "error reading core index file: retrieve CAS content at uri[coreIndexURI]: CAS error")
+		require.Contains(t, err.Error(), "error reading core index file: retrieve CAS content at uri["+unknownURI+"]: CAS error")
 	})
 
 	t.Run("error - parse core index operations error", func(t *testing.T) {
@@ -334,6 +473,269 @@ func TestHandler_GetTxnOperations(t *testing.T) {
 	})
 }
 
+func TestHandler_PrunedProvisionalFiles(t *testing.T) {
+	const createOpsNum = 2
+	const updateOpsNum = 3
+	const deactivateOpsNum = 2
+	const recoverOpsNum = 2
+
+	const coreOpsNum = createOpsNum + recoverOpsNum + deactivateOpsNum
+
+	pc := mocks.NewMockProtocolClient()
+	parser := operationparser.New(pc.Protocol)
+	cp := compression.New(compression.WithDefaultAlgorithms())
+
+	prepare := func(t *testing.T, prunedFiles ...string) (*prunedCAS, *txn.SidetreeTxn) {
+		t.Helper()
+
+		cas := mocks.NewMockCasClient(nil)
+
+		anchoringInfo, err := NewOperationHandler(pc.Protocol, cas, cp, parser, &mocks.MetricsProvider{}).
+			PrepareTxnFiles(getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum))
+		require.NoError(t, err)
+
+		pruned := &prunedCAS{DCAS: cas, pruned: make(map[string]bool)}
+
+		for _, artifact := range anchoringInfo.Artifacts {
+			for _, desc := range prunedFiles {
+				if artifact.Desc == desc {
+					pruned.pruned[artifact.ID] = true
+				}
+			}
+		}
+
+		return pruned, &txn.SidetreeTxn{
+			Namespace:         defaultNS,
+			AnchorString:      anchoringInfo.AnchorString,
+			TransactionNumber: 1,
+			TransactionTime:   1,
+		}
+	}
+
+	t.Run("provisional index file pruned", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "provisional index file", "provisional proof file", "chunk file")
+
+		// The transaction fails by default.
+		_, err := NewOperationProvider(pc.Protocol, parser, cas, cp).GetTxnOperations(sidetreeTxn)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading provisional index file")
+
+		store := NewMemIncompleteTxnStore()
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp, WithPrunedProvisionalFiles(store))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Len(t, txnOps, coreOpsNum)
+		requireCoreOpsWithoutDeltas(t, txnOps)
+
+		incomplete := store.List()
+		require.Len(t, incomplete, 1)
+		require.Equal(t, *sidetreeTxn, incomplete[0].Txn)
+		require.Contains(t, incomplete[0].Reason, "error reading provisional index file")
+		require.Equal(t, updateOpsNum, incomplete[0].NumUnavailableUpdates)
+		require.Empty(t, incomplete[0].UnavailableUpdates)
+
+		store.Delete(sidetreeTxn.AnchorString)
+		require.Empty(t, store.List())
+	})
+
+	t.Run("chunk file pruned", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "chunk file")
+
+		store := NewMemIncompleteTxnStore()
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp, WithPrunedProvisionalFiles(store))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Len(t, txnOps, coreOpsNum)
+		requireCoreOpsWithoutDeltas(t, txnOps)
+
+		incomplete := store.List()
+		require.Len(t, incomplete, 1)
+		require.Contains(t, incomplete[0].Reason, "error reading chunk file")
+		require.Equal(t, updateOpsNum, incomplete[0].NumUnavailableUpdates)
+		require.Len(t, incomplete[0].UnavailableUpdates, updateOpsNum)
+	})
+
+	t.Run("provisional proof file pruned", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "provisional proof file")
+
+		store := NewMemIncompleteTxnStore()
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp, WithPrunedProvisionalFiles(store))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Len(t, txnOps, coreOpsNum)
+
+		incomplete := store.List()
+		require.Len(t, incomplete, 1)
+		require.Contains(t, incomplete[0].Reason, "error reading provisional proof file")
+		require.Len(t, incomplete[0].UnavailableUpdates, updateOpsNum)
+	})
+
+	t.Run("no recorder", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "chunk file")
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp, WithPrunedProvisionalFiles(nil))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Len(t, txnOps, coreOpsNum)
+	})
+
+	t.Run("all files available - not recorded", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t)
+
+		store := NewMemIncompleteTxnStore()
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp, WithPrunedProvisionalFiles(store))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Len(t, txnOps, coreOpsNum+updateOpsNum)
+		require.Empty(t, store.List())
+	})
+
+	t.Run("error - core proof file pruned", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "core proof file", "chunk file")
+
+		store := NewMemIncompleteTxnStore()
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp, WithPrunedProvisionalFiles(store))
+
+		_, err := provider.GetTxnOperations(sidetreeTxn)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading core proof file")
+		require.Empty(t, store.List())
+	})
+
+	t.Run("error - number of operations doesn't match", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "provisional index file")
+
+		ad, err := ParseAnchorData(sidetreeTxn.AnchorString)
+		require.NoError(t, err)
+
+		ad.NumberOfOperations = coreOpsNum - 1
+		sidetreeTxn.AnchorString = ad.GetAnchorString()
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp,
+			WithPrunedProvisionalFiles(NewMemIncompleteTxnStore()))
+
+		_, err = provider.GetTxnOperations(sidetreeTxn)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "number of txn ops[6] doesn't match anchor string num of ops[5]")
+
+		cas, sidetreeTxn = prepare(t, "chunk file")
+
+		ad, err = ParseAnchorData(sidetreeTxn.AnchorString)
+		require.NoError(t, err)
+
+		ad.NumberOfOperations = coreOpsNum + updateOpsNum + 1
+		sidetreeTxn.AnchorString = ad.GetAnchorString()
+
+		provider = NewOperationProvider(pc.Protocol, parser, cas, cp,
+			WithPrunedProvisionalFiles(NewMemIncompleteTxnStore()))
+
+		_, err = provider.GetTxnOperations(sidetreeTxn)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "number of txn ops[9] doesn't match anchor string num of ops[10]")
+	})
+
+	t.Run("error - record incomplete transaction", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "chunk file")
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp,
+			WithPrunedProvisionalFiles(&mockIncompleteTxnRecorder{err: errors.New("injected recorder error")}))
+
+		_, err := provider.GetTxnOperations(sidetreeTxn)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "record incomplete transaction: injected recorder error")
+	})
+
+	t.Run("invalid chunk file", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t)
+
+		p := pc.Protocol
+		p.MaxChunkFileSize = 10
+
+		store := NewMemIncompleteTxnStore()
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, WithPrunedProvisionalFiles(store))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Len(t, txnOps, coreOpsNum)
+
+		incomplete := store.List()
+		require.Len(t, incomplete, 1)
+		require.Contains(t, incomplete[0].Reason, "exceeded maximum size 10")
+	})
+
+	t.Run("create without delta - empty document", func(t *testing.T) {
+		cas, sidetreeTxn := prepare(t, "chunk file")
+
+		provider := NewOperationProvider(pc.Protocol, parser, cas, cp, WithPrunedProvisionalFiles(nil))
+
+		txnOps, err := provider.GetTxnOperations(sidetreeTxn)
+		require.NoError(t, err)
+		require.Equal(t, coreoperation.TypeCreate, txnOps[0].Type)
+
+		rm, err := operationapplier.New(pc.Protocol, parser, doccomposer.New()).
+			Apply(txnOps[0], &protocol.ResolutionModel{})
+		require.NoError(t, err)
+		require.Empty(t, rm.Doc)
+		require.Empty(t, rm.UpdateCommitment)
+		require.NotEmpty(t, rm.RecoveryCommitment)
+	})
+}
+
+// requireCoreOpsWithoutDeltas ensures that the operations are the creates, recovers and deactivates (in that
+// order) and that none of them has a delta.
+func requireCoreOpsWithoutDeltas(t *testing.T, txnOps []*coreoperation.AnchoredOperation) {
+	t.Helper()
+
+	var types []coreoperation.Type
+
+	for _, op := range txnOps {
+		types = append(types, op.Type)
+
+		request := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(op.OperationRequest, &request))
+		require.Nil(t, request["delta"])
+	}
+
+	require.Equal(t, []coreoperation.Type{
+		coreoperation.TypeCreate, coreoperation.TypeCreate,
+		coreoperation.TypeRecover, coreoperation.TypeRecover,
+		coreoperation.TypeDeactivate, coreoperation.TypeDeactivate,
+	}, types)
+}
+
+func TestMemIncompleteTxnStore(t *testing.T) {
+	store := NewMemIncompleteTxnStore()
+
+	txn1 := &IncompleteTxn{Txn: txn.SidetreeTxn{AnchorString: "anchor1", TransactionTime: 2, TransactionNumber: 1}}
+	txn2 := &IncompleteTxn{Txn: txn.SidetreeTxn{AnchorString: "anchor2", TransactionTime: 1, TransactionNumber: 2}}
+	txn3 := &IncompleteTxn{Txn: txn.SidetreeTxn{AnchorString: "anchor3", TransactionTime: 1, TransactionNumber: 1}}
+
+	require.NoError(t, store.RecordIncompleteTxn(txn1))
+	require.NoError(t, store.RecordIncompleteTxn(txn2))
+	require.NoError(t, store.RecordIncompleteTxn(txn3))
+	require.Equal(t, []*IncompleteTxn{txn3, txn2, txn1}, store.List())
+
+	// A transaction which is recorded again replaces the previous record.
+	txn1Again := &IncompleteTxn{Txn: txn1.Txn, Reason: "again"}
+
+	require.NoError(t, store.RecordIncompleteTxn(txn1Again))
+	require.Equal(t, []*IncompleteTxn{txn3, txn2, txn1Again}, store.List())
+
+	store.Delete("anchor2")
+	require.Equal(t, []*IncompleteTxn{txn3, txn1Again}, store.List())
+}
+
 func TestHandler_GetCoreIndexFile(t *testing.T) {
 	cp := compression.New(compression.WithDefaultAlgorithms())
 	p := protocol.Protocol{
@@ -355,7 +757,7 @@ func TestHandler_GetCoreIndexFile(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		provider := NewOperationProvider(p, parser, cas, cp)
 
-		file, err := provider.getCoreIndexFile(address)
+		file, err := provider.getCoreIndexFile(context.Background(), address)
 		require.NoError(t, err)
 		require.NotNil(t, file)
 	})
@@ -363,7 +765,7 @@ func TestHandler_GetCoreIndexFile(t *testing.T) {
 	t.Run("error - core index file exceeds maximum size", func(t *testing.T) {
 		provider := NewOperationProvider(protocol.Protocol{MaxCoreIndexFileSize: 15, CompressionAlgorithm: compressionAlgorithm}, parser, cas, cp)
 
-		file, err := provider.getCoreIndexFile(address)
+		file, err := provider.getCoreIndexFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 15")
@@ -377,7 +779,7 @@ func TestHandler_GetCoreIndexFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, parser, cas, cp)
-		file, err := provider.getCoreIndexFile(address)
+		file, err := provider.getCoreIndexFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to parse content for core index file")
@@ -403,7 +805,7 @@ func TestHandler_GetCoreIndexFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, parser, cas, cp)
-		file, err := provider.getCoreIndexFile(address)
+		file, err := provider.getCoreIndexFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to validate suffix data for create[0]")
@@ -558,7 +960,7 @@ func TestHandler_GetProvisionalIndexFile(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getProvisionalIndexFile(address)
+		file, err := provider.getProvisionalIndexFile(context.Background(), address)
 		require.NoError(t, err)
 		require.NotNil(t, file)
 	})
@@ -568,7 +970,7 @@ func TestHandler_GetProvisionalIndexFile(t *testing.T) {
 		parser := operationparser.New(lowMaxFileSize)
 		provider := NewOperationProvider(lowMaxFileSize, parser, cas, cp)
 
-		file, err := provider.getProvisionalIndexFile(address)
+		file, err := provider.getProvisionalIndexFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 5")
@@ -584,7 +986,7 @@ func TestHandler_GetProvisionalIndexFile(t *testing.T) {
 
 		parser := operationparser.New(p)
 		provider := NewOperationProvider(p, parser, cas, cp)
-		file, err := provider.getProvisionalIndexFile(address)
+		file, err := provider.getProvisionalIndexFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to parse content for provisional index file")
@@ -709,7 +1111,7 @@ func TestHandler_GetChunkFile(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getChunkFile(address)
+		file, err := provider.getChunkFile(context.Background(), address)
 		require.NoError(t, err)
 		require.NotNil(t, file)
 	})
@@ -718,7 +1120,7 @@ func TestHandler_GetChunkFile(t *testing.T) {
 		lowMaxFileSize := protocol.Protocol{MaxChunkFileSize: 10, CompressionAlgorithm: compressionAlgorithm}
 		provider := NewOperationProvider(lowMaxFileSize, operationparser.New(p), cas, cp)
 
-		file, err := provider.getChunkFile(address)
+		file, err := provider.getChunkFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 10")
@@ -731,7 +1133,7 @@ func TestHandler_GetChunkFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
-		file, err := provider.getChunkFile(address)
+		file, err := provider.getChunkFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to parse content for chunk file")
@@ -755,7 +1157,7 @@ func TestHandler_GetChunkFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
-		file, err := provider.getChunkFile(address)
+		file, err := provider.getChunkFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to validate delta[0]")
@@ -805,24 +1207,35 @@ func TestHandler_readFromCAS(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.readFromCAS(address, maxFileSize)
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize)
+		require.NoError(t, err)
+		require.NotNil(t, file)
+	})
+
+	t.Run("success - CAS read time recorded", func(t *testing.T) {
+		metrics := newMockReadMetrics()
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, WithMetricsProvider(metrics))
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize)
 		require.NoError(t, err)
 		require.NotNil(t, file)
+		require.Equal(t, 1, metrics.readCount)
 	})
 
 	t.Run("error - read from CAS error", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), mocks.NewMockCasClient(errors.New("CAS error")), cp)
 
-		file, err := provider.getChunkFile("address")
+		file, err := provider.getChunkFile(context.Background(), unknownURI)
 		require.Error(t, err)
 		require.Nil(t, file)
-		require.Contains(t, err.Error(), " retrieve CAS content at uri[address]: CAS error")
+		require.Contains(t, err.Error(), " retrieve CAS content at uri["+unknownURI+"]: CAS error")
 	})
 
 	t.Run("error - content exceeds maximum size", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.readFromCAS(address, 20)
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, 20)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 20")
@@ -841,10 +1254,10 @@ func TestHandler_readFromCAS(t *testing.T) {
 		testAddress, err := cas.Write(testContent)
 		require.NoError(t, err)
 
-		file, err := provider.readFromCAS(testAddress, 247)
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, testAddress, 247)
 		require.Error(t, err)
 		require.Nil(t, file)
-		require.Contains(t, err.Error(), "decompressed content size 267 exceeded maximum decompressed content size 247")
+		require.Contains(t, err.Error(), "decompressed content size exceeded maximum decompressed content size 247")
 	})
 
 	t.Run("error - decompression error", func(t *testing.T) {
@@ -856,7 +1269,7 @@ func TestHandler_readFromCAS(t *testing.T) {
 
 		provider := NewOperationProvider(p2, operationparser.New(p2), cas, cp)
 
-		file, err := provider.readFromCAS(address, maxFileSize)
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "compression algorithm 'alg' not supported")
@@ -869,7 +1282,7 @@ func TestHandler_readFromCAS(t *testing.T) {
 			}),
 		)
 
-		_, err := provider.readFromCAS("address", maxFileSize,
+		_, err := provider.readFromCAS(context.Background(), chunkAlias, unknownURI, maxFileSize,
 			"https:orb.domain1.com", "https:orb.domain2.com")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "not found")
@@ -878,13 +1291,400 @@ func TestHandler_readFromCAS(t *testing.T) {
 	t.Run("alternate sources - no formatter", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		_, err := provider.readFromCAS("address", maxFileSize,
+		_, err := provider.readFromCAS(context.Background(), chunkAlias, unknownURI, maxFileSize,
 			"https:orb.domain1.com", "https:orb.domain2.com")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "not found")
 	})
 }
 
+func TestHandler_VerifyContent(t *testing.T) {
+	cp := compression.New(compression.WithDefaultAlgorithms())
+	p := protocol.Protocol{
+		MaxChunkFileSize:             maxFileSize,
+		CompressionAlgorithm:         compressionAlgorithm,
+		MaxMemoryDecompressionFactor: 3,
+	}
+
+	content, err := cp.Compress(compressionAlgorithm, []byte("{}"))
+	require.NoError(t, err)
+
+	address, err := mocks.NewMockCasClient(nil).Write(content)
+	require.NoError(t, err)
+
+	otherContent, err := cp.Compress(compressionAlgorithm, []byte(`{"other":true}`))
+	require.NoError(t, err)
+
+	formatter := WithSourceCASURIFormatter(func(uri, domain string) (string, error) {
+		return fmt.Sprintf("%s:%s", domain, uri), nil
+	})
+
+	t.Run("success - content from alternate source", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{
+			"https:orb.domain1.com:" + address: content,
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter)
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize,
+			"https:orb.domain1.com")
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+	})
+
+	t.Run("error - content doesn't match URI", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{address: otherContent})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize)
+		require.Error(t, err)
+		require.Nil(t, file)
+		require.True(t, errors.Is(err, contenthash.ErrMismatch))
+	})
+
+	t.Run("content doesn't match URI - alternate source used", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{
+			address:                            otherContent,
+			"https:orb.domain1.com:" + address: content,
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter)
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize,
+			"https:orb.domain1.com")
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+	})
+
+	t.Run("alternate source returns invalid content - source penalized", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{
+			"https:orb.domain1.com:" + address: otherContent,
+			"https:orb.domain2.com:" + address: content,
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter)
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize,
+			"https:orb.domain1.com", "https:orb.domain2.com")
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+		require.Equal(t, []string{"https:orb.domain2.com"},
+			provider.sources.candidates([]string{"https:orb.domain1.com", "https:orb.domain2.com"}))
+
+		// The penalized source is skipped.
+		reads := cas.reads["https:orb.domain1.com:"+address]
+
+		_, err = provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize,
+			"https:orb.domain1.com", "https:orb.domain2.com")
+		require.NoError(t, err)
+		require.Equal(t, reads, cas.reads["https:orb.domain1.com:"+address])
+	})
+
+	t.Run("penalty expires", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{
+			"https:orb.domain1.com:" + address: otherContent,
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter,
+			WithSourcePenalty(time.Millisecond))
+
+		_, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize,
+			"https:orb.domain1.com")
+		require.Error(t, err)
+
+		time.Sleep(5 * time.Millisecond)
+
+		require.Equal(t, []string{"https:orb.domain1.com"}, provider.sources.candidates([]string{"https:orb.domain1.com"}))
+	})
+
+	t.Run("success - unsupported URI format isn't verified", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{sampleCasURI: content})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, sampleCasURI, maxFileSize)
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+	})
+
+	t.Run("error - URI hash extractor", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{sampleCasURI: content})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp,
+			WithURIHashExtractor(func(uri string) (string, error) {
+				return "", errors.New("invalid URI")
+			}),
+		)
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, sampleCasURI, maxFileSize)
+		require.EqualError(t, err, "invalid URI")
+		require.Nil(t, file)
+		require.Zero(t, cas.reads[sampleCasURI])
+	})
+
+	t.Run("success - custom URI hash extractor", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{sampleCasURI: content})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp,
+			WithURIHashExtractor(func(uri string) (string, error) {
+				return address, nil
+			}),
+		)
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, sampleCasURI, maxFileSize)
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+	})
+}
+
+func TestHandler_AlternateSources(t *testing.T) {
+	cp := compression.New(compression.WithDefaultAlgorithms())
+	p := protocol.Protocol{
+		MaxChunkFileSize:             maxFileSize,
+		CompressionAlgorithm:         compressionAlgorithm,
+		MaxMemoryDecompressionFactor: 3,
+	}
+
+	content, err := cp.Compress(compressionAlgorithm, []byte("{}"))
+	require.NoError(t, err)
+
+	address, err := mocks.NewMockCasClient(nil).Write(content)
+	require.NoError(t, err)
+
+	formatter := WithSourceCASURIFormatter(func(uri, domain string) (string, error) {
+		return fmt.Sprintf("%s:%s", domain, uri), nil
+	})
+
+	t.Run("failing source backs off", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{
+			source2 + ":" + address: content,
+		})
+
+		metrics := newMockReadMetrics()
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter,
+			WithMetricsProvider(metrics))
+
+		for i := 0; i < 3; i++ {
+			file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1, source2)
+			require.NoError(t, err)
+			require.Equal(t, []byte("{}"), file)
+		}
+
+		require.Equal(t, 1, cas.reads[source1+":"+address])
+		require.Equal(t, 3, cas.reads[source2+":"+address])
+		require.Equal(t, 1, metrics.sourceReads[source1+":"+sourceReadFailure])
+		require.Equal(t, 3, metrics.sourceReads[source2+":"+sourceReadSuccess])
+		require.True(t, metrics.backoff[source1])
+	})
+
+	t.Run("healthiest source first", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{
+			source2 + ":" + address: content,
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter,
+			WithSourceBackoff(time.Nanosecond, time.Nanosecond))
+
+		_, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1, source2)
+		require.NoError(t, err)
+
+		time.Sleep(time.Millisecond)
+
+		cas.content[source1+":"+address] = content
+
+		// The source which failed is no longer backing off but it is tried after the healthier source.
+		_, err = provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1, source2)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, cas.reads[source1+":"+address])
+		require.Equal(t, 2, cas.reads[source2+":"+address])
+	})
+
+	t.Run("all sources backing off", func(t *testing.T) {
+		cas := newMapCAS(nil)
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter)
+
+		_, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1)
+		require.Error(t, err)
+
+		_, err = provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1)
+		require.Error(t, err)
+		require.Equal(t, 1, cas.reads[source1+":"+address])
+	})
+
+	t.Run("hedged - slow source", func(t *testing.T) {
+		var slowReads int32
+
+		cas := readFunc(func(uri string) ([]byte, error) {
+			switch uri {
+			case source1 + ":" + address:
+				atomic.AddInt32(&slowReads, 1)
+
+				time.Sleep(500 * time.Millisecond)
+
+				return content, nil
+			case source2 + ":" + address:
+				return content, nil
+			}
+
+			return nil, errors.New("not found")
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter,
+			WithHedgedSourceReads(10*time.Millisecond))
+
+		start := time.Now()
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1, source2)
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+		require.Less(t, time.Since(start), 400*time.Millisecond)
+		require.Equal(t, int32(1), atomic.LoadInt32(&slowReads))
+	})
+
+	t.Run("hedged - first source fails", func(t *testing.T) {
+		cas := newMapCAS(map[string][]byte{
+			source2 + ":" + address: content,
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter,
+			WithHedgedSourceReads(time.Hour))
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1, source2)
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+	})
+
+	t.Run("hedged - both sources fail", func(t *testing.T) {
+		var mutex sync.Mutex
+
+		reads := make(map[string]int)
+
+		cas := readFunc(func(uri string) ([]byte, error) {
+			mutex.Lock()
+			reads[uri]++
+			mutex.Unlock()
+
+			if uri == source3+":"+address {
+				return content, nil
+			}
+
+			return nil, errors.New("not found")
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter,
+			WithHedgedSourceReads(0))
+
+		file, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize,
+			source1, source2, source3)
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), file)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		require.Equal(t, 1, reads[source1+":"+address])
+		require.Equal(t, 1, reads[source2+":"+address])
+		require.Equal(t, 1, reads[source3+":"+address])
+	})
+
+	t.Run("hedged - all sources fail", func(t *testing.T) {
+		provider := NewOperationProvider(p, operationparser.New(p), newMapCAS(nil), cp, formatter,
+			WithHedgedSourceReads(0))
+
+		_, err := provider.readFromCAS(context.Background(), chunkAlias, address, maxFileSize, source1, source2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("hedged - context canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		cas := readFunc(func(uri string) ([]byte, error) {
+			if uri == address {
+				return nil, errors.New("not found")
+			}
+
+			<-release
+
+			return content, nil
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp, formatter,
+			WithHedgedSourceReads(0))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := provider.readFromCAS(ctx, chunkAlias, address, maxFileSize, source1, source2)
+		require.Error(t, err)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestHandler_DecompressionBomb(t *testing.T) {
+	const maxBombFileSize = 1 << 20
+
+	cp := compression.New(compression.WithDefaultAlgorithms())
+	defer func() { require.NoError(t, cp.Close()) }()
+
+	// 64MiB of zeros compresses to well under the maximum file size.
+	bomb := make([]byte, 64<<20)
+
+	for _, alg := range []string{"GZIP", "ZSTD"} {
+		p := protocol.Protocol{
+			MaxCoreIndexFileSize:         maxBombFileSize,
+			MaxProofFileSize:             maxBombFileSize,
+			MaxProvisionalIndexFileSize:  maxBombFileSize,
+			MaxChunkFileSize:             maxBombFileSize,
+			CompressionAlgorithm:         alg,
+			MaxMemoryDecompressionFactor: 3,
+		}
+
+		cas := mocks.NewMockCasClient(nil)
+
+		content, err := cp.Compress(alg, bomb)
+		require.NoError(t, err)
+		require.Less(t, len(content), maxBombFileSize)
+
+		uri, err := cas.Write(content)
+		require.NoError(t, err)
+
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
+
+		getFile := map[string]func() (interface{}, error){
+			"core index file": func() (interface{}, error) {
+				return provider.getCoreIndexFile(context.Background(), uri)
+			},
+			"core proof file": func() (interface{}, error) {
+				return provider.getCoreProofFile(context.Background(), uri)
+			},
+			"provisional index file": func() (interface{}, error) {
+				return provider.getProvisionalIndexFile(context.Background(), uri)
+			},
+			"provisional proof file": func() (interface{}, error) {
+				return provider.getProvisionalProofFile(context.Background(), uri)
+			},
+			"chunk file": func() (interface{}, error) {
+				return provider.getChunkFile(context.Background(), uri)
+			},
+		}
+
+		for name, get := range getFile {
+			t.Run(alg+" "+name, func(t *testing.T) {
+				_, err := get()
+				require.Error(t, err)
+				require.Contains(t, err.Error(),
+					fmt.Sprintf("exceeded maximum decompressed content size %d", 3*maxBombFileSize))
+			})
+		}
+	}
+}
+
 func TestHandler_GetCorePoofFile(t *testing.T) {
 	cp := compression.New(compression.WithDefaultAlgorithms())
 	p := protocol.Protocol{
@@ -902,7 +1702,7 @@ func TestHandler_GetCorePoofFile(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getCoreProofFile(uri)
+		file, err := provider.getCoreProofFile(context.Background(), uri)
 		require.NoError(t, err)
 		require.NotNil(t, file)
 	})
@@ -911,7 +1711,7 @@ func TestHandler_GetCorePoofFile(t *testing.T) {
 		lowMaxFileSize := protocol.Protocol{MaxProofFileSize: 10, CompressionAlgorithm: compressionAlgorithm}
 		provider := NewOperationProvider(lowMaxFileSize, operationparser.New(p), cas, cp)
 
-		file, err := provider.getCoreProofFile(uri)
+		file, err := provider.getCoreProofFile(context.Background(), uri)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 10")
@@ -924,7 +1724,7 @@ func TestHandler_GetCorePoofFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
-		file, err := provider.getCoreProofFile(address)
+		file, err := provider.getCoreProofFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to parse content for core proof file")
@@ -947,7 +1747,7 @@ func TestHandler_GetCorePoofFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
-		file, err := provider.getCoreProofFile(address)
+		file, err := provider.getCoreProofFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to validate signed data for recover[0]")
@@ -1046,7 +1846,7 @@ func TestHandler_GetProvisionalPoofFile(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getProvisionalProofFile(uri)
+		file, err := provider.getProvisionalProofFile(context.Background(), uri)
 		require.NoError(t, err)
 		require.NotNil(t, file)
 	})
@@ -1055,7 +1855,7 @@ func TestHandler_GetProvisionalPoofFile(t *testing.T) {
 		lowMaxFileSize := protocol.Protocol{MaxProofFileSize: 10, CompressionAlgorithm: compressionAlgorithm}
 		provider := NewOperationProvider(lowMaxFileSize, operationparser.New(p), cas, cp)
 
-		file, err := provider.getProvisionalProofFile(uri)
+		file, err := provider.getProvisionalProofFile(context.Background(), uri)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 10")
@@ -1068,7 +1868,7 @@ func TestHandler_GetProvisionalPoofFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
-		file, err := provider.getProvisionalProofFile(address)
+		file, err := provider.getProvisionalProofFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to parse content for provisional proof file")
@@ -1091,7 +1891,7 @@ func TestHandler_GetProvisionalPoofFile(t *testing.T) {
 		require.NoError(t, err)
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
-		file, err := provider.getProvisionalProofFile(address)
+		file, err := provider.getProvisionalProofFile(context.Background(), address)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to validate signed data for update[0]")
@@ -1190,7 +1990,7 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 		p := newMockProtocolClient().Protocol
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getBatchFiles(af)
+		file, err := provider.getBatchFiles(context.Background(), af)
 		require.NoError(t, err)
 		require.NotNil(t, file)
 	})
@@ -1201,7 +2001,7 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getBatchFiles(af)
+		file, err := provider.getBatchFiles(context.Background(), af)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 10")
@@ -1213,7 +2013,7 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getBatchFiles(af)
+		file, err := provider.getBatchFiles(context.Background(), af)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 7")
@@ -1251,7 +2051,7 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 			CoreProofFileURI:        "",
 		}
 
-		file, err := provider.getBatchFiles(af2)
+		file, err := provider.getBatchFiles(context.Background(), af2)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "failed to unmarshal provisional proof file: invalid character")
@@ -1263,7 +2063,7 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 
 		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
 
-		file, err := provider.getBatchFiles(af)
+		file, err := provider.getBatchFiles(context.Background(), af)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "exceeded maximum size 10")
@@ -1295,7 +2095,7 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 			ProvisionalIndexFileURI: pif2URI,
 		}
 
-		file, err := provider.getBatchFiles(cif)
+		file, err := provider.getBatchFiles(context.Background(), cif)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "missing provisional proof file URI")
@@ -1334,7 +2134,7 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 			},
 		}
 
-		file, err := provider.getBatchFiles(cif)
+		file, err := provider.getBatchFiles(context.Background(), cif)
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "number of recover ops[1] in core index doesn't match number of recover ops[0] in core proof")
@@ -1347,13 +2147,128 @@ func TestHandler_GetBatchFiles(t *testing.T) {
 		missingChunkURI, err := writeToCAS(&models.ProvisionalIndexFile{}, cas)
 		require.NoError(t, err)
 
-		file, err := provider.getBatchFiles(&models.CoreIndexFile{
+		file, err := provider.getBatchFiles(context.Background(), &models.CoreIndexFile{
 			ProvisionalIndexFileURI: missingChunkURI,
 		})
 		require.Error(t, err)
 		require.Nil(t, file)
 		require.Contains(t, err.Error(), "provisional index file is missing chunk file URI")
 	})
+
+	t.Run("success - concurrent fetches", func(t *testing.T) {
+		p := newMockProtocolClient().Protocol
+
+		delayed := newConcurrencyTrackingCAS(cas, 20*time.Millisecond)
+
+		provider := NewOperationProvider(p, operationparser.New(p), delayed, cp)
+
+		file, err := provider.getBatchFiles(context.Background(), af)
+		require.NoError(t, err)
+		require.NotNil(t, file)
+		require.Equal(t, 4, delayed.reads)
+		require.Greater(t, delayed.maxInFlight, 1)
+	})
+
+	t.Run("success - sequential fetches", func(t *testing.T) {
+		p := newMockProtocolClient().Protocol
+
+		delayed := newConcurrencyTrackingCAS(cas, 5*time.Millisecond)
+
+		provider := NewOperationProvider(p, operationparser.New(p), delayed, cp, WithMaxConcurrentFetches(1))
+
+		file, err := provider.getBatchFiles(context.Background(), af)
+		require.NoError(t, err)
+		require.NotNil(t, file)
+		require.Equal(t, 4, delayed.reads)
+		require.Equal(t, 1, delayed.maxInFlight)
+	})
+
+	t.Run("error - first fatal error cancels subsequent fetches", func(t *testing.T) {
+		p := newMockProtocolClient().Protocol
+
+		cpfFailed := make(chan struct{})
+
+		var reads int32
+
+		failingCAS := readFunc(func(uri string) ([]byte, error) {
+			switch uri {
+			case cpfURI:
+				close(cpfFailed)
+
+				return nil, errors.New("injected core proof error")
+			case pifURI:
+				<-cpfFailed
+
+				// Give the fetch group time to cancel the subsequent fetches.
+				time.Sleep(50 * time.Millisecond)
+
+				return nil, errors.New("injected provisional index error")
+			}
+
+			atomic.AddInt32(&reads, 1)
+
+			return cas.Read(uri)
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), failingCAS, cp,
+			WithSourceCASURIFormatter(func(uri, domain string) (string, error) {
+				return fmt.Sprintf("%s:%s", domain, uri), nil
+			}),
+		)
+
+		file, err := provider.getBatchFiles(context.Background(), af, "https:orb.domain1.com")
+		require.Error(t, err)
+		require.Nil(t, file)
+		require.Contains(t, err.Error(), "error reading core proof file")
+		require.Contains(t, err.Error(), "injected core proof error")
+
+		// The alternate source for the provisional index file, the provisional proof file and the chunk
+		// file are not read.
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&reads) == 1 // the alternate source for the core proof file
+		}, time.Second, 10*time.Millisecond)
+
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, int32(1), atomic.LoadInt32(&reads))
+	})
+
+	t.Run("error - errors reported in sequential order", func(t *testing.T) {
+		p := newMockProtocolClient().Protocol
+
+		failingCAS := readFunc(func(uri string) ([]byte, error) {
+			switch uri {
+			case pifURI:
+				return nil, errors.New("injected provisional index error")
+			case cpfURI:
+				time.Sleep(20 * time.Millisecond)
+
+				return nil, errors.New("injected core proof error")
+			}
+
+			return cas.Read(uri)
+		})
+
+		provider := NewOperationProvider(p, operationparser.New(p), failingCAS, cp)
+
+		file, err := provider.getBatchFiles(context.Background(), af)
+		require.Error(t, err)
+		require.Nil(t, file)
+		require.Contains(t, err.Error(), "error reading core proof file")
+		require.Contains(t, err.Error(), "injected core proof error")
+	})
+
+	t.Run("error - context canceled", func(t *testing.T) {
+		p := newMockProtocolClient().Protocol
+		provider := NewOperationProvider(p, operationparser.New(p), cas, cp)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		file, err := provider.getBatchFiles(ctx, af)
+		require.Error(t, err)
+		require.Nil(t, file)
+		require.ErrorIs(t, err, context.Canceled)
+	})
 }
 
 func TestHandler_assembleBatchOperations(t *testing.T) {
@@ -1712,3 +2627,151 @@ func newMockProtocolClient() *mocks.MockProtocolClient {
 }
 
 const sampleChunkFile = `{"chunks":[{"chunkFileUri":"EiDkiD-FuKC5mcsY4m0pd3OMTP7FAfo690gzN7-6JxcN1g"}],"operations":{"update":[{"didSuffix":"update-1","revealValue":"EiAdqFJ-x5QhwPq62DB9EfenKloqntykHJkZrwI6uxkoVQ"}]},"provisionalProofFileUri":"EiDdEHTL3VmFZO5hXoth8vTKnXgvfvW4lLJXyMjqs7ezUA"}`
+
+type mockReadMetrics struct {
+	mutex       sync.Mutex
+	readCount   int
+	sourceReads map[string]int
+	backoff     map[string]bool
+}
+
+func newMockReadMetrics() *mockReadMetrics {
+	return &mockReadMetrics{
+		sourceReads: make(map[string]int),
+		backoff:     make(map[string]bool),
+	}
+}
+
+func (m *mockReadMetrics) CASReadTime(time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.readCount++
+}
+
+func (m *mockReadMetrics) CASAlternateSourceRead(source, result string, _ time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.sourceReads[source+":"+result]++
+}
+
+func (m *mockReadMetrics) CASAlternateSourceBackoff(source string, backingOff bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.backoff[source] = backingOff
+}
+
+type mockAliasedCAS struct {
+	*mocks.MockCasClient
+
+	mutex        sync.Mutex
+	writeAliases []string
+	readAliases  []string
+}
+
+func newMockAliasedCAS() *mockAliasedCAS {
+	return &mockAliasedCAS{MockCasClient: mocks.NewMockCasClient(nil)}
+}
+
+func (m *mockAliasedCAS) WriteWithAlias(alias string, content []byte) (string, error) {
+	m.mutex.Lock()
+	m.writeAliases = append(m.writeAliases, alias)
+	m.mutex.Unlock()
+
+	return m.Write(content)
+}
+
+func (m *mockAliasedCAS) ReadWithAlias(alias, address string) ([]byte, error) {
+	m.mutex.Lock()
+	m.readAliases = append(m.readAliases, alias)
+	m.mutex.Unlock()
+
+	return m.Read(address)
+}
+
+type mapCAS struct {
+	mutex   sync.Mutex
+	content map[string][]byte
+	reads   map[string]int
+}
+
+func newMapCAS(content map[string][]byte) *mapCAS {
+	return &mapCAS{content: content, reads: make(map[string]int)}
+}
+
+func (m *mapCAS) Read(uri string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.reads[uri]++
+
+	content, ok := m.content[uri]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+
+	return content, nil
+}
+
+type readFunc func(uri string) ([]byte, error)
+
+func (f readFunc) Read(uri string) ([]byte, error) {
+	return f(uri)
+}
+
+type concurrencyTrackingCAS struct {
+	target DCAS
+	delay  time.Duration
+
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+	reads       int
+}
+
+func newConcurrencyTrackingCAS(target DCAS, delay time.Duration) *concurrencyTrackingCAS {
+	return &concurrencyTrackingCAS{target: target, delay: delay}
+}
+
+func (m *concurrencyTrackingCAS) Read(uri string) ([]byte, error) {
+	m.mutex.Lock()
+	m.reads++
+	m.inFlight++
+
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.mutex.Unlock()
+
+	time.Sleep(m.delay)
+
+	m.mutex.Lock()
+	m.inFlight--
+	m.mutex.Unlock()
+
+	return m.target.Read(uri)
+}
+
+// prunedCAS simulates a CAS from which some of the content was pruned.
+type prunedCAS struct {
+	DCAS
+	pruned map[string]bool
+}
+
+func (c *prunedCAS) Read(address string) ([]byte, error) {
+	if c.pruned[address] {
+		return nil, fmt.Errorf("content not found: %s", address)
+	}
+
+	return c.DCAS.Read(address)
+}
+
+type mockIncompleteTxnRecorder struct {
+	err error
+}
+
+func (r *mockIncompleteTxnRecorder) RecordIncompleteTxn(*IncompleteTxn) error {
+	return r.err
+}
@@ -0,0 +1,245 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+)
+
+const (
+	defaultSourceInitialBackoff = 5 * time.Second
+	defaultSourceMaxBackoff     = 5 * time.Minute
+	defaultMaxTrackedSources    = 1000
+
+	// latencyWeight is the weight of the latest sample in the moving average of a source's latency.
+	latencyWeight = 0.2
+
+	sourceReadSuccess = "success"
+	sourceReadFailure = "failure"
+	sourceReadInvalid = "invalid"
+)
+
+type sourceMetricsProvider interface {
+	CASAlternateSourceRead(source, result string, duration time.Duration)
+	CASAlternateSourceBackoff(source string, backingOff bool)
+}
+
+// sourceStats contains the health statistics of an alternate source.
+type sourceStats struct {
+	source              string
+	successes           int
+	failures            int
+	consecutiveFailures int
+	latency             time.Duration
+	backoffUntil        time.Time
+}
+
+// successRate returns the (smoothed) ratio of successful reads. A source without any reads has a
+// success rate of 0.5.
+func (s *sourceStats) successRate() float64 {
+	return float64(s.successes+1) / float64(s.successes+s.failures+2) //nolint:gomnd
+}
+
+// sourceManager tracks the health (success rate and latency) of the alternate CAS sources. The alternate
+// sources of a transaction are tried in the order of their health, and sources which are failing are
+// skipped for an exponentially increasing backoff period.
+//
+// Since the alternate sources are supplied by the (untrusted) anchors, at most maxSources sources are tracked.
+// The statistics of the least recently used source are evicted when a new source is tracked.
+type sourceManager struct {
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	penalty        time.Duration
+	maxSources     int
+	metrics        sourceMetricsProvider
+	now            func() time.Time
+
+	mutex sync.Mutex
+	stats map[string]*list.Element
+	order *list.List
+}
+
+func newSourceManager(initialBackoff, maxBackoff, penalty time.Duration, maxSources int,
+	metrics sourceMetricsProvider) *sourceManager {
+	return &sourceManager{
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		penalty:        penalty,
+		maxSources:     maxSources,
+		metrics:        metrics,
+		now:            time.Now,
+		stats:          make(map[string]*list.Element),
+		order:          list.New(),
+	}
+}
+
+// candidates returns the given sources, excluding the sources which are backing off, ordered by health:
+// sources with a higher success rate come first and sources with the same success rate are ordered by
+// latency. Otherwise, the given order is preserved.
+func (m *sourceManager) candidates(sources []string) []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+
+	var candidates []string
+
+	for _, source := range sources {
+		if m.isBackingOff(source, now) {
+			logger.Debug("Skipping alternate source which is backing off", logfields.WithSource(source))
+
+			continue
+		}
+
+		candidates = append(candidates, source)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		si, sj := m.lookup(candidates[i]), m.lookup(candidates[j])
+
+		if si.successRate() != sj.successRate() {
+			return si.successRate() > sj.successRate()
+		}
+
+		return si.latency < sj.latency
+	})
+
+	return candidates
+}
+
+// recordSuccess records a successful read from the source.
+func (m *sourceManager) recordSuccess(source string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.get(source)
+	s.successes++
+	s.consecutiveFailures = 0
+	s.backoffUntil = time.Time{}
+	s.updateLatency(latency)
+
+	m.metrics.CASAlternateSourceRead(source, sourceReadSuccess, latency)
+	m.metrics.CASAlternateSourceBackoff(source, false)
+}
+
+// recordFailure records a failed read from the source. The source backs off for a period which doubles
+// with each consecutive failure.
+func (m *sourceManager) recordFailure(source string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.get(source)
+	s.failures++
+	s.consecutiveFailures++
+	s.updateLatency(latency)
+
+	backoff := m.initialBackoff << (s.consecutiveFailures - 1)
+	if backoff > m.maxBackoff || backoff <= 0 {
+		backoff = m.maxBackoff
+	}
+
+	m.backoff(source, s, backoff)
+
+	m.metrics.CASAlternateSourceRead(source, sourceReadFailure, latency)
+}
+
+// penalize records a read from the source which returned invalid content. The source backs off for the
+// penalty period.
+func (m *sourceManager) penalize(source string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.get(source)
+	s.failures++
+	s.consecutiveFailures++
+	s.updateLatency(latency)
+
+	m.backoff(source, s, m.penalty)
+
+	m.metrics.CASAlternateSourceRead(source, sourceReadInvalid, latency)
+}
+
+func (m *sourceManager) backoff(source string, s *sourceStats, backoff time.Duration) {
+	backoffUntil := m.now().Add(backoff)
+	if backoffUntil.After(s.backoffUntil) {
+		s.backoffUntil = backoffUntil
+	}
+
+	logger.Info("Alternate source is backing off", logfields.WithSource(source), log.WithDuration(backoff),
+		logfields.WithAttempt(s.consecutiveFailures))
+
+	m.metrics.CASAlternateSourceBackoff(source, true)
+}
+
+func (m *sourceManager) isBackingOff(source string, now time.Time) bool {
+	s := m.lookup(source)
+	if s.backoffUntil.IsZero() {
+		return false
+	}
+
+	if now.Before(s.backoffUntil) {
+		return true
+	}
+
+	s.backoffUntil = time.Time{}
+
+	m.metrics.CASAlternateSourceBackoff(source, false)
+
+	return false
+}
+
+// lookup returns the statistics of the given source without tracking it. An untracked source has empty
+// statistics.
+func (m *sourceManager) lookup(source string) *sourceStats {
+	e, ok := m.stats[source]
+	if !ok {
+		return &sourceStats{source: source}
+	}
+
+	return e.Value.(*sourceStats) //nolint:forcetypeassert
+}
+
+// get returns the statistics of the given source, tracking the source if necessary.
+func (m *sourceManager) get(source string) *sourceStats {
+	if e, ok := m.stats[source]; ok {
+		m.order.MoveToFront(e)
+
+		return e.Value.(*sourceStats) //nolint:forcetypeassert
+	}
+
+	for m.order.Len() > 0 && m.order.Len() >= m.maxSources {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+
+		evicted := oldest.Value.(*sourceStats) //nolint:forcetypeassert
+		delete(m.stats, evicted.source)
+
+		logger.Debug("Evicted statistics of least recently used alternate source", logfields.WithSource(evicted.source))
+	}
+
+	s := &sourceStats{source: source}
+	m.stats[source] = m.order.PushFront(s)
+
+	return s
+}
+
+func (s *sourceStats) updateLatency(latency time.Duration) {
+	if s.successes+s.failures == 1 {
+		s.latency = latency
+
+		return
+	}
+
+	s.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(s.latency))
+}
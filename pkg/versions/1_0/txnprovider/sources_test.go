@@ -0,0 +1,130 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	source1 = "https:orb.domain1.com"
+	source2 = "https:orb.domain2.com"
+	source3 = "https:orb.domain3.com"
+)
+
+func TestSourceManager_Candidates(t *testing.T) {
+	t.Run("unknown sources - given order", func(t *testing.T) {
+		m := newSourceManager(time.Second, time.Minute, time.Hour, defaultMaxTrackedSources, newMockReadMetrics())
+
+		require.Equal(t, []string{source1, source2, source3}, m.candidates([]string{source1, source2, source3}))
+		require.Empty(t, m.candidates(nil))
+	})
+
+	t.Run("ordered by success rate", func(t *testing.T) {
+		m := newSourceManager(time.Nanosecond, time.Nanosecond, time.Hour, defaultMaxTrackedSources, newMockReadMetrics())
+
+		m.recordSuccess(source3, time.Millisecond)
+		m.recordFailure(source1, time.Millisecond)
+
+		time.Sleep(time.Millisecond)
+
+		require.Equal(t, []string{source3, source2, source1}, m.candidates([]string{source1, source2, source3}))
+	})
+
+	t.Run("same success rate - ordered by latency", func(t *testing.T) {
+		m := newSourceManager(time.Second, time.Minute, time.Hour, defaultMaxTrackedSources, newMockReadMetrics())
+
+		m.recordSuccess(source1, 300*time.Millisecond)
+		m.recordSuccess(source2, 100*time.Millisecond)
+		m.recordSuccess(source3, 200*time.Millisecond)
+
+		require.Equal(t, []string{source2, source3, source1}, m.candidates([]string{source1, source2, source3}))
+	})
+
+	t.Run("latency moving average", func(t *testing.T) {
+		m := newSourceManager(time.Second, time.Minute, time.Hour, defaultMaxTrackedSources, newMockReadMetrics())
+
+		m.recordSuccess(source1, 100*time.Millisecond)
+		require.Equal(t, 100*time.Millisecond, m.lookup(source1).latency)
+
+		m.recordSuccess(source1, 600*time.Millisecond)
+		require.Equal(t, 200*time.Millisecond, m.lookup(source1).latency)
+	})
+}
+
+func TestSourceManager_Backoff(t *testing.T) {
+	now := time.Now()
+
+	metrics := newMockReadMetrics()
+
+	m := newSourceManager(time.Second, 5*time.Second, time.Hour, defaultMaxTrackedSources, metrics)
+	m.now = func() time.Time { return now }
+
+	sources := []string{source1, source2}
+
+	m.recordFailure(source1, time.Millisecond)
+	require.Equal(t, []string{source2}, m.candidates(sources))
+	require.True(t, metrics.backoff[source1])
+	require.Equal(t, 1, metrics.sourceReads[source1+":"+sourceReadFailure])
+
+	now = now.Add(time.Second)
+	require.Equal(t, []string{source2, source1}, m.candidates(sources))
+	require.False(t, metrics.backoff[source1])
+
+	// The backoff doubles with each consecutive failure.
+	m.recordFailure(source1, time.Millisecond)
+	require.Equal(t, now.Add(2*time.Second), m.lookup(source1).backoffUntil)
+
+	m.recordFailure(source1, time.Millisecond)
+	require.Equal(t, now.Add(4*time.Second), m.lookup(source1).backoffUntil)
+
+	// The backoff is limited to the maximum.
+	m.recordFailure(source1, time.Millisecond)
+	require.Equal(t, now.Add(5*time.Second), m.lookup(source1).backoffUntil)
+
+	for i := 0; i < 100; i++ {
+		m.recordFailure(source1, time.Millisecond)
+	}
+
+	require.Equal(t, now.Add(5*time.Second), m.lookup(source1).backoffUntil)
+
+	// A success resets the backoff.
+	m.recordSuccess(source1, time.Millisecond)
+	require.True(t, m.lookup(source1).backoffUntil.IsZero())
+	require.Zero(t, m.lookup(source1).consecutiveFailures)
+	require.Equal(t, []string{source2, source1}, m.candidates(sources))
+
+	// A penalty isn't shortened by a subsequent failure.
+	m.penalize(source2, time.Millisecond)
+	m.recordFailure(source2, time.Millisecond)
+	require.Equal(t, now.Add(time.Hour), m.lookup(source2).backoffUntil)
+	require.Equal(t, 1, metrics.sourceReads[source2+":"+sourceReadInvalid])
+	require.Equal(t, []string{source1}, m.candidates(sources))
+}
+
+func TestSourceManager_MaxSources(t *testing.T) {
+	m := newSourceManager(time.Second, time.Minute, time.Hour, 2, newMockReadMetrics())
+
+	// Unknown sources aren't tracked when they're ordered.
+	m.candidates([]string{source1, source2, source3})
+	require.Empty(t, m.stats)
+
+	m.recordFailure(source1, time.Millisecond)
+	m.recordFailure(source2, time.Millisecond)
+	m.recordSuccess(source1, time.Millisecond)
+
+	// source2 is the least recently used source.
+	m.recordFailure(source3, time.Millisecond)
+	require.Len(t, m.stats, 2)
+	require.Equal(t, 1, m.lookup(source1).successes)
+	require.Zero(t, m.lookup(source2).failures)
+	require.Equal(t, 1, m.lookup(source3).failures)
+	require.Equal(t, []string{source2, source1}, m.candidates([]string{source1, source2, source3}))
+}
@@ -0,0 +1,500 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/trustbloc/sidetree-go/pkg/hashing"
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/model"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/contenthash"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider/models"
+)
+
+// Severity is the severity of a validation finding.
+type Severity string
+
+const (
+	// SeverityError indicates that the observer rejects the transaction because of the finding.
+	SeverityError Severity = "error"
+	// SeverityWarning indicates that the batch deviates from the protocol but the observer processes the
+	// transaction anyway (although the affected operations may be rejected when they're applied).
+	SeverityWarning Severity = "warning"
+)
+
+// Rule identifies the validation rule that a finding violates.
+type Rule string
+
+// Validation rules.
+const (
+	RuleAnchorString Rule = "anchor-string"
+	RuleUnavailable  Rule = "unavailable"
+	RuleContentHash  Rule = "content-hash"
+	RuleSizeLimit    Rule = "size-limit"
+	RuleCompression  Rule = "compression"
+	RuleParse        Rule = "parse"
+	RuleRequired     Rule = "required"
+	RuleURILength    Rule = "uri-length"
+	RuleHashLength   Rule = "hash-length"
+	RuleMultihash    Rule = "multihash"
+	RuleSuffixData   Rule = "suffix-data"
+	RuleSignedData   Rule = "signed-data"
+	RuleDelta        Rule = "delta"
+	RuleCount        Rule = "count"
+	RuleDuplicate    Rule = "duplicate"
+	RuleAssemble     Rule = "assemble"
+)
+
+// Finding is a violation of a validation rule. The file type and URI are empty if the finding applies to the
+// anchor string. The pointer is a JSON pointer (RFC 6901) to the offending value in the file, which is empty if
+// the finding applies to the whole file.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     Rule     `json:"rule"`
+	File     string   `json:"file,omitempty"`
+	URI      string   `json:"uri,omitempty"`
+	Pointer  string   `json:"pointer,omitempty"`
+	Message  string   `json:"message"`
+
+	err error
+}
+
+// ValidationReport contains all of the findings of the validation of an anchor's batch files, along with the
+// content of the files and the operations which were assembled from them.
+type ValidationReport struct {
+	AnchorString       string                           `json:"anchorString,omitempty"`
+	CoreIndexFileURI   string                           `json:"coreIndexFileURI,omitempty"`
+	NumberOfOperations int                              `json:"numberOfOperations,omitempty"`
+	Files              []*InspectedFile                 `json:"files,omitempty"`
+	Operations         map[string][]*InspectedOperation `json:"operations,omitempty"`
+	Findings           []*Finding                       `json:"findings,omitempty"`
+}
+
+// Valid returns true if the report doesn't contain any errors (i.e. the observer would process the transaction).
+func (r *ValidationReport) Valid() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Errors returns the findings with severity error.
+func (r *ValidationReport) Errors() []*Finding {
+	var errs []*Finding
+
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+
+	return errs
+}
+
+// findings collects the findings of a validation. The operation provider uses the same checks as the full
+// validation but only reports the first error.
+type findings struct {
+	file string
+	uri  string
+	list []*Finding
+}
+
+// in sets the file to which subsequent findings apply.
+func (f *findings) in(file, uri string) *findings {
+	f.file = file
+	f.uri = uri
+
+	return f
+}
+
+func (f *findings) error(rule Rule, pointer string, err error) {
+	f.add(SeverityError, rule, pointer, err)
+}
+
+func (f *findings) warning(rule Rule, pointer string, err error) {
+	f.add(SeverityWarning, rule, pointer, err)
+}
+
+func (f *findings) add(severity Severity, rule Rule, pointer string, err error) {
+	f.list = append(f.list, &Finding{
+		Severity: severity,
+		Rule:     rule,
+		File:     f.file,
+		URI:      f.uri,
+		Pointer:  pointer,
+		Message:  err.Error(),
+		err:      err,
+	})
+}
+
+// hasErrors returns true if any of the given files has an error.
+func (f *findings) hasErrors(files ...string) bool {
+	for _, finding := range f.list {
+		if finding.Severity != SeverityError {
+			continue
+		}
+
+		for _, file := range files {
+			if finding.File == file {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasRuleError returns true if the given rule is violated with severity error.
+func (f *findings) hasRuleError(rule Rule) bool {
+	for _, finding := range f.list {
+		if finding.Severity == SeverityError && finding.Rule == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// err returns the first error (ignoring warnings).
+func (f *findings) err() error {
+	for _, finding := range f.list {
+		if finding.Severity == SeverityError {
+			return finding.err
+		}
+	}
+
+	return nil
+}
+
+// ruleError associates an error with the validation rule that it violates.
+type ruleError struct {
+	rule Rule
+	err  error
+}
+
+func withRule(rule Rule, err error) error {
+	return &ruleError{rule: rule, err: err}
+}
+
+func (e *ruleError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ruleError) Unwrap() error {
+	return e.err
+}
+
+// ruleOf returns the rule which is violated by an error that occurred while reading a file from CAS.
+func ruleOf(err error) Rule {
+	var re *ruleError
+	if errors.As(err, &re) {
+		return re.rule
+	}
+
+	if errors.Is(err, contenthash.ErrMismatch) {
+		return RuleContentHash
+	}
+
+	return RuleUnavailable
+}
+
+// ValidateAnchor validates the batch files referenced by the given anchor string and returns all of the findings,
+// rather than only the first error as GetTxnOperations does. The same rules are applied as when the transaction
+// is processed (which are reported as errors) along with rules which the observer doesn't enforce (which are
+// reported as warnings). Each file is read and validated independently (as far as the files which reference it
+// could be read) and the report also contains the content of the files and the operations assembled from them.
+func (h *OperationProvider) ValidateAnchor(ctx context.Context, anchorString string,
+	alternateSources ...string) *ValidationReport {
+	report := &ValidationReport{AnchorString: anchorString}
+
+	anchorData, err := ParseAnchorData(anchorString)
+	if err != nil {
+		f := &findings{}
+		f.error(RuleAnchorString, "", err)
+
+		report.Findings = f.list
+
+		return report
+	}
+
+	report.CoreIndexFileURI = anchorData.CoreIndexFileURI
+	report.NumberOfOperations = anchorData.NumberOfOperations
+
+	h.validate(ctx, report, alternateSources)
+
+	return report
+}
+
+// ValidateCoreIndexFile validates the batch files referenced by the given core index file (see ValidateAnchor).
+// Since the number of operations in the anchor string is unknown, it isn't validated.
+func (h *OperationProvider) ValidateCoreIndexFile(ctx context.Context, coreIndexURI string,
+	alternateSources ...string) *ValidationReport {
+	report := &ValidationReport{CoreIndexFileURI: coreIndexURI}
+
+	h.validate(ctx, report, alternateSources)
+
+	return report
+}
+
+func (h *OperationProvider) validate(ctx context.Context, report *ValidationReport, alternateSources []string) {
+	v := &validator{OperationProvider: h, findings: &findings{}, alternateSources: alternateSources}
+
+	defer func() {
+		report.Files = v.files
+		report.Findings = v.findings.list
+	}()
+
+	files := v.readBatchFiles(ctx, report.CoreIndexFileURI)
+	if files == nil {
+		return
+	}
+
+	if report.AnchorString != "" {
+		v.checkOperationCounts(files, report.NumberOfOperations)
+	} else {
+		v.checkFileCounts(files)
+	}
+
+	v.checkDuplicateSuffixes(files)
+
+	v.assembleOperations(files, report)
+}
+
+type validator struct {
+	*OperationProvider
+
+	findings         *findings
+	alternateSources []string
+	files            []*InspectedFile
+}
+
+// readBatchFiles reads, parses and checks all of the batch files. The files which couldn't be read are nil.
+// Nil is returned if the core index file couldn't be read.
+func (v *validator) readBatchFiles(ctx context.Context, coreIndexURI string) *batchFiles {
+	cif, ok := v.readFile(ctx, coreIndexAlias, coreIndexURI, v.MaxCoreIndexFileSize,
+		func(content []byte) (interface{}, error) { return models.ParseCoreIndexFile(content) })
+	if !ok {
+		return nil
+	}
+
+	files := &batchFiles{CoreIndex: cif.(*models.CoreIndexFile)}
+
+	v.checkCoreIndexFile(files.CoreIndex, v.findings.in(coreIndexAlias, coreIndexURI))
+
+	if uri := files.CoreIndex.CoreProofFileURI; uri != "" {
+		if cpf, ok := v.readFile(ctx, coreProofAlias, uri, v.MaxProofFileSize,
+			func(content []byte) (interface{}, error) { return models.ParseCoreProofFile(content) }); ok {
+			files.CoreProof = cpf.(*models.CoreProofFile)
+
+			v.checkCoreProofFile(files.CoreProof, v.findings.in(coreProofAlias, uri))
+		}
+	}
+
+	if uri := files.CoreIndex.ProvisionalIndexFileURI; uri != "" {
+		if pif, ok := v.readFile(ctx, provisionalIndexAlias, uri, v.MaxProvisionalIndexFileSize,
+			func(content []byte) (interface{}, error) { return models.ParseProvisionalIndexFile(content) }); ok {
+			files.ProvisionalIndex = pif.(*models.ProvisionalIndexFile)
+
+			v.checkProvisionalIndexFile(files.ProvisionalIndex, v.findings.in(provisionalIndexAlias, uri))
+
+			v.readProvisionalFiles(ctx, files)
+		}
+	}
+
+	return files
+}
+
+func (v *validator) readProvisionalFiles(ctx context.Context, files *batchFiles) {
+	if uri := files.ProvisionalIndex.ProvisionalProofFileURI; uri != "" {
+		if ppf, ok := v.readFile(ctx, provisionalProofAlias, uri, v.MaxProofFileSize,
+			func(content []byte) (interface{}, error) { return models.ParseProvisionalProofFile(content) }); ok {
+			files.ProvisionalProof = ppf.(*models.ProvisionalProofFile)
+
+			v.checkProvisionalProofFile(files.ProvisionalProof, v.findings.in(provisionalProofAlias, uri))
+		}
+	}
+
+	if len(files.ProvisionalIndex.Chunks) == 0 {
+		v.findings.in(provisionalIndexAlias, files.CoreIndex.ProvisionalIndexFileURI).error(RuleRequired, "/chunks",
+			errors.New("provisional index file is missing chunk file URI"))
+
+		return
+	}
+
+	chunks := make([]*models.ChunkFile, 0, len(files.ProvisionalIndex.Chunks))
+
+	for _, chunk := range files.ProvisionalIndex.Chunks {
+		cf, ok := v.readFile(ctx, chunkAlias, chunk.ChunkFileURI, v.MaxChunkFileSize,
+			func(content []byte) (interface{}, error) { return models.ParseChunkFile(content) })
+		if !ok {
+			continue
+		}
+
+		v.checkChunkFile(cf.(*models.ChunkFile), v.findings.in(chunkAlias, chunk.ChunkFileURI))
+
+		chunks = append(chunks, cf.(*models.ChunkFile))
+	}
+
+	if len(chunks) == len(files.ProvisionalIndex.Chunks) {
+		files.Chunk = mergeChunkFiles(chunks)
+	}
+}
+
+// readFile reads and parses a batch file. The multihash of the URI is checked before the file is read.
+func (v *validator) readFile(ctx context.Context, alias, uri string, maxSize uint,
+	parse func(content []byte) (interface{}, error)) (interface{}, bool) {
+	f := v.findings.in(alias, uri)
+
+	file := &InspectedFile{Type: alias, URI: uri}
+
+	v.files = append(v.files, file)
+
+	encodedMultihash, err := v.extractURIHash(uri)
+
+	switch {
+	case errors.Is(err, ErrUnsupportedURIFormat):
+		// The content is read but can't be verified.
+		f.warning(RuleMultihash, "", err)
+	case err != nil:
+		f.error(RuleMultihash, "", err)
+
+		return nil, false
+	case !hashing.IsComputedUsingMultihashAlgorithms(encodedMultihash, v.MultihashAlgorithms):
+		f.warning(RuleMultihash, "", fmt.Errorf("CAS URI is not computed with the required hash algorithms: %d",
+			v.MultihashAlgorithms))
+	}
+
+	content, err := v.readFromCAS(ctx, alias, uri, maxSize, v.alternateSources...)
+	if err != nil {
+		f.error(ruleOf(err), "", err)
+
+		return nil, false
+	}
+
+	value, err := parse(content)
+	if err != nil {
+		f.error(RuleParse, "", err)
+
+		return nil, false
+	}
+
+	file.Content = value
+
+	return value, true
+}
+
+// checkFileCounts checks the operation counts across files. The checks which depend on a file that couldn't
+// be read are skipped.
+func (v *validator) checkFileCounts(files *batchFiles) {
+	if files.CoreIndex.CoreProofFileURI != "" && files.CoreProof != nil {
+		checkCoreFileCounts(files, v.findings)
+	}
+
+	pif := files.ProvisionalIndex
+
+	if pif != nil && files.Chunk != nil && (pif.ProvisionalProofFileURI == "" || files.ProvisionalProof != nil) {
+		checkProvisionalFileCounts(files, v.findings)
+	}
+}
+
+// checkOperationCounts checks the operation counts across files as well as the number of operations in the
+// anchor string. The checks which depend on a file that couldn't be read are skipped.
+func (v *validator) checkOperationCounts(files *batchFiles, numOps int) {
+	v.checkFileCounts(files)
+
+	pif := files.ProvisionalIndex
+
+	if files.CoreIndex.ProvisionalIndexFileURI != "" && pif == nil {
+		// The number of update operations is unknown.
+		return
+	}
+
+	total := len(suffixReferences(files, v.MultihashAlgorithms))
+
+	v.findings.in("", "")
+
+	if total != numOps {
+		v.findings.error(RuleCount, "", fmt.Errorf("number of txn ops[%d] doesn't match anchor string num of ops[%d]",
+			total, numOps))
+	}
+
+	if total > int(v.MaxOperationCount) {
+		v.findings.warning(RuleCount, "", fmt.Errorf("number of txn ops[%d] exceeds maximum operation count[%d]",
+			total, v.MaxOperationCount))
+	}
+}
+
+// checkDuplicateSuffixes reports every operation whose suffix is the same as the suffix of a previous operation
+// in the batch.
+func (v *validator) checkDuplicateSuffixes(files *batchFiles) {
+	seen := make(map[string]bool)
+
+	for _, ref := range suffixReferences(files, v.MultihashAlgorithms) {
+		if ref.suffix == "" {
+			continue
+		}
+
+		if seen[ref.suffix] {
+			v.findings.in(ref.file, ref.uri).error(RuleDuplicate, ref.pointer,
+				fmt.Errorf("duplicate values found [%s]", ref.suffix))
+
+			continue
+		}
+
+		seen[ref.suffix] = true
+	}
+}
+
+type suffixReference struct {
+	suffix  string
+	file    string
+	uri     string
+	pointer string
+}
+
+// suffixReferences returns the suffixes of all operations in the order in which the operations are assembled.
+// The suffix of a create operation is empty if it can't be computed.
+func suffixReferences(files *batchFiles, algorithms []uint) []*suffixReference {
+	var refs []*suffixReference
+
+	if ops := files.CoreIndex.Operations; ops != nil {
+		for i, op := range ops.Create {
+			ref := &suffixReference{file: coreIndexAlias, pointer: fmt.Sprintf("/operations/create/%d/suffixData", i)}
+
+			if op.SuffixData != nil {
+				ref.suffix, _ = model.GetUniqueSuffix(op.SuffixData, algorithms) //nolint:errcheck
+			}
+
+			refs = append(refs, ref)
+		}
+
+		for i, op := range ops.Recover {
+			refs = append(refs, &suffixReference{suffix: op.DidSuffix, file: coreIndexAlias,
+				pointer: fmt.Sprintf("/operations/recover/%d/didSuffix", i)})
+		}
+
+		for i, op := range ops.Deactivate {
+			refs = append(refs, &suffixReference{suffix: op.DidSuffix, file: coreIndexAlias,
+				pointer: fmt.Sprintf("/operations/deactivate/%d/didSuffix", i)})
+		}
+	}
+
+	if files.ProvisionalIndex != nil && files.ProvisionalIndex.Operations != nil {
+		for i, op := range files.ProvisionalIndex.Operations.Update {
+			refs = append(refs, &suffixReference{suffix: op.DidSuffix, file: provisionalIndexAlias,
+				uri: files.CoreIndex.ProvisionalIndexFileURI, pointer: fmt.Sprintf("/operations/update/%d/didSuffix", i)})
+		}
+	}
+
+	return refs
+}
@@ -0,0 +1,291 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/sidetree-go/pkg/versions/1_0/operationparser"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/compression"
+	"github.com/trustbloc/sidetree-svc-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-svc-go/pkg/versions/1_0/txnprovider/models"
+)
+
+func TestOperationProvider_ValidateAnchor(t *testing.T) {
+	const createOpsNum = 2
+	const updateOpsNum = 3
+	const deactivateOpsNum = 2
+	const recoverOpsNum = 2
+
+	pc := mocks.NewMockProtocolClient()
+	parser := operationparser.New(pc.Protocol)
+	cp := compression.New(compression.WithDefaultAlgorithms())
+
+	prepare := func(t *testing.T, prunedFiles ...string) (*prunedCAS, string) {
+		t.Helper()
+
+		cas := mocks.NewMockCasClient(nil)
+
+		anchoringInfo, err := NewOperationHandler(pc.Protocol, cas, cp, parser, &mocks.MetricsProvider{}).
+			PrepareTxnFiles(getTestOperations(createOpsNum, updateOpsNum, deactivateOpsNum, recoverOpsNum))
+		require.NoError(t, err)
+
+		pruned := &prunedCAS{DCAS: cas, pruned: make(map[string]bool)}
+
+		for _, artifact := range anchoringInfo.Artifacts {
+			for _, desc := range prunedFiles {
+				if artifact.Desc == desc {
+					pruned.pruned[artifact.ID] = true
+				}
+			}
+		}
+
+		return pruned, anchoringInfo.AnchorString
+	}
+
+	t.Run("success", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).ValidateAnchor(context.Background(), anchorString)
+		require.True(t, report.Valid(), "unexpected findings: %v", report.Findings)
+		require.Empty(t, errorFindings(report.Findings))
+		require.Equal(t, anchorString, report.AnchorString)
+		require.NotEmpty(t, report.CoreIndexFileURI)
+	})
+
+	t.Run("unsupported URI format", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp,
+			WithURIHashExtractor(func(uri string) (string, error) {
+				return "", fmt.Errorf("%w [%s]", ErrUnsupportedURIFormat, uri)
+			}),
+		).ValidateAnchor(context.Background(), anchorString)
+		require.True(t, report.Valid(), "unexpected findings: %v", report.Findings)
+		require.NotEmpty(t, findingsWithRule(report, RuleMultihash))
+		require.Equal(t, SeverityWarning, findingsWithRule(report, RuleMultihash)[0].Severity)
+	})
+
+	t.Run("all findings reported", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		p := pc.Protocol
+		p.MaxCasURILength = 20
+		p.MaxChunkFileSize = 10
+
+		report := NewOperationProvider(p, operationparser.New(p), cas, cp).
+			ValidateAnchor(context.Background(), anchorString)
+		require.False(t, report.Valid())
+
+		// The core proof and provisional index URIs in the core index file and the provisional proof and
+		// chunk URIs in the provisional index file exceed the maximum length.
+		uriFindings := findingsWithRule(report, RuleURILength)
+		require.Len(t, uriFindings, 4)
+		require.Equal(t, coreIndexAlias, uriFindings[0].File)
+		require.Equal(t, "/coreProofFileUri", uriFindings[0].Pointer)
+		require.Contains(t, uriFindings[0].Message, "core proof URI: CAS URI length")
+		require.Equal(t, "/provisionalIndexFileUri", uriFindings[1].Pointer)
+		require.Equal(t, provisionalIndexAlias, uriFindings[2].File)
+		require.Equal(t, "/provisionalProofFileUri", uriFindings[2].Pointer)
+		require.Equal(t, "/chunks/0/chunkFileUri", uriFindings[3].Pointer)
+
+		sizeFindings := findingsWithRule(report, RuleSizeLimit)
+		require.Len(t, sizeFindings, 1)
+		require.Equal(t, chunkAlias, sizeFindings[0].File)
+		require.Contains(t, sizeFindings[0].Message, "exceeded maximum size 10")
+
+		require.Len(t, errorFindings(report.Findings), 5)
+
+		_, err := json.Marshal(report)
+		require.NoError(t, err)
+	})
+
+	t.Run("file unavailable", func(t *testing.T) {
+		cas, anchorString := prepare(t, "provisional proof file", "chunk file")
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).ValidateAnchor(context.Background(), anchorString)
+		require.False(t, report.Valid())
+
+		errs := errorFindings(report.Findings)
+		require.Len(t, errs, 2)
+		require.Equal(t, RuleUnavailable, errs[0].Rule)
+		require.Equal(t, provisionalProofAlias, errs[0].File)
+		require.Equal(t, RuleUnavailable, errs[1].Rule)
+		require.Equal(t, chunkAlias, errs[1].File)
+	})
+
+	t.Run("core index file unavailable", func(t *testing.T) {
+		cas, anchorString := prepare(t, "core index file")
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).ValidateAnchor(context.Background(), anchorString)
+		require.Len(t, report.Findings, 1)
+		require.Equal(t, RuleUnavailable, report.Findings[0].Rule)
+		require.Equal(t, coreIndexAlias, report.Findings[0].File)
+		require.Equal(t, report.CoreIndexFileURI, report.Findings[0].URI)
+	})
+
+	t.Run("decompression failed", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		p := pc.Protocol
+		p.CompressionAlgorithm = "unknown"
+
+		report := NewOperationProvider(p, operationparser.New(p), cas, cp).
+			ValidateAnchor(context.Background(), anchorString)
+		require.Len(t, report.Findings, 1)
+		require.Equal(t, RuleCompression, report.Findings[0].Rule)
+		require.Equal(t, coreIndexAlias, report.Findings[0].File)
+	})
+
+	t.Run("number of operations doesn't match", func(t *testing.T) {
+		cas, anchorString := prepare(t)
+
+		ad, err := ParseAnchorData(anchorString)
+		require.NoError(t, err)
+
+		ad.NumberOfOperations++
+
+		p := pc.Protocol
+		p.MaxOperationCount = 5
+
+		report := NewOperationProvider(p, operationparser.New(p), cas, cp).
+			ValidateAnchor(context.Background(), ad.GetAnchorString())
+		require.False(t, report.Valid())
+
+		countFindings := findingsWithRule(report, RuleCount)
+		require.Len(t, countFindings, 2)
+		require.Equal(t, SeverityError, countFindings[0].Severity)
+		require.Contains(t, countFindings[0].Message,
+			"number of txn ops[9] doesn't match anchor string num of ops[10]")
+		require.Equal(t, SeverityWarning, countFindings[1].Severity)
+		require.Contains(t, countFindings[1].Message, "exceeds maximum operation count[5]")
+	})
+
+	t.Run("duplicate suffixes", func(t *testing.T) {
+		files, err := generateDefaultBatchFiles()
+		require.NoError(t, err)
+
+		files.ProvisionalIndex.Operations.Update[0].DidSuffix = files.CoreIndex.Operations.Recover[0].DidSuffix
+
+		cas := mocks.NewMockCasClient(nil)
+
+		files.ProvisionalIndex.Chunks[0].ChunkFileURI, err = writeToCAS(files.Chunk, cas)
+		require.NoError(t, err)
+
+		files.ProvisionalIndex.ProvisionalProofFileURI, err = writeToCAS(files.ProvisionalProof, cas)
+		require.NoError(t, err)
+
+		files.CoreIndex.ProvisionalIndexFileURI, err = writeToCAS(files.ProvisionalIndex, cas)
+		require.NoError(t, err)
+
+		files.CoreIndex.CoreProofFileURI, err = writeToCAS(files.CoreProof, cas)
+		require.NoError(t, err)
+
+		coreIndexURI, err := writeToCAS(files.CoreIndex, cas)
+		require.NoError(t, err)
+
+		anchorString := (&AnchorData{NumberOfOperations: 4, CoreIndexFileURI: coreIndexURI}).GetAnchorString()
+
+		report := NewOperationProvider(pc.Protocol, parser, cas, cp).ValidateAnchor(context.Background(), anchorString)
+		require.False(t, report.Valid())
+
+		errs := errorFindings(report.Findings)
+		require.Len(t, errs, 1)
+		require.Equal(t, RuleDuplicate, errs[0].Rule)
+		require.Equal(t, provisionalIndexAlias, errs[0].File)
+		require.Equal(t, "/operations/update/0/didSuffix", errs[0].Pointer)
+		require.Contains(t, errs[0].Message, "duplicate values found")
+
+		// The suffixes of the test operations aren't multihashes.
+		require.NotEmpty(t, findingsWithRule(report, RuleMultihash))
+		require.Equal(t, SeverityWarning, findingsWithRule(report, RuleMultihash)[0].Severity)
+	})
+
+	t.Run("invalid operation references", func(t *testing.T) {
+		files, err := generateDefaultBatchFiles()
+		require.NoError(t, err)
+
+		files.CoreIndex.Operations.Recover[0].RevealValue = ""
+		files.CoreIndex.Operations.Deactivate[0].DidSuffix = longValue
+		files.CoreIndex.Operations.Create = append(files.CoreIndex.Operations.Create, models.CreateReference{})
+
+		f := &findings{}
+
+		NewOperationProvider(pc.Protocol, parser, mocks.NewMockCasClient(nil), cp).
+			checkCoreIndexFile(files.CoreIndex, f.in(coreIndexAlias, "uri"))
+
+		errs := errorFindings(f.list)
+		require.Len(t, errs, 3)
+		require.Equal(t, RuleSuffixData, errs[0].Rule)
+		require.Equal(t, "/operations/create/1/suffixData", errs[0].Pointer)
+		require.Equal(t, RuleRequired, errs[1].Rule)
+		require.Equal(t, "/operations/recover/0/revealValue", errs[1].Pointer)
+		require.Equal(t, RuleHashLength, errs[2].Rule)
+		require.Equal(t, "/operations/deactivate/0/didSuffix", errs[2].Pointer)
+
+		require.EqualError(t, f.err(), "failed to validate suffix data for create[1]: missing suffix data")
+	})
+
+	t.Run("invalid anchor string", func(t *testing.T) {
+		report := NewOperationProvider(pc.Protocol, parser, mocks.NewMockCasClient(nil), cp).
+			ValidateAnchor(context.Background(), "invalid")
+		require.False(t, report.Valid())
+		require.Len(t, report.Findings, 1)
+		require.Equal(t, RuleAnchorString, report.Findings[0].Rule)
+		require.Empty(t, report.CoreIndexFileURI)
+	})
+}
+
+func TestFindings(t *testing.T) {
+	errFirst := errors.New("first")
+
+	f := &findings{}
+	require.NoError(t, f.err())
+
+	f.in(coreIndexAlias, "uri").warning(RuleMultihash, "/a", errors.New("warning"))
+	require.NoError(t, f.err())
+
+	f.error(RuleRequired, "/b", errFirst)
+	f.in(chunkAlias, "uri2").error(RuleDelta, "/c", errors.New("second"))
+
+	require.Equal(t, errFirst, f.err())
+	require.Len(t, f.list, 3)
+	require.Equal(t, coreIndexAlias, f.list[1].File)
+	require.Equal(t, chunkAlias, f.list[2].File)
+	require.Equal(t, "uri2", f.list[2].URI)
+}
+
+func errorFindings(findings []*Finding) []*Finding {
+	var result []*Finding
+
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			result = append(result, f)
+		}
+	}
+
+	return result
+}
+
+func findingsWithRule(report *ValidationReport, rule Rule) []*Finding {
+	var result []*Finding
+
+	for _, f := range report.Findings {
+		if f.Rule == rule {
+			result = append(result, f)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txnprovider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/internal/contenthash"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+)
+
+const defaultSourcePenalty = 10 * time.Minute
+
+// ErrUnsupportedURIFormat is returned by a URI hash extractor if the multihash of the content can't be
+// determined from the format of the CAS URI. The content of such a URI isn't verified.
+var ErrUnsupportedURIFormat = errors.New("unsupported CAS URI format")
+
+// uriHashExtractor returns the base64url-encoded multihash of the content that is referenced by the given
+// CAS URI. The default extractor supports URIs which are themselves an encoded multihash. Other URI
+// formats (e.g. IPFS CIDs) are supported by providing an extractor using WithURIHashExtractor.
+type uriHashExtractor func(uri string) (string, error)
+
+// multihashURIExtractor is the default URI hash extractor which expects the URI to be an encoded multihash.
+func multihashURIExtractor(uri string) (string, error) {
+	if err := contenthash.CheckMultihash(uri); err != nil {
+		return "", fmt.Errorf("%w [%s]: %s", ErrUnsupportedURIFormat, uri, err.Error())
+	}
+
+	return uri, nil
+}
+
+// verifyContent ensures that the multihash of the content matches the given CAS URI. The content isn't
+// verified if the format of the URI isn't recognised.
+func (h *OperationProvider) verifyContent(uri string, content []byte) error {
+	encodedMultihash, err := h.extractURIHash(uri)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedURIFormat) {
+			logger.Debug("Content not verified since the CAS URI format isn't recognised",
+				logfields.WithURIString(uri), log.WithError(err))
+
+			return nil
+		}
+
+		return err
+	}
+
+	if err := contenthash.Verify(encodedMultihash, content); err != nil {
+		return fmt.Errorf("uri[%s]: %w", uri, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultMaxDeliveryRecords = 100
+
+// DeliveryStatus is the status of a delivery attempt.
+type DeliveryStatus string
+
+const (
+	// DeliverySucceeded indicates that the webhook returned a 2xx status code.
+	DeliverySucceeded DeliveryStatus = "succeeded"
+
+	// DeliveryRetrying indicates that the attempt failed and the delivery will be retried.
+	DeliveryRetrying DeliveryStatus = "retrying"
+
+	// DeliveryFailed indicates that the attempt failed and the delivery won't be retried.
+	DeliveryFailed DeliveryStatus = "failed"
+
+	// DeliveryDropped indicates that the notification was dropped since the delivery queue was full.
+	DeliveryDropped DeliveryStatus = "dropped"
+)
+
+// DeliveryRecord records a delivery attempt.
+type DeliveryRecord struct {
+	SubscriptionID string         `json:"subscriptionId"`
+	EventID        string         `json:"eventId"`
+	URL            string         `json:"url"`
+	Attempt        int            `json:"attempt"`
+	Status         DeliveryStatus `json:"status"`
+	StatusCode     int            `json:"statusCode,omitempty"`
+	Error          string         `json:"error,omitempty"`
+	Time           time.Time      `json:"time"`
+}
+
+// MemDeliveryLog is an in-memory delivery log which keeps (up to) a maximum number of the most
+// recent records per subscription.
+type MemDeliveryLog struct {
+	maxRecords int
+	records    map[string][]*DeliveryRecord
+	mutex      sync.RWMutex
+}
+
+// NewMemDeliveryLog returns a new in-memory delivery log. If maxRecords is zero then a default of
+// 100 records per subscription is used.
+func NewMemDeliveryLog(maxRecords int) *MemDeliveryLog {
+	if maxRecords <= 0 {
+		maxRecords = defaultMaxDeliveryRecords
+	}
+
+	return &MemDeliveryLog{
+		maxRecords: maxRecords,
+		records:    make(map[string][]*DeliveryRecord),
+	}
+}
+
+// Put adds a record to the log.
+func (l *MemDeliveryLog) Put(record *DeliveryRecord) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	records := append(l.records[record.SubscriptionID], record)
+	if len(records) > l.maxRecords {
+		records = records[len(records)-l.maxRecords:]
+	}
+
+	l.records[record.SubscriptionID] = records
+}
+
+// Get returns the records for the given subscription (oldest first).
+func (l *MemDeliveryLog) Get(subscriptionID string) []*DeliveryRecord {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	records := make([]*DeliveryRecord, len(l.records[subscriptionID]))
+	copy(records, l.records[subscriptionID])
+
+	return records
+}
+
+// Delete deletes the records for the given subscription.
+func (l *MemDeliveryLog) Delete(subscriptionID string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.records, subscriptionID)
+}
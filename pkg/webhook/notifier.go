@@ -0,0 +1,469 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package webhook notifies subscribers of changes to documents by posting signed payloads to
+// the webhook URLs that were registered for the documents.
+//
+// Each payload is signed using HMAC-SHA256 with the secret of the subscription. The signature is computed
+// over the value of the timestamp header, a '.' and the request body, and is sent in the signature header
+// as 'sha256=<hex encoded signature>'. Failed deliveries are retried with exponential backoff and each
+// attempt is recorded in the delivery log.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trustbloc/logutil-go/pkg/log"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
+	logfields "github.com/trustbloc/sidetree-svc-go/pkg/internal/log"
+)
+
+var logger = log.New("sidetree-svc-webhook")
+
+const (
+	// SignatureHeader contains the signature of the payload.
+	SignatureHeader = "X-Sidetree-Signature"
+	// TimestampHeader contains the time (Unix seconds) at which the payload was signed.
+	TimestampHeader = "X-Sidetree-Timestamp"
+	// EventIDHeader contains the ID of the change event.
+	EventIDHeader = "X-Sidetree-Event-Id"
+
+	signaturePrefix = "sha256="
+	secretSize      = 32
+	idSize          = 16
+
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultQueueSize      = 1000
+	defaultWorkers        = 4
+	defaultTimeout        = 10 * time.Second
+)
+
+// Payload is the body of a webhook notification.
+type Payload struct {
+	SubscriptionID string                    `json:"subscriptionId"`
+	Event          *notification.ChangeEvent `json:"event"`
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type deliveryLog interface {
+	Put(record *DeliveryRecord)
+}
+
+type delivery struct {
+	subscription *Subscription
+	event        *notification.ChangeEvent
+	attempt      int
+	backoff      time.Duration
+}
+
+// Notifier delivers change events to the webhooks of the subscriptions that match the events.
+type Notifier struct {
+	httpClient     httpClient
+	deliveryLog    deliveryLog
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	workers        int
+
+	allowPrivateTargets bool
+
+	subscriptions map[string]*Subscription
+	mutex         sync.RWMutex
+	queue         chan *delivery
+	done          chan struct{}
+	wg            sync.WaitGroup
+	started       uint32
+	stopped       uint32
+}
+
+// Option is a notifier option.
+type Option func(n *Notifier)
+
+// WithHTTPClient sets the HTTP client used to deliver notifications. Note that the addresses to which a
+// custom client connects aren't checked, so only the hosts of the subscription URLs are checked.
+func WithHTTPClient(client httpClient) Option {
+	return func(n *Notifier) {
+		n.httpClient = client
+	}
+}
+
+// WithDeliveryLog sets the delivery log. Defaults to an in-memory log.
+func WithDeliveryLog(dl deliveryLog) Option {
+	return func(n *Notifier) {
+		n.deliveryLog = dl
+	}
+}
+
+// WithMaxAttempts sets the maximum number of delivery attempts for a notification.
+func WithMaxAttempts(value int) Option {
+	return func(n *Notifier) {
+		n.maxAttempts = value
+	}
+}
+
+// WithBackoff sets the initial and maximum backoff between delivery attempts. The backoff doubles
+// after each failed attempt.
+func WithBackoff(initial, max time.Duration) Option {
+	return func(n *Notifier) {
+		n.initialBackoff = initial
+		n.maxBackoff = max
+	}
+}
+
+// WithQueueSize sets the maximum number of pending deliveries. If the queue is full then
+// notifications are dropped.
+func WithQueueSize(value int) Option {
+	return func(n *Notifier) {
+		n.queue = make(chan *delivery, value)
+	}
+}
+
+// WithWorkers sets the number of concurrent delivery workers.
+func WithWorkers(value int) Option {
+	return func(n *Notifier) {
+		n.workers = value
+	}
+}
+
+// WithAllowPrivateTargets allows subscriptions to loopback, link-local and private addresses, which are
+// rejected by default so that the webhook endpoints can't be used to send requests into the local network.
+func WithAllowPrivateTargets() Option {
+	return func(n *Notifier) {
+		n.allowPrivateTargets = true
+	}
+}
+
+// New returns a new webhook notifier.
+func New(opts ...Option) *Notifier {
+	n := &Notifier{
+		deliveryLog:    NewMemDeliveryLog(defaultMaxDeliveryRecords),
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		workers:        defaultWorkers,
+		subscriptions:  make(map[string]*Subscription),
+		queue:          make(chan *delivery, defaultQueueSize),
+		done:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	if n.httpClient == nil {
+		n.httpClient = newHTTPClient(n.allowPrivateTargets)
+	}
+
+	return n
+}
+
+// Start starts the delivery workers.
+func (n *Notifier) Start() {
+	if !atomic.CompareAndSwapUint32(&n.started, 0, 1) {
+		return
+	}
+
+	for i := 0; i < n.workers; i++ {
+		n.wg.Add(1)
+
+		go n.deliver()
+	}
+}
+
+// Stop stops the delivery workers. Pending deliveries are abandoned.
+func (n *Notifier) Stop() {
+	if !atomic.CompareAndSwapUint32(&n.stopped, 0, 1) {
+		return
+	}
+
+	close(n.done)
+
+	n.wg.Wait()
+}
+
+// Subscribe registers the given subscription. An ID and a secret are generated if not provided.
+// The registered subscription (including the ID and secret) is returned.
+func (n *Notifier) Subscribe(subscription *Subscription) (*Subscription, error) {
+	if err := subscription.validate(n.allowPrivateTargets); err != nil {
+		return nil, fmt.Errorf("invalid subscription: %w", err)
+	}
+
+	sub := *subscription
+
+	if sub.ID == "" {
+		id, err := randomHex(idSize)
+		if err != nil {
+			return nil, err
+		}
+
+		sub.ID = id
+	}
+
+	if sub.Secret == "" {
+		secret, err := randomHex(secretSize)
+		if err != nil {
+			return nil, err
+		}
+
+		sub.Secret = secret
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if _, exists := n.subscriptions[sub.ID]; exists {
+		return nil, fmt.Errorf("subscription [%s] already exists", sub.ID)
+	}
+
+	n.subscriptions[sub.ID] = &sub
+
+	logger.Info("Added webhook subscription", logfields.WithSubscriptionID(sub.ID), logfields.WithURIString(sub.URL),
+		logfields.WithSuffixes(sub.Suffixes...))
+
+	return &sub, nil
+}
+
+// Unsubscribe removes the subscription with the given ID.
+func (n *Notifier) Unsubscribe(id string) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if _, exists := n.subscriptions[id]; !exists {
+		return ErrSubscriptionNotFound
+	}
+
+	delete(n.subscriptions, id)
+
+	logger.Info("Removed webhook subscription", logfields.WithSubscriptionID(id))
+
+	return nil
+}
+
+// Get returns the subscription with the given ID.
+func (n *Notifier) Get(id string) (*Subscription, error) {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	sub, exists := n.subscriptions[id]
+	if !exists {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	return sub, nil
+}
+
+// Publish queues a notification for each subscription that matches the given events.
+func (n *Notifier) Publish(events ...*notification.ChangeEvent) {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	for _, event := range events {
+		for _, sub := range n.subscriptions {
+			if !sub.matches(event) {
+				continue
+			}
+
+			n.enqueue(&delivery{subscription: sub, event: event, attempt: 1, backoff: n.initialBackoff})
+		}
+	}
+}
+
+// enqueue adds the given delivery to the queue. The delivery is dropped if the queue is full.
+func (n *Notifier) enqueue(d *delivery) {
+	select {
+	case <-n.done:
+		return
+	default:
+	}
+
+	select {
+	case n.queue <- d:
+	default:
+		logger.Warn("Webhook delivery queue is full. Dropping notification.",
+			logfields.WithSubscriptionID(d.subscription.ID), logfields.WithEventID(d.event.ID))
+
+		n.deliveryLog.Put(&DeliveryRecord{
+			SubscriptionID: d.subscription.ID,
+			EventID:        d.event.ID,
+			URL:            d.subscription.URL,
+			Attempt:        d.attempt,
+			Status:         DeliveryDropped,
+			Time:           time.Now(),
+		})
+	}
+}
+
+func (n *Notifier) deliver() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case d := <-n.queue:
+			n.attempt(d)
+		case <-n.done:
+			return
+		}
+	}
+}
+
+// attempt makes a single delivery attempt. If the attempt fails and may be retried then the delivery is
+// added to the queue again after the backoff period, so that the workers never wait for a backoff.
+func (n *Notifier) attempt(d *delivery) {
+	body, err := json.Marshal(&Payload{SubscriptionID: d.subscription.ID, Event: d.event})
+	if err != nil {
+		logger.Error("Error marshalling webhook payload", logfields.WithEventID(d.event.ID), log.WithError(err))
+
+		return
+	}
+
+	statusCode, err := n.post(d, body)
+
+	record := &DeliveryRecord{
+		SubscriptionID: d.subscription.ID,
+		EventID:        d.event.ID,
+		URL:            d.subscription.URL,
+		Attempt:        d.attempt,
+		StatusCode:     statusCode,
+		Time:           time.Now(),
+	}
+
+	if err == nil {
+		record.Status = DeliverySucceeded
+
+		n.deliveryLog.Put(record)
+
+		logger.Debug("Delivered webhook notification", logfields.WithSubscriptionID(d.subscription.ID),
+			logfields.WithEventID(d.event.ID), logfields.WithAttempt(d.attempt))
+
+		return
+	}
+
+	record.Error = err.Error()
+
+	if d.attempt >= n.maxAttempts || !retryable(statusCode) {
+		record.Status = DeliveryFailed
+
+		n.deliveryLog.Put(record)
+
+		logger.Warn("Failed to deliver webhook notification", logfields.WithSubscriptionID(d.subscription.ID),
+			logfields.WithEventID(d.event.ID), logfields.WithAttempt(d.attempt), log.WithError(err))
+
+		return
+	}
+
+	record.Status = DeliveryRetrying
+
+	n.deliveryLog.Put(record)
+
+	logger.Debug("Error delivering webhook notification. Will retry.", logfields.WithSubscriptionID(d.subscription.ID),
+		logfields.WithEventID(d.event.ID), logfields.WithAttempt(d.attempt), log.WithError(err))
+
+	next := &delivery{
+		subscription: d.subscription,
+		event:        d.event,
+		attempt:      d.attempt + 1,
+		backoff:      d.backoff * 2, //nolint:gomnd
+	}
+
+	if next.backoff > n.maxBackoff {
+		next.backoff = n.maxBackoff
+	}
+
+	time.AfterFunc(d.backoff, func() { n.enqueue(next) })
+}
+
+func (n *Notifier) post(d *delivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, d.subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, d.event.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, signaturePrefix+Sign(d.subscription.Secret, timestamp, body))
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if e := resp.Body.Close(); e != nil {
+			logger.Debug("Error closing response body", log.WithError(e))
+		}
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("webhook returned status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the given timestamp and body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify verifies the signature header of a webhook notification.
+func Verify(secret, timestamp, signatureHeader string, body []byte) bool {
+	if len(signatureHeader) <= len(signaturePrefix) || signatureHeader[:len(signaturePrefix)] != signaturePrefix {
+		return false
+	}
+
+	expected, err := hex.DecodeString(Sign(secret, timestamp, body))
+	if err != nil {
+		return false
+	}
+
+	actual, err := hex.DecodeString(signatureHeader[len(signaturePrefix):])
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(expected, actual)
+}
+
+// retryable returns true if the delivery should be retried for the given status code. Zero indicates
+// that no response was received.
+func retryable(statusCode int) bool {
+	return statusCode == 0 || statusCode >= http.StatusInternalServerError ||
+		statusCode == http.StatusTooManyRequests || statusCode == http.StatusRequestTimeout
+}
+
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,390 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
+)
+
+func TestNotifier_Subscribe(t *testing.T) {
+	n := New()
+
+	t.Run("success", func(t *testing.T) {
+		sub, err := n.Subscribe(&Subscription{URL: "https://example.com/hook", Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+		require.NotEmpty(t, sub.ID)
+		require.NotEmpty(t, sub.Secret)
+
+		s, err := n.Get(sub.ID)
+		require.NoError(t, err)
+		require.Equal(t, sub, s)
+
+		require.NoError(t, n.Unsubscribe(sub.ID))
+
+		_, err = n.Get(sub.ID)
+		require.ErrorIs(t, err, ErrSubscriptionNotFound)
+		require.ErrorIs(t, n.Unsubscribe(sub.ID), ErrSubscriptionNotFound)
+	})
+
+	t.Run("duplicate ID", func(t *testing.T) {
+		_, err := n.Subscribe(&Subscription{ID: "sub1", URL: "https://example.com/hook", Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		_, err = n.Subscribe(&Subscription{ID: "sub1", URL: "https://example.com/hook", Suffixes: []string{"suffix1"}})
+		require.EqualError(t, err, "subscription [sub1] already exists")
+	})
+
+	t.Run("invalid subscription", func(t *testing.T) {
+		_, err := n.Subscribe(&Subscription{URL: "ftp://example.com", Suffixes: []string{"suffix1"}})
+		require.EqualError(t, err, "invalid subscription: invalid URL scheme [ftp]")
+
+		_, err = n.Subscribe(&Subscription{URL: "https://", Suffixes: []string{"suffix1"}})
+		require.EqualError(t, err, "invalid subscription: URL must contain a host")
+
+		_, err = n.Subscribe(&Subscription{URL: ":", Suffixes: []string{"suffix1"}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid URL")
+
+		_, err = n.Subscribe(&Subscription{URL: "https://example.com/hook"})
+		require.EqualError(t, err, "invalid subscription: at least one suffix must be provided")
+	})
+
+	t.Run("private target", func(t *testing.T) {
+		for _, u := range []string{
+			"http://127.0.0.1:8080/hook",
+			"http://localhost/hook",
+			"http://10.1.2.3/hook",
+			"http://169.254.169.254/latest/meta-data",
+			"http://[::1]/hook",
+		} {
+			_, err := n.Subscribe(&Subscription{URL: u, Suffixes: []string{"suffix1"}})
+			require.ErrorIs(t, err, ErrTargetNotAllowed, u)
+		}
+
+		_, err := New(WithAllowPrivateTargets()).Subscribe(
+			&Subscription{URL: "http://127.0.0.1:8080/hook", Suffixes: []string{"suffix1"}},
+		)
+		require.NoError(t, err)
+	})
+}
+
+func TestNotifier_Publish(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		hook := newMockWebhook()
+		defer hook.Close()
+
+		dl := NewMemDeliveryLog(0)
+
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets())
+		n.Start()
+		defer n.Stop()
+
+		sub, err := n.Subscribe(&Subscription{
+			URL:            hook.URL,
+			Suffixes:       []string{"suffix1", "suffix2"},
+			OperationTypes: []operation.Type{operation.TypeUpdate, operation.TypeDeactivate},
+		})
+		require.NoError(t, err)
+
+		n.Publish(
+			newEvent("suffix1", operation.TypeUpdate, notification.StatusPublished),
+			newEvent("suffix1", operation.TypeRecover, notification.StatusPublished),
+			newEvent("suffix2", operation.TypeDeactivate, notification.StatusUnpublished),
+			newEvent("suffix3", operation.TypeUpdate, notification.StatusPublished),
+		)
+
+		require.Eventually(t, func() bool { return len(dl.Get(sub.ID)) == 1 }, time.Second, 10*time.Millisecond)
+
+		requests := hook.Requests()
+		require.Len(t, requests, 1)
+
+		req := requests[0]
+		require.True(t, Verify(sub.Secret, req.header.Get(TimestampHeader), req.header.Get(SignatureHeader), req.body))
+		require.False(t, Verify("other", req.header.Get(TimestampHeader), req.header.Get(SignatureHeader), req.body))
+
+		payload := &Payload{}
+		require.NoError(t, json.Unmarshal(req.body, payload))
+		require.Equal(t, sub.ID, payload.SubscriptionID)
+		require.Equal(t, "suffix1", payload.Event.UniqueSuffix)
+		require.Equal(t, operation.TypeUpdate, payload.Event.OperationType)
+		require.Equal(t, payload.Event.ID, req.header.Get(EventIDHeader))
+
+		records := dl.Get(sub.ID)
+		require.Equal(t, DeliverySucceeded, records[0].Status)
+		require.Equal(t, http.StatusOK, records[0].StatusCode)
+		require.Equal(t, 1, records[0].Attempt)
+	})
+
+	t.Run("include unpublished", func(t *testing.T) {
+		hook := newMockWebhook()
+		defer hook.Close()
+
+		dl := NewMemDeliveryLog(0)
+
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets())
+		n.Start()
+		defer n.Stop()
+
+		sub, err := n.Subscribe(&Subscription{URL: hook.URL, Suffixes: []string{"suffix1"}, IncludeUnpublished: true})
+		require.NoError(t, err)
+
+		n.Publish(newEvent("suffix1", operation.TypeUpdate, notification.StatusUnpublished))
+
+		require.Eventually(t, func() bool { return len(dl.Get(sub.ID)) == 1 }, time.Second, 10*time.Millisecond)
+		require.Len(t, hook.Requests(), 1)
+	})
+
+	t.Run("retry with backoff", func(t *testing.T) {
+		hook := newMockWebhook(http.StatusInternalServerError, http.StatusTooManyRequests)
+		defer hook.Close()
+
+		dl := NewMemDeliveryLog(0)
+
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets(), WithBackoff(10*time.Millisecond, 15*time.Millisecond), WithWorkers(1))
+		n.Start()
+		defer n.Stop()
+
+		sub, err := n.Subscribe(&Subscription{URL: hook.URL, Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		n.Publish(newEvent("suffix1", operation.TypeUpdate, notification.StatusPublished))
+
+		require.Eventually(t, func() bool { return len(dl.Get(sub.ID)) == 3 }, time.Second, 10*time.Millisecond)
+
+		records := dl.Get(sub.ID)
+		require.Equal(t, DeliveryRetrying, records[0].Status)
+		require.Equal(t, http.StatusInternalServerError, records[0].StatusCode)
+		require.Equal(t, DeliveryRetrying, records[1].Status)
+		require.Equal(t, http.StatusTooManyRequests, records[1].StatusCode)
+		require.Equal(t, DeliverySucceeded, records[2].Status)
+		require.Equal(t, 3, records[2].Attempt)
+
+		// The event ID is the same for each attempt.
+		requests := hook.Requests()
+		require.Len(t, requests, 3)
+		require.Equal(t, requests[0].header.Get(EventIDHeader), requests[2].header.Get(EventIDHeader))
+	})
+
+	t.Run("retry doesn't block workers", func(t *testing.T) {
+		failingHook := newMockWebhook(http.StatusServiceUnavailable)
+		defer failingHook.Close()
+
+		hook := newMockWebhook()
+		defer hook.Close()
+
+		dl := NewMemDeliveryLog(0)
+
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets(), WithBackoff(time.Minute, time.Minute), WithWorkers(1))
+		n.Start()
+		defer n.Stop()
+
+		failingSub, err := n.Subscribe(&Subscription{URL: failingHook.URL, Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		n.Publish(newEvent("suffix1", operation.TypeUpdate, notification.StatusPublished))
+
+		require.Eventually(t, func() bool { return len(dl.Get(failingSub.ID)) == 1 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, DeliveryRetrying, dl.Get(failingSub.ID)[0].Status)
+
+		sub, err := n.Subscribe(&Subscription{URL: hook.URL, Suffixes: []string{"suffix2"}})
+		require.NoError(t, err)
+
+		n.Publish(newEvent("suffix2", operation.TypeUpdate, notification.StatusPublished))
+
+		// The only worker isn't waiting for the backoff of the failed delivery.
+		require.Eventually(t, func() bool { return len(dl.Get(sub.ID)) == 1 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, DeliverySucceeded, dl.Get(sub.ID)[0].Status)
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		hook := newMockWebhook(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+		defer hook.Close()
+
+		dl := NewMemDeliveryLog(0)
+
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets(), WithBackoff(time.Millisecond, time.Millisecond), WithMaxAttempts(2))
+		n.Start()
+		defer n.Stop()
+
+		sub, err := n.Subscribe(&Subscription{URL: hook.URL, Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		n.Publish(newEvent("suffix1", operation.TypeUpdate, notification.StatusPublished))
+
+		require.Eventually(t, func() bool {
+			records := dl.Get(sub.ID)
+
+			return len(records) == 2 && records[1].Status == DeliveryFailed
+		}, time.Second, 10*time.Millisecond)
+
+		require.Contains(t, dl.Get(sub.ID)[1].Error, "webhook returned status code 502")
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		hook := newMockWebhook(http.StatusBadRequest)
+		defer hook.Close()
+
+		dl := NewMemDeliveryLog(0)
+
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets(), WithBackoff(time.Millisecond, time.Millisecond))
+		n.Start()
+		defer n.Stop()
+
+		sub, err := n.Subscribe(&Subscription{URL: hook.URL, Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		n.Publish(newEvent("suffix1", operation.TypeUpdate, notification.StatusPublished))
+
+		require.Eventually(t, func() bool { return len(dl.Get(sub.ID)) == 1 }, time.Second, 10*time.Millisecond)
+
+		time.Sleep(20 * time.Millisecond)
+
+		records := dl.Get(sub.ID)
+		require.Len(t, records, 1)
+		require.Equal(t, DeliveryFailed, records[0].Status)
+		require.Equal(t, http.StatusBadRequest, records[0].StatusCode)
+	})
+
+	t.Run("connection error", func(t *testing.T) {
+		hook := newMockWebhook()
+		hook.Close()
+
+		dl := NewMemDeliveryLog(0)
+
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets(), WithMaxAttempts(1))
+		n.Start()
+		defer n.Stop()
+
+		sub, err := n.Subscribe(&Subscription{URL: hook.URL, Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		n.Publish(newEvent("suffix1", operation.TypeUpdate, notification.StatusPublished))
+
+		require.Eventually(t, func() bool { return len(dl.Get(sub.ID)) == 1 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, DeliveryFailed, dl.Get(sub.ID)[0].Status)
+		require.Equal(t, 0, dl.Get(sub.ID)[0].StatusCode)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		dl := NewMemDeliveryLog(0)
+
+		// The notifier isn't started so the queue isn't drained.
+		n := New(WithDeliveryLog(dl), WithAllowPrivateTargets(), WithQueueSize(1))
+
+		sub, err := n.Subscribe(&Subscription{URL: "https://example.com/hook", Suffixes: []string{"suffix1"}})
+		require.NoError(t, err)
+
+		n.Publish(
+			newEvent("suffix1", operation.TypeUpdate, notification.StatusPublished),
+			newEvent("suffix1", operation.TypeDeactivate, notification.StatusPublished),
+		)
+
+		records := dl.Get(sub.ID)
+		require.Len(t, records, 1)
+		require.Equal(t, DeliveryDropped, records[0].Status)
+	})
+}
+
+func TestMemDeliveryLog(t *testing.T) {
+	dl := NewMemDeliveryLog(2)
+
+	dl.Put(&DeliveryRecord{SubscriptionID: "sub1", EventID: "event1"})
+	dl.Put(&DeliveryRecord{SubscriptionID: "sub1", EventID: "event2"})
+	dl.Put(&DeliveryRecord{SubscriptionID: "sub1", EventID: "event3"})
+	dl.Put(&DeliveryRecord{SubscriptionID: "sub2", EventID: "event1"})
+
+	records := dl.Get("sub1")
+	require.Len(t, records, 2)
+	require.Equal(t, "event2", records[0].EventID)
+	require.Equal(t, "event3", records[1].EventID)
+
+	dl.Delete("sub1")
+
+	require.Empty(t, dl.Get("sub1"))
+	require.Len(t, dl.Get("sub2"), 1)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"field":"value"}`)
+	sig := signaturePrefix + Sign("secret", "1700000000", body)
+
+	require.True(t, Verify("secret", "1700000000", sig, body))
+	require.False(t, Verify("secret", "1700000001", sig, body))
+	require.False(t, Verify("secret", "1700000000", sig, []byte(`{}`)))
+	require.False(t, Verify("secret", "1700000000", "sha256=xyz", body))
+	require.False(t, Verify("secret", "1700000000", "sha1=abc", body))
+}
+
+func newEvent(suffix string, opType operation.Type, status notification.Status) *notification.ChangeEvent {
+	return &notification.ChangeEvent{
+		ID:            string(status) + ":" + suffix + ":" + string(opType),
+		UniqueSuffix:  suffix,
+		OperationType: opType,
+		Status:        status,
+		Timestamp:     time.Now(),
+	}
+}
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// mockWebhook is a local HTTP server which records the notifications that it receives. The given status
+// codes are returned (in order) for the first requests; subsequent requests return 200.
+type mockWebhook struct {
+	*httptest.Server
+
+	statusCodes []int
+	requests    []*webhookRequest
+	mutex       sync.Mutex
+}
+
+func newMockWebhook(statusCodes ...int) *mockWebhook {
+	m := &mockWebhook{statusCodes: statusCodes}
+
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		m.requests = append(m.requests, &webhookRequest{header: r.Header.Clone(), body: body})
+
+		statusCode := http.StatusOK
+
+		if len(m.statusCodes) > 0 {
+			statusCode = m.statusCodes[0]
+			m.statusCodes = m.statusCodes[1:]
+		}
+
+		w.WriteHeader(statusCode)
+	}))
+
+	return m
+}
+
+func (m *mockWebhook) Requests() []*webhookRequest {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]*webhookRequest{}, m.requests...)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/trustbloc/sidetree-go/pkg/api/operation"
+
+	"github.com/trustbloc/sidetree-svc-go/pkg/api/notification"
+)
+
+// ErrSubscriptionNotFound is returned when a subscription is not found.
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// Subscription registers a webhook URL which is notified of changes to the given documents.
+type Subscription struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+
+	// Suffixes contains the unique suffixes of the documents for which notifications are sent.
+	Suffixes []string `json:"suffixes"`
+
+	// OperationTypes contains the operation types for which notifications are sent. If empty then
+	// notifications are sent for all operation types.
+	OperationTypes []operation.Type `json:"operationTypes,omitempty"`
+
+	// IncludeUnpublished indicates whether notifications are also sent for operations that were
+	// accepted by this node but not yet anchored.
+	IncludeUnpublished bool `json:"includeUnpublished,omitempty"`
+
+	// Secret is the key used to sign the notification payloads (HMAC-SHA256).
+	Secret string `json:"secret,omitempty"`
+
+	// Owner is the subject of the (authenticated) caller that registered the subscription. Only the owner
+	// may access or remove the subscription. Empty if the caller wasn't authenticated.
+	Owner string `json:"owner,omitempty"`
+}
+
+func (s *Subscription) validate(allowPrivateTargets bool) error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL scheme [%s]", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return errors.New("URL must contain a host")
+	}
+
+	if !allowPrivateTargets {
+		if err := checkHost(u.Hostname()); err != nil {
+			return err
+		}
+	}
+
+	if len(s.Suffixes) == 0 {
+		return errors.New("at least one suffix must be provided")
+	}
+
+	return nil
+}
+
+func (s *Subscription) matches(event *notification.ChangeEvent) bool {
+	if event.Status == notification.StatusUnpublished && !s.IncludeUnpublished {
+		return false
+	}
+
+	if len(s.OperationTypes) > 0 && !containsOperationType(s.OperationTypes, event.OperationType) {
+		return false
+	}
+
+	for _, suffix := range s.Suffixes {
+		if suffix == event.UniqueSuffix {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsOperationType(values []operation.Type, value operation.Type) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+/*
+Copyright Gen Digital Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// ErrTargetNotAllowed is returned if a webhook URL targets a loopback, link-local or private address.
+var ErrTargetNotAllowed = errors.New("webhook target not allowed")
+
+// isPrivateIP returns true if the given IP is a loopback, link-local, private or unspecified address.
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// checkHost returns an error if the given host (of a webhook URL) is a private IP address or a local host name.
+// Host names are also checked when they're resolved (see newHTTPClient).
+func checkHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: host [%s]", ErrTargetNotAllowed, host)
+	}
+
+	if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+		return fmt.Errorf("%w: address [%s]", ErrTargetNotAllowed, host)
+	}
+
+	return nil
+}
+
+// newHTTPClient returns the default HTTP client. Unless private targets are allowed, the client refuses to
+// connect to private addresses, which also covers host names that resolve to private addresses. A proxy
+// isn't used since the address of the target would then not be checked.
+func newHTTPClient(allowPrivateTargets bool) *http.Client {
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: defaultTimeout,
+	}
+
+	if !allowPrivateTargets {
+		transport.Proxy = nil
+
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return fmt.Errorf("%w: address [%s]", ErrTargetNotAllowed, host)
+			}
+
+			return nil
+		}
+	}
+
+	return &http.Client{Timeout: defaultTimeout, Transport: transport}
+}
@@ -8,7 +8,7 @@
 set -e
 
 # Packages to exclude
-PKGS=`go list github.com/trustbloc/sidetree-svc-go/pkg/... 2> /dev/null | \
+PKGS=`go list github.com/trustbloc/sidetree-svc-go/pkg/... github.com/trustbloc/sidetree-svc-go/cmd/... 2> /dev/null | \
                                                    grep -v /mocks | \
                                                    grep -v /api/`
 echo "Running pkg unit tests..."